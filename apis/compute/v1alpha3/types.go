@@ -20,6 +20,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+
+	apisv1alpha3 "github.com/crossplane/provider-azure/apis/v1alpha3"
 )
 
 const (
@@ -102,6 +104,10 @@ type AKSClusterStatus struct {
 
 	// Endpoint is the endpoint where the cluster can be reached
 	Endpoint string `json:"endpoint"`
+
+	// LastOperation represents the state of the last operation started by the
+	// controller.
+	LastOperation apisv1alpha3.AsyncOperation `json:"lastOperation,omitempty"`
 }
 
 // +kubebuilder:object:root=true
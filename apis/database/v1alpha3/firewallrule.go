@@ -20,6 +20,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+
+	apisv1alpha3 "github.com/crossplane/provider-azure/apis/v1alpha3"
 )
 
 // FirewallRuleProperties defines the properties of an Azure SQL firewall rule.
@@ -39,6 +41,10 @@ type FirewallRuleObservation struct {
 
 	// Type - Resource type.
 	Type string `json:"type,omitempty"`
+
+	// LastOperation represents the state of the last operation started by the
+	// controller.
+	LastOperation apisv1alpha3.AsyncOperation `json:"lastOperation,omitempty"`
 }
 
 // A FirewallRuleStatus represents the status of an Azure SQL firewall rule.
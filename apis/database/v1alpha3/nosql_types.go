@@ -21,6 +21,8 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/services/cosmos-db/mgmt/2015-04-08/documentdb"
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+
+	apisv1alpha3 "github.com/crossplane/provider-azure/apis/v1alpha3"
 )
 
 // +kubebuilder:object:root=true
@@ -179,4 +181,8 @@ type CosmosDBAccountStatus struct {
 	runtimev1alpha1.ResourceStatus `json:",inline"`
 	// + optional
 	AtProvider *CosmosDBAccountObservation `json:"atProvider,omitempty"`
+
+	// LastOperation represents the state of the last operation started by the
+	// controller.
+	LastOperation apisv1alpha3.AsyncOperation `json:"lastOperation,omitempty"`
 }
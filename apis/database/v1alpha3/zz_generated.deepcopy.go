@@ -243,6 +243,7 @@ func (in *CosmosDBAccountStatus) DeepCopyInto(out *CosmosDBAccountStatus) {
 		*out = new(CosmosDBAccountObservation)
 		**out = **in
 	}
+	out.LastOperation = in.LastOperation
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CosmosDBAccountStatus.
@@ -258,6 +259,7 @@ func (in *CosmosDBAccountStatus) DeepCopy() *CosmosDBAccountStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FirewallRuleObservation) DeepCopyInto(out *FirewallRuleObservation) {
 	*out = *in
+	out.LastOperation = in.LastOperation
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallRuleObservation.
@@ -694,6 +696,7 @@ func (in *VirtualNetworkRuleProperties) DeepCopy() *VirtualNetworkRuleProperties
 func (in *VirtualNetworkRuleStatus) DeepCopyInto(out *VirtualNetworkRuleStatus) {
 	*out = *in
 	in.ResourceStatus.DeepCopyInto(&out.ResourceStatus)
+	out.LastOperation = in.LastOperation
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualNetworkRuleStatus.
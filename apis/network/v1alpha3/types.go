@@ -20,6 +20,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+
+	apisv1alpha3 "github.com/crossplane/provider-azure/apis/v1alpha3"
 )
 
 // AddressSpace contains an array of IP address ranges that can be used by
@@ -98,6 +100,10 @@ type VirtualNetworkStatus struct {
 
 	// Type of this VirtualNetwork.
 	Type string `json:"type,omitempty"`
+
+	// LastOperation represents the state of the last operation started by the
+	// controller.
+	LastOperation apisv1alpha3.AsyncOperation `json:"lastOperation,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -199,6 +205,10 @@ type SubnetStatus struct {
 	// Purpose - A string identifying the intention of use for this subnet based
 	// on delegations and other user-defined properties.
 	Purpose string `json:"purpose,omitempty"`
+
+	// LastOperation represents the state of the last operation started by the
+	// controller.
+	LastOperation apisv1alpha3.AsyncOperation `json:"lastOperation,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -25,6 +25,7 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 
 	storagev1alpha3 "github.com/crossplane/provider-azure/apis/storage/v1alpha3"
+	"github.com/crossplane/provider-azure/apis/v1alpha3"
 )
 
 // MockAccount builder for testing account object
@@ -59,6 +60,12 @@ func (ta *MockAccount) WithUID(uid string) *MockAccount {
 	return ta
 }
 
+// WithAnnotations sets annotations
+func (ta *MockAccount) WithAnnotations(a map[string]string) *MockAccount {
+	ta.Account.ObjectMeta.Annotations = a
+	return ta
+}
+
 // WithDeleteTimestamp sets metadata deletion timestamp
 func (ta *MockAccount) WithDeleteTimestamp(t metav1.Time) *MockAccount {
 	ta.Account.ObjectMeta.DeletionTimestamp = &t
@@ -126,3 +133,9 @@ func (ta *MockAccount) WithStatusConditions(c ...runtimev1alpha1.Condition) *Moc
 	ta.Status.SetConditions(c...)
 	return ta
 }
+
+// WithStatusLastOperation sets the storage account's last operation.
+func (ta *MockAccount) WithStatusLastOperation(op v1alpha3.AsyncOperation) *MockAccount {
+	ta.Status.LastOperation = op
+	return ta
+}
@@ -63,6 +63,12 @@ func (tc *MockContainer) WithUID(uid string) *MockContainer {
 	return tc
 }
 
+// WithAnnotations sets annotations
+func (tc *MockContainer) WithAnnotations(a map[string]string) *MockContainer {
+	tc.Container.ObjectMeta.Annotations = a
+	return tc
+}
+
 // WithDeleteTimestamp sets deletion timestamp value
 func (tc *MockContainer) WithDeleteTimestamp(t time.Time) *MockContainer {
 	tc.Container.ObjectMeta.DeletionTimestamp = &metav1.Time{Time: t}
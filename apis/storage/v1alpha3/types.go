@@ -21,6 +21,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+
+	apisv1alpha3 "github.com/crossplane/provider-azure/apis/v1alpha3"
 )
 
 // AccountParameters define the desired state of an Azure Blob Storage Account.
@@ -43,6 +45,10 @@ type AccountStatus struct {
 	runtimev1alpha1.ResourceStatus `json:",inline"`
 
 	*StorageAccountStatus `json:",inline"`
+
+	// LastOperation represents the state of the last operation started by the
+	// controller.
+	LastOperation apisv1alpha3.AsyncOperation `json:"lastOperation,omitempty"`
 }
 
 // +kubebuilder:object:root=true
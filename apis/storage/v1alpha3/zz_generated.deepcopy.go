@@ -130,6 +130,7 @@ func (in *AccountStatus) DeepCopyInto(out *AccountStatus) {
 		*out = new(StorageAccountStatus)
 		(*in).DeepCopyInto(*out)
 	}
+	out.LastOperation = in.LastOperation
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccountStatus.
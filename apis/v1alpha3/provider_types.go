@@ -75,6 +75,10 @@ type ResourceGroupStatus struct {
 
 	// ProvisioningState - The provisioning state of the resource group.
 	ProvisioningState ProvisioningState `json:"provisioningState,omitempty"`
+
+	// LastOperation represents the state of the last operation started by the
+	// controller.
+	LastOperation AsyncOperation `json:"lastOperation,omitempty"`
 }
 
 // A ResourceGroup is a managed resource that represents an Azure Resource
@@ -192,6 +192,7 @@ func (in *ResourceGroupSpec) DeepCopy() *ResourceGroupSpec {
 func (in *ResourceGroupStatus) DeepCopyInto(out *ResourceGroupStatus) {
 	*out = *in
 	in.ResourceStatus.DeepCopyInto(&out.ResourceStatus)
+	out.LastOperation = in.LastOperation
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceGroupStatus.
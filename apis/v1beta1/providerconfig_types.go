@@ -25,6 +25,99 @@ import (
 // A ProviderConfigSpec defines the desired state of a ProviderConfig.
 type ProviderConfigSpec struct {
 	runtimev1alpha1.ProviderConfigSpec `json:",inline"`
+
+	// ClientID of the managed identity, or of the application federated with
+	// the provider's service account, that is used when the credentials source
+	// is InjectedIdentity. Defaults to the AZURE_CLIENT_ID environment
+	// variable of the provider pod.
+	// +optional
+	ClientID *string `json:"clientID,omitempty"`
+
+	// TenantID of the Azure Active Directory tenant that is used when the
+	// credentials source is InjectedIdentity. Defaults to the AZURE_TENANT_ID
+	// environment variable of the provider pod.
+	// +optional
+	TenantID *string `json:"tenantID,omitempty"`
+
+	// SubscriptionID of the Azure subscription that is used, overriding the
+	// subscription of the credentials. Defaults to the AZURE_SUBSCRIPTION_ID
+	// environment variable of the provider pod when the credentials source
+	// is InjectedIdentity. Managed resources may override it using the
+	// azure.crossplane.io/subscription-id annotation.
+	// +optional
+	SubscriptionID *string `json:"subscriptionID,omitempty"`
+
+	// Environment is the Azure cloud the provider connects to. It determines
+	// the Azure AD, Azure Resource Manager and Azure AD Graph endpoints that
+	// are used, overriding any endpoints set in the credentials secret.
+	// Defaults to AzurePublicCloud.
+	// +optional
+	// +kubebuilder:validation:Enum=AzurePublicCloud;AzureChinaCloud;AzureUSGovernmentCloud;AzureGermanCloud
+	Environment *string `json:"environment,omitempty"`
+
+	// ResourceManagerEndpoint of a custom Azure environment, e.g. an Azure
+	// Stack Hub. The Azure AD and Azure AD Graph endpoints of the environment
+	// are discovered from the metadata endpoint of this Azure Resource
+	// Manager. Takes precedence over Environment.
+	// +optional
+	ResourceManagerEndpoint *string `json:"resourceManagerEndpoint,omitempty"`
+
+	// KeyVault secret from which the credentials are read. The credentials
+	// source is used to authenticate to the Key Vault, e.g. using the managed
+	// identity injected into the provider pod.
+	// +optional
+	KeyVault *KeyVaultCredentials `json:"keyVault,omitempty"`
+
+	// AuxiliaryTenantIDs of up to three Azure Active Directory tenants other
+	// than the tenant of the credentials. Tokens for these tenants are sent
+	// alongside the primary token, allowing operations that reference
+	// resources in other tenants, e.g. cross-tenant virtual network peerings.
+	// Not supported for managed identities.
+	// +optional
+	// +kubebuilder:validation:MaxItems=3
+	AuxiliaryTenantIDs []string `json:"auxiliaryTenantIDs,omitempty"`
+}
+
+// Key Vault credentials formats.
+const (
+	// KeyVaultFormatCredentials is a JSON encoded Azure credentials file, as
+	// stored in a credentials secret.
+	KeyVaultFormatCredentials = "Credentials"
+
+	// KeyVaultFormatClientSecret is the client secret of a service principal.
+	KeyVaultFormatClientSecret = "ClientSecret"
+
+	// KeyVaultFormatClientCertificate is the client certificate of a service
+	// principal, either PEM encoded or a base64 encoded PKCS#12 archive.
+	KeyVaultFormatClientCertificate = "ClientCertificate"
+)
+
+// KeyVaultCredentials reference a Key Vault secret that contains the
+// credentials that must be used to connect to Azure.
+type KeyVaultCredentials struct {
+	// VaultURL of the Key Vault, e.g. https://my-vault.vault.azure.net/.
+	VaultURL string `json:"vaultURL"`
+
+	// SecretName of the Key Vault secret.
+	SecretName string `json:"secretName"`
+
+	// SecretVersion of the Key Vault secret. The latest version is used, and
+	// the credentials are refreshed when it changes, if omitted.
+	// +optional
+	SecretVersion *string `json:"secretVersion,omitempty"`
+
+	// Format of the Key Vault secret. A ClientSecret or ClientCertificate is
+	// used with the tenant and subscription of the credentials source.
+	// Defaults to Credentials.
+	// +optional
+	// +kubebuilder:validation:Enum=Credentials;ClientSecret;ClientCertificate
+	Format *string `json:"format,omitempty"`
+
+	// ClientID of the service principal whose ClientSecret or
+	// ClientCertificate is stored in the Key Vault secret. Defaults to the
+	// client ID of the credentials source.
+	// +optional
+	ClientID *string `json:"clientID,omitempty"`
 }
 
 // A ProviderConfigStatus represents the status of a ProviderConfig.
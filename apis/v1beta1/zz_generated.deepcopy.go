@@ -24,6 +24,36 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyVaultCredentials) DeepCopyInto(out *KeyVaultCredentials) {
+	*out = *in
+	if in.SecretVersion != nil {
+		in, out := &in.SecretVersion, &out.SecretVersion
+		*out = new(string)
+		**out = **in
+	}
+	if in.Format != nil {
+		in, out := &in.Format, &out.Format
+		*out = new(string)
+		**out = **in
+	}
+	if in.ClientID != nil {
+		in, out := &in.ClientID, &out.ClientID
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyVaultCredentials.
+func (in *KeyVaultCredentials) DeepCopy() *KeyVaultCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyVaultCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProviderConfig) DeepCopyInto(out *ProviderConfig) {
 	*out = *in
@@ -87,6 +117,41 @@ func (in *ProviderConfigList) DeepCopyObject() runtime.Object {
 func (in *ProviderConfigSpec) DeepCopyInto(out *ProviderConfigSpec) {
 	*out = *in
 	in.ProviderConfigSpec.DeepCopyInto(&out.ProviderConfigSpec)
+	if in.ClientID != nil {
+		in, out := &in.ClientID, &out.ClientID
+		*out = new(string)
+		**out = **in
+	}
+	if in.TenantID != nil {
+		in, out := &in.TenantID, &out.TenantID
+		*out = new(string)
+		**out = **in
+	}
+	if in.SubscriptionID != nil {
+		in, out := &in.SubscriptionID, &out.SubscriptionID
+		*out = new(string)
+		**out = **in
+	}
+	if in.Environment != nil {
+		in, out := &in.Environment, &out.Environment
+		*out = new(string)
+		**out = **in
+	}
+	if in.ResourceManagerEndpoint != nil {
+		in, out := &in.ResourceManagerEndpoint, &out.ResourceManagerEndpoint
+		*out = new(string)
+		**out = **in
+	}
+	if in.KeyVault != nil {
+		in, out := &in.KeyVault, &out.KeyVault
+		*out = new(KeyVaultCredentials)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AuxiliaryTenantIDs != nil {
+		in, out := &in.AuxiliaryTenantIDs, &out.AuxiliaryTenantIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderConfigSpec.
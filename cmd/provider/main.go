@@ -17,27 +17,77 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/api/global"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
 	"gopkg.in/alecthomas/kingpin.v2"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 
 	"github.com/crossplane/provider-azure/apis"
+	"github.com/crossplane/provider-azure/apis/v1beta1"
+	azure "github.com/crossplane/provider-azure/pkg/clients"
 	"github.com/crossplane/provider-azure/pkg/controller"
+	"github.com/crossplane/provider-azure/pkg/eventgrid"
+	"github.com/crossplane/provider-azure/pkg/export"
+	"github.com/crossplane/provider-azure/pkg/migrate"
+	"github.com/crossplane/provider-azure/pkg/reconciler"
 )
 
 func main() {
 	var (
 		app            = kingpin.New(filepath.Base(os.Args[0]), "Azure support for Crossplane.").DefaultEnvars()
 		debug          = app.Flag("debug", "Run with debug logging.").Short('d').Bool()
-		syncPeriod     = app.Flag("sync", "Controller manager sync period duration such as 300ms, 1.5h or 2h45m").Short('s').Default("1h").Duration()
-		leaderElection = app.Flag("leader-election", "Use leader election for the conroller manager.").Short('l').Default("false").OverrideDefaultFromEnvar("LEADER_ELECTION").Bool()
+		start          = app.Command("start", "Start the Azure controllers.").Default()
+		syncPeriod     = start.Flag("sync", "Controller manager sync period duration such as 300ms, 1.5h or 2h45m").Short('s').Default("1h").Duration()
+		leaderElection = start.Flag("leader-election", "Use leader election for the conroller manager.").Short('l').Default("false").OverrideDefaultFromEnvar("LEADER_ELECTION").Bool()
+		armReads       = start.Flag("arm-reads-per-second", "Maximum rate of Azure Resource Manager reads per subscription.").Default(fmt.Sprint(azure.DefaultReadsPerSecond)).Float64()
+		armWrites      = start.Flag("arm-writes-per-second", "Maximum rate of Azure Resource Manager writes per subscription.").Default(fmt.Sprint(azure.DefaultWritesPerSecond)).Float64()
+		armBurst       = start.Flag("arm-burst", "Maximum burst of Azure Resource Manager reads or writes per subscription.").Default(fmt.Sprint(azure.DefaultRateLimitBurst)).Int()
+		otlpEndpoint   = start.Flag("otlp-endpoint", "Address of an OpenTelemetry collector to export traces to using OTLP, such as localhost:55680. Traces are not recorded if unset.").String()
+		otlpInsecure   = start.Flag("otlp-insecure", "Export traces to the OpenTelemetry collector without TLS.").Bool()
+		traceSampling  = start.Flag("trace-sample-ratio", "Fraction of managed resource operations to trace when exporting traces.").Default("1").Float64()
+		eventGridAddr  = start.Flag("event-grid-address", "Address at which to receive Azure Event Grid resource write and delete events, such as :9443. Managed resources are reconciled when the Azure resources they manage change. Events are not received if unset. Requires --event-grid-key.").String()
+		eventGridKey   = start.Flag("event-grid-key", "Secret that Event Grid must supply as the key query parameter of the event subscription's endpoint URL. Required to receive Event Grid events.").String()
+		tlsCertFile    = start.Flag("event-grid-tls-cert", "PEM encoded certificate file with which to serve Event Grid events over HTTPS. Events are served over plain HTTP if unset, and must then only be exposed through an ingress that terminates TLS, because deliveries include the key.").String()
+		tlsKeyFile     = start.Flag("event-grid-tls-key", "PEM encoded private key file of --event-grid-tls-cert.").String()
+		concurrency    = start.Flag("max-concurrent-reconciles", "Maximum number of managed resources of each kind to reconcile concurrently.").Default("1").Int()
+		groupConc      = start.Flag("group-max-concurrent-reconciles", "Maximum number of managed resources of each kind in an API group to reconcile concurrently, such as database.azure.crossplane.io=5. May be repeated.").PlaceHolder("GROUP=N").StringMap()
+		kinds          = start.Flag("enable-kind", "Kind of managed resource to reconcile, such as Subnet.network.azure.crossplane.io. May be repeated. All kinds are reconciled if unset.").Strings()
+		pollInterval   = start.Flag("poll-interval", "Interval at which managed resources that are up to date are observed.").Default(reconciler.DefaultPollInterval.String()).Duration()
+		kindPoll       = start.Flag("kind-poll-interval", "Interval at which managed resources of a kind that are up to date are observed, such as Redis.cache.azure.crossplane.io=5m. May be repeated.").PlaceHolder("KIND=DURATION").StringMap()
+		pollJitter     = start.Flag("poll-jitter", "Maximum fraction of each requeue delay by which managed resources are randomly delayed, such as 0.1.").Default("0").Float64()
+		metricsAddr    = start.Flag("metrics-address", "Address at which to serve Prometheus metrics.").Default(":8080").String()
+		probeAddr      = start.Flag("health-probe-address", "Address at which to serve the /healthz liveness and /readyz readiness probes, such as :8081. Probes are not served if unset.").String()
+		graphInterval  = start.Flag("resource-graph-interval", "Interval at which to query Azure Resource Graph for the Azure resources of each subscription and type, such as 1m. Supported managed resources are observed from the query results rather than by reading each Azure resource. Azure Resource Graph is not queried if unset.").Duration()
+		startDryRun    = start.Flag("dry-run", "Observe Azure resources as usual, but record rather than send the requests that would create, update or delete them. Planned requests, with the values of secret properties such as passwords and keys redacted, are recorded as events, served as JSON at /plan on the metrics address, and written to stdout on exit.").Bool()
+		migrateCmd     = app.Command("migrate", "Create a ProviderConfig for every deprecated Provider, and make managed resources reference it instead.")
+		dryRun         = migrateCmd.Flag("dry-run", "Report, but do not persist, the changes the migration would make.").Bool()
+		exportCmd      = app.Command("export", "Write a managed resource for every supported Azure resource of a subscription to stdout, so that the managed resources adopt them.")
+		exportConfig   = exportCmd.Flag("provider-config", "ProviderConfig whose credentials are used to read Azure resources, and that exported managed resources reference.").Default("default").String()
+		exportSub      = exportCmd.Flag("subscription", "Subscription to export Azure resources from, if not the subscription of the ProviderConfig. Exported managed resources are annotated with the subscription.").String()
+		exportGroup    = exportCmd.Flag("resource-group", "Resource group to export Azure resources from. Azure resources are exported from every resource group of the subscription if unset.").String()
+		exportObserve  = exportCmd.Flag("observe-only", "Export managed resources whose management policy is ObserveOnly, so that they never change or delete the Azure resources they adopt.").Bool()
 	)
-	kingpin.MustParse(app.Parse(os.Args[1:]))
+	cmd := kingpin.MustParse(app.Parse(os.Args[1:]))
 
 	zl := zap.New(zap.UseDevMode(*debug))
 	log := logging.NewLogrLogger(zl.WithName("provider-azure"))
@@ -48,20 +98,209 @@ func main() {
 		ctrl.SetLogger(zl)
 	}
 
-	log.Debug("Starting", "sync-period", syncPeriod.String())
-
 	cfg, err := ctrl.GetConfig()
 	kingpin.FatalIfError(err, "Cannot get API server rest config")
 
+	if cmd == migrateCmd.FullCommand() {
+		kingpin.FatalIfError(runMigrate(cfg, *dryRun), "Cannot migrate from Providers to ProviderConfigs")
+		return
+	}
+
+	if cmd == exportCmd.FullCommand() {
+		kingpin.FatalIfError(runExport(cfg, *exportConfig, *exportSub, *exportGroup, *exportObserve), "Cannot export Azure resources")
+		return
+	}
+
+	log.Debug("Starting", "sync-period", syncPeriod.String(), "arm-reads-per-second", *armReads, "arm-writes-per-second", *armWrites, "arm-burst", *armBurst)
+	azure.ResourceManagerRateLimiter = azure.NewRateLimiter(*armReads, *armWrites, *armBurst)
+	kingpin.FatalIfError(azure.RegisterMetrics(metrics.Registry), "Cannot register Azure API metrics")
+
+	if *graphInterval > 0 {
+		// Every managed resource is observed at least once per sync period, so
+		// queries whose results were not read for longer are no longer needed.
+		log.Debug("Observing from Azure Resource Graph", "resource-graph-interval", graphInterval.String())
+		azure.ResourceGraph = azure.NewResourceGraphCache(*graphInterval, *syncPeriod, log)
+	}
+
+	if *otlpEndpoint != "" {
+		log.Debug("Exporting traces", "otlp-endpoint", *otlpEndpoint, "trace-sample-ratio", *traceSampling)
+		shutdown, err := startTracing(*otlpEndpoint, *otlpInsecure, *traceSampling)
+		kingpin.FatalIfError(err, "Cannot start exporting traces")
+		defer shutdown()
+	}
+
+	o, err := controllerOptions(*concurrency, *groupConc, *kinds, *pollInterval, *kindPoll, *pollJitter)
+	kingpin.FatalIfError(err, "Cannot parse controller options")
+
+	if *startDryRun {
+		log.Info("Running in dry-run mode; Azure resources will not be created, updated or deleted")
+		azure.DryRun = true
+		o.Plan = reconciler.NewPlan()
+	}
+
 	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
-		LeaderElection:   *leaderElection,
-		LeaderElectionID: "crossplane-leader-election-provider-azure",
-		SyncPeriod:       syncPeriod,
+		LeaderElection:         *leaderElection,
+		LeaderElectionID:       "crossplane-leader-election-provider-azure",
+		SyncPeriod:             syncPeriod,
+		MetricsBindAddress:     *metricsAddr,
+		HealthProbeBindAddress: *probeAddr,
 	})
 	kingpin.FatalIfError(err, "Cannot create controller manager")
 
+	if o.Plan != nil {
+		kingpin.FatalIfError(mgr.AddMetricsExtraHandler("/plan", o.Plan), "Cannot serve dry-run plan")
+	}
+
+	if *probeAddr != "" {
+		kingpin.FatalIfError(mgr.AddHealthzCheck("ping", healthz.Ping), "Cannot add liveness probe")
+		kingpin.FatalIfError(mgr.AddReadyzCheck("ping", healthz.Ping), "Cannot add readiness probe")
+	}
+
 	kingpin.FatalIfError(apis.AddToScheme(mgr.GetScheme()), "Cannot add Azure APIs to scheme")
-	kingpin.FatalIfError(controller.Setup(mgr, log), "Cannot setup Azure controllers")
-	kingpin.FatalIfError(mgr.Start(ctrl.SetupSignalHandler()), "Cannot start controller manager")
+	kingpin.FatalIfError(controller.Setup(mgr, log, o), "Cannot setup Azure controllers")
+
+	if azure.ResourceGraph != nil {
+		kingpin.FatalIfError(mgr.Add(azure.ResourceGraph), "Cannot add Azure Resource Graph cache")
+	}
+
+	if *eventGridAddr != "" {
+		if *eventGridKey == "" {
+			kingpin.Fatalf("Cannot receive Event Grid events without --event-grid-key")
+		}
+		if (*tlsCertFile == "") != (*tlsKeyFile == "") {
+			kingpin.Fatalf("Cannot serve Event Grid events over HTTPS without both --event-grid-tls-cert and --event-grid-tls-key")
+		}
+		log.Debug("Receiving Event Grid events", "event-grid-address", *eventGridAddr, "tls", *tlsCertFile != "")
+		r := eventgrid.NewReceiver(eventgrid.DefaultRegistry, eventgrid.WithKey(*eventGridKey), eventgrid.WithLogger(log))
+		so := []eventgrid.ServeOption{}
+		if *tlsCertFile != "" {
+			so = append(so, eventgrid.WithTLS(*tlsCertFile, *tlsKeyFile))
+		}
+		kingpin.FatalIfError(mgr.Add(eventgrid.Serve(*eventGridAddr, r, so...)), "Cannot add Event Grid receiver")
+	}
+	err = mgr.Start(ctrl.SetupSignalHandler())
+	if o.Plan != nil {
+		kingpin.FatalIfError(o.Plan.Write(os.Stdout), "Cannot write dry-run plan")
+	}
+	kingpin.FatalIfError(err, "Cannot start controller manager")
+}
+
+// controllerOptions returns the options of the Azure controllers given the
+// values of their flags.
+func controllerOptions(concurrency int, groupConcurrency map[string]string, kinds []string, poll time.Duration, kindPoll map[string]string, jitter float64) (reconciler.Options, error) {
+	o := reconciler.Options{
+		Kinds:                   kinds,
+		MaxConcurrentReconciles: concurrency,
+		GroupConcurrency:        map[string]int{},
+		PollInterval:            poll,
+		KindPollIntervals:       map[string]time.Duration{},
+		PollJitter:              jitter,
+	}
+	if concurrency < 1 || poll <= 0 || jitter < 0 {
+		return o, errors.New("maximum concurrent reconciles and poll interval must be positive, and poll jitter must not be negative")
+	}
+	for g, v := range groupConcurrency {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return o, errors.Errorf("invalid maximum concurrent reconciles %q of API group %s", v, g)
+		}
+		o.GroupConcurrency[g] = n
+	}
+	for k, v := range kindPoll {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return o, errors.Errorf("invalid poll interval %q of kind %s", v, k)
+		}
+		o.KindPollIntervals[k] = d
+	}
+	return o, nil
+}
+
+// startTracing exports traces to the OpenTelemetry collector at the supplied
+// endpoint. It returns a function that flushes and stops the exporter.
+func startTracing(endpoint string, insecure bool, ratio float64) (func(), error) {
+	opts := []otlp.ExporterOption{otlp.WithAddress(endpoint)}
+	if insecure {
+		opts = append(opts, otlp.WithInsecure())
+	}
+	exp, err := otlp.NewExporter(opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create OTLP exporter")
+	}
+
+	bsp := sdktrace.NewBatchSpanProcessor(exp)
+	global.SetTracerProvider(sdktrace.NewTracerProvider(
+		sdktrace.WithConfig(sdktrace.Config{
+			DefaultSampler: sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio)),
+			Resource:       resource.New(semconv.ServiceNameKey.String("provider-azure")),
+		}),
+		sdktrace.WithSpanProcessor(bsp),
+	))
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		bsp.Shutdown()
+		_ = exp.Shutdown(ctx)
+	}, nil
+}
+
+func runMigrate(cfg *rest.Config, dryRun bool) error {
+	s := runtime.NewScheme()
+	if err := apis.AddToScheme(s); err != nil {
+		return errors.Wrap(err, "cannot add Azure APIs to scheme")
+	}
+	c, err := client.New(cfg, client.Options{Scheme: s})
+	if err != nil {
+		return errors.Wrap(err, "cannot create API server client")
+	}
 
+	changes, err := migrate.NewMigrator(c, s, migrate.WithDryRun(dryRun)).Run(context.Background())
+	for _, ch := range changes {
+		if dryRun {
+			fmt.Printf("%s (dry run)\n", ch)
+			continue
+		}
+		fmt.Println(ch)
+	}
+	if err == nil && len(changes) == 0 {
+		fmt.Println("Nothing to migrate")
+	}
+	return err
+}
+
+func runExport(cfg *rest.Config, providerConfig, subscription, group string, observeOnly bool) error {
+	s := runtime.NewScheme()
+	if err := apis.AddToScheme(s); err != nil {
+		return errors.Wrap(err, "cannot add Azure APIs to scheme")
+	}
+	c, err := client.New(cfg, client.Options{Scheme: s})
+	if err != nil {
+		return errors.Wrap(err, "cannot create API server client")
+	}
+
+	ctx := context.Background()
+	pc := &v1beta1.ProviderConfig{}
+	if err := c.Get(ctx, types.NamespacedName{Name: providerConfig}, pc); err != nil {
+		return errors.Wrap(err, "cannot get ProviderConfig")
+	}
+	i, err := azure.ProviderConfigAuthInfo(ctx, c, pc)
+	if err != nil {
+		return errors.Wrap(err, "cannot get credentials of ProviderConfig")
+	}
+	creds := i.Content()
+	a, err := i.Authorizer(azure.ResourceManagerAudience(creds))
+	if err != nil {
+		return errors.Wrap(err, "cannot get Azure Resource Manager authorizer")
+	}
+
+	e := export.NewExporter(creds, a, providerConfig,
+		export.WithSubscription(subscription),
+		export.WithResourceGroup(group),
+		export.WithObserveOnly(observeOnly))
+	mgs, err := e.Export(ctx)
+	if err != nil {
+		return err
+	}
+	return export.Write(os.Stdout, mgs)
 }
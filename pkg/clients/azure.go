@@ -20,14 +20,17 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"os"
+	"regexp"
+	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
 	"github.com/Azure/go-autorest/autorest"
 	"github.com/Azure/go-autorest/autorest/azure"
-	"github.com/Azure/go-autorest/autorest/azure/auth"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -44,7 +47,29 @@ const (
 	// AsyncOperationStatusInProgress is the status value for AsyncOperation type
 	// that indicates the operation is still ongoing.
 	AsyncOperationStatusInProgress = "InProgress"
-	asyncOperationPollingMethod    = "AsyncOperation"
+	// AsyncOperationStatusSucceeded is the status value for AsyncOperation type
+	// that indicates the operation has succeeded.
+	AsyncOperationStatusSucceeded = "Succeeded"
+	// AsyncOperationStatusFailed is the status value for AsyncOperation type
+	// that indicates the operation has failed.
+	AsyncOperationStatusFailed = "Failed"
+	// AsyncOperationStatusCanceled is the status value for AsyncOperation type
+	// that indicates the operation was canceled.
+	AsyncOperationStatusCanceled = "Canceled"
+	asyncOperationPollingMethod  = "AsyncOperation"
+)
+
+// TypeLastAsyncOperation managed resources report the status of the last
+// asynchronous operation that was started to create, update, or delete their
+// external resource.
+const TypeLastAsyncOperation runtimev1alpha1.ConditionType = "LastAsyncOperation"
+
+// Reasons a managed resource reports for the status of its last asynchronous
+// operation.
+const (
+	ReasonAsyncOperationInProgress runtimev1alpha1.ConditionReason = "InProgress"
+	ReasonAsyncOperationSucceeded  runtimev1alpha1.ConditionReason = "Succeeded"
+	ReasonAsyncOperationFailed     runtimev1alpha1.ConditionReason = "Failed"
 )
 
 // Error strings.
@@ -56,6 +81,13 @@ const (
 	errCredSecretNotGiven        = "secretRef was not supplied"
 	errUnmarshalCredentialSecret = "cannot unmarshal the data in credentials secret"
 	errGetAuthorizer             = "cannot get authorizer from client credentials config"
+	errNoClientSecret            = "credentials secret contains neither a clientSecret nor a clientCertificate"
+	errAcquireToken              = "cannot acquire Azure AD token"
+	errListResourceGroups        = "cannot list resource groups"
+
+	errFetchAsyncOperation = "cannot fetch asynchronous operation"
+
+	errFmtAsyncOperationFailed = "asynchronous %s operation %s: %s"
 
 	errFmtUnsupportedCredSource = "unsupported credentials source %q"
 )
@@ -77,6 +109,8 @@ const (
 const (
 	CredentialsKeyClientID                       = "clientId"
 	CredentialsKeyClientSecret                   = "clientSecret"
+	CredentialsKeyClientCertificate              = "clientCertificate"
+	CredentialsKeyClientCertificatePassword      = "clientCertificatePassword"
 	CredentialsKeyTenantID                       = "tenantId"
 	CredentialsKeySubscriptionID                 = "subscriptionId"
 	CredentialsKeyActiveDirectoryEndpointURL     = "activeDirectoryEndpointUrl"
@@ -87,81 +121,261 @@ const (
 	CredentialsManagementEndpointURL             = "managementEndpointUrl"
 )
 
+// AnnotationKeySubscriptionID is the annotation of a managed resource that
+// overrides the subscription of the credentials it connects to Azure with.
+const AnnotationKeySubscriptionID = "azure.crossplane.io/subscription-id"
+
+// AnnotationKeyManagementPolicy is the annotation of a managed resource that
+// specifies how the Azure resource it manages is managed. Azure resources are
+// created, updated and deleted as usual unless it specifies otherwise.
+const AnnotationKeyManagementPolicy = "azure.crossplane.io/management-policy"
+
+// ManagementPolicyObserveOnly managed resources observe the Azure resource
+// they manage, and late initialize their parameters from it, but never create,
+// update or delete it. Existing Azure resources may be adopted by removing the
+// policy once their managed resource is late initialized.
+const ManagementPolicyObserveOnly = "ObserveOnly"
+
+// ErrObserveOnlyNotFound is returned when observing a managed resource whose
+// management policy is ObserveOnly and whose Azure resource does not exist.
+var ErrObserveOnlyNotFound = errors.New("the Azure resource does not exist, and is not created because the management policy is " + ManagementPolicyObserveOnly)
+
+// IsObserveOnly returns true if the management policy of the supplied managed
+// resource is ObserveOnly.
+func IsObserveOnly(o metav1.Object) bool {
+	return o.GetAnnotations()[AnnotationKeyManagementPolicy] == ManagementPolicyObserveOnly
+}
+
 // GetAuthInfo figures out how to connect to Azure API and returns the necessary
 // information to be used for controllers to construct their specific clients.
 func GetAuthInfo(ctx context.Context, c client.Client, mg resource.Managed) (content map[string]string, authorizer autorest.Authorizer, err error) {
+	i, err := LoadAuthInfo(ctx, c, mg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return i.resourceManager()
+}
+
+// LoadAuthInfo returns the AuthInfo of the ProviderConfig, or the deprecated
+// Provider, referenced by the supplied managed resource. AuthInfo is cached
+// until the referenced ProviderConfig or its credentials secret changes.
+func LoadAuthInfo(ctx context.Context, c client.Client, mg resource.Managed) (*AuthInfo, error) {
 	switch {
 	case mg.GetProviderConfigReference() != nil:
-		return UseProviderConfig(ctx, c, mg)
+		return loadProviderConfigAuthInfo(ctx, c, mg)
 	case mg.GetProviderReference() != nil:
-		return UseProvider(ctx, c, mg)
+		return loadProviderAuthInfo(ctx, c, mg)
 	default:
-		return nil, nil, errors.New(errNeitherPCNorPGiven)
+		return nil, errors.New(errNeitherPCNorPGiven)
+	}
+}
+
+// forResource returns AuthInfo for the supplied managed resource, i.e. with
+// the subscription of its AnnotationKeySubscriptionID annotation, if any.
+func forResource(i *AuthInfo, mg resource.Managed) *AuthInfo {
+	if id := mg.GetAnnotations()[AnnotationKeySubscriptionID]; id != "" {
+		return i.WithSubscriptionID(id)
 	}
+	return i
 }
 
 // UseProvider to return the necessary information to construct an Azure client.
 // Deprecated: Use UseProviderConfig
 func UseProvider(ctx context.Context, c client.Client, mg resource.Managed) (content map[string]string, authorizer autorest.Authorizer, err error) {
+	i, err := loadProviderAuthInfo(ctx, c, mg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return i.resourceManager()
+}
+
+func loadProviderAuthInfo(ctx context.Context, c client.Client, mg resource.Managed) (*AuthInfo, error) {
 	p := &v1alpha3.Provider{}
 	if err := c.Get(ctx, types.NamespacedName{Name: mg.GetProviderReference().Name}, p); err != nil {
-		return nil, nil, errors.Wrap(err, errGetProvider)
+		return nil, errors.Wrap(err, errGetProvider)
 	}
+	i, err := ProviderAuthInfo(ctx, c, p)
+	if err != nil {
+		return nil, err
+	}
+	return forResource(i, mg), nil
+}
 
+// ProviderAuthInfo returns the AuthInfo of the supplied Provider. AuthInfo is
+// cached until the Provider or its credentials secret changes.
+func ProviderAuthInfo(ctx context.Context, c client.Client, p *v1alpha3.Provider) (*AuthInfo, error) {
 	ref := p.Spec.CredentialsSecretRef
 	s := &corev1.Secret{}
 	if err := c.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}, s); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	m := map[string]string{}
-	if err := json.Unmarshal(s.Data[ref.Key], &m); err != nil {
-		return nil, nil, errors.Wrap(err, errUnmarshalCredentialSecret)
-	}
-	cfg := auth.NewClientCredentialsConfig(m[CredentialsKeyClientID], m[CredentialsKeyClientSecret], m[CredentialsKeyTenantID])
-	cfg.AADEndpoint = m[CredentialsKeyActiveDirectoryEndpointURL]
-	cfg.Resource = m[CredentialsKeyResourceManagerEndpointURL]
 
-	a, err := cfg.Authorizer()
-	return m, a, errors.Wrap(err, errGetAuthorizer)
+	return authInfos.load(authInfoKey(v1alpha3.ProviderKind, p), p.GetResourceVersion()+"/"+s.GetResourceVersion(), 0, func() (map[string]string, error) {
+		m, err := parseCredentials(s.Data[ref.Key])
+		if err != nil {
+			return nil, err
+		}
+		DefaultEnvironment(m)
+		return m, nil
+	})
 }
 
 // UseProviderConfig to return the necessary information to construct an Azure
 // client.
 func UseProviderConfig(ctx context.Context, c client.Client, mg resource.Managed) (content map[string]string, authorizer autorest.Authorizer, err error) {
+	i, err := loadProviderConfigAuthInfo(ctx, c, mg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return i.resourceManager()
+}
+
+func loadProviderConfigAuthInfo(ctx context.Context, c client.Client, mg resource.Managed) (*AuthInfo, error) {
 	pc := &v1beta1.ProviderConfig{}
 	t := resource.NewProviderConfigUsageTracker(c, &v1beta1.ProviderConfigUsage{})
 	if err := t.Track(ctx, mg); err != nil {
-		return nil, nil, errors.Wrap(err, errTrackProviderConfigUsage)
+		return nil, errors.Wrap(err, errTrackProviderConfigUsage)
 	}
 	if err := c.Get(ctx, types.NamespacedName{Name: mg.GetProviderConfigReference().Name}, pc); err != nil {
-		return nil, nil, errors.Wrap(err, errGetProviderConfig)
+		return nil, errors.Wrap(err, errGetProviderConfig)
+	}
+	i, err := ProviderConfigAuthInfo(ctx, c, pc)
+	if err != nil {
+		return nil, err
+	}
+	return forResource(i, mg), nil
+}
+
+// ProviderConfigAuthInfo returns the AuthInfo of the supplied ProviderConfig.
+// AuthInfo is cached until the ProviderConfig or its credentials secret
+// changes.
+func ProviderConfigAuthInfo(ctx context.Context, c client.Client, pc *v1beta1.ProviderConfig) (*AuthInfo, error) { // nolint:gocyclo
+	var s *corev1.Secret
+	version := pc.GetResourceVersion()
+	switch src := pc.Spec.Credentials.Source; src {
+	case runtimev1alpha1.CredentialsSourceSecret:
+		ref := pc.Spec.Credentials.SecretRef
+		if ref == nil {
+			return nil, errors.New(errCredSecretNotGiven)
+		}
+		s = &corev1.Secret{}
+		if err := c.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}, s); err != nil {
+			return nil, err
+		}
+		version += "/" + s.GetResourceVersion()
+	case runtimev1alpha1.CredentialsSourceInjectedIdentity:
+	default:
+		return nil, errors.Errorf(errFmtUnsupportedCredSource, src)
 	}
 
-	// NOTE(muvaf): When we implement the workload identity, we will only need to
-	// return a different type of option.ClientOption, which is WithTokenSource().
-	if s := pc.Spec.Credentials.Source; s != runtimev1alpha1.CredentialsSourceSecret {
-		return nil, authorizer, errors.Errorf(errFmtUnsupportedCredSource, s)
+	key := authInfoKey(v1beta1.ProviderConfigKind, pc)
+	content := func() (map[string]string, error) {
+		m := InjectedIdentityCredentials(pc.Spec, os.Getenv)
+		if s != nil {
+			var err error
+			if m, err = parseCredentials(s.Data[pc.Spec.Credentials.SecretRef.Key]); err != nil {
+				return nil, err
+			}
+		}
+		return m, setProviderConfigEnvironment(ctx, pc, m)
+	}
+
+	kv := pc.Spec.KeyVault
+	if kv == nil {
+		return authInfos.load(key, version, 0, func() (map[string]string, error) {
+			m, err := content()
+			if err != nil {
+				return nil, err
+			}
+			setProviderConfigOverrides(pc, m)
+			return m, nil
+		})
 	}
 
-	ref := pc.Spec.Credentials.SecretRef
-	if ref == nil {
-		return nil, authorizer, errors.New(errCredSecretNotGiven)
+	// The credentials source is used to bootstrap access to the Key Vault
+	// that contains the actual credentials.
+	b, err := authInfos.load(key+"/bootstrap", version, 0, content)
+	if err != nil {
+		return nil, err
 	}
+	ttl := KeyVaultRefreshInterval
+	if kv.SecretVersion != nil {
+		ttl = 0
+	}
+	return authInfos.load(key, version, ttl, func() (map[string]string, error) {
+		r, err := KeyVaultResource(kv.VaultURL)
+		if err != nil {
+			return nil, err
+		}
+		a, err := b.Authorizer(r)
+		if err != nil {
+			return nil, errors.Wrap(err, errGetKeyVaultAuthorizer)
+		}
+		bm := b.Content()
+		m, err := KeyVaultCredentials(ctx, a, *kv, bm)
+		if err != nil {
+			return nil, err
+		}
+		// The environment was already resolved for the bootstrap credentials.
+		if pc.Spec.ResourceManagerEndpoint != nil || pc.Spec.Environment != nil {
+			for _, k := range environmentKeys {
+				m[k] = bm[k]
+			}
+		}
+		setProviderConfigOverrides(pc, m)
+		DefaultEnvironment(m)
+		return m, nil
+	})
+}
 
+// setProviderConfigOverrides sets the subscription and auxiliary tenants of
+// the supplied ProviderConfig, if any, in the supplied credentials content.
+func setProviderConfigOverrides(pc *v1beta1.ProviderConfig, m map[string]string) {
+	if pc.Spec.SubscriptionID != nil {
+		m[CredentialsKeySubscriptionID] = *pc.Spec.SubscriptionID
+	}
+	if len(pc.Spec.AuxiliaryTenantIDs) > 0 {
+		m[CredentialsKeyAuxiliaryTenantIDs] = strings.Join(pc.Spec.AuxiliaryTenantIDs, auxiliaryTenantIDsSeparator)
+	}
+}
+
+// setProviderConfigEnvironment sets the endpoints of the Azure environment
+// configured by the supplied ProviderConfig in the supplied credentials
+// content, defaulting to the Azure public cloud.
+func setProviderConfigEnvironment(ctx context.Context, pc *v1beta1.ProviderConfig, m map[string]string) error {
+	switch {
+	case pc.Spec.ResourceManagerEndpoint != nil:
+		if err := DiscoverEnvironment(ctx, MetadataClient, m, *pc.Spec.ResourceManagerEndpoint); err != nil {
+			return err
+		}
+	case pc.Spec.Environment != nil:
+		if err := SetEnvironment(m, *pc.Spec.Environment); err != nil {
+			return err
+		}
+	}
+	DefaultEnvironment(m)
+	return nil
+}
+
+// CredentialsFromSecret returns the content of the JSON encoded Azure
+// credentials stored in the supplied secret key.
+func CredentialsFromSecret(ctx context.Context, c client.Client, ref runtimev1alpha1.SecretKeySelector) (map[string]string, error) {
 	s := &corev1.Secret{}
 	if err := c.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}, s); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
+	return parseCredentials(s.Data[ref.Key])
+}
+
+func parseCredentials(data []byte) (map[string]string, error) {
 	m := map[string]string{}
-	if err := json.Unmarshal(s.Data[ref.Key], &m); err != nil {
-		return nil, nil, errors.Wrap(err, errUnmarshalCredentialSecret)
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, errors.Wrap(err, errUnmarshalCredentialSecret)
 	}
-	cfg := auth.NewClientCredentialsConfig(m[CredentialsKeyClientID], m[CredentialsKeyClientSecret], m[CredentialsKeyTenantID])
-	cfg.AADEndpoint = m[CredentialsKeyActiveDirectoryEndpointURL]
-	cfg.Resource = m[CredentialsKeyResourceManagerEndpointURL]
-
-	a, err := cfg.Authorizer()
-	return m, a, errors.Wrap(err, errGetAuthorizer)
+	if m[CredentialsKeyClientSecret] == "" && m[CredentialsKeyClientCertificate] == "" {
+		return nil, errors.New(errNoClientSecret)
+	}
+	return m, nil
 }
 
 // Client struct that represents the information needed to connect to the Azure services as a client
@@ -176,6 +390,8 @@ type Client struct {
 type Credentials struct {
 	ClientID                       string `json:"clientId"`
 	ClientSecret                   string `json:"clientSecret"`
+	ClientCertificate              string `json:"clientCertificate"`
+	ClientCertificatePassword      string `json:"clientCertificatePassword"`
 	TenantID                       string `json:"tenantId"`
 	SubscriptionID                 string `json:"subscriptionId"`
 	ActiveDirectoryEndpointURL     string `json:"activeDirectoryEndpointUrl"`
@@ -183,6 +399,21 @@ type Credentials struct {
 	ActiveDirectoryGraphResourceID string `json:"activeDirectoryGraphResourceId"`
 }
 
+// Map returns the credentials content keyed by the credentials keys.
+func (c Credentials) Map() map[string]string {
+	return map[string]string{
+		CredentialsKeyClientID:                       c.ClientID,
+		CredentialsKeyClientSecret:                   c.ClientSecret,
+		CredentialsKeyClientCertificate:              c.ClientCertificate,
+		CredentialsKeyClientCertificatePassword:      c.ClientCertificatePassword,
+		CredentialsKeyTenantID:                       c.TenantID,
+		CredentialsKeySubscriptionID:                 c.SubscriptionID,
+		CredentialsKeyActiveDirectoryEndpointURL:     c.ActiveDirectoryEndpointURL,
+		CredentialsKeyResourceManagerEndpointURL:     c.ResourceManagerEndpointURL,
+		CredentialsKeyActiveDirectoryGraphResourceID: c.ActiveDirectoryGraphResourceID,
+	}
+}
+
 // NewClient returns a client that can be used to connect to Azure services
 // using the supplied JSON credentials.
 func NewClient(credentials []byte) (*Client, error) {
@@ -191,12 +422,9 @@ func NewClient(credentials []byte) (*Client, error) {
 		return nil, errors.Wrap(err, "failed to unmarshal azure client secret data")
 	}
 
-	// create a config object from the loaded credentials data
-	config := auth.NewClientCredentialsConfig(creds.ClientID, creds.ClientSecret, creds.TenantID)
-	config.AADEndpoint = creds.ActiveDirectoryEndpointURL
-	config.Resource = creds.ResourceManagerEndpointURL
-
-	authorizer, err := config.Authorizer()
+	m := creds.Map()
+	DefaultEnvironment(m)
+	authorizer, err := NewAuthorizer(m, ResourceManagerAudience(m))
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get authorizer from config")
 	}
@@ -207,9 +435,12 @@ func NewClient(credentials []byte) (*Client, error) {
 			SubscriptionID:                 creds.SubscriptionID,
 			ClientID:                       creds.ClientID,
 			ClientSecret:                   creds.ClientSecret,
+			ClientCertificate:              creds.ClientCertificate,
+			ClientCertificatePassword:      creds.ClientCertificatePassword,
 			TenantID:                       creds.TenantID,
-			ActiveDirectoryEndpointURL:     creds.ActiveDirectoryEndpointURL,
-			ActiveDirectoryGraphResourceID: creds.ActiveDirectoryGraphResourceID,
+			ActiveDirectoryEndpointURL:     m[CredentialsKeyActiveDirectoryEndpointURL],
+			ResourceManagerEndpointURL:     m[CredentialsKeyResourceManagerEndpointURL],
+			ActiveDirectoryGraphResourceID: m[CredentialsKeyActiveDirectoryGraphResourceID],
 		},
 	}, nil
 }
@@ -217,12 +448,60 @@ func NewClient(credentials []byte) (*Client, error) {
 // ValidateClient verifies if the given client is valid by testing if it can make an Azure service API call
 // TODO: is there a better way to validate the Azure client?
 func ValidateClient(client *Client) error {
-	groupsClient := resources.NewGroupsClient(client.SubscriptionID)
-	groupsClient.Authorizer = client.Authorizer
-	groupsClient.AddToUserAgent(UserAgent)
+	return validate(context.TODO(), client.Authorizer, client.ResourceManagerEndpointURL, client.SubscriptionID)
+}
+
+// ValidateAuthInfo verifies that the supplied AuthInfo can be used to acquire
+// an Azure AD token for the Azure Resource Manager, and to make a cheap Azure
+// Resource Manager API call in its subscription.
+func ValidateAuthInfo(ctx context.Context, i *AuthInfo) error {
+	m := i.Content()
+	a, err := i.Authorizer(ResourceManagerAudience(m))
+	if err != nil {
+		return errors.Wrap(err, errGetAuthorizer)
+	}
+	// Preparing a request with the authorizer acquires a token, or refreshes
+	// it if necessary, without sending the request.
+	r, err := http.NewRequest(http.MethodGet, m[CredentialsKeyResourceManagerEndpointURL], nil)
+	if err != nil {
+		return errors.Wrap(err, errAcquireToken)
+	}
+	if _, err := autorest.Prepare(r.WithContext(ctx), a.WithAuthorization()); err != nil {
+		return errors.Wrap(err, errAcquireToken)
+	}
+	return validate(ctx, a, m[CredentialsKeyResourceManagerEndpointURL], m[CredentialsKeySubscriptionID])
+}
 
-	_, err := groupsClient.ListComplete(context.TODO(), "", nil)
-	return err
+func validate(ctx context.Context, a autorest.Authorizer, baseURI, subscriptionID string) error {
+	groupsClient := resources.NewGroupsClientWithBaseURI(baseURI, subscriptionID)
+	ConfigureClient(&groupsClient.Client, a)
+	_ = groupsClient.AddToUserAgent(UserAgent)
+
+	_, err := groupsClient.List(ctx, "", to.Int32Ptr(1))
+	return errors.Wrap(err, errListResourceGroups)
+}
+
+// aadErrorCode matches Azure AD error codes, e.g. AADSTS7000215.
+var aadErrorCode = regexp.MustCompile(`AADSTS\d+`)
+
+// ErrorCode returns the Azure AD error code, e.g. AADSTS7000215, or the Azure
+// Resource Manager error code, e.g. AuthorizationFailed, of the supplied error.
+// It returns an empty string if the error has no such code.
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	if c := aadErrorCode.FindString(err.Error()); c != "" {
+		return c
+	}
+	var de autorest.DetailedError
+	if !errors.As(err, &de) {
+		return ""
+	}
+	if se := serviceError(de); se != nil {
+		return se.Code
+	}
+	return ""
 }
 
 // FetchAsyncOperation updates the given operation object with the most up-to-date
@@ -262,6 +541,91 @@ func FetchAsyncOperation(ctx context.Context, client autorest.Sender, as *v1alph
 	return nil
 }
 
+// NewAsyncOperation returns an AsyncOperation that tracks the supplied future,
+// which was returned for a request made with the supplied HTTP method.
+func NewAsyncOperation(method string, f azure.Future) v1alpha3.AsyncOperation {
+	as := v1alpha3.AsyncOperation{
+		Method:     method,
+		PollingURL: f.PollingURL(),
+		Status:     f.Status(),
+	}
+	inFlight.track(as)
+	return as
+}
+
+// AsyncOperationInProgress returns true if the supplied operation was started
+// by a request made with the supplied HTTP method and is still ongoing.
+func AsyncOperationInProgress(as v1alpha3.AsyncOperation, method string) bool {
+	return as.Method == method && as.Status == AsyncOperationStatusInProgress
+}
+
+// LastAsyncOperationInProgress returns a condition indicating that the last
+// asynchronous operation of a managed resource is still ongoing.
+func LastAsyncOperationInProgress() runtimev1alpha1.Condition {
+	return runtimev1alpha1.Condition{
+		Type:               TypeLastAsyncOperation,
+		Status:             corev1.ConditionUnknown,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonAsyncOperationInProgress,
+	}
+}
+
+// LastAsyncOperationSucceeded returns a condition indicating that the last
+// asynchronous operation of a managed resource has succeeded.
+func LastAsyncOperationSucceeded() runtimev1alpha1.Condition {
+	return runtimev1alpha1.Condition{
+		Type:               TypeLastAsyncOperation,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonAsyncOperationSucceeded,
+	}
+}
+
+// LastAsyncOperationFailed returns a condition indicating that the last
+// asynchronous operation of a managed resource has failed or was canceled.
+func LastAsyncOperationFailed(msg string) runtimev1alpha1.Condition {
+	return runtimev1alpha1.Condition{
+		Type:               TypeLastAsyncOperation,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonAsyncOperationFailed,
+		Message:            msg,
+	}
+}
+
+// UpdateLastOperation fetches the status of the supplied operation unless it
+// has already completed, and reports it as the LastAsyncOperation condition of
+// the supplied resource. It returns an error if the operation failed or was
+// canceled since its status was last fetched, so that the failure is recorded
+// as an event by the managed resource reconciler. Operations that completed
+// are not fetched again; their polling URLs eventually expire.
+func UpdateLastOperation(ctx context.Context, client autorest.Sender, o resource.Conditioned, as *v1alpha3.AsyncOperation) error {
+	status := as.Status
+	if status == "" || status == AsyncOperationStatusInProgress {
+		if err := FetchAsyncOperation(ctx, client, as); err != nil {
+			return errors.Wrap(err, errFetchAsyncOperation)
+		}
+	}
+	inFlight.track(*as)
+
+	switch as.Status {
+	case AsyncOperationStatusInProgress:
+		o.SetConditions(LastAsyncOperationInProgress())
+	case AsyncOperationStatusSucceeded:
+		o.SetConditions(LastAsyncOperationSucceeded())
+	case AsyncOperationStatusFailed, AsyncOperationStatusCanceled:
+		msg := as.ErrorMessage
+		if msg == "" {
+			msg = "no error message was returned"
+		}
+		o.SetConditions(LastAsyncOperationFailed(msg))
+		if status != as.Status {
+			return errors.Errorf(errFmtAsyncOperationFailed, as.Method, strings.ToLower(as.Status), msg)
+		}
+	}
+	return nil
+}
+
 // IsNotFound returns a value indicating whether the given error represents that the resource was not found.
 func IsNotFound(err error) bool {
 	detailedError, ok := err.(autorest.DetailedError)
@@ -26,12 +26,25 @@ import (
 	"testing"
 
 	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/google/go-cmp/cmp"
 	"github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 
 	"github.com/crossplane/provider-azure/apis/v1alpha3"
+	"github.com/crossplane/provider-azure/apis/v1beta1"
 )
 
 const (
@@ -145,6 +158,116 @@ func TestFetchAsyncOperation(t *testing.T) {
 
 }
 
+func TestUpdateLastOperation(t *testing.T) {
+	failedResponse := `{"status": "Failed", "error": {"code": "Conflict", "message": "boom"}}`
+	failedMessage := `Code="Conflict" Message="boom"`
+
+	pollingURL := "https://crossplane.io"
+
+	type args struct {
+		sender autorest.Sender
+		as     *v1alpha3.AsyncOperation
+	}
+	type want struct {
+		op         *v1alpha3.AsyncOperation
+		conditions []runtimev1alpha1.Condition
+		err        error
+	}
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"NoOperation": {
+			args: args{
+				as: &v1alpha3.AsyncOperation{},
+			},
+			want: want{
+				op: &v1alpha3.AsyncOperation{},
+			},
+		},
+		"Failed": {
+			args: args{
+				as: &v1alpha3.AsyncOperation{
+					Method:     http.MethodPut,
+					PollingURL: pollingURL,
+					Status:     AsyncOperationStatusInProgress,
+				},
+				sender: autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+					req.URL, _ = url.Parse("https://crossplane.io/resource1")
+					return &http.Response{
+						Request:       req,
+						StatusCode:    http.StatusOK,
+						Body:          ioutil.NopCloser(strings.NewReader(failedResponse)),
+						ContentLength: int64(len([]byte(failedResponse))),
+					}, nil
+				}),
+			},
+			want: want{
+				op: &v1alpha3.AsyncOperation{
+					Method:       http.MethodPut,
+					PollingURL:   pollingURL,
+					Status:       AsyncOperationStatusFailed,
+					ErrorMessage: failedMessage,
+				},
+				conditions: []runtimev1alpha1.Condition{LastAsyncOperationFailed(failedMessage)},
+				err:        errors.Errorf(errFmtAsyncOperationFailed, http.MethodPut, "failed", failedMessage),
+			},
+		},
+		"AlreadyFailed": {
+			args: args{
+				as: &v1alpha3.AsyncOperation{
+					Method:       http.MethodPut,
+					PollingURL:   pollingURL,
+					Status:       AsyncOperationStatusFailed,
+					ErrorMessage: failedMessage,
+				},
+			},
+			want: want{
+				op: &v1alpha3.AsyncOperation{
+					Method:       http.MethodPut,
+					PollingURL:   pollingURL,
+					Status:       AsyncOperationStatusFailed,
+					ErrorMessage: failedMessage,
+				},
+				conditions: []runtimev1alpha1.Condition{LastAsyncOperationFailed(failedMessage)},
+			},
+		},
+		"AlreadySucceeded": {
+			args: args{
+				as: &v1alpha3.AsyncOperation{
+					Method:     http.MethodDelete,
+					PollingURL: pollingURL,
+					Status:     AsyncOperationStatusSucceeded,
+				},
+			},
+			want: want{
+				op: &v1alpha3.AsyncOperation{
+					Method:     http.MethodDelete,
+					PollingURL: pollingURL,
+					Status:     AsyncOperationStatusSucceeded,
+				},
+				conditions: []runtimev1alpha1.Condition{LastAsyncOperationSucceeded()},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			mg := &fake.Managed{}
+			err := UpdateLastOperation(context.Background(), tc.args.sender, mg, tc.args.as)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("UpdateLastOperation(...): -want error, +got error:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.op, tc.args.as); diff != "" {
+				t.Errorf("UpdateLastOperation(...): -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(runtimev1alpha1.ConditionedStatus{Conditions: tc.want.conditions}, mg.ConditionedStatus, test.EquateConditions()); diff != "" {
+				t.Errorf("UpdateLastOperation(...): -want conditions, +got conditions:\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestIsNotFound(t *testing.T) {
 	g := gomega.NewGomegaWithT(t)
 
@@ -163,6 +286,100 @@ func TestIsNotFound(t *testing.T) {
 	}
 }
 
+func TestUseProviderConfig(t *testing.T) {
+	secret := `{"clientId": "cool-client", "clientSecret": "cool-secret", "tenantId": "cool-tenant", "subscriptionId": "secret-subscription"}`
+
+	type args struct {
+		spec        v1beta1.ProviderConfigSpec
+		annotations map[string]string
+	}
+	cases := map[string]struct {
+		args args
+		want string
+	}{
+		"SecretSubscription": {
+			want: "secret-subscription",
+		},
+		"ProviderConfigSubscription": {
+			args: args{
+				spec: v1beta1.ProviderConfigSpec{SubscriptionID: to.StringPtr("pc-subscription")},
+			},
+			want: "pc-subscription",
+		},
+		"ResourceSubscription": {
+			args: args{
+				spec:        v1beta1.ProviderConfigSpec{SubscriptionID: to.StringPtr("pc-subscription")},
+				annotations: map[string]string{AnnotationKeySubscriptionID: "resource-subscription"},
+			},
+			want: "resource-subscription",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			kube := &test.MockClient{
+				MockGet: func(_ context.Context, _ client.ObjectKey, obj runtime.Object) error {
+					switch o := obj.(type) {
+					case *v1beta1.ProviderConfig:
+						o.SetUID(types.UID(name))
+						o.Spec = tc.args.spec
+						o.Spec.Credentials.Source = runtimev1alpha1.CredentialsSourceSecret
+						o.Spec.Credentials.SecretRef = &runtimev1alpha1.SecretKeySelector{Key: "creds"}
+					case *corev1.Secret:
+						o.Data = map[string][]byte{"creds": []byte(secret)}
+					default:
+						return kerrors.NewNotFound(schema.GroupResource{}, "")
+					}
+					return nil
+				},
+				MockCreate: test.NewMockCreateFn(nil),
+			}
+			mg := &fake.Managed{
+				ObjectMeta:               metav1.ObjectMeta{UID: "cool-resource", Annotations: tc.args.annotations},
+				ProviderConfigReferencer: fake.ProviderConfigReferencer{Ref: &runtimev1alpha1.Reference{Name: "cool-pc"}},
+			}
+
+			creds, _, err := UseProviderConfig(context.Background(), kube, mg)
+			if err != nil {
+				t.Fatalf("UseProviderConfig(...): %s", err)
+			}
+			if diff := cmp.Diff(tc.want, creds[CredentialsKeySubscriptionID]); diff != "" {
+				t.Errorf("UseProviderConfig(...): -want subscription, +got subscription:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	cases := map[string]struct {
+		err  error
+		want string
+	}{
+		"Nil": {},
+		"NoCode": {
+			err: errors.New("boom"),
+		},
+		"AzureAD": {
+			err:  errors.Wrap(errors.New("adal: Refresh request failed. Response body: AADSTS7000215: Invalid client secret provided."), "cannot acquire token"),
+			want: "AADSTS7000215",
+		},
+		"ResourceManager": {
+			err: errors.Wrap(autorest.DetailedError{
+				Original: &azure.RequestError{ServiceError: &azure.ServiceError{Code: "AuthorizationFailed"}},
+			}, "cannot list resource groups"),
+			want: "AuthorizationFailed",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, ErrorCode(tc.err)); diff != "" {
+				t.Errorf("ErrorCode(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestStringHelpers(t *testing.T) {
 	t.Run("ToStringMap", func(t *testing.T) {
 		original := make(map[string]*string)
@@ -0,0 +1,166 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// authInfos caches the AuthInfo of every ProviderConfig and Provider, so that
+// their authorizers, and thus their Azure AD tokens, are shared by all
+// controllers.
+var authInfos = newAuthInfoCache()
+
+// AuthInfo is the information required to connect to the Azure API, i.e. the
+// content of Azure credentials and the authorizers created from it. It is
+// safe for concurrent use.
+type AuthInfo struct {
+	content map[string]string
+
+	subscriptionID string
+	authorizers    *authorizers
+}
+
+// authorizers are created once per resource and shared by all copies of an
+// AuthInfo.
+type authorizers struct {
+	mu sync.Mutex
+	m  map[string]autorest.Authorizer
+}
+
+// NewAuthInfo returns AuthInfo for the supplied credentials content.
+func NewAuthInfo(content map[string]string) *AuthInfo {
+	return &AuthInfo{content: content, authorizers: &authorizers{m: map[string]autorest.Authorizer{}}}
+}
+
+// WithSubscriptionID returns a copy of the AuthInfo whose content has the
+// supplied subscription ID. The copy shares the authorizers of the AuthInfo.
+func (i *AuthInfo) WithSubscriptionID(id string) *AuthInfo {
+	c := *i
+	c.subscriptionID = id
+	return &c
+}
+
+// Content returns a copy of the credentials content.
+func (i *AuthInfo) Content() map[string]string {
+	m := make(map[string]string, len(i.content))
+	for k, v := range i.content {
+		m[k] = v
+	}
+	if i.subscriptionID != "" {
+		m[CredentialsKeySubscriptionID] = i.subscriptionID
+	}
+	return m
+}
+
+// Authorizer returns an authorizer for the supplied resource, e.g. the Azure
+// Resource Manager or the Azure AD Graph endpoint. Authorizers are created once
+// per resource and refresh their Azure AD token only when it expires.
+func (i *AuthInfo) Authorizer(resource string) (autorest.Authorizer, error) {
+	i.authorizers.mu.Lock()
+	defer i.authorizers.mu.Unlock()
+	if a, ok := i.authorizers.m[resource]; ok {
+		return a, nil
+	}
+	a, err := NewAuthorizer(i.content, resource)
+	if err != nil {
+		return nil, err
+	}
+	i.authorizers.m[resource] = a
+	return a, nil
+}
+
+// resourceManager returns the credentials content and an authorizer for the
+// Azure Resource Manager.
+func (i *AuthInfo) resourceManager() (map[string]string, autorest.Authorizer, error) {
+	m := i.Content()
+	a, err := i.Authorizer(ResourceManagerAudience(m))
+	return m, a, errors.Wrap(err, errGetAuthorizer)
+}
+
+// An authInfoCache caches AuthInfo by key, e.g. the ProviderConfig it was read
+// from, and version, e.g. the resource versions of the ProviderConfig and its
+// credentials secret. Only one version is cached per key.
+type authInfoCache struct {
+	mu      sync.RWMutex
+	entries map[string]authInfoEntry
+}
+
+// An authInfoEntry is a cached AuthInfo, the version it was read at and, if
+// it has no version, when it expires.
+type authInfoEntry struct {
+	info    *AuthInfo
+	version string
+	expires time.Time
+}
+
+// fresh returns true if the entry is of the supplied version and has not
+// expired.
+func (e authInfoEntry) fresh(version string) bool {
+	return e.version == version && (e.expires.IsZero() || time.Now().Before(e.expires))
+}
+
+func newAuthInfoCache() *authInfoCache {
+	return &authInfoCache{entries: map[string]authInfoEntry{}}
+}
+
+// load returns the cached AuthInfo of the supplied key if it is of the
+// supplied version. Otherwise it caches and returns AuthInfo for the
+// credentials content returned by the supplied function. Content that is read
+// from outside the API server, which has no version, may instead be cached for
+// the supplied time to live. Once it expires the content is read again, but the
+// cached AuthInfo and thus its authorizers are kept unless the content changed.
+// Errors are not cached.
+func (c *authInfoCache) load(key, version string, ttl time.Duration, content func() (map[string]string, error)) (*AuthInfo, error) {
+	c.mu.RLock()
+	e, ok := c.entries[key]
+	c.mu.RUnlock()
+	if ok && e.fresh(version) {
+		return e.info, nil
+	}
+
+	m, err := content()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Another caller may have cached the same or newer content meanwhile.
+	e, ok = c.entries[key]
+	if !ok || e.version != version || !reflect.DeepEqual(e.info.content, m) {
+		e = authInfoEntry{info: NewAuthInfo(m), version: version}
+	}
+	e.expires = time.Time{}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+	c.entries[key] = e
+	return e.info, nil
+}
+
+// authInfoKey returns the cache key of AuthInfo read from the supplied object
+// of the supplied kind.
+func authInfoKey(kind string, o metav1.Object) string {
+	return kind + "/" + o.GetName() + "/" + string(o.GetUID())
+}
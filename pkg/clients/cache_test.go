@@ -0,0 +1,207 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestAuthInfoCacheLoad(t *testing.T) {
+	errBoom := errors.New("boom")
+	c := newAuthInfoCache()
+
+	calls := 0
+	content := func(m map[string]string, err error) func() (map[string]string, error) {
+		return func() (map[string]string, error) {
+			calls++
+			return m, err
+		}
+	}
+
+	type want struct {
+		content map[string]string
+		calls   int
+		err     error
+	}
+	cases := []struct {
+		name    string
+		key     string
+		version string
+		content func() (map[string]string, error)
+		want    want
+	}{
+		{
+			name:    "Miss",
+			key:     "ProviderConfig/cool",
+			version: "1/1",
+			content: content(map[string]string{CredentialsKeyClientID: "cool-client"}, nil),
+			want:    want{content: map[string]string{CredentialsKeyClientID: "cool-client"}, calls: 1},
+		},
+		{
+			name:    "Hit",
+			key:     "ProviderConfig/cool",
+			version: "1/1",
+			content: content(map[string]string{CredentialsKeyClientID: "other-client"}, nil),
+			want:    want{content: map[string]string{CredentialsKeyClientID: "cool-client"}, calls: 1},
+		},
+		{
+			name:    "SecretChanged",
+			key:     "ProviderConfig/cool",
+			version: "1/2",
+			content: content(map[string]string{CredentialsKeyClientID: "rotated-client"}, nil),
+			want:    want{content: map[string]string{CredentialsKeyClientID: "rotated-client"}, calls: 2},
+		},
+		{
+			name:    "ErrorNotCached",
+			key:     "ProviderConfig/cool",
+			version: "1/3",
+			content: content(nil, errBoom),
+			want:    want{calls: 3, err: errBoom},
+		},
+		{
+			name:    "RetryAfterError",
+			key:     "ProviderConfig/cool",
+			version: "1/3",
+			content: content(map[string]string{CredentialsKeyClientID: "fixed-client"}, nil),
+			want:    want{content: map[string]string{CredentialsKeyClientID: "fixed-client"}, calls: 4},
+		},
+	}
+
+	for _, tc := range cases {
+		i, err := c.load(tc.key, tc.version, 0, tc.content)
+		if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+			t.Errorf("%s: load(...): -want error, +got error:\n%s", tc.name, diff)
+		}
+		if diff := cmp.Diff(tc.want.calls, calls); diff != "" {
+			t.Errorf("%s: load(...): -want calls, +got calls:\n%s", tc.name, diff)
+		}
+		if i == nil {
+			continue
+		}
+		if diff := cmp.Diff(tc.want.content, i.Content()); diff != "" {
+			t.Errorf("%s: load(...): -want content, +got content:\n%s", tc.name, diff)
+		}
+	}
+}
+
+func TestAuthInfoCacheLoadTTL(t *testing.T) {
+	c := newAuthInfoCache()
+	value := "cool-secret"
+	content := func() (map[string]string, error) {
+		return map[string]string{CredentialsKeyClientSecret: value}, nil
+	}
+
+	expire := func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		e := c.entries["ProviderConfig/cool"]
+		e.expires = time.Now().Add(-time.Second)
+		c.entries["ProviderConfig/cool"] = e
+	}
+
+	first, _ := c.load("ProviderConfig/cool", "1", time.Hour, content)
+	if got, _ := c.load("ProviderConfig/cool", "1", time.Hour, content); got != first {
+		t.Errorf("load(...): want cached AuthInfo before it expires")
+	}
+
+	// Expire the cached AuthInfo without changing the content.
+	expire()
+	unchanged, _ := c.load("ProviderConfig/cool", "1", time.Hour, content)
+	if unchanged != first {
+		t.Errorf("load(...): want cached AuthInfo when expired content did not change")
+	}
+	if !c.entries["ProviderConfig/cool"].expires.After(time.Now()) {
+		t.Errorf("load(...): want expiry of unchanged AuthInfo to be extended")
+	}
+
+	// Expire the cached AuthInfo and change the content.
+	expire()
+	value = "rotated-secret"
+	changed, _ := c.load("ProviderConfig/cool", "1", time.Hour, content)
+	if changed == first {
+		t.Errorf("load(...): want new AuthInfo when expired content changed")
+	}
+	if diff := cmp.Diff(map[string]string{CredentialsKeyClientSecret: "rotated-secret"}, changed.Content()); diff != "" {
+		t.Errorf("load(...): -want content, +got content:\n%s", diff)
+	}
+}
+
+func TestAuthInfoAuthorizer(t *testing.T) {
+	i := NewAuthInfo(map[string]string{
+		CredentialsKeyClientID:                   "cool-client",
+		CredentialsKeyClientSecret:               "cool-secret",
+		CredentialsKeyTenantID:                   "cool-tenant",
+		CredentialsKeyActiveDirectoryEndpointURL: "https://login.microsoftonline.com/",
+	})
+
+	arm, err := i.Authorizer("https://management.azure.com/")
+	if err != nil {
+		t.Fatalf("Authorizer(...): %s", err)
+	}
+	again, err := i.Authorizer("https://management.azure.com/")
+	if err != nil {
+		t.Fatalf("Authorizer(...): %s", err)
+	}
+	if arm != again {
+		t.Errorf("Authorizer(...): want the cached authorizer for the same resource")
+	}
+	graph, err := i.Authorizer("https://graph.windows.net/")
+	if err != nil {
+		t.Fatalf("Authorizer(...): %s", err)
+	}
+	if arm == graph {
+		t.Errorf("Authorizer(...): want a distinct authorizer for a different resource")
+	}
+}
+
+func TestAuthInfoCacheLoadConcurrent(t *testing.T) {
+	c := newAuthInfoCache()
+	content := func() (map[string]string, error) {
+		return map[string]string{CredentialsKeyClientSecret: "cool-secret"}, nil
+	}
+	first, _ := c.load("ProviderConfig/cool", "1", time.Nanosecond, content)
+
+	// The cached AuthInfo expires immediately, so every load refreshes it
+	// while others read it. Run with -race to detect unsynchronized access.
+	var wg sync.WaitGroup
+	for n := 0; n < 16; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				i, err := c.load("ProviderConfig/cool", "1", time.Nanosecond, content)
+				if err != nil {
+					t.Errorf("load(...): %s", err)
+					return
+				}
+				if i != first {
+					t.Errorf("load(...): want cached AuthInfo when expired content did not change")
+					return
+				}
+				_ = i.Content()
+			}
+		}()
+	}
+	wg.Wait()
+}
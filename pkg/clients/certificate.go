@@ -0,0 +1,124 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pkcs12"
+)
+
+// PEM block types that may appear in a client certificate.
+const (
+	pemTypeCertificate   = "CERTIFICATE"
+	pemTypeRSAPrivateKey = "RSA PRIVATE KEY"
+	pemTypePrivateKey    = "PRIVATE KEY"
+)
+
+const (
+	errDecodeCertificateBase64 = "client certificate is neither PEM nor base64 encoded PKCS#12"
+	errDecodePKCS12            = "cannot decode PKCS#12 client certificate"
+	errParseCertificate        = "cannot parse client certificate"
+	errParsePrivateKey         = "cannot parse client certificate private key"
+	errDecryptPrivateKey       = "cannot decrypt client certificate private key"
+	errNoCertificate           = "client certificate does not contain a certificate"
+	errNoPrivateKey            = "client certificate does not contain a private key"
+	errNotRSAPrivateKey        = "client certificate private key is not an RSA key"
+)
+
+// ParseClientCertificate returns the certificate and RSA private key of the
+// supplied service principal client certificate. The certificate may either be
+// PEM encoded, containing both the certificate and its private key, or a base64
+// encoded PKCS#12 (PFX) archive. The password is used to decrypt the PKCS#12
+// archive or an encrypted PEM private key, and may be empty.
+func ParseClientCertificate(data []byte, password string) (*x509.Certificate, *rsa.PrivateKey, error) {
+	if bytes.Contains(data, []byte("-----BEGIN")) {
+		return parsePEMCertificate(data, password)
+	}
+
+	pfx, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, errDecodeCertificateBase64)
+	}
+	key, cert, err := pkcs12.Decode(pfx, password)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, errDecodePKCS12)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, nil, errors.New(errNotRSAPrivateKey)
+	}
+	return cert, rsaKey, nil
+}
+
+func parsePEMCertificate(data []byte, password string) (*x509.Certificate, *rsa.PrivateKey, error) { // nolint:gocyclo
+	var cert *x509.Certificate
+	var key *rsa.PrivateKey
+
+	for b, rest := pem.Decode(data); b != nil; b, rest = pem.Decode(rest) {
+		switch b.Type {
+		case pemTypeCertificate:
+			// The first certificate is the client certificate. Any others
+			// belong to its chain.
+			if cert != nil {
+				continue
+			}
+			c, err := x509.ParseCertificate(b.Bytes)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, errParseCertificate)
+			}
+			cert = c
+		case pemTypeRSAPrivateKey:
+			der := b.Bytes
+			if x509.IsEncryptedPEMBlock(b) { // nolint:staticcheck
+				d, err := x509.DecryptPEMBlock(b, []byte(password)) // nolint:staticcheck
+				if err != nil {
+					return nil, nil, errors.Wrap(err, errDecryptPrivateKey)
+				}
+				der = d
+			}
+			k, err := x509.ParsePKCS1PrivateKey(der)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, errParsePrivateKey)
+			}
+			key = k
+		case pemTypePrivateKey:
+			k, err := x509.ParsePKCS8PrivateKey(b.Bytes)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, errParsePrivateKey)
+			}
+			rsaKey, ok := k.(*rsa.PrivateKey)
+			if !ok {
+				return nil, nil, errors.New(errNotRSAPrivateKey)
+			}
+			key = rsaKey
+		}
+	}
+
+	if cert == nil {
+		return nil, nil, errors.New(errNoCertificate)
+	}
+	if key == nil {
+		return nil, nil, errors.New(errNoPrivateKey)
+	}
+	return cert, key, nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestParseClientCertificate(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "crossplane"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := x509.EncryptPEMBlock(rand.Reader, pemTypeRSAPrivateKey, x509.MarshalPKCS1PrivateKey(key), []byte("cool-password"), x509.PEMCipherAES256) // nolint:staticcheck
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: pemTypeCertificate, Bytes: der})
+	pkcs1PEM := pem.EncodeToMemory(&pem.Block{Type: pemTypeRSAPrivateKey, Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	pkcs8PEM := pem.EncodeToMemory(&pem.Block{Type: pemTypePrivateKey, Bytes: pkcs8})
+	encryptedPEM := pem.EncodeToMemory(encrypted)
+
+	type args struct {
+		data     []byte
+		password string
+	}
+	type want struct {
+		ok  bool
+		err error
+	}
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"PKCS1": {
+			args: args{data: append(append([]byte{}, certPEM...), pkcs1PEM...)},
+			want: want{ok: true},
+		},
+		"PKCS8": {
+			args: args{data: append(append([]byte{}, pkcs8PEM...), certPEM...)},
+			want: want{ok: true},
+		},
+		"EncryptedPKCS1": {
+			args: args{data: append(append([]byte{}, certPEM...), encryptedPEM...), password: "cool-password"},
+			want: want{ok: true},
+		},
+		"WrongPassword": {
+			args: args{data: append(append([]byte{}, certPEM...), encryptedPEM...), password: "not-cool"},
+			want: want{err: errors.Wrap(x509.IncorrectPasswordError, errDecryptPrivateKey)},
+		},
+		"NoPrivateKey": {
+			args: args{data: certPEM},
+			want: want{err: errors.New(errNoPrivateKey)},
+		},
+		"NoCertificate": {
+			args: args{data: pkcs1PEM},
+			want: want{err: errors.New(errNoCertificate)},
+		},
+		"NotBase64": {
+			args: args{data: []byte("definitely not a certificate")},
+			want: want{err: errors.Wrap(errors.New("illegal base64 data at input byte 10"), errDecodeCertificateBase64)},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cert, k, err := ParseClientCertificate(tc.args.data, tc.args.password)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("ParseClientCertificate(...): -want error, +got error:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.ok, cert != nil && k != nil && k.N.Cmp(key.N) == 0); diff != "" {
+				t.Errorf("ParseClientCertificate(...): -want parsed, +got parsed:\n%s", diff)
+			}
+		})
+	}
+}
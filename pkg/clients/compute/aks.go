@@ -19,6 +19,7 @@ package compute
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/authorization/mgmt/2015-07-01/authorization"
@@ -26,7 +27,6 @@ import (
 	"github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2018-03-31/containerservice"
 	"github.com/Azure/azure-sdk-for-go/services/graphrbac/1.6/graphrbac"
 	"github.com/Azure/go-autorest/autorest"
-	"github.com/Azure/go-autorest/autorest/adal"
 	"github.com/Azure/go-autorest/autorest/date"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/google/uuid"
@@ -58,6 +58,7 @@ type AKSClient interface {
 	EnsureManagedCluster(ctx context.Context, ac *v1alpha3.AKSCluster, secret string) error
 	DeleteManagedCluster(ctx context.Context, ac *v1alpha3.AKSCluster) error
 	GetKubeConfig(ctx context.Context, ac *v1alpha3.AKSCluster) ([]byte, error)
+	GetRESTClient() autorest.Sender
 }
 
 // An AggregateClient aggregates the various clients used by the AKS controller.
@@ -69,39 +70,34 @@ type AggregateClient struct {
 }
 
 // NewAggregateClient produces the various clients used by the AKS controller.
-func NewAggregateClient(creds map[string]string, auth autorest.Authorizer) (AKSClient, error) {
-	mcc := containerservice.NewManagedClustersClient(creds[azure.CredentialsKeySubscriptionID])
-	mcc.Authorizer = auth
+func NewAggregateClient(i *azure.AuthInfo) (AKSClient, error) {
+	creds := i.Content()
+	auth, err := i.Authorizer(azure.ResourceManagerAudience(creds))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create Resource Manager authorizer")
+	}
+
+	mcc := containerservice.NewManagedClustersClientWithBaseURI(creds[azure.CredentialsKeyResourceManagerEndpointURL], creds[azure.CredentialsKeySubscriptionID])
+	azure.ConfigureClient(&mcc.Client, auth)
 	_ = mcc.AddToUserAgent(azure.UserAgent)
 
-	rac := authorization.NewRoleAssignmentsClient(creds[azure.CredentialsKeySubscriptionID])
-	rac.Authorizer = auth
+	rac := authorization.NewRoleAssignmentsClientWithBaseURI(creds[azure.CredentialsKeyResourceManagerEndpointURL], creds[azure.CredentialsKeySubscriptionID])
+	azure.ConfigureClient(&rac.Client, auth)
 	_ = rac.AddToUserAgent(azure.UserAgent)
 
-	cfg, err := adal.NewOAuthConfig(creds[azure.CredentialsKeyActiveDirectoryEndpointURL], creds[azure.CredentialsKeyTenantID])
+	ta, err := i.Authorizer(creds[azure.CredentialsKeyActiveDirectoryGraphResourceID])
 	if err != nil {
-		return nil, errors.Wrap(err, "cannot create OAuth configuration")
-	}
-
-	token, err := adal.NewServicePrincipalToken(*cfg,
-		creds[azure.CredentialsKeyClientID],
-		creds[azure.CredentialsKeyClientSecret],
-		creds[azure.CredentialsKeyActiveDirectoryGraphResourceID])
-	if err != nil {
-		return nil, errors.Wrap(err, "cannot create service principal token")
-	}
-	if err := token.Refresh(); err != nil {
-		return nil, errors.Wrap(err, "cannot refresh service principal token")
+		return nil, errors.Wrap(err, "cannot create Graph authorizer")
 	}
 
-	ta := autorest.NewBearerAuthorizer(token)
-
-	ac := graphrbac.NewApplicationsClient(creds[azure.CredentialsKeyTenantID])
+	ac := graphrbac.NewApplicationsClientWithBaseURI(creds[azure.CredentialsKeyActiveDirectoryGraphResourceID], creds[azure.CredentialsKeyTenantID])
 	ac.Authorizer = ta
+	ac.Sender = autorest.DecorateSender(azure.Transport, azure.Metrics, azure.Tracing, azure.DryRunIntercept)
 	_ = ac.AddToUserAgent(azure.UserAgent)
 
-	spc := graphrbac.NewServicePrincipalsClient(creds[azure.CredentialsKeyTenantID])
+	spc := graphrbac.NewServicePrincipalsClientWithBaseURI(creds[azure.CredentialsKeyActiveDirectoryGraphResourceID], creds[azure.CredentialsKeyTenantID])
 	spc.Authorizer = ta
+	spc.Sender = autorest.DecorateSender(azure.Transport, azure.Metrics, azure.Tracing, azure.DryRunIntercept)
 	_ = spc.AddToUserAgent(azure.UserAgent)
 
 	return AggregateClient{
@@ -135,8 +131,12 @@ func (c AggregateClient) EnsureManagedCluster(ctx context.Context, ac *v1alpha3.
 	}
 
 	mc := newManagedCluster(ac, to.String(app.AppID), secret)
-	_, err = c.ManagedClusters.CreateOrUpdate(ctx, ac.Spec.ResourceGroupName, meta.GetExternalName(ac), mc)
-	return err
+	f, err := c.ManagedClusters.CreateOrUpdate(ctx, ac.Spec.ResourceGroupName, meta.GetExternalName(ac), mc)
+	if err != nil {
+		return err
+	}
+	ac.Status.LastOperation = azure.NewAsyncOperation(http.MethodPut, f.Future)
+	return nil
 }
 
 // DeleteManagedCluster deletes the supplied AKS cluster, including its service
@@ -145,8 +145,17 @@ func (c AggregateClient) DeleteManagedCluster(ctx context.Context, ac *v1alpha3.
 	if err := c.deleteApplication(ctx, meta.GetExternalName(ac)); err != nil {
 		return err
 	}
-	_, err := c.ManagedClusters.Delete(ctx, ac.Spec.ResourceGroupName, meta.GetExternalName(ac))
-	return err
+	f, err := c.ManagedClusters.Delete(ctx, ac.Spec.ResourceGroupName, meta.GetExternalName(ac))
+	if err != nil {
+		return err
+	}
+	ac.Status.LastOperation = azure.NewAsyncOperation(http.MethodDelete, f.Future)
+	return nil
+}
+
+// GetRESTClient returns the underlying REST client that the client object uses.
+func (c AggregateClient) GetRESTClient() autorest.Sender {
+	return c.ManagedClusters.Client
 }
 
 // GetKubeConfig produces a kubeconfig file that configures access to the
@@ -205,10 +214,17 @@ func (c AggregateClient) ensureApplication(ctx context.Context, name, secret str
 }
 
 func (c AggregateClient) ensureServicePrincipal(ctx context.Context, appID string) (graphrbac.ServicePrincipal, error) {
+	p := graphrbac.ServicePrincipalCreateParameters{AppID: to.StringPtr(appID), AccountEnabled: to.BoolPtr(true)}
+
+	// An application without an ID was only planned in dry-run mode, and
+	// thus has no service principal yet.
+	if appID == "" {
+		return c.ServicePrincipals.Create(ctx, p)
+	}
+
 	r, err := c.Applications.GetServicePrincipalsIDByAppID(ctx, appID)
 	if azure.IsNotFound(err) {
 		// Create it.
-		p := graphrbac.ServicePrincipalCreateParameters{AppID: to.StringPtr(appID), AccountEnabled: to.BoolPtr(true)}
 		return c.ServicePrincipals.Create(ctx, p)
 	}
 	if err != nil {
@@ -230,20 +246,25 @@ func (c AggregateClient) ensureRoleAssignment(ctx context.Context, principalID,
 		return err
 	}
 
-	filter := fmt.Sprintf("principalId eq '%s'", principalID)
-	for l, err := c.RoleAssignments.ListForScopeComplete(ctx, scope, filter); l.NotDone(); err = l.NextWithContext(ctx) {
-		if err != nil {
-			return err
+	// A principal without an ID was only planned in dry-run mode, and thus
+	// has no role assignments yet.
+	if principalID != "" {
+		filter := fmt.Sprintf("principalId eq '%s'", principalID)
+		for l, err := c.RoleAssignments.ListForScopeComplete(ctx, scope, filter); l.NotDone(); err = l.NextWithContext(ctx) {
+			if err != nil {
+				return err
+			}
+
+			// We really do want to stop here if our principal already has a
+			// role definition for this scope; we presume it's one we created
+			// earlier.
+			return nil // nolint:staticcheck
 		}
-
-		// We really do want to stop here if our principal already has a role
-		// definition for this scope; we presume it's one we created earlier.
-		return nil // nolint:staticcheck
 	}
 
 	p := authorizationmgmt.RoleAssignmentCreateParameters{Properties: &authorizationmgmt.RoleAssignmentProperties{
 		RoleDefinitionID: azure.ToStringPtr(fmt.Sprintf("/subscriptions/%s%s", c.RoleAssignments.SubscriptionID, roleID)),
-		PrincipalID:      azure.ToStringPtr(principalID),
+		PrincipalID:      to.StringPtr(principalID),
 	}}
 	_, err = c.RoleAssignments.Create(ctx, scope, name.String(), p)
 	return err
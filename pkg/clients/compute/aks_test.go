@@ -0,0 +1,193 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compute
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/authorization/mgmt/2015-07-01/authorization"
+	"github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2018-03-31/containerservice"
+	"github.com/Azure/azure-sdk-for-go/services/graphrbac/1.6/graphrbac"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+
+	"github.com/crossplane/provider-azure/apis/compute/v1alpha3"
+	azure "github.com/crossplane/provider-azure/pkg/clients"
+	"github.com/crossplane/provider-azure/pkg/clients/recorder"
+)
+
+// TestAggregateClient replays the interactions of an AggregateClient with
+// Azure that are recorded in testdata/aggregateclient.json. Run it with
+// AZURE_RECORDER_MODE=record and AZURE_AUTH_LOCATION set to record them again.
+// The resource group must exist.
+func TestAggregateClient(t *testing.T) {
+	const (
+		group    = "crossplane-recorder"
+		location = "westus"
+		cluster  = "crossplane-recorder-aks"
+		secret   = "Cr0ssplane-recorder"
+	)
+	rec, creds, stop := recorder.Start(t, "aggregateclient")
+	defer stop()
+	ctx := context.Background()
+
+	c, err := NewAggregateClient(azure.NewAuthInfo(creds))
+	if err != nil {
+		t.Fatalf("NewAggregateClient(...): %s", err)
+	}
+
+	ac := &v1alpha3.AKSCluster{Spec: v1alpha3.AKSClusterSpec{AKSClusterParameters: v1alpha3.AKSClusterParameters{
+		ResourceGroupName: group,
+		Location:          location,
+		Version:           "1.17.11",
+		NodeVMSize:        "Standard_B2s",
+		DNSNamePrefix:     cluster,
+	}}}
+	meta.SetExternalName(ac, cluster)
+
+	if err := c.EnsureManagedCluster(ctx, ac, secret); err != nil {
+		t.Fatalf("EnsureManagedCluster(...): %s", err)
+	}
+	rec.WaitForOperation(ctx, t, c.GetRESTClient(), &ac.Status.LastOperation)
+	if diff := cmp.Diff(azure.AsyncOperationStatusSucceeded, ac.Status.LastOperation.Status); diff != "" {
+		t.Errorf("EnsureManagedCluster(...): -want operation status, +got operation status:\n%s", diff)
+	}
+
+	mc, err := c.GetManagedCluster(ctx, ac)
+	if err != nil {
+		t.Fatalf("GetManagedCluster(...): %s", err)
+	}
+	if diff := cmp.Diff("Succeeded", to.String(mc.ProvisioningState)); diff != "" {
+		t.Errorf("GetManagedCluster(...): -want provisioning state, +got provisioning state:\n%s", diff)
+	}
+
+	// Recorded kubeconfigs are redacted.
+	kc, err := c.GetKubeConfig(ctx, ac)
+	if err != nil {
+		t.Fatalf("GetKubeConfig(...): %s", err)
+	}
+	if rec.Mode() == recorder.ModeReplay {
+		if diff := cmp.Diff("REDACTED", string(kc)); diff != "" {
+			t.Errorf("GetKubeConfig(...): -want, +got:\n%s", diff)
+		}
+	}
+
+	if err := c.DeleteManagedCluster(ctx, ac); err != nil {
+		t.Fatalf("DeleteManagedCluster(...): %s", err)
+	}
+	rec.WaitForOperation(ctx, t, c.GetRESTClient(), &ac.Status.LastOperation)
+	if _, err := c.GetManagedCluster(ctx, ac); !azure.IsNotFound(err) {
+		t.Errorf("GetManagedCluster(...): want not found error after deletion, got %v", err)
+	}
+}
+
+func TestEnsureManagedClusterDryRun(t *testing.T) {
+	const (
+		subscription = "bf1b0e59-93da-42e0-82c6-5a1d94227911"
+		tenant       = "cool-tenant"
+		group        = "coolgroup"
+		cluster      = "coolcluster"
+		secret       = "hunter2-but-longer"
+		subnet       = "/subscriptions/" + subscription + "/resourceGroups/" + group + "/providers/Microsoft.Network/virtualNetworks/coolnet/subnets/coolsubnet"
+	)
+
+	azure.DryRun = true
+	defer func() { azure.DryRun = false }()
+
+	// Reads are answered as if no application exists yet. Requests that
+	// would change Azure resources never reach the sender.
+	var sent []string
+	s := autorest.DecorateSender(autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+		sent = append(sent, r.Method+" "+r.URL.Path)
+		return &http.Response{
+			Request:    r,
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(`{"value":[]}`)),
+		}, nil
+	}), azure.DryRunIntercept)
+
+	mcc := containerservice.NewManagedClustersClient(subscription)
+	mcc.Sender = s
+	rac := authorization.NewRoleAssignmentsClient(subscription)
+	rac.Sender = s
+	ac := graphrbac.NewApplicationsClient(tenant)
+	ac.Sender = s
+	spc := graphrbac.NewServicePrincipalsClient(tenant)
+	spc.Sender = s
+	c := AggregateClient{ManagedClusters: mcc, Applications: ac, ServicePrincipals: spc, RoleAssignments: rac}
+
+	cr := &v1alpha3.AKSCluster{Spec: v1alpha3.AKSClusterSpec{AKSClusterParameters: v1alpha3.AKSClusterParameters{
+		ResourceGroupName: group,
+		Location:          "westus",
+		Version:           "1.17.11",
+		NodeVMSize:        "Standard_B2s",
+		DNSNamePrefix:     cluster,
+		VnetSubnetID:      subnet,
+	}}}
+	meta.SetExternalName(cr, cluster)
+
+	ctx, requests := azure.WithDryRun(context.Background())
+	if err := c.EnsureManagedCluster(ctx, cr, secret); err != nil {
+		t.Fatalf("EnsureManagedCluster(...): %s", err)
+	}
+
+	got := []string{}
+	var mc containerservice.ManagedCluster
+	for _, r := range requests() {
+		if strings.Contains(r.String(), secret) {
+			t.Errorf("EnsureManagedCluster(...): planned request contains the service principal secret: %s", r)
+		}
+		u := r.URL[:strings.Index(r.URL, "?")]
+		if i := strings.Index(u, "/roleAssignments/"); i >= 0 {
+			// Role assignments are named after a random UUID.
+			u = u[:i] + "/roleAssignments/"
+		}
+		got = append(got, r.Method+" "+u)
+		if r.Method == http.MethodPut && strings.Contains(r.URL, "managedClusters") {
+			if err := json.Unmarshal(r.Body, &mc); err != nil {
+				t.Fatalf("json.Unmarshal(...): %s", err)
+			}
+		}
+	}
+	want := []string{
+		http.MethodPost + " " + graphrbac.DefaultBaseURI + "/" + tenant + "/applications",
+		http.MethodPost + " " + graphrbac.DefaultBaseURI + "/" + tenant + "/servicePrincipals",
+		http.MethodPut + " " + authorization.DefaultBaseURI + "/" + subnet + "/providers/Microsoft.Authorization/roleAssignments/",
+		http.MethodPut + " " + containerservice.DefaultBaseURI + "/subscriptions/" + subscription + "/resourceGroups/" + group + "/providers/Microsoft.ContainerService/managedClusters/" + cluster,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("EnsureManagedCluster(...): -want planned requests, +got planned requests:\n%s", diff)
+	}
+	if diff := cmp.Diff(cr.Spec.Version, to.String(mc.KubernetesVersion)); diff != "" {
+		t.Errorf("EnsureManagedCluster(...): -want planned Kubernetes version, +got planned Kubernetes version:\n%s", diff)
+	}
+
+	for _, r := range sent {
+		if !strings.HasPrefix(r, http.MethodGet) {
+			t.Errorf("EnsureManagedCluster(...): request that would change an Azure resource was sent: %s", r)
+		}
+	}
+}
@@ -24,7 +24,6 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/services/cosmos-db/mgmt/2015-04-08/documentdb"
 	"github.com/Azure/azure-sdk-for-go/services/cosmos-db/mgmt/2015-04-08/documentdb/documentdbapi"
-	"github.com/Azure/go-autorest/autorest/azure/auth"
 	"github.com/pkg/errors"
 
 	"github.com/crossplane/provider-azure/apis/database/v1alpha3"
@@ -42,17 +41,15 @@ func NewDatabaseAccountClient(credentials []byte) (AccountClient, error) {
 		return nil, errors.Wrap(err, "cannot unmarshal Azure client secret data")
 	}
 
-	config := auth.NewClientCredentialsConfig(creds.ClientID, creds.ClientSecret, creds.TenantID)
-	config.AADEndpoint = creds.ActiveDirectoryEndpointURL
-	config.Resource = creds.ResourceManagerEndpointURL
-
-	authorizer, err := config.Authorizer()
+	m := creds.Map()
+	azure.DefaultEnvironment(m)
+	authorizer, err := azure.NewAuthorizer(m, azure.ResourceManagerAudience(m))
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get authorizer from config")
 	}
 
-	client := documentdb.NewDatabaseAccountsClient(creds.SubscriptionID)
-	client.Authorizer = authorizer
+	client := documentdb.NewDatabaseAccountsClientWithBaseURI(m[azure.CredentialsKeyResourceManagerEndpointURL], creds.SubscriptionID)
+	azure.ConfigureClient(&client.Client, authorizer)
 
 	if err := client.AddToUserAgent(azure.UserAgent); err != nil {
 		return nil, errors.Wrap(err, "cannot add to Azure client user agent")
@@ -75,6 +72,17 @@ func ToDatabaseAccountCreateOrUpdate(s *v1alpha3.CosmosDBAccountSpec) documentdb
 	}
 }
 
+// FromDatabaseAccount produces CosmosDBAccountParameters, except for the
+// resource group, from documentdb.DatabaseAccount.
+func FromDatabaseAccount(in documentdb.DatabaseAccount) v1alpha3.CosmosDBAccountParameters {
+	return v1alpha3.CosmosDBAccountParameters{
+		Kind:       in.Kind,
+		Location:   azure.ToString(in.Location),
+		Properties: fromDatabaseProperties(in.DatabaseAccountProperties),
+		Tags:       azure.ToStringMap(in.Tags),
+	}
+}
+
 // UpdateCosmosDBAccountObservation produces SQLServerObservation from
 // documentdb.CosmosDBAccountStatus.
 func UpdateCosmosDBAccountObservation(o *v1alpha3.CosmosDBAccountStatus, in documentdb.DatabaseAccount) {
@@ -119,13 +127,25 @@ func fromDatabaseProperties(a *documentdb.DatabaseAccountProperties) v1alpha3.Co
 // CheckEqualDatabaseProperties compares the observed state with the desired
 // spec.
 func CheckEqualDatabaseProperties(p v1alpha3.CosmosDBAccountProperties, a documentdb.DatabaseAccount) bool {
+	return len(DatabasePropertiesDiff(p, a)) == 0
+}
+
+// DatabasePropertiesDiff returns the fields of the desired spec that differ
+// from the observed state.
+func DatabasePropertiesDiff(p v1alpha3.CosmosDBAccountProperties, a documentdb.DatabaseAccount) azure.Diff {
 	o := fromDatabaseProperties(a.DatabaseAccountProperties)
 
 	// asouza: only keep attributes that can be modified in the comparison.
-	return (equalConsistencyPolicyIfNotNull(p.ConsistencyPolicy, o.ConsistencyPolicy) &&
-		checkEqualLocations(p.Locations, o.Locations) &&
-		equalBoolIfNotNull(p.EnableAutomaticFailover, o.EnableAutomaticFailover) &&
-		equalBoolIfNotNull(p.EnableMultipleWriteLocations, o.EnableMultipleWriteLocations))
+	var d azure.Diff
+	if !equalConsistencyPolicyIfNotNull(p.ConsistencyPolicy, o.ConsistencyPolicy) {
+		d = append(d, azure.FieldDiff{Path: "spec.forProvider.properties.consistencyPolicy", Desired: p.ConsistencyPolicy, Observed: o.ConsistencyPolicy})
+	}
+	if !checkEqualLocations(p.Locations, o.Locations) {
+		d = append(d, azure.FieldDiff{Path: "spec.forProvider.properties.locations", Desired: p.Locations, Observed: o.Locations})
+	}
+	d = d.Compare("spec.forProvider.properties.enableAutomaticFailover", azure.ToBool(p.EnableAutomaticFailover), azure.ToBool(o.EnableAutomaticFailover))
+	d = d.Compare("spec.forProvider.properties.enableMultipleWriteLocations", azure.ToBool(p.EnableMultipleWriteLocations), azure.ToBool(o.EnableMultipleWriteLocations))
+	return d
 }
 
 func equalConsistencyPolicyIfNotNull(spec, current *v1alpha3.CosmosDBAccountConsistencyPolicy) bool {
@@ -136,10 +156,6 @@ func equalConsistencyPolicyIfNotNull(spec, current *v1alpha3.CosmosDBAccountCons
 	return true
 }
 
-func equalBoolIfNotNull(spec, current *bool) bool {
-	return azure.ToBool(spec) == azure.ToBool(current)
-}
-
 func toDatabaseConsistencyPolicy(a *v1alpha3.CosmosDBAccountConsistencyPolicy) *documentdb.ConsistencyPolicy {
 	if a == nil {
 		return nil
@@ -129,6 +129,51 @@ func TestToDatabaseAccountCreateOrUpdate(t *testing.T) {
 	})
 }
 
+func TestFromDatabaseAccount(t *testing.T) {
+	kind := documentdb.DatabaseAccountKind("MongoDB")
+	location := "uswest"
+
+	got := FromDatabaseAccount(documentdb.DatabaseAccount{
+		Kind:     kind,
+		Location: &location,
+		Tags:     map[string]*string{"cool": azure.ToStringPtr("very")},
+		DatabaseAccountProperties: &documentdb.DatabaseAccountProperties{
+			DatabaseAccountOfferType: documentdb.Standard,
+			ConsistencyPolicy: &documentdb.ConsistencyPolicy{
+				DefaultConsistencyLevel: documentdb.Eventual,
+			},
+			ReadLocations: &[]documentdb.Location{
+				{
+					LocationName:     &location,
+					FailoverPriority: azure.ToInt32Ptr(0, azure.FieldRequired),
+					IsZoneRedundant:  azure.ToBoolPtr(true),
+				},
+			},
+		},
+	})
+	want := v1alpha3.CosmosDBAccountParameters{
+		Kind:     kind,
+		Location: location,
+		Tags:     map[string]string{"cool": "very"},
+		Properties: v1alpha3.CosmosDBAccountProperties{
+			DatabaseAccountOfferType: string(documentdb.Standard),
+			ConsistencyPolicy: &v1alpha3.CosmosDBAccountConsistencyPolicy{
+				DefaultConsistencyLevel: string(documentdb.Eventual),
+			},
+			Locations: []v1alpha3.CosmosDBAccountLocation{
+				{
+					LocationName:     location,
+					FailoverPriority: 0,
+					IsZoneRedundant:  true,
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("FromDatabaseAccount() diff:\n%s", diff)
+	}
+}
+
 func TestCheckEqualDatabaseProperties(t *testing.T) {
 	location := "uswest"
 
@@ -20,18 +20,15 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"reflect"
 	"strconv"
 
 	"github.com/Azure/azure-sdk-for-go/services/mysql/mgmt/2017-12-01/mysql"
 	"github.com/Azure/go-autorest/autorest"
-	"github.com/google/go-cmp/cmp"
 
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 
 	azuredbv1alpha3 "github.com/crossplane/provider-azure/apis/database/v1alpha3"
 	azuredbv1beta1 "github.com/crossplane/provider-azure/apis/database/v1beta1"
-	"github.com/crossplane/provider-azure/apis/v1alpha3"
 	azure "github.com/crossplane/provider-azure/pkg/clients"
 )
 
@@ -112,10 +109,7 @@ func (c *MySQLServerClient) CreateServer(ctx context.Context, cr *azuredbv1beta1
 	if err != nil {
 		return err
 	}
-	cr.Status.AtProvider.LastOperation = v1alpha3.AsyncOperation{
-		PollingURL: op.PollingURL(),
-		Method:     http.MethodPut,
-	}
+	cr.Status.AtProvider.LastOperation = azure.NewAsyncOperation(http.MethodPut, op.Future)
 	return nil
 }
 
@@ -147,10 +141,7 @@ func (c *MySQLServerClient) UpdateServer(ctx context.Context, cr *azuredbv1beta1
 	if err != nil {
 		return err
 	}
-	cr.Status.AtProvider.LastOperation = v1alpha3.AsyncOperation{
-		PollingURL: op.PollingURL(),
-		Method:     http.MethodPatch,
-	}
+	cr.Status.AtProvider.LastOperation = azure.NewAsyncOperation(http.MethodPatch, op.Future)
 	return nil
 }
 
@@ -160,10 +151,7 @@ func (c *MySQLServerClient) DeleteServer(ctx context.Context, cr *azuredbv1beta1
 	if err != nil {
 		return err
 	}
-	cr.Status.AtProvider.LastOperation = v1alpha3.AsyncOperation{
-		PollingURL: op.PollingURL(),
-		Method:     http.MethodDelete,
-	}
+	cr.Status.AtProvider.LastOperation = azure.NewAsyncOperation(http.MethodDelete, op.Future)
 	return nil
 }
 
@@ -180,16 +168,18 @@ func NewMySQLVirtualNetworkRuleParameters(v *azuredbv1alpha3.MySQLServerVirtualN
 
 // MySQLServerVirtualNetworkRuleNeedsUpdate determines if a virtual network rule needs to be updated
 func MySQLServerVirtualNetworkRuleNeedsUpdate(kube *azuredbv1alpha3.MySQLServerVirtualNetworkRule, az mysql.VirtualNetworkRule) bool {
-	up := NewMySQLVirtualNetworkRuleParameters(kube)
+	return len(MySQLServerVirtualNetworkRuleDiff(kube, az)) > 0
+}
 
-	switch {
-	case !reflect.DeepEqual(up.VirtualNetworkRuleProperties.VirtualNetworkSubnetID, az.VirtualNetworkRuleProperties.VirtualNetworkSubnetID):
-		return true
-	case !reflect.DeepEqual(up.VirtualNetworkRuleProperties.IgnoreMissingVnetServiceEndpoint, az.VirtualNetworkRuleProperties.IgnoreMissingVnetServiceEndpoint):
-		return true
-	}
+// MySQLServerVirtualNetworkRuleDiff returns the fields of the supplied
+// MySQLServerVirtualNetworkRule that differ from the supplied VirtualNetworkRule.
+func MySQLServerVirtualNetworkRuleDiff(kube *azuredbv1alpha3.MySQLServerVirtualNetworkRule, az mysql.VirtualNetworkRule) azure.Diff {
+	up := NewMySQLVirtualNetworkRuleParameters(kube)
 
-	return false
+	var d azure.Diff
+	d = d.Compare("spec.properties.virtualNetworkSubnetId", up.VirtualNetworkRuleProperties.VirtualNetworkSubnetID, az.VirtualNetworkRuleProperties.VirtualNetworkSubnetID)
+	d = d.Compare("spec.properties.ignoreMissingVnetServiceEndpoint", up.VirtualNetworkRuleProperties.IgnoreMissingVnetServiceEndpoint, az.VirtualNetworkRuleProperties.IgnoreMissingVnetServiceEndpoint)
+	return d
 }
 
 // UpdateMySQLVirtualNetworkRuleStatusFromAzure updates the status related to the external
@@ -215,8 +205,21 @@ func NewMySQLFirewallRuleParameters(r *azuredbv1alpha3.MySQLServerFirewallRule)
 // MySQLServerFirewallRuleIsUpToDate returns true if the supplied FirewallRule
 // appears to be up to date with the supplied MySQLServerFirewallRule.
 func MySQLServerFirewallRuleIsUpToDate(kube *azuredbv1alpha3.MySQLServerFirewallRule, az mysql.FirewallRule) bool {
+	return len(MySQLServerFirewallRuleDiff(kube, az)) == 0
+}
+
+// MySQLServerFirewallRuleDiff returns the fields of the supplied
+// MySQLServerFirewallRule that differ from the supplied FirewallRule.
+func MySQLServerFirewallRuleDiff(kube *azuredbv1alpha3.MySQLServerFirewallRule, az mysql.FirewallRule) azure.Diff {
+	if az.FirewallRuleProperties == nil {
+		return azure.Diff{{Path: "spec.forProvider", Desired: kube.Spec.ForProvider}}
+	}
 	up := NewMySQLFirewallRuleParameters(kube)
-	return cmp.Equal(up.FirewallRuleProperties, az.FirewallRuleProperties)
+
+	var d azure.Diff
+	d = d.Compare("spec.forProvider.startIpAddress", up.FirewallRuleProperties.StartIPAddress, az.FirewallRuleProperties.StartIPAddress)
+	d = d.Compare("spec.forProvider.endIpAddress", up.FirewallRuleProperties.EndIPAddress, az.FirewallRuleProperties.EndIPAddress)
+	return d
 }
 
 // The name must match the specification of the SKU, so, we don't allow user
@@ -263,31 +266,30 @@ func LateInitializeMySQL(p *azuredbv1beta1.SQLServerParameters, in mysql.Server)
 
 // IsMySQLUpToDate is used to report whether given mysql.Server is in
 // sync with the SQLServerParameters that user desires.
-func IsMySQLUpToDate(p azuredbv1beta1.SQLServerParameters, in mysql.Server) bool { // nolint:gocyclo
-	if in.StorageProfile == nil || in.Sku == nil {
-		return false
+func IsMySQLUpToDate(p azuredbv1beta1.SQLServerParameters, in mysql.Server) bool {
+	return len(MySQLDiff(p, in)) == 0
+}
+
+// MySQLDiff returns the fields of the supplied SQLServerParameters that differ
+// from the supplied mysql.Server.
+func MySQLDiff(p azuredbv1beta1.SQLServerParameters, in mysql.Server) azure.Diff {
+	var d azure.Diff
+	d = d.Compare("spec.forProvider.sslEnforcement", p.SSLEnforcement, string(in.SslEnforcement))
+	d = d.Compare("spec.forProvider.version", p.Version, string(in.Version))
+	d = d.Compare("spec.forProvider.tags", azure.ToStringPtrMap(p.Tags), in.Tags)
+	if in.Sku == nil {
+		d = append(d, azure.FieldDiff{Path: "spec.forProvider.sku", Desired: p.SKU})
+	} else {
+		d = d.Compare("spec.forProvider.sku.tier", p.SKU.Tier, string(in.Sku.Tier))
+		d = d.Compare("spec.forProvider.sku.capacity", p.SKU.Capacity, azure.ToInt(in.Sku.Capacity))
+		d = d.Compare("spec.forProvider.sku.family", p.SKU.Family, azure.ToString(in.Sku.Family))
 	}
-	switch {
-	case p.SSLEnforcement != string(in.SslEnforcement):
-		return false
-	case p.Version != string(in.Version):
-		return false
-	case !reflect.DeepEqual(azure.ToStringPtrMap(p.Tags), in.Tags):
-		return false
-	case p.SKU.Tier != string(in.Sku.Tier):
-		return false
-	case p.SKU.Capacity != azure.ToInt(in.Sku.Capacity):
-		return false
-	case p.SKU.Family != azure.ToString(in.Sku.Family):
-		return false
-	case !reflect.DeepEqual(azure.ToInt32PtrFromIntPtr(p.StorageProfile.BackupRetentionDays), in.StorageProfile.BackupRetentionDays):
-		return false
-	case azure.ToString(p.StorageProfile.GeoRedundantBackup) != string(in.StorageProfile.GeoRedundantBackup):
-		return false
-	case p.StorageProfile.StorageMB != azure.ToInt(in.StorageProfile.StorageMB):
-		return false
-	case azure.ToString(p.StorageProfile.StorageAutogrow) != string(in.StorageProfile.StorageAutogrow):
-		return false
+	if in.StorageProfile == nil {
+		return append(d, azure.FieldDiff{Path: "spec.forProvider.storageProfile", Desired: p.StorageProfile})
 	}
-	return true
+	d = d.Compare("spec.forProvider.storageProfile.backupRetentionDays", azure.ToInt32PtrFromIntPtr(p.StorageProfile.BackupRetentionDays), in.StorageProfile.BackupRetentionDays)
+	d = d.Compare("spec.forProvider.storageProfile.geoRedundantBackup", azure.ToString(p.StorageProfile.GeoRedundantBackup), string(in.StorageProfile.GeoRedundantBackup))
+	d = d.Compare("spec.forProvider.storageProfile.storageMB", p.StorageProfile.StorageMB, azure.ToInt(in.StorageProfile.StorageMB))
+	d = d.Compare("spec.forProvider.storageProfile.storageAutogrow", azure.ToString(p.StorageProfile.StorageAutogrow), string(in.StorageProfile.StorageAutogrow))
+	return d
 }
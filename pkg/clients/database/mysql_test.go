@@ -17,9 +17,14 @@ limitations under the License.
 package database
 
 import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/Azure/azure-sdk-for-go/services/mysql/mgmt/2017-12-01/mysql"
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/google/go-cmp/cmp"
 
@@ -27,7 +32,9 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 
 	"github.com/crossplane/provider-azure/apis/database/v1alpha3"
+	"github.com/crossplane/provider-azure/apis/database/v1beta1"
 	azure "github.com/crossplane/provider-azure/pkg/clients"
+	"github.com/crossplane/provider-azure/pkg/clients/recorder"
 )
 
 const (
@@ -357,3 +364,101 @@ func TestMySQLServerFirewallRuleIsUpToDate(t *testing.T) {
 		})
 	}
 }
+
+// TestMySQLServerClient replays the interactions of a MySQLServerClient with
+// Azure that are recorded in testdata/mysqlserverclient.json. Run it with
+// AZURE_RECORDER_MODE=record and AZURE_AUTH_LOCATION set to record them.
+// TODO: Record the interactions against Azure. The test is skipped until they
+// are recorded.
+func TestMySQLServerClient(t *testing.T) {
+	const (
+		cassette = "mysqlserverclient"
+		group    = "crossplane-recorder"
+		location = "westus"
+		server   = "crossplane-recorder-mysql"
+		password = "Cr0ssplane-recorder"
+	)
+	_, err := os.Stat(filepath.Join("testdata", cassette+".json"))
+	if recorder.Mode(os.Getenv(recorder.EnvMode)) != recorder.ModeRecord && os.IsNotExist(err) {
+		t.Skipf("no interactions with Azure are recorded in testdata/%s.json", cassette)
+	}
+	rec, creds, stop := recorder.Start(t, cassette)
+	defer stop()
+	ctx := context.Background()
+
+	a, err := azure.NewAuthorizer(creds, azure.ResourceManagerAudience(creds))
+	if err != nil {
+		t.Fatalf("azure.NewAuthorizer(...): %s", err)
+	}
+	groups := resources.NewGroupsClientWithBaseURI(creds[azure.CredentialsKeyResourceManagerEndpointURL], creds[azure.CredentialsKeySubscriptionID])
+	azure.ConfigureClient(&groups.Client, a)
+	servers := mysql.NewServersClientWithBaseURI(creds[azure.CredentialsKeyResourceManagerEndpointURL], creds[azure.CredentialsKeySubscriptionID])
+	azure.ConfigureClient(&servers.Client, a)
+	c := NewMySQLServerClient(servers)
+
+	if _, err := groups.CreateOrUpdate(ctx, group, resources.Group{Location: to.StringPtr(location)}); err != nil {
+		t.Fatalf("groups.CreateOrUpdate(...): %s", err)
+	}
+
+	cr := &v1beta1.MySQLServer{Spec: v1beta1.SQLServerSpec{ForProvider: v1beta1.SQLServerParameters{
+		ResourceGroupName:  group,
+		Location:           location,
+		AdministratorLogin: "crossplane",
+		Version:            "5.7",
+		SSLEnforcement:     "Disabled",
+		SKU:                v1beta1.SKU{Tier: "GeneralPurpose", Capacity: 2, Family: "Gen5"},
+		StorageProfile:     v1beta1.StorageProfile{StorageMB: 5120},
+	}}}
+	meta.SetExternalName(cr, server)
+
+	// Servers cannot be created in resource groups that do not exist.
+	missing := cr.DeepCopy()
+	missing.Spec.ForProvider.ResourceGroupName = group + "-missing"
+	err = c.CreateServer(ctx, missing, password)
+	if diff := cmp.Diff("ResourceGroupNotFound", azure.ErrorCode(err)); diff != "" {
+		t.Errorf("CreateServer(...): -want error code, +got error code:\n%s", diff)
+	}
+
+	if err := c.CreateServer(ctx, cr, password); err != nil {
+		t.Fatalf("CreateServer(...): %s", err)
+	}
+	rec.WaitForOperation(ctx, t, c.GetRESTClient(), &cr.Status.AtProvider.LastOperation)
+	if diff := cmp.Diff(azure.AsyncOperationStatusSucceeded, cr.Status.AtProvider.LastOperation.Status); diff != "" {
+		t.Errorf("CreateServer(...): -want operation status, +got operation status:\n%s", diff)
+	}
+	got, err := c.GetServer(ctx, cr)
+	if err != nil {
+		t.Fatalf("GetServer(...): %s", err)
+	}
+	if diff := cmp.Diff(mysql.ServerStateReady, got.UserVisibleState); diff != "" {
+		t.Errorf("GetServer(...): -want state, +got state:\n%s", diff)
+	}
+
+	cr.Spec.ForProvider.SSLEnforcement = "Enabled"
+	if err := c.UpdateServer(ctx, cr); err != nil {
+		t.Fatalf("UpdateServer(...): %s", err)
+	}
+	rec.WaitForOperation(ctx, t, c.GetRESTClient(), &cr.Status.AtProvider.LastOperation)
+	got, err = c.GetServer(ctx, cr)
+	if err != nil {
+		t.Fatalf("GetServer(...): %s", err)
+	}
+	if diff := cmp.Diff(mysql.SslEnforcementEnumEnabled, got.SslEnforcement); diff != "" {
+		t.Errorf("GetServer(...): -want SSL enforcement, +got SSL enforcement:\n%s", diff)
+	}
+
+	if err := c.DeleteServer(ctx, cr); err != nil {
+		t.Fatalf("DeleteServer(...): %s", err)
+	}
+	rec.WaitForOperation(ctx, t, c.GetRESTClient(), &cr.Status.AtProvider.LastOperation)
+	if _, err := c.GetServer(ctx, cr); !azure.IsNotFound(err) {
+		t.Errorf("GetServer(...): want not found error after deletion, got %v", err)
+	}
+
+	f, err := groups.Delete(ctx, group)
+	if err != nil {
+		t.Fatalf("groups.Delete(...): %s", err)
+	}
+	op := azure.NewAsyncOperation(http.MethodDelete, f.Future)
+	rec.WaitForOperation(ctx, t, groups.Client, &op)
+}
@@ -20,19 +20,16 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"reflect"
 	"strconv"
 
 	"github.com/Azure/azure-sdk-for-go/services/mysql/mgmt/2017-12-01/mysql"
 	"github.com/Azure/azure-sdk-for-go/services/postgresql/mgmt/2017-12-01/postgresql"
 	"github.com/Azure/go-autorest/autorest"
-	"github.com/google/go-cmp/cmp"
 
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 
 	azuredbv1alpha3 "github.com/crossplane/provider-azure/apis/database/v1alpha3"
 	azuredbv1beta1 "github.com/crossplane/provider-azure/apis/database/v1beta1"
-	"github.com/crossplane/provider-azure/apis/v1alpha3"
 	azure "github.com/crossplane/provider-azure/pkg/clients"
 )
 
@@ -104,10 +101,7 @@ func (c *PostgreSQLServerClient) CreateServer(ctx context.Context, cr *azuredbv1
 	if err != nil {
 		return err
 	}
-	cr.Status.AtProvider.LastOperation = v1alpha3.AsyncOperation{
-		PollingURL: op.PollingURL(),
-		Method:     http.MethodPut,
-	}
+	cr.Status.AtProvider.LastOperation = azure.NewAsyncOperation(http.MethodPut, op.Future)
 	return nil
 }
 
@@ -139,10 +133,7 @@ func (c *PostgreSQLServerClient) UpdateServer(ctx context.Context, cr *azuredbv1
 	if err != nil {
 		return err
 	}
-	cr.Status.AtProvider.LastOperation = v1alpha3.AsyncOperation{
-		PollingURL: op.PollingURL(),
-		Method:     http.MethodPatch,
-	}
+	cr.Status.AtProvider.LastOperation = azure.NewAsyncOperation(http.MethodPatch, op.Future)
 	return nil
 }
 
@@ -152,10 +143,7 @@ func (c *PostgreSQLServerClient) DeleteServer(ctx context.Context, cr *azuredbv1
 	if err != nil {
 		return err
 	}
-	cr.Status.AtProvider.LastOperation = v1alpha3.AsyncOperation{
-		PollingURL: op.PollingURL(),
-		Method:     http.MethodDelete,
-	}
+	cr.Status.AtProvider.LastOperation = azure.NewAsyncOperation(http.MethodDelete, op.Future)
 	return nil
 }
 
@@ -172,16 +160,18 @@ func NewPostgreSQLVirtualNetworkRuleParameters(v *azuredbv1alpha3.PostgreSQLServ
 
 // PostgreSQLServerVirtualNetworkRuleNeedsUpdate determines if a virtual network rule needs to be updated
 func PostgreSQLServerVirtualNetworkRuleNeedsUpdate(kube *azuredbv1alpha3.PostgreSQLServerVirtualNetworkRule, az postgresql.VirtualNetworkRule) bool {
-	up := NewPostgreSQLVirtualNetworkRuleParameters(kube)
+	return len(PostgreSQLServerVirtualNetworkRuleDiff(kube, az)) > 0
+}
 
-	switch {
-	case !reflect.DeepEqual(up.VirtualNetworkRuleProperties.VirtualNetworkSubnetID, az.VirtualNetworkRuleProperties.VirtualNetworkSubnetID):
-		return true
-	case !reflect.DeepEqual(up.VirtualNetworkRuleProperties.IgnoreMissingVnetServiceEndpoint, az.VirtualNetworkRuleProperties.IgnoreMissingVnetServiceEndpoint):
-		return true
-	}
+// PostgreSQLServerVirtualNetworkRuleDiff returns the fields of the supplied
+// PostgreSQLServerVirtualNetworkRule that differ from the supplied VirtualNetworkRule.
+func PostgreSQLServerVirtualNetworkRuleDiff(kube *azuredbv1alpha3.PostgreSQLServerVirtualNetworkRule, az postgresql.VirtualNetworkRule) azure.Diff {
+	up := NewPostgreSQLVirtualNetworkRuleParameters(kube)
 
-	return false
+	var d azure.Diff
+	d = d.Compare("spec.properties.virtualNetworkSubnetId", up.VirtualNetworkRuleProperties.VirtualNetworkSubnetID, az.VirtualNetworkRuleProperties.VirtualNetworkSubnetID)
+	d = d.Compare("spec.properties.ignoreMissingVnetServiceEndpoint", up.VirtualNetworkRuleProperties.IgnoreMissingVnetServiceEndpoint, az.VirtualNetworkRuleProperties.IgnoreMissingVnetServiceEndpoint)
+	return d
 }
 
 // UpdatePostgreSQLVirtualNetworkRuleStatusFromAzure updates the status related to the external
@@ -207,8 +197,21 @@ func NewPostgreSQLFirewallRuleParameters(r *azuredbv1alpha3.PostgreSQLServerFire
 // PostgreSQLServerFirewallRuleIsUpToDate returns true if the supplied FirewallRule
 // appears to be up to date with the supplied PostgreSQLServerFirewallRule.
 func PostgreSQLServerFirewallRuleIsUpToDate(kube *azuredbv1alpha3.PostgreSQLServerFirewallRule, az postgresql.FirewallRule) bool {
+	return len(PostgreSQLServerFirewallRuleDiff(kube, az)) == 0
+}
+
+// PostgreSQLServerFirewallRuleDiff returns the fields of the supplied
+// PostgreSQLServerFirewallRule that differ from the supplied FirewallRule.
+func PostgreSQLServerFirewallRuleDiff(kube *azuredbv1alpha3.PostgreSQLServerFirewallRule, az postgresql.FirewallRule) azure.Diff {
+	if az.FirewallRuleProperties == nil {
+		return azure.Diff{{Path: "spec.forProvider", Desired: kube.Spec.ForProvider}}
+	}
 	up := NewPostgreSQLFirewallRuleParameters(kube)
-	return cmp.Equal(up.FirewallRuleProperties, az.FirewallRuleProperties)
+
+	var d azure.Diff
+	d = d.Compare("spec.forProvider.startIpAddress", up.FirewallRuleProperties.StartIPAddress, az.FirewallRuleProperties.StartIPAddress)
+	d = d.Compare("spec.forProvider.endIpAddress", up.FirewallRuleProperties.EndIPAddress, az.FirewallRuleProperties.EndIPAddress)
+	return d
 }
 
 // The name must match the specification of the SKU, so, we don't allow user
@@ -255,31 +258,30 @@ func LateInitializePostgreSQL(p *azuredbv1beta1.SQLServerParameters, in postgres
 
 // IsPostgreSQLUpToDate is used to report whether given postgresql.Server is in
 // sync with the SQLServerParameters that user desires.
-func IsPostgreSQLUpToDate(p azuredbv1beta1.SQLServerParameters, in postgresql.Server) bool { // nolint:gocyclo
-	if in.StorageProfile == nil || in.Sku == nil {
-		return false
+func IsPostgreSQLUpToDate(p azuredbv1beta1.SQLServerParameters, in postgresql.Server) bool {
+	return len(PostgreSQLDiff(p, in)) == 0
+}
+
+// PostgreSQLDiff returns the fields of the supplied SQLServerParameters that differ
+// from the supplied postgresql.Server.
+func PostgreSQLDiff(p azuredbv1beta1.SQLServerParameters, in postgresql.Server) azure.Diff {
+	var d azure.Diff
+	d = d.Compare("spec.forProvider.sslEnforcement", p.SSLEnforcement, string(in.SslEnforcement))
+	d = d.Compare("spec.forProvider.version", p.Version, string(in.Version))
+	d = d.Compare("spec.forProvider.tags", azure.ToStringPtrMap(p.Tags), in.Tags)
+	if in.Sku == nil {
+		d = append(d, azure.FieldDiff{Path: "spec.forProvider.sku", Desired: p.SKU})
+	} else {
+		d = d.Compare("spec.forProvider.sku.tier", p.SKU.Tier, string(in.Sku.Tier))
+		d = d.Compare("spec.forProvider.sku.capacity", p.SKU.Capacity, azure.ToInt(in.Sku.Capacity))
+		d = d.Compare("spec.forProvider.sku.family", p.SKU.Family, azure.ToString(in.Sku.Family))
 	}
-	switch {
-	case p.SSLEnforcement != string(in.SslEnforcement):
-		return false
-	case p.Version != string(in.Version):
-		return false
-	case !reflect.DeepEqual(azure.ToStringPtrMap(p.Tags), in.Tags):
-		return false
-	case p.SKU.Tier != string(in.Sku.Tier):
-		return false
-	case p.SKU.Capacity != azure.ToInt(in.Sku.Capacity):
-		return false
-	case p.SKU.Family != azure.ToString(in.Sku.Family):
-		return false
-	case !reflect.DeepEqual(azure.ToInt32PtrFromIntPtr(p.StorageProfile.BackupRetentionDays), in.StorageProfile.BackupRetentionDays):
-		return false
-	case azure.ToString(p.StorageProfile.GeoRedundantBackup) != string(in.StorageProfile.GeoRedundantBackup):
-		return false
-	case p.StorageProfile.StorageMB != azure.ToInt(in.StorageProfile.StorageMB):
-		return false
-	case azure.ToString(p.StorageProfile.StorageAutogrow) != string(in.StorageProfile.StorageAutogrow):
-		return false
+	if in.StorageProfile == nil {
+		return append(d, azure.FieldDiff{Path: "spec.forProvider.storageProfile", Desired: p.StorageProfile})
 	}
-	return true
+	d = d.Compare("spec.forProvider.storageProfile.backupRetentionDays", azure.ToInt32PtrFromIntPtr(p.StorageProfile.BackupRetentionDays), in.StorageProfile.BackupRetentionDays)
+	d = d.Compare("spec.forProvider.storageProfile.geoRedundantBackup", azure.ToString(p.StorageProfile.GeoRedundantBackup), string(in.StorageProfile.GeoRedundantBackup))
+	d = d.Compare("spec.forProvider.storageProfile.storageMB", p.StorageProfile.StorageMB, azure.ToInt(in.StorageProfile.StorageMB))
+	d = d.Compare("spec.forProvider.storageProfile.storageAutogrow", azure.ToString(p.StorageProfile.StorageAutogrow), string(in.StorageProfile.StorageAutogrow))
+	return d
 }
@@ -0,0 +1,129 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+)
+
+// TypeUpToDate managed resources report whether their external resource was
+// last observed to be up to date with their desired state, and which fields
+// differ if it was not.
+const TypeUpToDate runtimev1alpha1.ConditionType = "UpToDate"
+
+// Reasons a managed resource reports for whether its external resource is up
+// to date.
+const (
+	ReasonUpToDate runtimev1alpha1.ConditionReason = "UpToDate"
+	ReasonDrifted  runtimev1alpha1.ConditionReason = "Drifted"
+)
+
+// A FieldDiff is a field whose desired value differs from the value observed
+// in Azure.
+type FieldDiff struct {
+	// Path of the field in the managed resource, e.g. spec.forProvider.version.
+	Path string
+
+	// Desired value of the field.
+	Desired interface{}
+
+	// Observed value of the field.
+	Observed interface{}
+}
+
+// String returns a human readable representation of the FieldDiff.
+func (d FieldDiff) String() string {
+	return fmt.Sprintf("%s: desired %s, observed %s", d.Path, formatValue(d.Desired), formatValue(d.Observed))
+}
+
+// A Diff is the set of fields whose desired value differs from the value
+// observed in Azure. An empty Diff indicates that the external resource is up
+// to date.
+type Diff []FieldDiff
+
+// Compare returns the Diff with a FieldDiff at the supplied path appended if
+// the supplied desired and observed values differ. Pointers are compared by
+// the values they point to.
+func (d Diff) Compare(path string, desired, observed interface{}) Diff {
+	if reflect.DeepEqual(desired, observed) {
+		return d
+	}
+	return append(d, FieldDiff{Path: path, Desired: desired, Observed: observed})
+}
+
+// String returns a human readable representation of the Diff.
+func (d Diff) String() string {
+	s := make([]string, len(d))
+	for i := range d {
+		s[i] = d[i].String()
+	}
+	return strings.Join(s, "; ")
+}
+
+// formatValue formats values as JSON, which dereferences pointers and makes
+// empty strings and unset values distinguishable.
+func formatValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+// UpToDate returns a condition indicating that the external resource of a
+// managed resource is up to date with its desired state.
+func UpToDate() runtimev1alpha1.Condition {
+	return runtimev1alpha1.Condition{
+		Type:               TypeUpToDate,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonUpToDate,
+	}
+}
+
+// Drifted returns a condition indicating that the external resource of a
+// managed resource differs from its desired state by the supplied Diff.
+func Drifted(d Diff) runtimev1alpha1.Condition {
+	return runtimev1alpha1.Condition{
+		Type:               TypeUpToDate,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonDrifted,
+		Message:            d.String(),
+	}
+}
+
+// SetDrift reports the supplied Diff as the UpToDate condition of the supplied
+// resource. It returns true if the Diff is empty, i.e. the external resource
+// is up to date.
+func SetDrift(o resource.Conditioned, d Diff) bool {
+	if len(d) == 0 {
+		o.SetConditions(UpToDate())
+		return true
+	}
+	o.SetConditions(Drifted(d))
+	return false
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestDiff(t *testing.T) {
+	var d Diff
+	d = d.Compare("spec.forProvider.version", "5.7", "5.7")
+	d = d.Compare("spec.forProvider.sku.capacity", ToInt32Ptr(2), ToInt32Ptr(2))
+	if len(d) != 0 {
+		t.Errorf("d.Compare(...): equal values should not be reported as drifted: %s", d)
+	}
+
+	d = d.Compare("spec.forProvider.sku.tier", "GeneralPurpose", "Basic")
+	d = d.Compare("spec.forProvider.tags", map[string]*string{"cool": ToStringPtr("very")}, nil)
+	d = d.Compare("spec.forProvider.sslEnforcement", ToStringPtr("", FieldRequired), (*string)(nil))
+
+	want := `spec.forProvider.sku.tier: desired "GeneralPurpose", observed "Basic"; ` +
+		`spec.forProvider.tags: desired {"cool":"very"}, observed null; ` +
+		`spec.forProvider.sslEnforcement: desired "", observed null`
+	if diff := cmp.Diff(want, d.String()); diff != "" {
+		t.Errorf("d.String(): -want, +got:\n%s", diff)
+	}
+}
+
+func TestSetDrift(t *testing.T) {
+	drifted := Diff{{Path: "spec.forProvider.version", Desired: "5.7", Observed: "5.6"}}
+
+	type want struct {
+		upToDate   bool
+		conditions []runtimev1alpha1.Condition
+	}
+	cases := map[string]struct {
+		reason string
+		d      Diff
+		want   want
+	}{
+		"UpToDate": {
+			reason: "An empty diff should be reported as up to date.",
+			want: want{
+				upToDate:   true,
+				conditions: []runtimev1alpha1.Condition{UpToDate()},
+			},
+		},
+		"Drifted": {
+			reason: "A non-empty diff should be reported as drifted, with a message describing each differing field.",
+			d:      drifted,
+			want: want{
+				conditions: []runtimev1alpha1.Condition{{
+					Type:    TypeUpToDate,
+					Status:  "False",
+					Reason:  ReasonDrifted,
+					Message: `spec.forProvider.version: desired "5.7", observed "5.6"`,
+				}},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			o := &fake.Managed{}
+			got := SetDrift(o, tc.d)
+			if diff := cmp.Diff(tc.want.upToDate, got); diff != "" {
+				t.Errorf("\n%s\nSetDrift(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.conditions, o.Conditions, test.EquateConditions()); diff != "" {
+				t.Errorf("\n%s\nSetDrift(...): -want conditions, +got conditions:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Azure/go-autorest/autorest"
+)
+
+// ErrorCodeDryRun is the error code of the responses returned in place of the
+// requests that are not sent in dry-run mode.
+const ErrorCodeDryRun = "DryRun"
+
+// DryRun causes the Azure API clients that the provider builds to record,
+// rather than send, the requests that would change Azure resources.
+var DryRun = false
+
+// Redacted replaces the values of secret properties, such as passwords and
+// keys, in the bodies of PlannedRequests.
+const Redacted = "REDACTED"
+
+// A PlannedRequest is a request that would have changed an Azure resource, but
+// that was not sent because the provider runs in dry-run mode. The values of
+// secret properties of its body are Redacted, because PlannedRequests are
+// recorded as events and served without authentication.
+type PlannedRequest struct {
+	Method string          `json:"method"`
+	URL    string          `json:"url"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// String returns a human readable representation of the PlannedRequest.
+func (r PlannedRequest) String() string {
+	if len(r.Body) == 0 {
+		return r.Method + " " + r.URL
+	}
+	return r.Method + " " + r.URL + " " + string(r.Body)
+}
+
+type plannedRequests struct {
+	mu       sync.Mutex
+	requests []PlannedRequest
+}
+
+type plannedRequestsKey struct{}
+
+// WithDryRun returns a context in which every request that would change an
+// Azure resource, including POST requests, is recorded rather than sent in
+// dry-run mode. It also returns a function that returns the requests recorded
+// so far.
+func WithDryRun(ctx context.Context) (context.Context, func() []PlannedRequest) {
+	p := &plannedRequests{}
+	return context.WithValue(ctx, plannedRequestsKey{}, p), func() []PlannedRequest {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return append([]PlannedRequest(nil), p.requests...)
+	}
+}
+
+// Unplanned returns a context derived from the supplied context, which may have
+// been returned by WithDryRun, whose requests are not recorded. Requests that
+// would change Azure resources are still not sent in dry-run mode, but POST
+// requests are, so that planned operations may use them to read Azure
+// resources, e.g. to check whether a name is available.
+func Unplanned(ctx context.Context) context.Context {
+	return context.WithValue(ctx, plannedRequestsKey{}, (*plannedRequests)(nil))
+}
+
+// DryRunIntercept is an autorest.SendDecorator that, in dry-run mode, records
+// rather than sends the requests that would change Azure resources. POST
+// requests are only intercepted if their context was returned by WithDryRun,
+// because they are also used to read secrets such as access keys.
+//
+// Intercepted requests whose context was returned by WithDryRun are answered
+// with a successful response whose body is the body of the request, so that
+// operations that make several requests, e.g. to create an Azure AD
+// application and then an AKS cluster that uses it, plan all of them. Other
+// intercepted requests are answered with an error with the DryRun error code.
+func DryRunIntercept(s autorest.Sender) autorest.Sender {
+	return autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+		if !DryRun {
+			return s.Do(r)
+		}
+		p, _ := r.Context().Value(plannedRequestsKey{}).(*plannedRequests)
+		planned := p != nil
+		switch r.Method {
+		case http.MethodPut, http.MethodPatch, http.MethodDelete:
+		case http.MethodPost:
+			if !planned {
+				return s.Do(r)
+			}
+		default:
+			return s.Do(r)
+		}
+		if !planned {
+			return dryRunResponse(r), nil
+		}
+		b := readBody(r)
+		pr := PlannedRequest{Method: r.Method, URL: r.URL.String(), Body: requestBody(b)}
+		p.mu.Lock()
+		p.requests = append(p.requests, pr)
+		p.mu.Unlock()
+		return plannedResponse(r, b), nil
+	})
+}
+
+// readBody reads and closes the body of the supplied request, if any.
+func readBody(r *http.Request) []byte {
+	if r.Body == nil {
+		return nil
+	}
+	b, err := ioutil.ReadAll(r.Body)
+	_ = r.Body.Close()
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// requestBody returns the supplied request body as JSON, with the values of
+// its secret properties Redacted. Bodies that are not JSON may not be
+// inspected for secrets, and are returned as the JSON string Redacted.
+func requestBody(b []byte) json.RawMessage {
+	if len(b) == 0 {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		s, _ := json.Marshal(Redacted)
+		return s
+	}
+	if !redact(v) {
+		return b
+	}
+	s, _ := json.Marshal(v)
+	return s
+}
+
+// redact replaces the values of the secret properties of the supplied JSON
+// value, and of the values it contains, with Redacted. It returns true if any
+// were replaced.
+func redact(v interface{}) bool {
+	redacted := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, p := range v {
+			if p != nil && isSecretProperty(k) {
+				v[k] = Redacted
+				redacted = true
+				continue
+			}
+			redacted = redact(p) || redacted
+		}
+	case []interface{}:
+		for _, e := range v {
+			redacted = redact(e) || redacted
+		}
+	}
+	return redacted
+}
+
+// isSecretProperty returns true if the supplied property of an Azure resource
+// may contain a secret, e.g. the administratorLoginPassword of a database
+// server, the primaryKey of a cache, or the secret of a service principal.
+func isSecretProperty(name string) bool {
+	n := strings.ToLower(name)
+	for _, s := range []string{"password", "secret", "connectionstring"} {
+		if strings.Contains(n, s) {
+			return true
+		}
+	}
+	return strings.HasSuffix(n, "key") || strings.HasSuffix(n, "keys")
+}
+
+// plannedResponse returns a response that the Azure SDK for Go treats as the
+// success of the supplied request, including of long-running operations. Its
+// body is the supplied body of the request.
+func plannedResponse(r *http.Request, body []byte) *http.Response {
+	h := http.Header{}
+	if len(body) > 0 {
+		h.Set("Content-Type", "application/json; charset=utf-8")
+	}
+	return &http.Response{
+		Request:       r,
+		Status:        fmt.Sprintf("%d %s", http.StatusOK, http.StatusText(http.StatusOK)),
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}
+}
+
+// dryRunResponse returns a response that the Azure SDK for Go treats as an
+// error that is not retried.
+func dryRunResponse(r *http.Request) *http.Response {
+	body := fmt.Sprintf(`{"error":{"code":%q,"message":"%s %s was not sent because the provider runs in dry-run mode"}}`, ErrorCodeDryRun, r.Method, r.URL.Path)
+	return &http.Response{
+		Request:       r,
+		Status:        fmt.Sprintf("%d %s", http.StatusBadRequest, http.StatusText(http.StatusBadRequest)),
+		StatusCode:    http.StatusBadRequest,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json; charset=utf-8"}},
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/mysql/mgmt/2017-12-01/mysql"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-06-01/network"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/provider-azure/pkg/clients/fake/arm"
+)
+
+func TestDryRunIntercept(t *testing.T) {
+	const (
+		subscription = "bf1b0e59-93da-42e0-82c6-5a1d94227911"
+		group        = "coolgroup"
+	)
+	s := arm.NewServer()
+	defer s.Close()
+	groupID := "/subscriptions/" + subscription + "/resourceGroups/" + group
+	s.Add(groupID, map[string]interface{}{"location": "westus"})
+	s.Add(groupID+"/providers/Microsoft.Network/virtualNetworks/existingnet", map[string]interface{}{"location": "westus"})
+
+	DryRun = true
+	defer func() { DryRun = false }()
+
+	cl := network.NewVirtualNetworksClientWithBaseURI(s.URL, subscription)
+	ConfigureClient(&cl.Client, autorest.NullAuthorizer{})
+	vnet := network.VirtualNetwork{
+		Location: to.StringPtr("westus"),
+		VirtualNetworkPropertiesFormat: &network.VirtualNetworkPropertiesFormat{
+			AddressSpace: &network.AddressSpace{AddressPrefixes: &[]string{"10.0.0.0/16"}},
+		},
+	}
+
+	// Planned requests succeed, so that operations that make several
+	// requests plan all of them. Other intercepted requests fail.
+	ctx, requests := WithDryRun(context.Background())
+	f, err := cl.CreateOrUpdate(ctx, group, "coolnet", vnet)
+	if err != nil {
+		t.Errorf("cl.CreateOrUpdate(...): %s", err)
+	}
+	if done, err := f.DoneWithContext(ctx, cl); !done || err != nil {
+		t.Errorf("f.DoneWithContext(...): want done planned operation, got %t, %v", done, err)
+	}
+	if _, err := cl.Delete(context.Background(), group, "existingnet"); err == nil {
+		t.Errorf("cl.Delete(...): want error, got nil")
+	}
+
+	body, _ := json.Marshal(vnet)
+	want := []PlannedRequest{{
+		Method: "PUT",
+		URL:    s.URL + groupID + "/providers/Microsoft.Network/virtualNetworks/coolnet?api-version=2019-06-01",
+		Body:   body,
+	}}
+	if diff := cmp.Diff(want, requests()); diff != "" {
+		t.Errorf("requests(): -want, +got:\n%s", diff)
+	}
+
+	if _, ok := s.Resource(groupID + "/providers/Microsoft.Network/virtualNetworks/coolnet"); ok {
+		t.Errorf("cl.CreateOrUpdate(...): virtual network was created in dry-run mode")
+	}
+	if _, ok := s.Resource(groupID + "/providers/Microsoft.Network/virtualNetworks/existingnet"); !ok {
+		t.Errorf("cl.Delete(...): virtual network was deleted in dry-run mode")
+	}
+	if _, err := cl.Get(ctx, group, "existingnet", ""); err != nil {
+		t.Errorf("cl.Get(...): reads should be sent in dry-run mode: %s", err)
+	}
+}
+
+func TestDryRunInterceptRedactsSecrets(t *testing.T) {
+	const (
+		subscription = "bf1b0e59-93da-42e0-82c6-5a1d94227911"
+		group        = "coolgroup"
+		password     = "hunter2-but-longer"
+	)
+	s := arm.NewServer()
+	defer s.Close()
+	s.Add("/subscriptions/"+subscription+"/resourceGroups/"+group, map[string]interface{}{"location": "westus"})
+
+	DryRun = true
+	defer func() { DryRun = false }()
+
+	cl := mysql.NewServersClientWithBaseURI(s.URL, subscription)
+	ConfigureClient(&cl.Client, autorest.NullAuthorizer{})
+	server := mysql.ServerForCreate{
+		Location: to.StringPtr("westus"),
+		Properties: &mysql.ServerPropertiesForDefaultCreate{
+			AdministratorLogin:         to.StringPtr("cooladmin"),
+			AdministratorLoginPassword: to.StringPtr(password),
+			CreateMode:                 mysql.CreateModeDefault,
+		},
+	}
+
+	ctx, requests := WithDryRun(context.Background())
+	if _, err := cl.Create(ctx, group, "coolserver", server); err != nil {
+		t.Errorf("cl.Create(...): %s", err)
+	}
+
+	got := requests()
+	if len(got) != 1 {
+		t.Fatalf("requests(): want 1 planned request, got %d", len(got))
+	}
+	if strings.Contains(got[0].String(), password) {
+		t.Errorf("requests(): planned request contains the administrator login password: %s", got[0])
+	}
+	body := struct {
+		Properties map[string]interface{} `json:"properties"`
+	}{}
+	if err := json.Unmarshal(got[0].Body, &body); err != nil {
+		t.Fatalf("json.Unmarshal(...): %s", err)
+	}
+	want := map[string]interface{}{
+		"administratorLogin":         "cooladmin",
+		"administratorLoginPassword": Redacted,
+		"createMode":                 string(mysql.CreateModeDefault),
+	}
+	if diff := cmp.Diff(want, body.Properties); diff != "" {
+		t.Errorf("requests(): -want body properties, +got body properties:\n%s", diff)
+	}
+}
+
+func TestRequestBody(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		body   string
+		want   string
+	}{
+		"NoSecrets": {
+			reason: "Bodies without secrets should be returned unchanged.",
+			body:   `{"location": "westus", "properties": {"keySource": "Microsoft.Storage"}}`,
+			want:   `{"location": "westus", "properties": {"keySource": "Microsoft.Storage"}}`,
+		},
+		"Secrets": {
+			reason: "The values of passwords, secrets and keys should be redacted wherever they are nested.",
+			body:   `{"properties":{"administratorLoginPassword":"p","servicePrincipalProfile":{"clientId":"c","secret":"s"},"accessKeys":[{"primaryKey":"k"}],"connectionStrings":["cs"]},"tags":[{"storageAccountKey":"k"}]}`,
+			want:   `{"properties":{"accessKeys":"REDACTED","administratorLoginPassword":"REDACTED","connectionStrings":"REDACTED","servicePrincipalProfile":{"clientId":"c","secret":"REDACTED"}},"tags":[{"storageAccountKey":"REDACTED"}]}`,
+		},
+		"NotJSON": {
+			reason: "Bodies that are not JSON should be redacted, because they cannot be inspected for secrets.",
+			body:   "client_secret=s",
+			want:   `"REDACTED"`,
+		},
+		"Empty": {
+			reason: "Empty bodies should not be returned.",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := string(requestBody([]byte(tc.body)))
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nrequestBody(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+)
+
+// CredentialsKeyResourceManagerAudience is the key of the audience of Azure AD
+// tokens for the Azure Resource Manager. It is only set for environments whose
+// endpoints are discovered, e.g. Azure Stack Hub, where it differs from the
+// Azure Resource Manager endpoint.
+const CredentialsKeyResourceManagerAudience = "resourceManagerAudience"
+
+// Credentials keys of the DNS suffixes of the data plane endpoints of an Azure
+// environment, e.g. core.windows.net for the blob endpoint of a storage
+// account named cool, i.e. https://cool.blob.core.windows.net.
+const (
+	CredentialsKeyStorageEndpointSuffix      = "storageEndpointSuffix"
+	CredentialsKeyKeyVaultDNSSuffix          = "keyVaultDnsSuffix"
+	CredentialsKeySQLServerHostnameSuffix    = "sqlServerHostnameSuffix"
+	CredentialsKeyContainerRegistryDNSSuffix = "containerRegistryDnsSuffix"
+)
+
+const (
+	metadataEndpointsPath = "/metadata/endpoints?api-version=1.0"
+	metadataTimeout       = 30 * time.Second
+)
+
+const (
+	errFmtUnknownEnvironment   = "unknown Azure environment %q"
+	errFmtDiscoverEnvironment  = "cannot discover Azure environment of %s"
+	errFmtMetadataStatus       = "unexpected status %q from metadata endpoint"
+	errNewMetadataRequest      = "cannot create metadata request"
+	errDecodeMetadataEndpoints = "cannot decode metadata endpoints"
+	errNoLoginEndpoint         = "metadata endpoints do not contain a login endpoint"
+)
+
+// environmentKeys are the credentials keys that are set by an Azure
+// environment.
+var environmentKeys = []string{
+	CredentialsKeyActiveDirectoryEndpointURL,
+	CredentialsKeyResourceManagerEndpointURL,
+	CredentialsKeyActiveDirectoryGraphResourceID,
+	CredentialsKeyResourceManagerAudience,
+	CredentialsKeyStorageEndpointSuffix,
+	CredentialsKeyKeyVaultDNSSuffix,
+	CredentialsKeySQLServerHostnameSuffix,
+	CredentialsKeyContainerRegistryDNSSuffix,
+}
+
+// MetadataClient is the HTTP client used to discover the endpoints of an
+// Azure environment.
+var MetadataClient = &http.Client{Timeout: metadataTimeout}
+
+// metadataEndpoints is the document served by the metadata endpoint of an
+// Azure Resource Manager.
+type metadataEndpoints struct {
+	GraphEndpoint  string `json:"graphEndpoint"`
+	Authentication struct {
+		LoginEndpoint string   `json:"loginEndpoint"`
+		Audiences     []string `json:"audiences"`
+	} `json:"authentication"`
+	Suffixes struct {
+		Storage           string `json:"storage"`
+		KeyVaultDNS       string `json:"keyVaultDns"`
+		SQLServerHostname string `json:"sqlServerHostname"`
+		ACRLoginServer    string `json:"acrLoginServer"`
+	} `json:"suffixes"`
+}
+
+// environmentEndpoints returns the endpoints of the supplied Azure
+// environment keyed by their credentials keys.
+func environmentEndpoints(env azure.Environment) map[string]string {
+	return map[string]string{
+		CredentialsKeyActiveDirectoryEndpointURL:     env.ActiveDirectoryEndpoint,
+		CredentialsKeyResourceManagerEndpointURL:     env.ResourceManagerEndpoint,
+		CredentialsKeyActiveDirectoryGraphResourceID: env.GraphEndpoint,
+		CredentialsKeyStorageEndpointSuffix:          env.StorageEndpointSuffix,
+		CredentialsKeyKeyVaultDNSSuffix:              strings.TrimPrefix(env.KeyVaultDNSSuffix, "."),
+		CredentialsKeySQLServerHostnameSuffix:        strings.TrimPrefix(env.SQLDatabaseDNSSuffix, "."),
+		CredentialsKeyContainerRegistryDNSSuffix:     strings.TrimPrefix(env.ContainerRegistryDNSSuffix, "."),
+	}
+}
+
+// SetEnvironment sets the Azure AD, Azure Resource Manager and Azure AD Graph
+// endpoints, and the DNS suffixes, of the named Azure environment, e.g. AzureChinaCloud, in the
+// supplied credentials content. Any endpoints that are already set are
+// overridden.
+func SetEnvironment(creds map[string]string, name string) error {
+	env, err := azure.EnvironmentFromName(name)
+	if err != nil {
+		return errors.Wrapf(err, errFmtUnknownEnvironment, name)
+	}
+	for k, v := range environmentEndpoints(env) {
+		creds[k] = v
+	}
+	return nil
+}
+
+// DefaultEnvironment sets the endpoints of the Azure public cloud for any
+// endpoints that are not set in the supplied credentials content.
+func DefaultEnvironment(creds map[string]string) {
+	for k, v := range environmentEndpoints(azure.PublicCloud) {
+		if creds[k] == "" {
+			creds[k] = v
+		}
+	}
+}
+
+// DiscoverEnvironment sets the Azure AD and Azure AD Graph endpoints, the
+// token audience and the DNS suffixes that are discovered from the metadata
+// endpoint of the supplied Azure Resource Manager endpoint, e.g. that of an
+// Azure Stack Hub, in the supplied credentials content. Any endpoints that are
+// already set are overridden, and those that are not discovered are unset.
+func DiscoverEnvironment(ctx context.Context, hc *http.Client, creds map[string]string, endpoint string) error {
+	md, err := getMetadataEndpoints(ctx, hc, endpoint)
+	if err != nil {
+		return errors.Wrapf(err, errFmtDiscoverEnvironment, endpoint)
+	}
+	creds[CredentialsKeyResourceManagerEndpointURL] = endpoint
+	creds[CredentialsKeyActiveDirectoryEndpointURL] = md.Authentication.LoginEndpoint
+	creds[CredentialsKeyActiveDirectoryGraphResourceID] = md.GraphEndpoint
+	delete(creds, CredentialsKeyResourceManagerAudience)
+	if len(md.Authentication.Audiences) > 0 {
+		creds[CredentialsKeyResourceManagerAudience] = md.Authentication.Audiences[0]
+	}
+	suffixes := map[string]string{
+		CredentialsKeyStorageEndpointSuffix:      md.Suffixes.Storage,
+		CredentialsKeyKeyVaultDNSSuffix:          md.Suffixes.KeyVaultDNS,
+		CredentialsKeySQLServerHostnameSuffix:    md.Suffixes.SQLServerHostname,
+		CredentialsKeyContainerRegistryDNSSuffix: md.Suffixes.ACRLoginServer,
+	}
+	for k, v := range suffixes {
+		delete(creds, k)
+		if v = strings.TrimPrefix(v, "."); v != "" {
+			creds[k] = v
+		}
+	}
+	return nil
+}
+
+// BlobEndpoint returns the blob service endpoint of the named storage account
+// in the Azure environment of the supplied credentials content.
+func BlobEndpoint(creds map[string]string, account string) string {
+	suffix := creds[CredentialsKeyStorageEndpointSuffix]
+	if suffix == "" {
+		suffix = azure.PublicCloud.StorageEndpointSuffix
+	}
+	return "https://" + account + ".blob." + suffix
+}
+
+func getMetadataEndpoints(ctx context.Context, hc *http.Client, endpoint string) (*metadataEndpoints, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(endpoint, "/")+metadataEndpointsPath, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, errNewMetadataRequest)
+	}
+	rsp, err := hc.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close() // nolint:errcheck
+	if rsp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf(errFmtMetadataStatus, rsp.Status)
+	}
+	md := &metadataEndpoints{}
+	if err := json.NewDecoder(rsp.Body).Decode(md); err != nil {
+		return nil, errors.Wrap(err, errDecodeMetadataEndpoints)
+	}
+	if md.Authentication.LoginEndpoint == "" {
+		return nil, errors.New(errNoLoginEndpoint)
+	}
+	return md, nil
+}
+
+// ResourceManagerAudience returns the audience of Azure AD tokens for the
+// Azure Resource Manager of the supplied credentials content. This is the
+// discovered token audience if there is one, and the Azure Resource Manager
+// endpoint otherwise.
+func ResourceManagerAudience(creds map[string]string) string {
+	if a := creds[CredentialsKeyResourceManagerAudience]; a != "" {
+		return a
+	}
+	return creds[CredentialsKeyResourceManagerEndpointURL]
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestSetEnvironment(t *testing.T) {
+	type args struct {
+		creds map[string]string
+		name  string
+	}
+	type want struct {
+		creds map[string]string
+		err   error
+	}
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"China": {
+			args: args{
+				creds: map[string]string{
+					CredentialsKeyClientID:                   "cool-client",
+					CredentialsKeyResourceManagerEndpointURL: "https://management.azure.com/",
+				},
+				name: "AzureChinaCloud",
+			},
+			want: want{
+				creds: map[string]string{
+					CredentialsKeyClientID:                       "cool-client",
+					CredentialsKeyActiveDirectoryEndpointURL:     "https://login.chinacloudapi.cn/",
+					CredentialsKeyResourceManagerEndpointURL:     "https://management.chinacloudapi.cn/",
+					CredentialsKeyActiveDirectoryGraphResourceID: "https://graph.chinacloudapi.cn/",
+					CredentialsKeyStorageEndpointSuffix:          "core.chinacloudapi.cn",
+					CredentialsKeyKeyVaultDNSSuffix:              "vault.azure.cn",
+					CredentialsKeySQLServerHostnameSuffix:        "database.chinacloudapi.cn",
+					CredentialsKeyContainerRegistryDNSSuffix:     "azurecr.cn",
+				},
+			},
+		},
+		"Unknown": {
+			args: args{
+				creds: map[string]string{},
+				name:  "AzureMoonCloud",
+			},
+			want: want{
+				creds: map[string]string{},
+				err:   errors.Wrapf(errors.New(`autorest/azure: There is no cloud environment matching the name "AZUREMOONCLOUD"`), errFmtUnknownEnvironment, "AzureMoonCloud"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := SetEnvironment(tc.args.creds, tc.args.name)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("SetEnvironment(...): -want error, +got error:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.creds, tc.args.creds); diff != "" {
+				t.Errorf("SetEnvironment(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDefaultEnvironment(t *testing.T) {
+	creds := map[string]string{
+		CredentialsKeyActiveDirectoryEndpointURL: "https://login.microsoftonline.us/",
+	}
+	DefaultEnvironment(creds)
+	want := map[string]string{
+		CredentialsKeyActiveDirectoryEndpointURL:     "https://login.microsoftonline.us/",
+		CredentialsKeyResourceManagerEndpointURL:     "https://management.azure.com/",
+		CredentialsKeyActiveDirectoryGraphResourceID: "https://graph.windows.net/",
+		CredentialsKeyStorageEndpointSuffix:          "core.windows.net",
+		CredentialsKeyKeyVaultDNSSuffix:              "vault.azure.net",
+		CredentialsKeySQLServerHostnameSuffix:        "database.windows.net",
+		CredentialsKeyContainerRegistryDNSSuffix:     "azurecr.io",
+	}
+	if diff := cmp.Diff(want, creds); diff != "" {
+		t.Errorf("DefaultEnvironment(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestDiscoverEnvironment(t *testing.T) {
+	type want struct {
+		creds map[string]string
+		err   error
+	}
+	cases := map[string]struct {
+		handler http.HandlerFunc
+		want    want
+	}{
+		"Discovered": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/metadata/endpoints" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				_, _ = w.Write([]byte(`{
+					"graphEndpoint": "https://graph.local.azurestack.external/",
+					"authentication": {
+						"loginEndpoint": "https://adfs.local.azurestack.external/adfs/",
+						"audiences": ["https://management.adfs.azurestack.external/cool-audience"]
+					}
+				}`))
+			},
+			want: want{
+				creds: map[string]string{
+					CredentialsKeyActiveDirectoryEndpointURL:     "https://adfs.local.azurestack.external/adfs/",
+					CredentialsKeyActiveDirectoryGraphResourceID: "https://graph.local.azurestack.external/",
+					CredentialsKeyResourceManagerAudience:        "https://management.adfs.azurestack.external/cool-audience",
+				},
+			},
+		},
+		"DiscoveredSuffixes": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{
+					"graphEndpoint": "https://graph.local.azurestack.external/",
+					"authentication": {
+						"loginEndpoint": "https://adfs.local.azurestack.external/adfs/",
+						"audiences": ["https://management.adfs.azurestack.external/cool-audience"]
+					},
+					"suffixes": {
+						"storage": "local.azurestack.external",
+						"keyVaultDns": ".vault.local.azurestack.external",
+						"acrLoginServer": "azurecr.local.azurestack.external"
+					}
+				}`))
+			},
+			want: want{
+				creds: map[string]string{
+					CredentialsKeyActiveDirectoryEndpointURL:     "https://adfs.local.azurestack.external/adfs/",
+					CredentialsKeyActiveDirectoryGraphResourceID: "https://graph.local.azurestack.external/",
+					CredentialsKeyResourceManagerAudience:        "https://management.adfs.azurestack.external/cool-audience",
+					CredentialsKeyStorageEndpointSuffix:          "local.azurestack.external",
+					CredentialsKeyKeyVaultDNSSuffix:              "vault.local.azurestack.external",
+					CredentialsKeyContainerRegistryDNSSuffix:     "azurecr.local.azurestack.external",
+				},
+			},
+		},
+		"NotFound": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			want: want{
+				err: errors.Errorf(errFmtMetadataStatus, "404 Not Found"),
+			},
+		},
+		"NoLoginEndpoint": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"graphEndpoint": "https://graph.local.azurestack.external/"}`))
+			},
+			want: want{
+				err: errors.New(errNoLoginEndpoint),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(tc.handler)
+			defer srv.Close()
+
+			creds := map[string]string{CredentialsKeyClientID: "cool-client"}
+			err := DiscoverEnvironment(context.Background(), srv.Client(), creds, srv.URL+"/")
+
+			var want error
+			if tc.want.err != nil {
+				want = errors.Wrapf(tc.want.err, errFmtDiscoverEnvironment, srv.URL+"/")
+			}
+			if diff := cmp.Diff(want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("DiscoverEnvironment(...): -want error, +got error:\n%s", diff)
+			}
+			if tc.want.err != nil {
+				return
+			}
+			wantCreds := map[string]string{
+				CredentialsKeyClientID:                   "cool-client",
+				CredentialsKeyResourceManagerEndpointURL: srv.URL + "/",
+			}
+			for k, v := range tc.want.creds {
+				wantCreds[k] = v
+			}
+			if diff := cmp.Diff(wantCreds, creds); diff != "" {
+				t.Errorf("DiscoverEnvironment(...): -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.creds[CredentialsKeyResourceManagerAudience], ResourceManagerAudience(creds)); diff != "" {
+				t.Errorf("ResourceManagerAudience(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBlobEndpoint(t *testing.T) {
+	cases := map[string]struct {
+		creds map[string]string
+		want  string
+	}{
+		"PublicCloud": {
+			creds: map[string]string{},
+			want:  "https://cool.blob.core.windows.net",
+		},
+		"AzureStack": {
+			creds: map[string]string{CredentialsKeyStorageEndpointSuffix: "local.azurestack.external"},
+			want:  "https://cool.blob.local.azurestack.external",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, BlobEndpoint(tc.creds, "cool")); diff != "" {
+				t.Errorf("BlobEndpoint(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+)
+
+// An ErrorClass categorizes the errors returned by Azure APIs by how a
+// controller should react to them.
+type ErrorClass string
+
+// Error classes.
+const (
+	// ErrorClassUnknown errors could not be attributed to any other class.
+	// They are retried.
+	ErrorClassUnknown ErrorClass = "Unknown"
+
+	// ErrorClassNotFound errors indicate that the requested resource does not
+	// exist.
+	ErrorClassNotFound ErrorClass = "NotFound"
+
+	// ErrorClassThrottled errors indicate that too many requests were made.
+	// They are retried, no sooner than Azure asks.
+	ErrorClassThrottled ErrorClass = "Throttled"
+
+	// ErrorClassConflict errors indicate that the resource is in a state that
+	// does not allow the request, for example because another operation is
+	// in progress. They are retried.
+	ErrorClassConflict ErrorClass = "Conflict"
+
+	// ErrorClassTransient errors indicate that Azure failed to serve an
+	// otherwise valid request. They are retried.
+	ErrorClassTransient ErrorClass = "Transient"
+
+	// ErrorClassBadRequest errors indicate that Azure rejected the request,
+	// often because a dependency is not ready yet, a lock prevents it, or a
+	// parent resource is still provisioning. They are retried after a long
+	// backoff.
+	ErrorClassBadRequest ErrorClass = "BadRequest"
+
+	// ErrorClassQuotaExceeded errors indicate that the request would exceed a
+	// subscription or regional quota. Retrying will not help until the quota
+	// is raised.
+	ErrorClassQuotaExceeded ErrorClass = "QuotaExceeded"
+
+	// ErrorClassInvalidParameter errors indicate that the request was invalid.
+	// Retrying will not help until the request is changed.
+	ErrorClassInvalidParameter ErrorClass = "InvalidParameter"
+)
+
+// Backoffs after which requests that caused errors Azure is unlikely to stop
+// returning soon are retried.
+var (
+	// BadRequestBackoff is the backoff of BadRequest errors.
+	BadRequestBackoff = 5 * time.Minute
+
+	// TerminalErrorBackoff is the backoff of errors that are not retryable.
+	// Their requests are still retried, because their cause, e.g. a quota, may
+	// be resolved outside of the managed resource.
+	TerminalErrorBackoff = 30 * time.Minute
+)
+
+// Azure Resource Manager error codes that are classified regardless of the
+// HTTP status code they were returned with.
+var errorCodeClasses = map[string]ErrorClass{
+	"TooManyRequests":                     ErrorClassThrottled,
+	"SubscriptionRequestsThrottled":       ErrorClassThrottled,
+	"ResourceRequestsThrottled":           ErrorClassThrottled,
+	"TenantRequestsThrottled":             ErrorClassThrottled,
+	"AnotherOperationInProgress":          ErrorClassConflict,
+	"OperationNotAllowed":                 ErrorClassBadRequest,
+	"InvalidParameter":                    ErrorClassInvalidParameter,
+	"InvalidRequestContent":               ErrorClassInvalidParameter,
+	"InvalidRequestFormat":                ErrorClassInvalidParameter,
+	"InvalidTemplate":                     ErrorClassInvalidParameter,
+	"LocationNotAvailableForResourceType": ErrorClassInvalidParameter,
+}
+
+// An Error is an error returned by an Azure API, classified by how a
+// controller should react to it.
+type Error struct {
+	// Class of the error.
+	Class ErrorClass
+
+	// StatusCode is the HTTP status code Azure returned, if any.
+	StatusCode int
+
+	// Code is the Azure Resource Manager error code, e.g.
+	// AnotherOperationInProgress, if any.
+	Code string
+
+	// Message is the error message Azure returned, if any.
+	Message string
+
+	// RetryAfter is how long Azure asked clients to wait before retrying the
+	// request, if it did.
+	RetryAfter time.Duration
+}
+
+// Retryable returns true if retrying the request that caused the error may
+// succeed without the request being changed.
+func (e *Error) Retryable() bool {
+	switch e.Class {
+	case ErrorClassQuotaExceeded, ErrorClassInvalidParameter:
+		return false
+	default:
+		return true
+	}
+}
+
+// Backoff returns how long to wait before retrying the request that caused
+// the error. It is no shorter than the Retry-After delay Azure asked for, and
+// is zero for errors that may be retried as soon as the controller sees fit.
+func (e *Error) Backoff() time.Duration {
+	b := time.Duration(0)
+	switch {
+	case !e.Retryable():
+		b = TerminalErrorBackoff
+	case e.Class == ErrorClassBadRequest:
+		b = BadRequestBackoff
+	}
+	if e.RetryAfter > b {
+		return e.RetryAfter
+	}
+	return b
+}
+
+// Reason returns a CamelCase reason for the error, suitable for use in events
+// and conditions. It is the Azure error code if there is one, or the class of
+// the error otherwise.
+func (e *Error) Reason() string {
+	if e.Code != "" {
+		return e.Code
+	}
+	return string(e.Class)
+}
+
+// Classify the supplied error. It returns nil if the error was not returned by
+// an Azure API.
+func Classify(err error) *Error {
+	var de autorest.DetailedError
+	if !errors.As(err, &de) {
+		return nil
+	}
+
+	e := &Error{Class: ErrorClassUnknown, Code: ErrorCode(err)}
+	if sc, ok := de.StatusCode.(int); ok {
+		e.StatusCode = sc
+	}
+	if se := serviceError(de); se != nil {
+		e.Message = se.Message
+	}
+	if de.Response != nil {
+		e.RetryAfter = retryAfter(de.Response.Header.Get(autorest.HeaderRetryAfter), time.Now())
+		if e.StatusCode == 0 {
+			e.StatusCode = de.Response.StatusCode
+		}
+	}
+
+	switch {
+	case errorCodeClasses[e.Code] != "":
+		e.Class = errorCodeClasses[e.Code]
+	case strings.HasSuffix(e.Code, "QuotaExceeded"):
+		e.Class = ErrorClassQuotaExceeded
+	case e.StatusCode == http.StatusNotFound:
+		e.Class = ErrorClassNotFound
+	case e.StatusCode == http.StatusTooManyRequests:
+		e.Class = ErrorClassThrottled
+	case e.StatusCode == http.StatusConflict:
+		e.Class = ErrorClassConflict
+	case e.StatusCode == http.StatusBadRequest:
+		e.Class = ErrorClassBadRequest
+	case e.StatusCode >= http.StatusInternalServerError:
+		e.Class = ErrorClassTransient
+	}
+
+	return e
+}
+
+// IsRetryable returns false if the supplied error was returned by an Azure
+// API and retrying the request that caused it will not succeed until the
+// request is changed. All other errors are considered retryable.
+func IsRetryable(err error) bool {
+	e := Classify(err)
+	return e == nil || e.Retryable()
+}
+
+// RetryAfter returns how long Azure asked clients to wait before retrying the
+// request that caused the supplied error. It returns zero if Azure did not
+// specify a delay.
+func RetryAfter(err error) time.Duration {
+	if e := Classify(err); e != nil {
+		return e.RetryAfter
+	}
+	return 0
+}
+
+// Backoff returns how long to wait before retrying the request that caused the
+// supplied error. It returns zero if the error was not returned by an Azure
+// API, or may be retried as soon as the controller sees fit.
+func Backoff(err error) time.Duration {
+	if e := Classify(err); e != nil {
+		return e.Backoff()
+	}
+	return 0
+}
+
+func serviceError(de autorest.DetailedError) *azure.ServiceError {
+	switch o := de.Original.(type) {
+	case *azure.RequestError:
+		return o.ServiceError
+	case *azure.ServiceError:
+		return o
+	}
+	return nil
+}
+
+// retryAfter parses the supplied Retry-After header, which may be either a
+// number of seconds or an HTTP date.
+func retryAfter(h string, now time.Time) time.Duration {
+	if h == "" {
+		return 0
+	}
+	if s, err := strconv.Atoi(h); err == nil {
+		if s < 0 {
+			return 0
+		}
+		return time.Duration(s) * time.Second
+	}
+	t, err := http.ParseTime(h)
+	if err != nil || t.Before(now) {
+		return 0
+	}
+	return t.Sub(now)
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+)
+
+func detailedError(status int, code string, header http.Header) error {
+	return errors.Wrap(autorest.DetailedError{
+		StatusCode: status,
+		Original:   &azure.RequestError{ServiceError: &azure.ServiceError{Code: code, Message: "boom"}},
+		Response:   &http.Response{StatusCode: status, Header: header},
+	}, "cannot do the thing")
+}
+
+func TestClassify(t *testing.T) {
+	cases := map[string]struct {
+		err  error
+		want *Error
+	}{
+		"NotAzure": {
+			err: errors.New("boom"),
+		},
+		"NotFound": {
+			err:  detailedError(http.StatusNotFound, "ResourceNotFound", nil),
+			want: &Error{Class: ErrorClassNotFound, StatusCode: http.StatusNotFound, Code: "ResourceNotFound", Message: "boom"},
+		},
+		"Throttled": {
+			err: detailedError(http.StatusTooManyRequests, "", http.Header{autorest.HeaderRetryAfter: []string{"17"}}),
+			want: &Error{
+				Class:      ErrorClassThrottled,
+				StatusCode: http.StatusTooManyRequests,
+				Message:    "boom",
+				RetryAfter: 17 * time.Second,
+			},
+		},
+		"AnotherOperationInProgress": {
+			err:  detailedError(http.StatusConflict, "AnotherOperationInProgress", nil),
+			want: &Error{Class: ErrorClassConflict, StatusCode: http.StatusConflict, Code: "AnotherOperationInProgress", Message: "boom"},
+		},
+		"QuotaExceeded": {
+			err:  detailedError(http.StatusConflict, "PublicIPCountLimitReached", nil),
+			want: &Error{Class: ErrorClassConflict, StatusCode: http.StatusConflict, Code: "PublicIPCountLimitReached", Message: "boom"},
+		},
+		"CoreQuotaExceeded": {
+			err:  detailedError(http.StatusConflict, "CoreQuotaExceeded", nil),
+			want: &Error{Class: ErrorClassQuotaExceeded, StatusCode: http.StatusConflict, Code: "CoreQuotaExceeded", Message: "boom"},
+		},
+		"InvalidParameter": {
+			err:  detailedError(http.StatusBadRequest, "InvalidParameter", nil),
+			want: &Error{Class: ErrorClassInvalidParameter, StatusCode: http.StatusBadRequest, Code: "InvalidParameter", Message: "boom"},
+		},
+		"OperationNotAllowed": {
+			err:  detailedError(http.StatusBadRequest, "OperationNotAllowed", nil),
+			want: &Error{Class: ErrorClassBadRequest, StatusCode: http.StatusBadRequest, Code: "OperationNotAllowed", Message: "boom"},
+		},
+		"BadRequest": {
+			err:  detailedError(http.StatusBadRequest, "ParentResourceNotReady", nil),
+			want: &Error{Class: ErrorClassBadRequest, StatusCode: http.StatusBadRequest, Code: "ParentResourceNotReady", Message: "boom"},
+		},
+		"Transient": {
+			err:  detailedError(http.StatusServiceUnavailable, "", nil),
+			want: &Error{Class: ErrorClassTransient, StatusCode: http.StatusServiceUnavailable, Message: "boom"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := Classify(tc.err)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Classify(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	cases := map[string]struct {
+		err  error
+		want bool
+	}{
+		"NotAzure": {
+			err:  errors.New("boom"),
+			want: true,
+		},
+		"Throttled": {
+			err:  detailedError(http.StatusTooManyRequests, "SubscriptionRequestsThrottled", nil),
+			want: true,
+		},
+		"QuotaExceeded": {
+			err:  detailedError(http.StatusBadRequest, "QuotaExceeded", nil),
+			want: false,
+		},
+		"InvalidParameter": {
+			err:  detailedError(http.StatusBadRequest, "InvalidRequestContent", nil),
+			want: false,
+		},
+		"BadRequest": {
+			err:  detailedError(http.StatusBadRequest, "OperationNotAllowed", nil),
+			want: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, IsRetryable(tc.err)); diff != "" {
+				t.Errorf("IsRetryable(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cases := map[string]struct {
+		err  error
+		want time.Duration
+	}{
+		"NotAzure": {
+			err: errors.New("boom"),
+		},
+		"Conflict": {
+			err: detailedError(http.StatusConflict, "AnotherOperationInProgress", nil),
+		},
+		"Throttled": {
+			err:  detailedError(http.StatusTooManyRequests, "", http.Header{autorest.HeaderRetryAfter: []string{"17"}}),
+			want: 17 * time.Second,
+		},
+		"BadRequest": {
+			err:  detailedError(http.StatusBadRequest, "OperationNotAllowed", nil),
+			want: BadRequestBackoff,
+		},
+		"BadRequestRetryAfter": {
+			err:  detailedError(http.StatusBadRequest, "OperationNotAllowed", http.Header{autorest.HeaderRetryAfter: []string{"3600"}}),
+			want: time.Hour,
+		},
+		"Terminal": {
+			err:  detailedError(http.StatusBadRequest, "InvalidParameter", nil),
+			want: TerminalErrorBackoff,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, Backoff(tc.err)); diff != "" {
+				t.Errorf("Backoff(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := map[string]struct {
+		header string
+		want   time.Duration
+	}{
+		"Missing": {},
+		"Seconds": {
+			header: "30",
+			want:   30 * time.Second,
+		},
+		"Date": {
+			header: now.Add(2 * time.Minute).Format(http.TimeFormat),
+			want:   2 * time.Minute,
+		},
+		"PastDate": {
+			header: now.Add(-2 * time.Minute).Format(http.TimeFormat),
+		},
+		"Malformed": {
+			header: "soon",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, retryAfter(tc.header, now)); diff != "" {
+				t.Errorf("retryAfter(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package arm
+
+import (
+	"strings"
+)
+
+const namespaceResources = "Microsoft.Resources"
+
+// A target is the resource, collection or action that a request targets.
+type target struct {
+	subscription string
+	namespace    string
+
+	// id and name of the targeted resource.
+	id   string
+	name string
+
+	// typ is the lower case type of the targeted resource, e.g.
+	// microsoft.network/virtualnetworks/subnets. displayType is the type as
+	// it was requested, e.g. Microsoft.Network/virtualNetworks/subnets.
+	typ         string
+	displayType string
+
+	// Keys of the resource group and the parent resource of the targeted
+	// resource. The parent of a top level resource is its resource group.
+	group     string
+	groupName string
+	parent    string
+
+	// trailing is the segment that follows the targeted resource, i.e. an
+	// action such as listKeys, or the type of a collection.
+	trailing string
+}
+
+// parse the supplied Azure Resource Manager request path, e.g.
+// /subscriptions/s/resourceGroups/g/providers/Microsoft.Network/virtualNetworks/v
+func parse(path string) (target, bool) {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segs) == 3 && strings.EqualFold(segs[0], "subscriptions") && strings.EqualFold(segs[2], "resourceGroups") {
+		// The collection of the resource groups of a subscription.
+		return target{subscription: segs[1], namespace: namespaceResources, id: "/" + strings.Join(segs[:2], "/"), trailing: segs[2]}, true
+	}
+	if len(segs) < 4 || !strings.EqualFold(segs[0], "subscriptions") {
+		return target{}, false
+	}
+
+	t := target{subscription: segs[1], namespace: namespaceResources}
+	if strings.EqualFold(segs[2], "resourceGroups") {
+		t.group, t.groupName = key(strings.Join(segs[:4], "/")), segs[3]
+	}
+
+	var types, ends []int
+	for i := 2; i < len(segs); {
+		if strings.EqualFold(segs[i], "providers") && i+1 < len(segs) {
+			// Extension resources, e.g. locks, start a new type.
+			t.namespace, types = segs[i+1], nil
+			i += 2
+			continue
+		}
+		if i+1 == len(segs) {
+			t.trailing = segs[i]
+			break
+		}
+		types = append(types, i)
+		ends = append(ends, i+2)
+		i += 2
+	}
+	if len(ends) == 0 {
+		return target{}, false
+	}
+
+	display := make([]string, 0, len(types)+1)
+	if t.namespace != namespaceResources {
+		display = append(display, t.namespace)
+	}
+	for _, i := range types {
+		display = append(display, segs[i])
+	}
+	end := ends[len(ends)-1]
+	t.id = "/" + strings.Join(segs[:end], "/")
+	t.name = segs[end-1]
+	t.typ = strings.ToLower(strings.Join(display, "/"))
+	t.displayType = strings.Join(display, "/")
+	if t.namespace == namespaceResources {
+		t.displayType = namespaceResources + "/" + t.displayType
+	}
+	if len(ends) > 1 {
+		t.parent = key(strings.Join(segs[:ends[len(ends)-2]], "/"))
+	}
+	return t, true
+}
+
+// A resourceType determines how the fake Azure Resource Manager handles
+// requests for resources of a particular type.
+type resourceType struct {
+	// Whether PUT, PATCH and DELETE requests start asynchronous operations.
+	asyncPut    bool
+	asyncPatch  bool
+	asyncDelete bool
+
+	// hiddenWhileCreating resources are not found until the asynchronous
+	// operation that creates them completes.
+	hiddenWhileCreating bool
+
+	// ready sets the properties that Azure sets once the resource was
+	// created or updated, if any.
+	ready func(r *resource)
+
+	// actions that may be invoked by POST requests, by lower case name.
+	actions map[string]func(r *resource) interface{}
+}
+
+// collection returns the lower case type of the resources listed by a request
+// for the supplied target, if it targets a collection rather than an action.
+// Collections of top level resources are targeted as the type of a resource
+// group's namespace, e.g. microsoft.network, followed by the resource type.
+func collection(t target) (string, bool) {
+	if t.trailing == "" {
+		return "", false
+	}
+	typ := strings.ToLower(strings.TrimPrefix(t.typ+"/"+t.trailing, "/"))
+	_, ok := resourceTypes[typ]
+	return typ, ok
+}
+
+func (rt resourceType) complete(r *resource) {
+	if rt.ready != nil {
+		rt.ready(r)
+	}
+}
+
+// resourceTypes supported by the fake Azure Resource Manager, by lower case
+// type.
+var resourceTypes = map[string]resourceType{
+	typeResourceGroup: {
+		asyncDelete: true,
+	},
+	"microsoft.network/virtualnetworks": {
+		asyncPut:    true,
+		asyncDelete: true,
+	},
+	"microsoft.network/virtualnetworks/subnets": {
+		asyncPut:    true,
+		asyncDelete: true,
+	},
+	"microsoft.dbformysql/servers": {
+		asyncPut:            true,
+		asyncPatch:          true,
+		asyncDelete:         true,
+		hiddenWhileCreating: true,
+		ready:               serverReady("mysql"),
+	},
+	"microsoft.dbformysql/servers/firewallrules": {
+		asyncPut:    true,
+		asyncDelete: true,
+	},
+	"microsoft.dbformysql/servers/virtualnetworkrules": {
+		asyncPut:    true,
+		asyncDelete: true,
+	},
+	"microsoft.dbforpostgresql/servers": {
+		asyncPut:            true,
+		asyncPatch:          true,
+		asyncDelete:         true,
+		hiddenWhileCreating: true,
+		ready:               serverReady("postgres"),
+	},
+	"microsoft.dbforpostgresql/servers/firewallrules": {
+		asyncPut:    true,
+		asyncDelete: true,
+	},
+	"microsoft.dbforpostgresql/servers/virtualnetworkrules": {
+		asyncPut:    true,
+		asyncDelete: true,
+	},
+	"microsoft.cache/redis": {
+		asyncPut:    true,
+		asyncDelete: true,
+		ready:       redisReady,
+		actions:     map[string]func(r *resource) interface{}{"listkeys": redisKeys},
+	},
+	"microsoft.documentdb/databaseaccounts": {
+		asyncPut:    true,
+		asyncDelete: true,
+	},
+	"microsoft.containerservice/managedclusters": {
+		asyncPut:    true,
+		asyncDelete: true,
+	},
+	"microsoft.storage/storageaccounts": {
+		asyncPut:            true,
+		hiddenWhileCreating: true,
+		ready:               storageAccountReady,
+		actions:             map[string]func(r *resource) interface{}{"listkeys": storageAccountKeys},
+	},
+}
+
+func serverReady(engine string) func(r *resource) {
+	return func(r *resource) {
+		p := r.properties()
+		p["userVisibleState"] = "Ready"
+		p["fullyQualifiedDomainName"] = r.body["name"].(string) + "." + engine + ".database.azure.com"
+	}
+}
+
+func redisReady(r *resource) {
+	p := r.properties()
+	p["hostName"] = r.body["name"].(string) + ".redis.cache.windows.net"
+	p["port"] = 6379
+	p["sslPort"] = 6380
+}
+
+func redisKeys(r *resource) interface{} {
+	return map[string]string{
+		"primaryKey":   "primary-" + key(r.id),
+		"secondaryKey": "secondary-" + key(r.id),
+	}
+}
+
+func storageAccountReady(r *resource) {
+	name := r.body["name"].(string)
+	p := r.properties()
+	p["statusOfPrimary"] = "available"
+	p["primaryEndpoints"] = map[string]string{
+		"blob":  "https://" + name + ".blob.core.windows.net/",
+		"queue": "https://" + name + ".queue.core.windows.net/",
+		"table": "https://" + name + ".table.core.windows.net/",
+		"file":  "https://" + name + ".file.core.windows.net/",
+	}
+}
+
+func storageAccountKeys(r *resource) interface{} {
+	return map[string]interface{}{
+		"keys": []map[string]string{
+			{"keyName": "key1", "value": "key1-" + key(r.id), "permissions": "FULL"},
+			{"keyName": "key2", "value": "key2-" + key(r.id), "permissions": "FULL"},
+		},
+	}
+}
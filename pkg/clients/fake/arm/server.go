@@ -0,0 +1,604 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package arm contains a fake Azure Resource Manager that serves the subset of
+// the Azure Resource Manager API used by the provider's controllers, including
+// long running operations, in process. It is intended for end-to-end tests.
+package arm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of asynchronous operations.
+const (
+	StatusInProgress = "InProgress"
+	StatusSucceeded  = "Succeeded"
+	StatusFailed     = "Failed"
+)
+
+// Provisioning states of resources.
+const (
+	ProvisioningStateCreating  = "Creating"
+	ProvisioningStateUpdating  = "Updating"
+	ProvisioningStateDeleting  = "Deleting"
+	ProvisioningStateSucceeded = "Succeeded"
+	ProvisioningStateFailed    = "Failed"
+)
+
+const (
+	// DefaultPollsUntilDone is the number of times an asynchronous operation
+	// is polled before it completes, by default.
+	DefaultPollsUntilDone = 2
+
+	headerAsyncOperation = "Azure-AsyncOperation"
+	headerRequestID      = "x-ms-request-id"
+	headerCorrelationID  = "x-ms-correlation-request-id"
+	headerClientID       = "x-ms-client-request-id"
+
+	pathMetadata      = "/metadata/endpoints"
+	pathSuffixToken   = "/oauth2/token"
+	fmtOperationPath  = "/subscriptions/%s/providers/%s/locations/%s/operations/%s"
+	typeOperations    = "/locations/operations"
+	typeResourceGroup = "resourcegroups"
+)
+
+// A ServiceError is returned by the fake Azure Resource Manager when a request
+// or an asynchronous operation fails.
+type ServiceError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type resource struct {
+	id     string
+	parent string
+	typ    string
+	body   map[string]interface{}
+
+	// Some resources, e.g. MySQL servers, are not found until the operation
+	// that creates them completes.
+	hidden bool
+
+	// The asynchronous operation in progress, if any.
+	operation *operation
+}
+
+func (r *resource) properties() map[string]interface{} {
+	p, ok := r.body["properties"].(map[string]interface{})
+	if !ok {
+		p = map[string]interface{}{}
+		r.body["properties"] = p
+	}
+	return p
+}
+
+type operation struct {
+	method   string
+	resource string
+	started  time.Time
+	polls    int
+	status   string
+	err      *ServiceError
+}
+
+// An Option configures a Server.
+type Option func(*Server)
+
+// WithPollsUntilDone configures the number of times asynchronous operations
+// are polled before they complete.
+func WithPollsUntilDone(n int) Option {
+	return func(s *Server) {
+		s.pollsUntilDone = n
+	}
+}
+
+// WithOperationDuration configures asynchronous operations to also complete
+// once the supplied duration elapsed, like they do in Azure, regardless of
+// whether they were polled. Controllers that observe the provisioning state of
+// a resource rather than polling its operation depend on this.
+func WithOperationDuration(d time.Duration) Option {
+	return func(s *Server) {
+		s.operationDuration = d
+	}
+}
+
+// A Server is a fake Azure Resource Manager. It also serves the metadata
+// endpoint of a custom Azure environment and an Azure Active Directory token
+// endpoint, so that clients may discover and authenticate to it like they
+// would to an Azure Stack Hub.
+type Server struct {
+	*httptest.Server
+
+	pollsUntilDone    int
+	operationDuration time.Duration
+
+	mu         sync.Mutex
+	resources  map[string]*resource
+	operations map[string]*operation
+	failures   map[string]*ServiceError
+}
+
+// NewServer starts and returns a fake Azure Resource Manager. Callers should
+// call Close when finished, to shut it down.
+func NewServer(o ...Option) *Server {
+	s := &Server{
+		pollsUntilDone: DefaultPollsUntilDone,
+		resources:      map[string]*resource{},
+		operations:     map[string]*operation{},
+		failures:       map[string]*ServiceError{},
+	}
+	for _, fn := range o {
+		fn(s)
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Resource returns a copy of the body of the resource with the supplied ID, if
+// it exists.
+func (s *Server) Resource(id string) (map[string]interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.resources[key(id)]
+	if !ok {
+		return nil, false
+	}
+	b, _ := json.Marshal(r.body)
+	body := map[string]interface{}{}
+	_ = json.Unmarshal(b, &body)
+	return body, true
+}
+
+// Add a resource with the supplied ID and body, as if it had been created
+// outside of the provider. The resource is ready, regardless of its type.
+func (s *Server) Add(id string, body map[string]interface{}) {
+	t, ok := parse(id)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	body["id"], body["name"], body["type"] = t.id, t.name, t.displayType
+	r := &resource{id: t.id, parent: t.parent, typ: t.typ, body: body}
+	r.properties()["provisioningState"] = ProvisioningStateSucceeded
+	resourceTypes[t.typ].complete(r)
+	s.resources[key(t.id)] = r
+}
+
+// Fail causes the next asynchronous operation on the resource with the
+// supplied ID to fail with the supplied error.
+func (s *Server) Fail(id string, e ServiceError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[key(id)] = &e
+}
+
+// ServeHTTP serves the fake Azure Resource Manager API.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(headerRequestID, uuid.New().String())
+	w.Header().Set(headerCorrelationID, uuid.New().String())
+	if id := r.Header.Get(headerClientID); id != "" {
+		w.Header().Set(headerClientID, id)
+	}
+
+	switch {
+	case r.URL.Path == pathMetadata:
+		s.metadata(w)
+		return
+	case strings.HasSuffix(r.URL.Path, pathSuffixToken):
+		s.token(w, r)
+		return
+	case r.URL.Query().Get("api-version") == "":
+		writeError(w, http.StatusBadRequest, "MissingApiVersionParameter", "The api-version query parameter (?api-version=) is required for all requests.")
+		return
+	}
+
+	t, ok := parse(r.URL.Path)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "InvalidRequestUri", fmt.Sprintf("The request URI %q is not valid.", r.URL.Path))
+		return
+	}
+	if strings.HasSuffix("/"+t.typ, typeOperations) {
+		s.poll(w, key(t.id))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+	if typ, ok := collection(t); ok && r.Method == http.MethodGet {
+		s.list(w, t, typ)
+		return
+	}
+
+	rt, ok := resourceTypes[t.typ]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "InvalidResourceType", fmt.Sprintf("The resource type %q is not supported.", t.typ))
+		return
+	}
+
+	switch {
+	case t.trailing != "" && r.Method == http.MethodPost:
+		s.action(w, t, rt)
+	case t.trailing != "":
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", fmt.Sprintf("The %s method is not supported for %q.", r.Method, r.URL.Path))
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		s.get(w, r, t)
+	case r.Method == http.MethodPut:
+		s.put(w, r, t, rt)
+	case r.Method == http.MethodPatch:
+		s.patch(w, r, t, rt)
+	case r.Method == http.MethodDelete:
+		s.delete(w, r, t, rt)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", fmt.Sprintf("The %s method is not supported for %q.", r.Method, r.URL.Path))
+	}
+}
+
+func (s *Server) metadata(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"graphEndpoint": s.URL + "/",
+		"authentication": map[string]interface{}{
+			"loginEndpoint": s.URL + "/",
+			"audiences":     []string{s.URL + "/"},
+		},
+	})
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": uuid.New().String(),
+		"token_type":   "Bearer",
+		"expires_in":   "3600",
+		"expires_on":   strconv.FormatInt(now.Add(time.Hour).Unix(), 10),
+		"not_before":   strconv.FormatInt(now.Unix(), 10),
+		"resource":     r.FormValue("resource"),
+	})
+}
+
+// exists returns an error if the resource group or the parent resource of the
+// supplied target does not exist.
+func (s *Server) exists(t target) *ServiceError {
+	if t.group != "" && t.typ != typeResourceGroup {
+		if _, ok := s.visible(t.group); !ok {
+			return &ServiceError{Code: "ResourceGroupNotFound", Message: fmt.Sprintf("Resource group %q could not be found.", t.groupName)}
+		}
+	}
+	if t.parent != "" && t.parent != t.group {
+		if _, ok := s.visible(t.parent); !ok {
+			return &ServiceError{Code: "ParentResourceNotFound", Message: fmt.Sprintf("Parent resource of %q could not be found.", t.id)}
+		}
+	}
+	return nil
+}
+
+func (s *Server) visible(k string) (*resource, bool) {
+	r, ok := s.resources[k]
+	if !ok || r.hidden {
+		return nil, false
+	}
+	return r, true
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request, t target) {
+	if e := s.exists(t); e != nil {
+		writeServiceError(w, http.StatusNotFound, e)
+		return
+	}
+	res, ok := s.visible(key(t.id))
+	if !ok {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeServiceError(w, http.StatusNotFound, notFound(t))
+		return
+	}
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, http.StatusOK, res.body)
+}
+
+// list the resources of the supplied lower case type within the supplied
+// target, i.e. a subscription, resource group or parent resource.
+func (s *Server) list(w http.ResponseWriter, t target, typ string) {
+	if e := s.exists(t); e != nil {
+		writeServiceError(w, http.StatusNotFound, e)
+		return
+	}
+	within := key(t.id)
+	if t.group != "" && within != t.group {
+		if _, ok := s.visible(within); !ok {
+			writeError(w, http.StatusNotFound, "ParentResourceNotFound", fmt.Sprintf("Parent resource %q could not be found.", t.id))
+			return
+		}
+	}
+
+	var keys []string
+	for k, r := range s.resources {
+		if r.typ == typ && !r.hidden && strings.HasPrefix(k, within+"/") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	value := make([]map[string]interface{}, len(keys))
+	for i, k := range keys {
+		value[i] = s.resources[k].body
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"value": value})
+}
+
+func (s *Server) put(w http.ResponseWriter, r *http.Request, t target, rt resourceType) {
+	if e := s.exists(t); e != nil {
+		writeServiceError(w, http.StatusNotFound, e)
+		return
+	}
+	body := map[string]interface{}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRequestContent", err.Error())
+		return
+	}
+
+	k := key(t.id)
+	existing, exists := s.resources[k]
+	if exists && existing.operation != nil {
+		writeError(w, http.StatusConflict, "AnotherOperationInProgress", fmt.Sprintf("Another operation on %q is in progress.", t.id))
+		return
+	}
+
+	body["id"], body["name"], body["type"] = t.id, t.name, t.displayType
+	res := &resource{id: t.id, parent: t.parent, typ: t.typ, body: body}
+	s.resources[k] = res
+
+	if !rt.asyncPut {
+		res.properties()["provisioningState"] = ProvisioningStateSucceeded
+		rt.complete(res)
+		status := http.StatusCreated
+		if exists {
+			status = http.StatusOK
+		}
+		writeJSON(w, status, res.body)
+		return
+	}
+
+	res.properties()["provisioningState"] = ProvisioningStateUpdating
+	if !exists {
+		res.properties()["provisioningState"] = ProvisioningStateCreating
+		res.hidden = rt.hiddenWhileCreating
+	}
+	s.start(w, r, t, res)
+	writeJSON(w, http.StatusAccepted, res.body)
+}
+
+func (s *Server) patch(w http.ResponseWriter, r *http.Request, t target, rt resourceType) {
+	res, ok := s.visible(key(t.id))
+	if !ok {
+		writeServiceError(w, http.StatusNotFound, notFound(t))
+		return
+	}
+	if res.operation != nil {
+		writeError(w, http.StatusConflict, "AnotherOperationInProgress", fmt.Sprintf("Another operation on %q is in progress.", t.id))
+		return
+	}
+	body := map[string]interface{}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRequestContent", err.Error())
+		return
+	}
+	merge(res.body, body)
+
+	if !rt.asyncPatch {
+		writeJSON(w, http.StatusOK, res.body)
+		return
+	}
+	res.properties()["provisioningState"] = ProvisioningStateUpdating
+	s.start(w, r, t, res)
+	writeJSON(w, http.StatusAccepted, res.body)
+}
+
+func (s *Server) delete(w http.ResponseWriter, r *http.Request, t target, rt resourceType) {
+	k := key(t.id)
+	res, ok := s.visible(k)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if res.operation != nil {
+		// Deleting a resource that is already being deleted is accepted.
+		if res.operation.method == http.MethodDelete {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		writeError(w, http.StatusConflict, "AnotherOperationInProgress", fmt.Sprintf("Another operation on %q is in progress.", t.id))
+		return
+	}
+
+	if !rt.asyncDelete {
+		s.remove(k)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	res.properties()["provisioningState"] = ProvisioningStateDeleting
+	s.start(w, r, t, res)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) action(w http.ResponseWriter, t target, rt resourceType) {
+	res, ok := s.visible(key(t.id))
+	if !ok {
+		writeServiceError(w, http.StatusNotFound, notFound(t))
+		return
+	}
+	fn, ok := rt.actions[strings.ToLower(t.trailing)]
+	if !ok {
+		writeError(w, http.StatusNotFound, "InvalidAction", fmt.Sprintf("The action %q is not supported for %q.", t.trailing, t.typ))
+		return
+	}
+	writeJSON(w, http.StatusOK, fn(res))
+}
+
+// start an asynchronous operation on the supplied resource, and return its
+// polling URL in the headers of the supplied response.
+func (s *Server) start(w http.ResponseWriter, r *http.Request, t target, res *resource) {
+	op := &operation{method: r.Method, resource: key(t.id), started: time.Now(), status: StatusInProgress}
+	if e, ok := s.failures[op.resource]; ok {
+		op.err = e
+		delete(s.failures, op.resource)
+	}
+	res.operation = op
+
+	location, _ := res.body["location"].(string)
+	if location == "" {
+		location = "global"
+	}
+	id := uuid.New().String()
+	s.operations[key(fmt.Sprintf(fmtOperationPath, t.subscription, t.namespace, location, id))] = op
+
+	u := s.URL + fmt.Sprintf(fmtOperationPath, t.subscription, t.namespace, location, id) + "?api-version=" + r.URL.Query().Get("api-version")
+	w.Header().Set(headerAsyncOperation, u)
+	w.Header().Set("Location", u)
+}
+
+// poll the asynchronous operation with the supplied key, completing it once
+// it was polled enough times.
+func (s *Server) poll(w http.ResponseWriter, k string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+	op, ok := s.operations[k]
+	if !ok {
+		writeError(w, http.StatusNotFound, "OperationNotFound", "The operation could not be found.")
+		return
+	}
+	if op.status == StatusInProgress {
+		op.polls++
+		if op.polls >= s.pollsUntilDone {
+			s.complete(op)
+		}
+	}
+
+	body := map[string]interface{}{"status": op.status}
+	if op.err != nil {
+		body["error"] = op.err
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// expire completes the asynchronous operations that are in progress for longer
+// than the configured operation duration, if any.
+func (s *Server) expire() {
+	if s.operationDuration == 0 {
+		return
+	}
+	for _, op := range s.operations {
+		if op.status == StatusInProgress && time.Since(op.started) >= s.operationDuration {
+			s.complete(op)
+		}
+	}
+}
+
+func (s *Server) complete(op *operation) {
+	op.status = StatusSucceeded
+	if op.err != nil {
+		op.status = StatusFailed
+	}
+
+	res, ok := s.resources[op.resource]
+	if !ok {
+		return
+	}
+	res.operation = nil
+	res.hidden = false
+
+	switch {
+	case op.err != nil:
+		res.properties()["provisioningState"] = ProvisioningStateFailed
+	case op.method == http.MethodDelete:
+		s.remove(op.resource)
+	default:
+		res.properties()["provisioningState"] = ProvisioningStateSucceeded
+		resourceTypes[res.typ].complete(res)
+	}
+}
+
+// remove the resource with the supplied key, and all resources within it.
+func (s *Server) remove(k string) {
+	delete(s.resources, k)
+	for ck, r := range s.resources {
+		if r.parent == k || strings.HasPrefix(ck, k+"/") {
+			s.remove(ck)
+		}
+	}
+}
+
+func notFound(t target) *ServiceError {
+	if t.typ == typeResourceGroup {
+		return &ServiceError{Code: "ResourceGroupNotFound", Message: fmt.Sprintf("Resource group %q could not be found.", t.name)}
+	}
+	return &ServiceError{Code: "ResourceNotFound", Message: fmt.Sprintf("The Resource %q under resource group %q was not found.", t.displayType+"/"+t.name, t.groupName)}
+}
+
+// merge the supplied patch into the supplied body.
+func merge(body, patch map[string]interface{}) {
+	for k, v := range patch {
+		pm, ok := v.(map[string]interface{})
+		bm, bok := body[k].(map[string]interface{})
+		if ok && bok {
+			merge(bm, pm)
+			continue
+		}
+		body[k] = v
+	}
+}
+
+func key(id string) string {
+	return strings.ToLower(strings.Trim(id, "/"))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+	w.WriteHeader(status)
+	_, _ = w.Write(b)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeServiceError(w, status, &ServiceError{Code: code, Message: message})
+}
+
+func writeServiceError(w http.ResponseWriter, status int, e *ServiceError) {
+	w.Header().Set("x-ms-error-code", e.Code)
+	writeJSON(w, status, map[string]interface{}{"error": e})
+}
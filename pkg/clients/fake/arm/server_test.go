@@ -0,0 +1,251 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package arm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/mysql/mgmt/2017-12-01/mysql"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-06-01/network"
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/provider-azure/apis/v1alpha3"
+	azure "github.com/crossplane/provider-azure/pkg/clients"
+)
+
+const (
+	subscription = "bf1b0e59-93da-42e0-82c6-5a1d94227911"
+	group        = "coolgroup"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]struct {
+		path string
+		want target
+		ok   bool
+	}{
+		"ResourceGroup": {
+			path: "/subscriptions/sub/resourcegroups/coolgroup",
+			want: target{
+				subscription: "sub",
+				namespace:    "Microsoft.Resources",
+				id:           "/subscriptions/sub/resourcegroups/coolgroup",
+				name:         "coolgroup",
+				typ:          "resourcegroups",
+				displayType:  "Microsoft.Resources/resourcegroups",
+				group:        "subscriptions/sub/resourcegroups/coolgroup",
+				groupName:    "coolgroup",
+			},
+			ok: true,
+		},
+		"ChildResourceAction": {
+			path: "/subscriptions/sub/resourceGroups/coolgroup/providers/Microsoft.Network/virtualNetworks/coolnet/subnets/coolsubnet/prepareNetworkPolicies",
+			want: target{
+				subscription: "sub",
+				namespace:    "Microsoft.Network",
+				id:           "/subscriptions/sub/resourceGroups/coolgroup/providers/Microsoft.Network/virtualNetworks/coolnet/subnets/coolsubnet",
+				name:         "coolsubnet",
+				typ:          "microsoft.network/virtualnetworks/subnets",
+				displayType:  "Microsoft.Network/virtualNetworks/subnets",
+				group:        "subscriptions/sub/resourcegroups/coolgroup",
+				groupName:    "coolgroup",
+				parent:       "subscriptions/sub/resourcegroups/coolgroup/providers/microsoft.network/virtualnetworks/coolnet",
+				trailing:     "prepareNetworkPolicies",
+			},
+			ok: true,
+		},
+		"ResourceGroups": {
+			path: "/subscriptions/sub/resourcegroups",
+			want: target{
+				subscription: "sub",
+				namespace:    "Microsoft.Resources",
+				id:           "/subscriptions/sub",
+				trailing:     "resourcegroups",
+			},
+			ok: true,
+		},
+		"Collection": {
+			path: "/subscriptions/sub/resourceGroups/coolgroup/providers/Microsoft.Network/virtualNetworks",
+			want: target{
+				subscription: "sub",
+				namespace:    "Microsoft.Network",
+				id:           "/subscriptions/sub/resourceGroups/coolgroup",
+				name:         "coolgroup",
+				typ:          "microsoft.network",
+				displayType:  "Microsoft.Network",
+				group:        "subscriptions/sub/resourcegroups/coolgroup",
+				groupName:    "coolgroup",
+				trailing:     "virtualNetworks",
+			},
+			ok: true,
+		},
+		"Invalid": {
+			path: "/tenants/cool",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, ok := parse(tc.path)
+			if diff := cmp.Diff(tc.ok, ok); diff != "" {
+				t.Errorf("parse(...): -want ok, +got ok:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(target{})); diff != "" {
+				t.Errorf("parse(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestServer exercises the fake Azure Resource Manager using the clients of
+// the Azure SDK, including polling asynchronous operations the same way the
+// controllers do.
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer()
+	defer s.Close()
+
+	groups := resources.NewGroupsClientWithBaseURI(s.URL, subscription)
+	if _, err := groups.CreateOrUpdate(ctx, group, resources.Group{Location: to.StringPtr("westus")}); err != nil {
+		t.Fatalf("groups.CreateOrUpdate(...): %s", err)
+	}
+
+	// Child resources cannot be created before their parents.
+	subnets := network.NewSubnetsClientWithBaseURI(s.URL, subscription)
+	_, err := subnets.CreateOrUpdate(ctx, group, "coolnet", "coolsubnet", network.Subnet{})
+	if diff := cmp.Diff("ParentResourceNotFound", azure.ErrorCode(err)); diff != "" {
+		t.Errorf("subnets.CreateOrUpdate(...): -want error code, +got error code:\n%s", diff)
+	}
+
+	vnets := network.NewVirtualNetworksClientWithBaseURI(s.URL, subscription)
+	f, err := vnets.CreateOrUpdate(ctx, group, "coolnet", network.VirtualNetwork{Location: to.StringPtr("westus")})
+	if err != nil {
+		t.Fatalf("vnets.CreateOrUpdate(...): %s", err)
+	}
+	op := azure.NewAsyncOperation(http.MethodPut, f.Future)
+	for _, want := range []string{StatusInProgress, StatusSucceeded} {
+		if err := azure.FetchAsyncOperation(ctx, vnets.Client, &op); err != nil {
+			t.Fatalf("FetchAsyncOperation(...): %s", err)
+		}
+		if diff := cmp.Diff(want, op.Status); diff != "" {
+			t.Errorf("FetchAsyncOperation(...): -want status, +got status:\n%s", diff)
+		}
+	}
+	vnet, err := vnets.Get(ctx, group, "coolnet", "")
+	if err != nil {
+		t.Fatalf("vnets.Get(...): %s", err)
+	}
+	if diff := cmp.Diff(ProvisioningStateSucceeded, to.String(vnet.ProvisioningState)); diff != "" {
+		t.Errorf("vnets.Get(...): -want provisioning state, +got provisioning state:\n%s", diff)
+	}
+
+	// Asynchronous operations may be made to fail.
+	s.Fail(to.String(vnet.ID)+"/subnets/coolsubnet", ServiceError{Code: "InUseSubnetCannotBeUpdated", Message: "boom"})
+	sbf, err := subnets.CreateOrUpdate(ctx, group, "coolnet", "coolsubnet", network.Subnet{})
+	if err != nil {
+		t.Fatalf("subnets.CreateOrUpdate(...): %s", err)
+	}
+	op = azure.NewAsyncOperation(http.MethodPut, sbf.Future)
+	for i := 0; i < DefaultPollsUntilDone; i++ {
+		if err := azure.FetchAsyncOperation(ctx, subnets.Client, &op); err != nil {
+			t.Fatalf("FetchAsyncOperation(...): %s", err)
+		}
+	}
+	if diff := cmp.Diff(StatusFailed, op.Status); diff != "" {
+		t.Errorf("FetchAsyncOperation(...): -want status, +got status:\n%s", diff)
+	}
+
+	// MySQL servers are not found until they are created.
+	servers := mysql.NewServersClientWithBaseURI(s.URL, subscription)
+	sf, err := servers.Create(ctx, group, "coolserver", mysql.ServerForCreate{Location: to.StringPtr("westus"), Properties: &mysql.ServerPropertiesForDefaultCreate{AdministratorLogin: to.StringPtr("cool"), AdministratorLoginPassword: to.StringPtr("secret")}})
+	if err != nil {
+		t.Fatalf("servers.Create(...): %s", err)
+	}
+	if _, err := servers.Get(ctx, group, "coolserver"); !azure.IsNotFound(err) {
+		t.Errorf("servers.Get(...): want not found error while creating, got %v", err)
+	}
+	op = azure.NewAsyncOperation(http.MethodPut, sf.Future)
+	for i := 0; i < DefaultPollsUntilDone; i++ {
+		if err := azure.FetchAsyncOperation(ctx, servers.Client, &op); err != nil {
+			t.Fatalf("FetchAsyncOperation(...): %s", err)
+		}
+	}
+	server, err := servers.Get(ctx, group, "coolserver")
+	if err != nil {
+		t.Fatalf("servers.Get(...): %s", err)
+	}
+	if diff := cmp.Diff(mysql.ServerStateReady, server.UserVisibleState); diff != "" {
+		t.Errorf("servers.Get(...): -want state, +got state:\n%s", diff)
+	}
+
+	// Resources may be listed by resource group and by parent resource.
+	gl, err := groups.List(ctx, "", nil)
+	if err != nil {
+		t.Fatalf("groups.List(...): %s", err)
+	}
+	if diff := cmp.Diff(1, len(gl.Values())); diff != "" {
+		t.Errorf("groups.List(...): -want resource groups, +got resource groups:\n%s", diff)
+	}
+	vl, err := vnets.List(ctx, group)
+	if err != nil {
+		t.Fatalf("vnets.List(...): %s", err)
+	}
+	if diff := cmp.Diff([]string{"coolnet"}, names(vl.Values())); diff != "" {
+		t.Errorf("vnets.List(...): -want, +got:\n%s", diff)
+	}
+	sl, err := subnets.List(ctx, group, "coolnet")
+	if err != nil {
+		t.Fatalf("subnets.List(...): %s", err)
+	}
+	if diff := cmp.Diff(1, len(sl.Values())); diff != "" {
+		t.Errorf("subnets.List(...): -want subnets, +got subnets:\n%s", diff)
+	}
+	_, err = subnets.List(ctx, group, "othernet")
+	if diff := cmp.Diff("ParentResourceNotFound", azure.ErrorCode(err)); diff != "" {
+		t.Errorf("subnets.List(...): -want error code, +got error code:\n%s", diff)
+	}
+
+	// Deleting a resource group deletes everything in it.
+	df, err := groups.Delete(ctx, group)
+	if err != nil {
+		t.Fatalf("groups.Delete(...): %s", err)
+	}
+	op = azure.NewAsyncOperation(http.MethodDelete, df.Future)
+	for i := 0; i < DefaultPollsUntilDone; i++ {
+		if err := azure.FetchAsyncOperation(ctx, groups.Client, &op); err != nil {
+			t.Fatalf("FetchAsyncOperation(...): %s", err)
+		}
+	}
+	if diff := cmp.Diff(v1alpha3.AsyncOperation{Method: http.MethodDelete, PollingURL: op.PollingURL, Status: StatusSucceeded}, op); diff != "" {
+		t.Errorf("FetchAsyncOperation(...): -want, +got:\n%s", diff)
+	}
+	if _, ok := s.Resource(to.String(vnet.ID)); ok {
+		t.Errorf("groups.Delete(...): virtual network in deleted resource group still exists")
+	}
+}
+
+func names(vnets []network.VirtualNetwork) []string {
+	n := make([]string, len(vnets))
+	for i, v := range vnets {
+		n[i] = to.String(v.Name)
+	}
+	return n
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/provider-azure/apis/v1beta1"
+)
+
+// Environment variables that are injected into the provider pod by the Azure
+// AD workload identity webhook, or set by the operator.
+const (
+	EnvClientID           = "AZURE_CLIENT_ID"
+	EnvTenantID           = "AZURE_TENANT_ID"
+	EnvSubscriptionID     = "AZURE_SUBSCRIPTION_ID"
+	EnvFederatedTokenFile = "AZURE_FEDERATED_TOKEN_FILE"
+	EnvAuthorityHost      = "AZURE_AUTHORITY_HOST"
+)
+
+// CredentialsKeyFederatedTokenFile is the key of the path to a projected
+// service account token that is exchanged for an Azure AD token. It is only
+// set for the InjectedIdentity credentials source.
+const CredentialsKeyFederatedTokenFile = "federatedTokenFile"
+
+// CredentialsKeyAuxiliaryTenantIDs is the key of the semicolon separated IDs
+// of the auxiliary tenants for which tokens are supplied in addition to the
+// token of the primary tenant, e.g. to operate on resources across tenants.
+const CredentialsKeyAuxiliaryTenantIDs = "auxiliaryTenantIds"
+
+const auxiliaryTenantIDsSeparator = ";"
+
+const (
+	clientAssertionTypeJWT = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+	errReadFederatedToken = "cannot read federated token file"
+	errNewOAuthConfig     = "cannot create OAuth configuration"
+
+	errAuxTenantsManagedIdentity = "auxiliary tenants are not supported for managed identities"
+	errFmtAuxTenantToken         = "cannot create token for auxiliary tenant %q"
+)
+
+// InjectedIdentityCredentials returns the credentials content for the
+// identity that is injected into the provider pod, i.e. either a workload
+// identity federated token or a managed identity. Values set in the supplied
+// ProviderConfigSpec take precedence over the ones read from the environment.
+func InjectedIdentityCredentials(s v1beta1.ProviderConfigSpec, getenv func(string) string) map[string]string {
+	m := map[string]string{
+		CredentialsKeyClientID:                   getenv(EnvClientID),
+		CredentialsKeyTenantID:                   getenv(EnvTenantID),
+		CredentialsKeySubscriptionID:             getenv(EnvSubscriptionID),
+		CredentialsKeyFederatedTokenFile:         getenv(EnvFederatedTokenFile),
+		CredentialsKeyActiveDirectoryEndpointURL: getenv(EnvAuthorityHost),
+	}
+	if s.ClientID != nil {
+		m[CredentialsKeyClientID] = *s.ClientID
+	}
+	if s.TenantID != nil {
+		m[CredentialsKeyTenantID] = *s.TenantID
+	}
+	if s.SubscriptionID != nil {
+		m[CredentialsKeySubscriptionID] = *s.SubscriptionID
+	}
+	return m
+}
+
+// NewAuthorizer returns an authorizer for the supplied resource, e.g. the
+// Azure Resource Manager or the Azure AD Graph endpoint, using the supplied
+// credentials content. A client secret is used if one is present, then a
+// client certificate, then a federated token file. Otherwise the managed
+// identity of the pod is used. A multi-tenant authorizer, that also supplies
+// tokens for the auxiliary tenants, is returned if auxiliary tenants are set.
+func NewAuthorizer(creds map[string]string, resource string) (autorest.Authorizer, error) {
+	aux := AuxiliaryTenantIDs(creds)
+
+	if creds[CredentialsKeyClientSecret] == "" && creds[CredentialsKeyClientCertificate] == "" && creds[CredentialsKeyFederatedTokenFile] == "" {
+		if len(aux) > 0 {
+			return nil, errors.New(errAuxTenantsManagedIdentity)
+		}
+		cfg := auth.NewMSIConfig()
+		cfg.Resource = resource
+		cfg.ClientID = creds[CredentialsKeyClientID]
+		return cfg.Authorizer()
+	}
+
+	t, err := newServicePrincipalToken(creds, creds[CredentialsKeyTenantID], resource)
+	if err != nil {
+		return nil, err
+	}
+	if len(aux) == 0 {
+		return autorest.NewBearerAuthorizer(t), nil
+	}
+
+	mt := &adal.MultiTenantServicePrincipalToken{PrimaryToken: t}
+	for _, tenant := range aux {
+		at, err := newServicePrincipalToken(creds, tenant, resource)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtAuxTenantToken, tenant)
+		}
+		mt.AuxiliaryTokens = append(mt.AuxiliaryTokens, at)
+	}
+	return autorest.NewMultiTenantServicePrincipalTokenAuthorizer(mt), nil
+}
+
+// newServicePrincipalToken returns a token for the supplied resource in the
+// supplied tenant that authenticates using the client secret, client
+// certificate or federated token file of the supplied credentials content.
+func newServicePrincipalToken(creds map[string]string, tenant, resource string) (*adal.ServicePrincipalToken, error) {
+	cfg, err := adal.NewOAuthConfig(creds[CredentialsKeyActiveDirectoryEndpointURL], tenant)
+	if err != nil {
+		return nil, errors.Wrap(err, errNewOAuthConfig)
+	}
+	var t *adal.ServicePrincipalToken
+	switch {
+	case creds[CredentialsKeyClientSecret] != "":
+		t, err = adal.NewServicePrincipalToken(*cfg, creds[CredentialsKeyClientID], creds[CredentialsKeyClientSecret], resource)
+	case creds[CredentialsKeyClientCertificate] != "":
+		cert, key, cerr := ParseClientCertificate([]byte(creds[CredentialsKeyClientCertificate]), creds[CredentialsKeyClientCertificatePassword])
+		if cerr != nil {
+			return nil, cerr
+		}
+		t, err = adal.NewServicePrincipalTokenFromCertificate(*cfg, creds[CredentialsKeyClientID], cert, key, resource)
+	default:
+		t, err = adal.NewServicePrincipalTokenWithSecret(*cfg, creds[CredentialsKeyClientID], resource,
+			&FederatedTokenSecret{Path: creds[CredentialsKeyFederatedTokenFile]})
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.SetSender(Transport)
+	return t, nil
+}
+
+// AuxiliaryTenantIDs returns the auxiliary tenants of the supplied credentials
+// content.
+func AuxiliaryTenantIDs(creds map[string]string) []string {
+	var ids []string
+	for _, id := range strings.Split(creds[CredentialsKeyAuxiliaryTenantIDs], auxiliaryTenantIDsSeparator) {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// A FederatedTokenSecret authenticates to Azure AD using a signed token that
+// is issued by a federated identity provider, e.g. a projected Kubernetes
+// service account token. The token is read from the file every time a new
+// Azure AD token is requested, since the kubelet rotates it.
+type FederatedTokenSecret struct {
+	Path string
+}
+
+// SetAuthenticationValues populates the form that is submitted when
+// acquiring an Azure AD token with the federated token as client assertion.
+func (s *FederatedTokenSecret) SetAuthenticationValues(_ *adal.ServicePrincipalToken, v *url.Values) error {
+	t, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		return errors.Wrap(err, errReadFederatedToken)
+	}
+	v.Set("client_assertion_type", clientAssertionTypeJWT)
+	v.Set("client_assertion", strings.TrimSpace(string(t)))
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (s FederatedTokenSecret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Path string `json:"path"`
+	}{
+		Type: "FederatedTokenSecret",
+		Path: s.Path,
+	})
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/provider-azure/apis/v1beta1"
+)
+
+func TestInjectedIdentityCredentials(t *testing.T) {
+	env := map[string]string{
+		EnvClientID:           "env-client",
+		EnvTenantID:           "env-tenant",
+		EnvSubscriptionID:     "env-subscription",
+		EnvFederatedTokenFile: "/var/run/secrets/azure/tokens/azure-identity-token",
+		EnvAuthorityHost:      "https://login.microsoftonline.us/",
+	}
+
+	cases := map[string]struct {
+		spec   v1beta1.ProviderConfigSpec
+		getenv func(string) string
+		want   map[string]string
+	}{
+		"ManagedIdentityDefaults": {
+			getenv: func(string) string { return "" },
+			want: map[string]string{
+				CredentialsKeyClientID:                   "",
+				CredentialsKeyTenantID:                   "",
+				CredentialsKeySubscriptionID:             "",
+				CredentialsKeyFederatedTokenFile:         "",
+				CredentialsKeyActiveDirectoryEndpointURL: "",
+			},
+		},
+		"WorkloadIdentityFromEnvironment": {
+			getenv: func(k string) string { return env[k] },
+			want: map[string]string{
+				CredentialsKeyClientID:                   "env-client",
+				CredentialsKeyTenantID:                   "env-tenant",
+				CredentialsKeySubscriptionID:             "env-subscription",
+				CredentialsKeyFederatedTokenFile:         "/var/run/secrets/azure/tokens/azure-identity-token",
+				CredentialsKeyActiveDirectoryEndpointURL: "https://login.microsoftonline.us/",
+			},
+		},
+		"SpecOverridesEnvironment": {
+			spec: v1beta1.ProviderConfigSpec{
+				ClientID:       to.StringPtr("spec-client"),
+				TenantID:       to.StringPtr("spec-tenant"),
+				SubscriptionID: to.StringPtr("spec-subscription"),
+			},
+			getenv: func(k string) string { return env[k] },
+			want: map[string]string{
+				CredentialsKeyClientID:                   "spec-client",
+				CredentialsKeyTenantID:                   "spec-tenant",
+				CredentialsKeySubscriptionID:             "spec-subscription",
+				CredentialsKeyFederatedTokenFile:         "/var/run/secrets/azure/tokens/azure-identity-token",
+				CredentialsKeyActiveDirectoryEndpointURL: "https://login.microsoftonline.us/",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := InjectedIdentityCredentials(tc.spec, tc.getenv)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("InjectedIdentityCredentials(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewAuthorizer(t *testing.T) {
+	creds := map[string]string{
+		CredentialsKeyClientID:                   "cool-client",
+		CredentialsKeyClientSecret:               "cool-secret",
+		CredentialsKeyTenantID:                   "cool-tenant",
+		CredentialsKeyActiveDirectoryEndpointURL: "https://login.microsoftonline.com/",
+	}
+	withAux := map[string]string{CredentialsKeyAuxiliaryTenantIDs: "other-tenant; another-tenant"}
+	for k, v := range creds {
+		withAux[k] = v
+	}
+
+	type want struct {
+		multiTenant bool
+		err         error
+	}
+	cases := map[string]struct {
+		creds map[string]string
+		want  want
+	}{
+		"SingleTenant": {
+			creds: creds,
+			want:  want{multiTenant: false},
+		},
+		"AuxiliaryTenants": {
+			creds: withAux,
+			want:  want{multiTenant: true},
+		},
+		"ManagedIdentityAuxiliaryTenants": {
+			creds: map[string]string{CredentialsKeyAuxiliaryTenantIDs: "other-tenant"},
+			want:  want{err: errors.New(errAuxTenantsManagedIdentity)},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a, err := NewAuthorizer(tc.creds, "https://management.azure.com/")
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("NewAuthorizer(...): -want error, +got error:\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			_, single := a.(*autorest.BearerAuthorizer)
+			if diff := cmp.Diff(tc.want.multiTenant, !single); diff != "" {
+				t.Errorf("NewAuthorizer(...): -want multi-tenant, +got multi-tenant:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAuxiliaryTenantIDs(t *testing.T) {
+	got := AuxiliaryTenantIDs(map[string]string{CredentialsKeyAuxiliaryTenantIDs: "other-tenant; ;another-tenant"})
+	if diff := cmp.Diff([]string{"other-tenant", "another-tenant"}, got); diff != "" {
+		t.Errorf("AuxiliaryTenantIDs(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestFederatedTokenSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "federated-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir) // nolint:errcheck
+
+	path := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(path, []byte("a.signed.jwt\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	v := url.Values{}
+	s := &FederatedTokenSecret{Path: path}
+	if err := s.SetAuthenticationValues(nil, &v); err != nil {
+		t.Errorf("SetAuthenticationValues(...): %s", err)
+	}
+	want := url.Values{
+		"client_assertion_type": []string{clientAssertionTypeJWT},
+		"client_assertion":      []string{"a.signed.jwt"},
+	}
+	if diff := cmp.Diff(want, v); diff != "" {
+		t.Errorf("SetAuthenticationValues(...): -want, +got:\n%s", diff)
+	}
+
+	missing := &FederatedTokenSecret{Path: filepath.Join(dir, "missing")}
+	if err := missing.SetAuthenticationValues(nil, &url.Values{}); err == nil {
+		t.Errorf("SetAuthenticationValues(...): want error reading missing token file, got nil")
+	}
+}
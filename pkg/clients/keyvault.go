@@ -0,0 +1,111 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/keyvault/v7.0/keyvault"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/provider-azure/apis/v1beta1"
+)
+
+// KeyVaultRefreshInterval is the interval at which credentials read from the
+// latest version of a Key Vault secret are refreshed.
+const KeyVaultRefreshInterval = 5 * time.Minute
+
+const (
+	errParseVaultURL          = "cannot parse Key Vault URL"
+	errGetKeyVaultSecret      = "cannot get Key Vault secret"
+	errEmptyKeyVaultSecret    = "Key Vault secret has no value"
+	errFmtUnknownVaultFormat  = "unknown Key Vault secret format %q"
+	errGetKeyVaultAuthorizer  = "cannot get Key Vault authorizer"
+	errNoKeyVaultSecretClient = "Key Vault secret format requires a client ID"
+)
+
+// KeyVaultResource returns the Azure AD resource of the supplied Key Vault
+// URL, e.g. https://vault.azure.net for https://my-vault.vault.azure.net/.
+func KeyVaultResource(vaultURL string) (string, error) {
+	u, err := url.Parse(vaultURL)
+	if err != nil {
+		return "", errors.Wrap(err, errParseVaultURL)
+	}
+	h := u.Hostname()
+	if i := strings.Index(h, "."); i > 0 {
+		h = h[i+1:]
+	}
+	return u.Scheme + "://" + h, nil
+}
+
+// KeyVaultCredentials returns the credentials content read from the supplied
+// Key Vault secret, using the supplied authorizer. ClientSecret and
+// ClientCertificate secrets are combined with the supplied bootstrap
+// credentials content, i.e. the content of the credentials source.
+func KeyVaultCredentials(ctx context.Context, a autorest.Authorizer, kv v1beta1.KeyVaultCredentials, bootstrap map[string]string) (map[string]string, error) {
+	c := keyvault.New()
+	c.Authorizer = a
+	c.Sender = Transport
+	_ = c.AddToUserAgent(UserAgent)
+
+	s, err := c.GetSecret(ctx, strings.TrimSuffix(kv.VaultURL, "/"), kv.SecretName, to.String(kv.SecretVersion))
+	if err != nil {
+		return nil, errors.Wrap(err, errGetKeyVaultSecret)
+	}
+	v := to.String(s.Value)
+	if v == "" {
+		return nil, errors.New(errEmptyKeyVaultSecret)
+	}
+
+	f := v1beta1.KeyVaultFormatCredentials
+	if kv.Format != nil {
+		f = *kv.Format
+	}
+	if f == v1beta1.KeyVaultFormatCredentials {
+		return parseCredentials([]byte(v))
+	}
+
+	m := map[string]string{}
+	for k, v := range bootstrap {
+		m[k] = v
+	}
+	delete(m, CredentialsKeyClientSecret)
+	delete(m, CredentialsKeyClientCertificate)
+	delete(m, CredentialsKeyClientCertificatePassword)
+	delete(m, CredentialsKeyFederatedTokenFile)
+	if kv.ClientID != nil {
+		m[CredentialsKeyClientID] = *kv.ClientID
+	}
+	if m[CredentialsKeyClientID] == "" {
+		return nil, errors.New(errNoKeyVaultSecretClient)
+	}
+
+	switch f {
+	case v1beta1.KeyVaultFormatClientSecret:
+		m[CredentialsKeyClientSecret] = v
+	case v1beta1.KeyVaultFormatClientCertificate:
+		m[CredentialsKeyClientCertificate] = v
+	default:
+		return nil, errors.Errorf(errFmtUnknownVaultFormat, f)
+	}
+	return m, nil
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/provider-azure/apis/v1beta1"
+)
+
+func TestKeyVaultResource(t *testing.T) {
+	cases := map[string]struct {
+		url  string
+		want string
+	}{
+		"PublicCloud": {
+			url:  "https://my-vault.vault.azure.net/",
+			want: "https://vault.azure.net",
+		},
+		"ChinaCloud": {
+			url:  "https://my-vault.vault.azure.cn",
+			want: "https://vault.azure.cn",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := KeyVaultResource(tc.url)
+			if err != nil {
+				t.Fatalf("KeyVaultResource(...): %s", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("KeyVaultResource(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestKeyVaultCredentials(t *testing.T) {
+	bootstrap := map[string]string{
+		CredentialsKeyClientID:           "managed-identity",
+		CredentialsKeyTenantID:           "cool-tenant",
+		CredentialsKeySubscriptionID:     "cool-subscription",
+		CredentialsKeyFederatedTokenFile: "/var/run/secrets/azure/tokens/azure-identity-token",
+	}
+
+	type args struct {
+		value string
+		kv    v1beta1.KeyVaultCredentials
+	}
+	type want struct {
+		creds map[string]string
+		err   error
+	}
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"Credentials": {
+			args: args{
+				value: `{"clientId": "cool-client", "clientSecret": "cool-secret", "tenantId": "other-tenant"}`,
+				kv:    v1beta1.KeyVaultCredentials{SecretName: "creds"},
+			},
+			want: want{
+				creds: map[string]string{
+					CredentialsKeyClientID:     "cool-client",
+					CredentialsKeyClientSecret: "cool-secret",
+					CredentialsKeyTenantID:     "other-tenant",
+				},
+			},
+		},
+		"ClientSecret": {
+			args: args{
+				value: "cool-secret",
+				kv: v1beta1.KeyVaultCredentials{
+					SecretName: "secret",
+					Format:     to.StringPtr(v1beta1.KeyVaultFormatClientSecret),
+					ClientID:   to.StringPtr("cool-client"),
+				},
+			},
+			want: want{
+				creds: map[string]string{
+					CredentialsKeyClientID:       "cool-client",
+					CredentialsKeyClientSecret:   "cool-secret",
+					CredentialsKeyTenantID:       "cool-tenant",
+					CredentialsKeySubscriptionID: "cool-subscription",
+				},
+			},
+		},
+		"ClientCertificate": {
+			args: args{
+				value: "Y29vbC1jZXJ0aWZpY2F0ZQ==",
+				kv: v1beta1.KeyVaultCredentials{
+					SecretName:    "certificate",
+					SecretVersion: to.StringPtr("v1"),
+					Format:        to.StringPtr(v1beta1.KeyVaultFormatClientCertificate),
+				},
+			},
+			want: want{
+				creds: map[string]string{
+					CredentialsKeyClientID:          "managed-identity",
+					CredentialsKeyClientCertificate: "Y29vbC1jZXJ0aWZpY2F0ZQ==",
+					CredentialsKeyTenantID:          "cool-tenant",
+					CredentialsKeySubscriptionID:    "cool-subscription",
+				},
+			},
+		},
+		"InvalidCredentials": {
+			args: args{
+				value: `{"clientId": "cool-client"}`,
+				kv:    v1beta1.KeyVaultCredentials{SecretName: "creds"},
+			},
+			want: want{
+				err: errors.New(errNoClientSecret),
+			},
+		},
+		"EmptySecret": {
+			args: args{
+				kv: v1beta1.KeyVaultCredentials{SecretName: "empty"},
+			},
+			want: want{
+				err: errors.New(errEmptyKeyVaultSecret),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				want := "/secrets/" + tc.args.kv.SecretName + "/" + to.String(tc.args.kv.SecretVersion)
+				if r.URL.Path != want {
+					t.Errorf("GET %s: want path %s", r.URL.Path, want)
+				}
+				_ = json.NewEncoder(w).Encode(map[string]string{"value": tc.args.value})
+			}))
+			defer srv.Close()
+
+			tc.args.kv.VaultURL = srv.URL + "/"
+			got, err := KeyVaultCredentials(context.Background(), autorest.NullAuthorizer{}, tc.args.kv, bootstrap)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("KeyVaultCredentials(...): -want error, +got error:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.creds, got); diff != "" {
+				t.Errorf("KeyVaultCredentials(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
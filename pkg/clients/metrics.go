@@ -0,0 +1,267 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/crossplane/provider-azure/apis/v1alpha3"
+)
+
+const (
+	metricsNamespace = "provider_azure"
+
+	// HeaderErrorCode is the response header in which some Azure services
+	// return the code of an error.
+	HeaderErrorCode = "x-ms-error-code"
+
+	// The status and error code of requests that did not get a response.
+	statusRequestFailed    = "RequestFailed"
+	errorCodeRequestFailed = "RequestFailed"
+
+	errorCodeUnknown = "Unknown"
+
+	serviceBlob      = "Microsoft.Storage/blob"
+	serviceGraph     = "Microsoft.Graph"
+	serviceResources = "Microsoft.Resources"
+)
+
+var (
+	apiRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "api_requests_total",
+		Help:      "Number of requests made to Azure APIs.",
+	}, []string{"service", "operation", "resource_kind", "status"})
+
+	apiRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "api_request_duration_seconds",
+		Help:      "Latency of requests made to Azure APIs.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 10),
+	}, []string{"service", "operation", "resource_kind", "status"})
+
+	apiErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "api_errors_total",
+		Help:      "Number of requests made to Azure APIs that failed, by Azure error code.",
+	}, []string{"service", "operation", "resource_kind", "status", "code"})
+
+	asyncOperationsInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "async_operations_in_flight",
+		Help:      "Number of asynchronous operations that managed resources are waiting for.",
+	}, []string{"service", "method"})
+)
+
+// RegisterMetrics registers the metrics of the requests made to Azure APIs
+// with the supplied registerer.
+func RegisterMetrics(r prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{apiRequests, apiRequestDuration, apiErrors, asyncOperationsInFlight} {
+		if err := r.Register(c); err != nil {
+			return errors.Wrap(err, "cannot register Azure API metrics")
+		}
+	}
+	return nil
+}
+
+// Metrics is an autorest.SendDecorator that records the number, latency and
+// errors of the requests made to Azure APIs.
+func Metrics(s autorest.Sender) autorest.Sender {
+	return autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := s.Do(r)
+		observeRequest(r, resp, time.Since(start))
+		return resp, err
+	})
+}
+
+func observeRequest(r *http.Request, resp *http.Response, d time.Duration) {
+	service, operation, kind := requestLabels(r)
+	status, code := statusRequestFailed, errorCodeRequestFailed
+	if resp != nil {
+		status, code = strconv.Itoa(resp.StatusCode), ""
+		if resp.StatusCode >= http.StatusBadRequest {
+			code = errorCode(resp)
+		}
+	}
+
+	apiRequests.WithLabelValues(service, operation, kind, status).Inc()
+	apiRequestDuration.WithLabelValues(service, operation, kind, status).Observe(d.Seconds())
+	if code != "" {
+		apiErrors.WithLabelValues(service, operation, kind, status, code).Inc()
+	}
+}
+
+// requestLabels returns the service, operation and resource kind of the
+// supplied request. The resource kind of Azure Resource Manager requests is
+// the resource type path, e.g. servers/virtualNetworkRules.
+func requestLabels(r *http.Request) (service, operation, kind string) {
+	if strings.Contains(r.URL.Host, ".blob.") {
+		return blobRequestLabels(r)
+	}
+	if strings.HasPrefix(r.URL.Host, "graph.") {
+		return graphRequestLabels(r)
+	}
+
+	operation = r.Method
+	segs := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	// Extension resources, such as locks, follow a second providers segment,
+	// so the last one determines the service.
+	service, rest := serviceResources, segs
+	if len(segs) >= 2 && strings.EqualFold(segs[0], "subscriptions") {
+		rest = segs[2:]
+	}
+	for i := len(segs) - 2; i >= 0; i-- {
+		if strings.EqualFold(segs[i], "providers") {
+			service, rest = segs[i+1], segs[i+2:]
+			break
+		}
+	}
+
+	// A trailing segment that does not name a resource is either the action
+	// of a POST request, e.g. listKeys, or the type of a listed collection.
+	if len(rest)%2 == 1 && r.Method == http.MethodPost {
+		operation = r.Method + " " + rest[len(rest)-1]
+		rest = rest[:len(rest)-1]
+	}
+	types := make([]string, 0, (len(rest)+1)/2)
+	for i := 0; i < len(rest); i += 2 {
+		types = append(types, rest[i])
+	}
+	kind = strings.Join(types, "/")
+	if kind == "" {
+		kind = "subscriptions"
+	}
+	return service, operation, kind
+}
+
+// graphRequestLabels returns the labels of Azure AD Graph requests, whose paths
+// are the tenant followed by alternating collections and object IDs, e.g.
+// /{tenant}/applications/{objectID}/passwordCredentials. The resource kind is
+// the collection path, e.g. applications/passwordCredentials, so that object
+// IDs do not leak into it.
+func graphRequestLabels(r *http.Request) (service, operation, kind string) {
+	segs := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	types := make([]string, 0, len(segs)/2)
+	for i := 1; i < len(segs); i += 2 {
+		types = append(types, segs[i])
+	}
+	kind = strings.Join(types, "/")
+	if kind == "" {
+		kind = "tenants"
+	}
+	return serviceGraph, r.Method, kind
+}
+
+func blobRequestLabels(r *http.Request) (service, operation, kind string) {
+	q := r.URL.Query()
+	operation = r.Method
+	if comp := q.Get("comp"); comp != "" {
+		operation = r.Method + " " + comp
+	}
+	kind = "blobs"
+	if q.Get("restype") == "container" {
+		kind = "containers"
+	}
+	return serviceBlob, operation, kind
+}
+
+// errorCode returns the Azure error code of the supplied response, which is
+// either returned in a header or in the body. The body is left unread.
+func errorCode(resp *http.Response) string {
+	if c := resp.Header.Get(HeaderErrorCode); c != "" {
+		return c
+	}
+	if resp.Body == nil {
+		return errorCodeUnknown
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(b))
+	if err != nil {
+		return errorCodeUnknown
+	}
+
+	body := &struct {
+		Code  string `json:"code"`
+		Error *struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}{}
+	if json.Unmarshal(b, body) != nil {
+		return errorCodeUnknown
+	}
+	switch {
+	case body.Error != nil && body.Error.Code != "":
+		return body.Error.Code
+	case body.Code != "":
+		return body.Code
+	}
+	return errorCodeUnknown
+}
+
+// inFlight tracks the asynchronous operations that are in progress by their
+// polling URL, so that each operation is counted once no matter how many
+// times its status is fetched.
+var inFlight = &asyncOperations{labels: map[string]prometheus.Labels{}}
+
+type asyncOperations struct {
+	mu     sync.Mutex
+	labels map[string]prometheus.Labels
+}
+
+// track records whether the supplied asynchronous operation is in progress.
+func (o *asyncOperations) track(as v1alpha3.AsyncOperation) {
+	if as.PollingURL == "" {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	l, ok := o.labels[as.PollingURL]
+	switch {
+	case as.Status == AsyncOperationStatusInProgress && !ok:
+		l = prometheus.Labels{"service": pollingService(as.PollingURL), "method": as.Method}
+		o.labels[as.PollingURL] = l
+		asyncOperationsInFlight.With(l).Inc()
+	case as.Status != AsyncOperationStatusInProgress && ok:
+		delete(o.labels, as.PollingURL)
+		asyncOperationsInFlight.With(l).Dec()
+	}
+}
+
+func pollingService(pollingURL string) string {
+	u, err := url.Parse(pollingURL)
+	if err != nil {
+		return serviceResources
+	}
+	s, _, _ := requestLabels(&http.Request{URL: u})
+	return s
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/crossplane/provider-azure/apis/v1alpha3"
+)
+
+func TestRequestLabels(t *testing.T) {
+	const sub = "https://management.azure.com/subscriptions/bf1b0e59-93da-42e0-82c6-5a1d94227911"
+
+	type want struct {
+		service   string
+		operation string
+		kind      string
+	}
+	cases := map[string]struct {
+		method string
+		url    string
+		want   want
+	}{
+		"ResourceGroup": {
+			method: http.MethodPut,
+			url:    sub + "/resourcegroups/coolgroup?api-version=2018-05-01",
+			want:   want{service: "Microsoft.Resources", operation: http.MethodPut, kind: "resourcegroups"},
+		},
+		"ChildResource": {
+			method: http.MethodGet,
+			url:    sub + "/resourceGroups/coolgroup/providers/Microsoft.DBforMySQL/servers/coolserver/virtualNetworkRules/coolrule",
+			want:   want{service: "Microsoft.DBforMySQL", operation: http.MethodGet, kind: "servers/virtualNetworkRules"},
+		},
+		"Collection": {
+			method: http.MethodGet,
+			url:    sub + "/resourceGroups/coolgroup/providers/Microsoft.DBforMySQL/servers/coolserver/virtualNetworkRules",
+			want:   want{service: "Microsoft.DBforMySQL", operation: http.MethodGet, kind: "servers/virtualNetworkRules"},
+		},
+		"Action": {
+			method: http.MethodPost,
+			url:    sub + "/resourceGroups/coolgroup/providers/Microsoft.Storage/storageAccounts/coolaccount/listKeys",
+			want:   want{service: "Microsoft.Storage", operation: "POST listKeys", kind: "storageAccounts"},
+		},
+		"ExtensionResource": {
+			method: http.MethodPut,
+			url:    sub + "/resourceGroups/coolgroup/providers/Microsoft.Sql/servers/coolserver/providers/Microsoft.Authorization/locks/coollock",
+			want:   want{service: "Microsoft.Authorization", operation: http.MethodPut, kind: "locks"},
+		},
+		"GraphApplication": {
+			method: http.MethodGet,
+			url:    "https://graph.windows.net/cool-tenant/applications/9d5e6a8b-4f7c-4f0e-8a41-0c3a5a1c7e2d?api-version=1.6",
+			want:   want{service: "Microsoft.Graph", operation: http.MethodGet, kind: "applications"},
+		},
+		"GraphCollection": {
+			method: http.MethodPost,
+			url:    "https://graph.chinacloudapi.cn/cool-tenant/servicePrincipals?api-version=1.6",
+			want:   want{service: "Microsoft.Graph", operation: http.MethodPost, kind: "servicePrincipals"},
+		},
+		"GraphChildCollection": {
+			method: http.MethodPatch,
+			url:    "https://graph.windows.net/cool-tenant/applications/9d5e6a8b-4f7c-4f0e-8a41-0c3a5a1c7e2d/passwordCredentials?api-version=1.6",
+			want:   want{service: "Microsoft.Graph", operation: http.MethodPatch, kind: "applications/passwordCredentials"},
+		},
+		"BlobContainer": {
+			method: http.MethodPut,
+			url:    "https://coolaccount.blob.core.windows.net/coolcontainer?comp=acl&restype=container",
+			want:   want{service: "Microsoft.Storage/blob", operation: "PUT acl", kind: "containers"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r, _ := http.NewRequest(tc.method, tc.url, nil)
+			service, operation, kind := requestLabels(r)
+			got := want{service: service, operation: operation, kind: kind}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("requestLabels(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResponseErrorCode(t *testing.T) {
+	cases := map[string]struct {
+		header http.Header
+		body   string
+		want   string
+	}{
+		"Header": {
+			header: http.Header{"X-Ms-Error-Code": []string{"ContainerAlreadyExists"}},
+			want:   "ContainerAlreadyExists",
+		},
+		"ResourceManagerBody": {
+			body: `{"error":{"code":"ResourceGroupNotFound","message":"boom"}}`,
+			want: "ResourceGroupNotFound",
+		},
+		"ServiceBody": {
+			body: `{"code":"ServerNotFound","message":"boom"}`,
+			want: "ServerNotFound",
+		},
+		"Unknown": {
+			body: "boom",
+			want: errorCodeUnknown,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			resp := &http.Response{Header: tc.header, Body: ioutil.NopCloser(strings.NewReader(tc.body))}
+			if diff := cmp.Diff(tc.want, errorCode(resp)); diff != "" {
+				t.Errorf("errorCode(...): -want, +got:\n%s", diff)
+			}
+			b, _ := ioutil.ReadAll(resp.Body)
+			if diff := cmp.Diff(tc.body, string(b)); diff != "" {
+				t.Errorf("errorCode(...): -want body, +got body:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	s := autorest.DecorateSender(autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusConflict,
+			Header:     http.Header{"X-Ms-Error-Code": []string{"AnotherOperationInProgress"}},
+			Request:    r,
+		}, nil
+	}), Metrics)
+
+	r, _ := http.NewRequest(http.MethodDelete, "https://management.azure.com/subscriptions/sub/resourceGroups/coolgroup/providers/Microsoft.Cache/Redis/coolcache", nil)
+	requests := apiRequests.WithLabelValues("Microsoft.Cache", http.MethodDelete, "Redis", "409")
+	errs := apiErrors.WithLabelValues("Microsoft.Cache", http.MethodDelete, "Redis", "409", "AnotherOperationInProgress")
+	before := []float64{testutil.ToFloat64(requests), testutil.ToFloat64(errs)}
+
+	if _, err := s.Do(r); err != nil {
+		t.Fatalf("s.Do(...): %s", err)
+	}
+
+	got := []float64{testutil.ToFloat64(requests) - before[0], testutil.ToFloat64(errs) - before[1]}
+	if diff := cmp.Diff([]float64{1, 1}, got); diff != "" {
+		t.Errorf("s.Do(...): -want requests and errors, +got requests and errors:\n%s", diff)
+	}
+}
+
+func TestAsyncOperationsTrack(t *testing.T) {
+	const pollingURL = "https://management.azure.com/subscriptions/sub/providers/Microsoft.Network/locations/westus/operations/op"
+	labels := prometheus.Labels{"service": "Microsoft.Network", "method": http.MethodPut}
+
+	cases := map[string]struct {
+		statuses []string
+		want     float64
+	}{
+		"InProgress": {
+			statuses: []string{AsyncOperationStatusInProgress, AsyncOperationStatusInProgress},
+			want:     1,
+		},
+		"Succeeded": {
+			statuses: []string{AsyncOperationStatusInProgress, AsyncOperationStatusSucceeded},
+			want:     0,
+		},
+		"NeverInProgress": {
+			statuses: []string{AsyncOperationStatusFailed},
+			want:     0,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			asyncOperationsInFlight.Reset()
+			o := &asyncOperations{labels: map[string]prometheus.Labels{}}
+			for _, s := range tc.statuses {
+				o.track(v1alpha3.AsyncOperation{Method: http.MethodPut, PollingURL: pollingURL, Status: s})
+			}
+			if diff := cmp.Diff(tc.want, testutil.ToFloat64(asyncOperationsInFlight.With(labels))); diff != "" {
+				t.Errorf("track(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
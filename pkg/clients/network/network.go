@@ -17,8 +17,6 @@ limitations under the License.
 package network
 
 import (
-	"reflect"
-
 	networkmgmt "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-06-01/network"
 
 	"github.com/crossplane/provider-azure/apis/network/v1alpha3"
@@ -42,20 +40,20 @@ func NewVirtualNetworkParameters(v *v1alpha3.VirtualNetwork) networkmgmt.Virtual
 
 // VirtualNetworkNeedsUpdate determines if a virtual network need to be updated
 func VirtualNetworkNeedsUpdate(kube *v1alpha3.VirtualNetwork, az networkmgmt.VirtualNetwork) bool {
-	up := NewVirtualNetworkParameters(kube)
+	return len(VirtualNetworkDiff(kube, az)) > 0
+}
 
-	switch {
-	case !reflect.DeepEqual(up.VirtualNetworkPropertiesFormat.AddressSpace, az.VirtualNetworkPropertiesFormat.AddressSpace):
-		return true
-	case !reflect.DeepEqual(up.VirtualNetworkPropertiesFormat.EnableDdosProtection, az.VirtualNetworkPropertiesFormat.EnableDdosProtection):
-		return true
-	case !reflect.DeepEqual(up.VirtualNetworkPropertiesFormat.EnableVMProtection, az.VirtualNetworkPropertiesFormat.EnableVMProtection):
-		return true
-	case !reflect.DeepEqual(up.Tags, az.Tags):
-		return true
-	}
+// VirtualNetworkDiff returns the fields of the supplied VirtualNetwork that
+// differ from the supplied Azure virtual network.
+func VirtualNetworkDiff(kube *v1alpha3.VirtualNetwork, az networkmgmt.VirtualNetwork) azure.Diff {
+	up := NewVirtualNetworkParameters(kube)
 
-	return false
+	var d azure.Diff
+	d = d.Compare("spec.properties.addressSpace", up.VirtualNetworkPropertiesFormat.AddressSpace, az.VirtualNetworkPropertiesFormat.AddressSpace)
+	d = d.Compare("spec.properties.enableDdosProtection", up.VirtualNetworkPropertiesFormat.EnableDdosProtection, az.VirtualNetworkPropertiesFormat.EnableDdosProtection)
+	d = d.Compare("spec.properties.enableVmProtection", up.VirtualNetworkPropertiesFormat.EnableVMProtection, az.VirtualNetworkPropertiesFormat.EnableVMProtection)
+	d = d.Compare("spec.tags", up.Tags, az.Tags)
+	return d
 }
 
 // UpdateVirtualNetworkStatusFromAzure updates the status related to the external
@@ -93,9 +91,16 @@ func NewServiceEndpoints(e []v1alpha3.ServiceEndpointPropertiesFormat) *[]networ
 
 // SubnetNeedsUpdate determines if a virtual network need to be updated
 func SubnetNeedsUpdate(kube *v1alpha3.Subnet, az networkmgmt.Subnet) bool {
+	return len(SubnetDiff(kube, az)) > 0
+}
+
+// SubnetDiff returns the fields of the supplied Subnet that differ from the
+// supplied Azure subnet.
+func SubnetDiff(kube *v1alpha3.Subnet, az networkmgmt.Subnet) azure.Diff {
 	up := NewSubnetParameters(kube)
 
-	return !reflect.DeepEqual(up.SubnetPropertiesFormat.AddressPrefix, az.SubnetPropertiesFormat.AddressPrefix)
+	var d azure.Diff
+	return d.Compare("spec.properties.addressPrefix", up.SubnetPropertiesFormat.AddressPrefix, az.SubnetPropertiesFormat.AddressPrefix)
 }
 
 // UpdateSubnetStatusFromAzure updates the status related to the external
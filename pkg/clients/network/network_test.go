@@ -267,6 +267,69 @@ func TestVirtualNetworkNeedsUpdate(t *testing.T) {
 	}
 }
 
+func TestVirtualNetworkDiff(t *testing.T) {
+	az := networkmgmt.VirtualNetwork{
+		VirtualNetworkPropertiesFormat: &networkmgmt.VirtualNetworkPropertiesFormat{
+			AddressSpace: &networkmgmt.AddressSpace{
+				AddressPrefixes: &addressPrefixes,
+			},
+			EnableDdosProtection: to.BoolPtr(enableDDOSProtection),
+			EnableVMProtection:   to.BoolPtr(enableVMProtection),
+		},
+		Tags: azure.ToStringPtrMap(tags),
+	}
+
+	cases := []struct {
+		name string
+		kube *v1alpha3.VirtualNetwork
+		want azure.Diff
+	}{
+		{
+			name: "Drifted",
+			kube: &v1alpha3.VirtualNetwork{
+				Spec: v1alpha3.VirtualNetworkSpec{
+					VirtualNetworkPropertiesFormat: v1alpha3.VirtualNetworkPropertiesFormat{
+						AddressSpace: v1alpha3.AddressSpace{
+							AddressPrefixes: addressPrefixes,
+						},
+						EnableDDOSProtection: !enableDDOSProtection,
+						EnableVMProtection:   enableVMProtection,
+					},
+					Tags: map[string]string{"three": "test"},
+				},
+			},
+			want: azure.Diff{
+				{Path: "spec.properties.enableDdosProtection", Desired: to.BoolPtr(!enableDDOSProtection), Observed: to.BoolPtr(enableDDOSProtection)},
+				{Path: "spec.tags", Desired: azure.ToStringPtrMap(map[string]string{"three": "test"}), Observed: azure.ToStringPtrMap(tags)},
+			},
+		},
+		{
+			name: "UpToDate",
+			kube: &v1alpha3.VirtualNetwork{
+				Spec: v1alpha3.VirtualNetworkSpec{
+					VirtualNetworkPropertiesFormat: v1alpha3.VirtualNetworkPropertiesFormat{
+						AddressSpace: v1alpha3.AddressSpace{
+							AddressPrefixes: addressPrefixes,
+						},
+						EnableDDOSProtection: enableDDOSProtection,
+						EnableVMProtection:   enableVMProtection,
+					},
+					Tags: tags,
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := VirtualNetworkDiff(tc.kube, az)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("VirtualNetworkDiff(...): -want, +got\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestUpdateVirtualNetworkStatusFromAzure(t *testing.T) {
 	mockCondition := runtimev1alpha1.Condition{Message: "mockMessage"}
 	resourceStatus := runtimev1alpha1.ResourceStatus{
@@ -0,0 +1,88 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+)
+
+// AnnotationKeyDeletionProtection is the annotation of a managed resource that
+// protects the Azure resource it manages from being deleted when it is set to
+// "true". Managed resources whose deletion policy is Delete are not deleted
+// until the annotation is removed.
+const AnnotationKeyDeletionProtection = "azure.crossplane.io/deletion-protection"
+
+// TypeDeletionProtected managed resources report that the deletion of their
+// Azure resource was refused because deletion protection is enabled.
+const TypeDeletionProtected runtimev1alpha1.ConditionType = "DeletionProtected"
+
+// ReasonDeletionRefused managed resources were deleted while deletion
+// protection was enabled.
+const ReasonDeletionRefused runtimev1alpha1.ConditionReason = "DeletionRefused"
+
+// ReasonDeletionAllowed managed resources were refused deletion, but their
+// deletion protection has since been disabled.
+const ReasonDeletionAllowed runtimev1alpha1.ConditionReason = "DeletionAllowed"
+
+// ErrDeletionProtected is returned when deleting the Azure resource of a
+// managed resource whose deletion protection is enabled.
+var ErrDeletionProtected = errors.New("the Azure resource is not deleted because deletion protection is enabled; remove the " + AnnotationKeyDeletionProtection + " annotation to delete it")
+
+// IsDeletionProtected returns true if the deletion protection of the supplied
+// managed resource is enabled.
+func IsDeletionProtected(o metav1.Object) bool {
+	return o.GetAnnotations()[AnnotationKeyDeletionProtection] == "true"
+}
+
+// DeletionProtected returns a condition indicating that the deletion of the
+// Azure resource of a managed resource was refused because deletion protection
+// is enabled.
+func DeletionProtected() runtimev1alpha1.Condition {
+	return runtimev1alpha1.Condition{
+		Type:               TypeDeletionProtected,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonDeletionRefused,
+		Message:            ErrDeletionProtected.Error(),
+	}
+}
+
+// DeletionAllowed returns a condition indicating that the deletion protection
+// of a managed resource whose deletion was refused has since been disabled.
+func DeletionAllowed() runtimev1alpha1.Condition {
+	return runtimev1alpha1.Condition{
+		Type:               TypeDeletionProtected,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonDeletionAllowed,
+	}
+}
+
+// ResetDeletionProtected sets the DeletionAllowed condition of the supplied
+// managed resource if its deletion was refused, but its deletion protection
+// is no longer enabled.
+func ResetDeletionProtected(mg resource.Managed) {
+	if IsDeletionProtected(mg) || mg.GetCondition(TypeDeletionProtected).Status != corev1.ConditionTrue {
+		return
+	}
+	mg.SetConditions(DeletionAllowed())
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+// Default limits of the requests the provider makes to Azure Resource Manager
+// in each subscription. Azure Resource Manager allows 12000 reads and 1200
+// writes per subscription per hour.
+const (
+	DefaultReadsPerSecond  = 3.0
+	DefaultWritesPerSecond = 0.3
+	DefaultRateLimitBurst  = 50
+)
+
+// Azure Resource Manager reports the number of requests that remain in the
+// current subscription quota in these response headers.
+const (
+	HeaderRemainingSubscriptionReads  = "x-ms-ratelimit-remaining-subscription-reads"
+	HeaderRemainingSubscriptionWrites = "x-ms-ratelimit-remaining-subscription-writes"
+)
+
+const (
+	// The rate limit is lowered so that the requests Azure Resource Manager
+	// reports as remaining are spread over this window.
+	remainingRequestsWindow = 1 * time.Minute
+
+	// The rate limit is never lowered below this limit, so that requests do
+	// not stall indefinitely when Azure Resource Manager reports that no
+	// requests remain.
+	minimumAdaptedLimit rate.Limit = 0.1
+
+	errRateLimit = "cannot wait for Azure Resource Manager rate limit"
+)
+
+// subscriptionPath matches the subscription of Azure Resource Manager request
+// paths, e.g. /subscriptions/bf1b0e59-93da-42e0-82c6-5a1d94227911/resourceGroups.
+var subscriptionPath = regexp.MustCompile(`(?i)^/subscriptions/([^/]+)`)
+
+// ResourceManagerRateLimiter is shared by all Azure Resource Manager clients
+// that the provider builds.
+var ResourceManagerRateLimiter = NewRateLimiter(DefaultReadsPerSecond, DefaultWritesPerSecond, DefaultRateLimitBurst)
+
+// Transport sends the HTTP requests of all Azure API clients that the provider
+// builds, including the requests that acquire Azure AD tokens. Tests may
+// replace it before building clients, e.g. with a recorder.Recorder that
+// records or replays their interactions with Azure.
+var Transport autorest.Sender = autorest.CreateSender()
+
+// ConfigureClient configures the supplied Azure Resource Manager client to
+// authorize its requests using the supplied authorizer, and to limit their
+// rate using the ResourceManagerRateLimiter. The requests are recorded by the
+// Azure API metrics and traced once the rate limit allows them to be sent by
+// the Transport. The Azure resources they write are not read from the
+// ResourceGraph cache until it is refreshed. Requests that would write Azure
+// resources are not sent at all in dry-run mode.
+func ConfigureClient(c *autorest.Client, a autorest.Authorizer) {
+	c.Authorizer = TracingAuthorizer(a)
+	c.Sender = autorest.DecorateSender(Transport, Metrics, Tracing, ResourceManagerRateLimiter.Limit, ResourceGraph.Invalidate, DryRunIntercept)
+}
+
+// A RateLimiter limits the rate of the requests made to Azure Resource Manager
+// using a token bucket per subscription, separately for reads and writes. The
+// limits are lowered while Azure Resource Manager reports that few requests
+// remain in the subscription quota.
+type RateLimiter struct {
+	reads  rate.Limit
+	writes rate.Limit
+	burst  int
+
+	mu            sync.Mutex
+	subscriptions map[string]*subscriptionLimiter
+}
+
+type subscriptionLimiter struct {
+	reads  *rate.Limiter
+	writes *rate.Limiter
+}
+
+// NewRateLimiter returns a RateLimiter that allows the supplied number of
+// reads and writes per second in each subscription, with the supplied burst.
+func NewRateLimiter(reads, writes float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		reads:         rate.Limit(reads),
+		writes:        rate.Limit(writes),
+		burst:         burst,
+		subscriptions: map[string]*subscriptionLimiter{},
+	}
+}
+
+// Limit is an autorest.SendDecorator that waits until the rate limit of the
+// subscription of each request allows it to be sent.
+func (l *RateLimiter) Limit(s autorest.Sender) autorest.Sender {
+	return autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+		sl := l.subscription(r)
+		lim, limit, header := sl.writes, l.writes, HeaderRemainingSubscriptionWrites
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			lim, limit, header = sl.reads, l.reads, HeaderRemainingSubscriptionReads
+		}
+
+		if err := lim.Wait(r.Context()); err != nil {
+			return nil, errors.Wrap(err, errRateLimit)
+		}
+		resp, err := s.Do(r)
+		if resp != nil {
+			adapt(lim, limit, resp.Header.Get(header))
+		}
+		return resp, err
+	})
+}
+
+// subscription returns the limiter of the subscription of the supplied
+// request. Requests that do not target a subscription share a limiter.
+func (l *RateLimiter) subscription(r *http.Request) *subscriptionLimiter {
+	id := ""
+	if m := subscriptionPath.FindStringSubmatch(r.URL.Path); m != nil {
+		id = strings.ToLower(m[1])
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sl, ok := l.subscriptions[id]
+	if !ok {
+		sl = &subscriptionLimiter{
+			reads:  rate.NewLimiter(l.reads, l.burst),
+			writes: rate.NewLimiter(l.writes, l.burst),
+		}
+		l.subscriptions[id] = sl
+	}
+	return sl
+}
+
+// adapt the supplied limiter to the number of remaining requests reported by
+// Azure Resource Manager, never exceeding the supplied configured limit.
+func adapt(lim *rate.Limiter, limit rate.Limit, remaining string) {
+	n, err := strconv.Atoi(remaining)
+	if err != nil {
+		return
+	}
+	adapted := rate.Limit(float64(n) / remainingRequestsWindow.Seconds())
+	if adapted < minimumAdaptedLimit {
+		adapted = minimumAdaptedLimit
+	}
+	if adapted > limit {
+		adapted = limit
+	}
+	lim.SetLimit(adapted)
+}
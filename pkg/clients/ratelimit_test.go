@@ -0,0 +1,106 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/time/rate"
+)
+
+func TestRateLimiterLimit(t *testing.T) {
+	const (
+		subscription = "bf1b0e59-93da-42e0-82c6-5a1d94227911"
+		other        = "302de427-dba9-4452-8583-a4268e46de6b"
+	)
+
+	type want struct {
+		sent  int
+		reads rate.Limit
+	}
+	cases := map[string]struct {
+		limiter  *RateLimiter
+		requests []string
+		header   http.Header
+		want     want
+	}{
+		"WithinBurst": {
+			limiter:  NewRateLimiter(0.001, 0.001, 2),
+			requests: []string{http.MethodGet, http.MethodGet, http.MethodPut, http.MethodDelete},
+			want:     want{sent: 4, reads: 0.001},
+		},
+		"ExceedsBurst": {
+			limiter:  NewRateLimiter(0.001, 0.001, 1),
+			requests: []string{http.MethodGet, http.MethodGet},
+			want:     want{sent: 1, reads: 0.001},
+		},
+		"FewRemainingReads": {
+			limiter:  NewRateLimiter(100, 100, 10),
+			requests: []string{http.MethodGet},
+			header:   http.Header{"X-Ms-Ratelimit-Remaining-Subscription-Reads": []string{"60"}},
+			want:     want{sent: 1, reads: 1},
+		},
+		"NoRemainingReads": {
+			limiter:  NewRateLimiter(100, 100, 10),
+			requests: []string{http.MethodGet},
+			header:   http.Header{"X-Ms-Ratelimit-Remaining-Subscription-Reads": []string{"0"}},
+			want:     want{sent: 1, reads: minimumAdaptedLimit},
+		},
+		"ManyRemainingReads": {
+			limiter:  NewRateLimiter(3, 100, 10),
+			requests: []string{http.MethodGet},
+			header:   http.Header{"X-Ms-Ratelimit-Remaining-Subscription-Reads": []string{"11999"}},
+			want:     want{sent: 1, reads: 3},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			sent := 0
+			s := autorest.DecorateSender(autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+				sent++
+				return &http.Response{StatusCode: http.StatusOK, Header: tc.header, Request: r}, nil
+			}), tc.limiter.Limit)
+
+			for _, m := range tc.requests {
+				ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+				r, _ := http.NewRequest(m, "https://management.azure.com/subscriptions/"+subscription+"/resourceGroups", nil)
+				_, _ = s.Do(r.WithContext(ctx))
+				cancel()
+			}
+
+			// Requests to another subscription are not limited by this one.
+			r, _ := http.NewRequest(http.MethodGet, "https://management.azure.com/subscriptions/"+other+"/resourceGroups", nil)
+			if _, err := s.Do(r); err != nil {
+				t.Errorf("s.Do(...): unexpected error for another subscription: %s", err)
+			}
+
+			if diff := cmp.Diff(tc.want.sent, sent-1); diff != "" {
+				t.Errorf("s.Do(...): -want sent, +got sent:\n%s", diff)
+			}
+			got := tc.limiter.subscriptions[subscription].reads.Limit()
+			if diff := cmp.Diff(tc.want.reads, got); diff != "" {
+				t.Errorf("s.Do(...): -want reads limit, +got reads limit:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package recorder records the interactions of Azure API clients with Azure
+// to cassettes, and replays them offline.
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/pkg/errors"
+)
+
+const (
+	errReadCassette      = "cannot read cassette"
+	errUnmarshalCassette = "cannot unmarshal cassette"
+	errMarshalCassette   = "cannot marshal cassette"
+	errWriteCassette     = "cannot write cassette"
+	errReadBody          = "cannot read body"
+	errFmtNoInteraction  = "no recorded interaction matches %s %s"
+	errFmtNotReplayed    = "%d recorded interactions were not replayed, the first is %s %s"
+)
+
+// A Mode determines whether a Recorder records or replays interactions.
+type Mode string
+
+// Recorder modes.
+const (
+	// ModeReplay replays the interactions of a cassette without sending any
+	// requests.
+	ModeReplay Mode = "replay"
+
+	// ModeRecord sends requests and records the interactions to a cassette.
+	ModeRecord Mode = "record"
+)
+
+// Redacted replaces the secrets of recorded interactions, e.g. passwords and
+// access keys. It is valid base64, since many secrets, e.g. storage account
+// keys, are base64 encoded and must be decodable when they are replayed.
+const Redacted = "UkVEQUNURUQ="
+
+// A Cassette is a sequence of recorded interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// An Interaction is a request and the response it received.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// A Request that was recorded.
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// A Response that was recorded.
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// An Option configures a Recorder.
+type Option func(*Recorder)
+
+// WithScrubbed replaces all occurrences of the supplied value in recorded
+// interactions with the supplied replacement, e.g. the ID of the subscription
+// in which interactions are recorded with a placeholder subscription ID.
+func WithScrubbed(value, replacement string) Option {
+	return func(r *Recorder) {
+		if value != "" && value != replacement {
+			r.replacements = append(r.replacements, value, replacement)
+		}
+	}
+}
+
+// A Recorder is an autorest.Sender that records or replays interactions with
+// Azure. Secrets, authorization headers and scrubbed values are never
+// recorded.
+type Recorder struct {
+	path   string
+	mode   Mode
+	sender autorest.Sender
+
+	replacements []string
+
+	mu       sync.Mutex
+	cassette Cassette
+	replayed []bool
+}
+
+// New returns a Recorder that records interactions to, or replays them from,
+// the cassette at the supplied path. Recorded requests are sent using the
+// supplied sender.
+func New(path string, m Mode, s autorest.Sender, o ...Option) (*Recorder, error) {
+	r := &Recorder{path: path, mode: m, sender: s}
+	for _, fn := range o {
+		fn(r)
+	}
+	if m == ModeRecord {
+		return r, nil
+	}
+
+	b, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Wrap(err, errReadCassette)
+	}
+	if err := json.Unmarshal(b, &r.cassette); err != nil {
+		return nil, errors.Wrap(err, errUnmarshalCassette)
+	}
+	r.replayed = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Do records or replays the supplied request.
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	if r.mode == ModeRecord {
+		return r.record(req)
+	}
+	return r.replay(req)
+}
+
+// Stop the Recorder, writing the cassette if it is recording. A replaying
+// Recorder returns an error if any of the recorded interactions were not
+// replayed.
+func (r *Recorder) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.mode != ModeRecord {
+		return r.unreplayed()
+	}
+	b, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, errMarshalCassette)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0750); err != nil {
+		return errors.Wrap(err, errWriteCassette)
+	}
+	return errors.Wrap(ioutil.WriteFile(r.path, append(b, '\n'), 0600), errWriteCassette)
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	body, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.sender.Do(req)
+	if err != nil {
+		return resp, err
+	}
+	rbody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	i := Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    r.scrub(req.URL.String()),
+			Body:   r.scrub(scrubBody(req.Header.Get("Content-Type"), body)),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     r.scrubHeader(resp.Header),
+			Body:       r.scrub(scrubBody(resp.Header.Get("Content-Type"), rbody)),
+		},
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// replay the first interaction that was not yet replayed and whose request
+// matches the method, path and query of the supplied request. The host is not
+// matched, so that interactions recorded against one Azure environment may be
+// replayed against another.
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for n, i := range r.cassette.Interactions {
+		if r.replayed[n] || !matches(i.Request, req) {
+			continue
+		}
+		r.replayed[n] = true
+		h := http.Header{}
+		for k, v := range i.Response.Header {
+			h[k] = append([]string(nil), v...)
+		}
+		return &http.Response{
+			Status:        http.StatusText(i.Response.StatusCode),
+			StatusCode:    i.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        h,
+			Body:          ioutil.NopCloser(strings.NewReader(i.Response.Body)),
+			ContentLength: int64(len(i.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, errors.Errorf(errFmtNoInteraction, req.Method, req.URL.String())
+}
+
+func (r *Recorder) unreplayed() error {
+	count, first := 0, -1
+	for n, replayed := range r.replayed {
+		if replayed {
+			continue
+		}
+		if first < 0 {
+			first = n
+		}
+		count++
+	}
+	if count == 0 {
+		return nil
+	}
+	i := r.cassette.Interactions[first]
+	return errors.Errorf(errFmtNotReplayed, count, i.Request.Method, i.Request.URL)
+}
+
+func matches(recorded Request, req *http.Request) bool {
+	if recorded.Method != req.Method {
+		return false
+	}
+	u, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Path, req.URL.Path) && u.Query().Encode() == req.URL.Query().Encode()
+}
+
+// readBody reads the supplied body, replacing it so that it may be read again.
+func readBody(body *io.ReadCloser) (string, error) {
+	if *body == nil || *body == http.NoBody {
+		return "", nil
+	}
+	b, err := ioutil.ReadAll(*body)
+	_ = (*body).Close()
+	if err != nil {
+		return "", errors.Wrap(err, errReadBody)
+	}
+	*body = ioutil.NopCloser(bytes.NewReader(b))
+	return string(b), nil
+}
+
+func (r *Recorder) scrub(s string) string {
+	if len(r.replacements) == 0 {
+		return s
+	}
+	return strings.NewReplacer(r.replacements...).Replace(s)
+}
+
+// scrubHeader returns the supplied response headers, less those that may
+// contain secrets, with all scrubbed values replaced.
+func (r *Recorder) scrubHeader(h http.Header) http.Header {
+	out := http.Header{}
+	for k, v := range h {
+		if secretHeaders[http.CanonicalHeaderKey(k)] {
+			continue
+		}
+		for _, s := range v {
+			out.Add(k, r.scrub(s))
+		}
+	}
+	return out
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recorder
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScrubBody(t *testing.T) {
+	cases := map[string]struct {
+		contentType string
+		body        string
+		want        string
+	}{
+		"JSON": {
+			contentType: "application/json",
+			body:        `{"properties":{"administratorLogin":"cool","administratorLoginPassword":"secret"}}`,
+			want:        `{"properties":{"administratorLogin":"cool","administratorLoginPassword":"` + Redacted + `"}}`,
+		},
+		"JSONNamedValues": {
+			contentType: "application/json",
+			body:        `{"keys":[{"keyName":"key1","value":"secret"}]}`,
+			want:        `{"keys":[{"keyName":"key1","value":"` + Redacted + `"}]}`,
+		},
+		"Token": {
+			contentType: "application/json; charset=utf-8",
+			body:        `{"access_token":"secret","expires_on":"1602580000"}`,
+			want:        `{"access_token":"` + Redacted + `","expires_on":"` + neverExpires + `"}`,
+		},
+		"Form": {
+			contentType: "application/x-www-form-urlencoded",
+			body:        "client_id=cool&client_secret=secret",
+			want:        "client_id=cool&client_secret=" + url.QueryEscape(Redacted),
+		},
+		"Other": {
+			contentType: "application/xml",
+			body:        "<Password>secret</Password>",
+			want:        "<Password>secret</Password>",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := scrubBody(tc.contentType, tc.body)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("scrubBody(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	const subscription = "bf1b0e59-93da-42e0-82c6-5a1d94227911"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "secret")
+		_, _ = w.Write([]byte(`{"id":"` + r.URL.Path + `","properties":{"primaryKey":"secret"}}`))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "recorder")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir(...): %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "testdata", "cassette.json")
+
+	get := func(s autorest.Sender, u string) string {
+		t.Helper()
+		r, _ := http.NewRequest(http.MethodGet, u, nil)
+		resp, err := s.Do(r)
+		if err != nil {
+			t.Fatalf("s.Do(...): %s", err)
+		}
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		return string(b)
+	}
+
+	rec, err := New(path, ModeRecord, autorest.CreateSender(), WithScrubbed(subscription, SubscriptionID))
+	if err != nil {
+		t.Fatalf("New(...): %s", err)
+	}
+	get(rec, srv.URL+"/subscriptions/"+subscription+"?api-version=2018-05-01")
+	if err := rec.Stop(); err != nil {
+		t.Fatalf("rec.Stop(): %s", err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		t.Fatalf("ioutil.ReadFile(...): %s", err)
+	}
+	for _, s := range []string{subscription, "secret"} {
+		if strings.Contains(string(b), s) {
+			t.Errorf("rec.Stop(): cassette contains %q", s)
+		}
+	}
+
+	// Interactions are replayed regardless of the host they were recorded
+	// against, and only once.
+	rep, err := New(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("New(...): %s", err)
+	}
+	want := `{"id":"/subscriptions/` + SubscriptionID + `","properties":{"primaryKey":"` + Redacted + `"}}`
+	got := get(rep, "https://management.azure.com/subscriptions/"+SubscriptionID+"?api-version=2018-05-01")
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("rep.Do(...): -want, +got:\n%s", diff)
+	}
+	r, _ := http.NewRequest(http.MethodGet, "https://management.azure.com/subscriptions/"+SubscriptionID+"?api-version=2018-05-01", nil)
+	if _, err := rep.Do(r); err == nil {
+		t.Errorf("rep.Do(...): want error replaying an interaction twice")
+	}
+	if err := rep.Stop(); err != nil {
+		t.Errorf("rep.Stop(): %s", err)
+	}
+}
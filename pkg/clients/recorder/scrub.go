@@ -0,0 +1,130 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recorder
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// secretHeaders are response headers that are never recorded.
+var secretHeaders = map[string]bool{
+	"Set-Cookie": true,
+}
+
+// secretFields are the form fields and JSON object keys whose values are
+// secrets, by lower case name, in addition to those with a secretSuffix.
+var secretFields = map[string]bool{
+	"client_assertion": true,
+}
+
+// secretSuffixes are the suffixes of lower case form fields and JSON object
+// keys whose values are secrets, e.g. client_secret, administratorLoginPassword
+// or primaryKey.
+var secretSuffixes = []string{"password", "secret", "token", "key", "connectionstring"}
+
+// neverExpires replaces the expiry of recorded Azure AD tokens, so that they
+// are not refreshed while interactions are replayed.
+const neverExpires = "4102444800"
+
+// secretValueSiblings are the lower case JSON object keys that indicate that
+// the value key of the same object is a secret, e.g. the value of a storage
+// account key, an Azure AD application password or an AKS cluster
+// kubeconfig.
+var secretValueSiblings = []string{"keyid", "keyname", "name"}
+
+func isSecret(field string) bool {
+	f := strings.ToLower(field)
+	if secretFields[f] {
+		return true
+	}
+	for _, s := range secretSuffixes {
+		if strings.HasSuffix(f, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// scrubBody replaces the secrets of the supplied JSON or form encoded body.
+// Bodies of other content types are returned unchanged.
+func scrubBody(contentType, body string) string {
+	switch {
+	case body == "":
+		return body
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		v, err := url.ParseQuery(body)
+		if err != nil {
+			return body
+		}
+		for k := range v {
+			if isSecret(k) {
+				v.Set(k, Redacted)
+			}
+		}
+		return v.Encode()
+	}
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(body), &v); err != nil {
+		return body
+	}
+	b, err := json.Marshal(scrubJSON(v))
+	if err != nil {
+		return body
+	}
+	return string(b)
+}
+
+func scrubJSON(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			if _, ok := e.(string); ok && (isSecret(k) || isSecretValue(k, t)) {
+				t[k] = Redacted
+				continue
+			}
+			if k == "expires_on" {
+				t[k] = neverExpires
+				continue
+			}
+			t[k] = scrubJSON(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = scrubJSON(e)
+		}
+	}
+	return v
+}
+
+// isSecretValue returns true if the supplied key of the supplied object is the
+// value of a named secret, e.g. {"keyName": "key1", "value": "secret"}.
+func isSecretValue(k string, o map[string]interface{}) bool {
+	if strings.ToLower(k) != "value" {
+		return false
+	}
+	for sk := range o {
+		for _, s := range secretValueSiblings {
+			if strings.ToLower(sk) == s {
+				return true
+			}
+		}
+	}
+	return false
+}
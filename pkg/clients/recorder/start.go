@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+
+	"github.com/crossplane/provider-azure/apis/v1alpha3"
+	azure "github.com/crossplane/provider-azure/pkg/clients"
+)
+
+// Environment variables that configure recorded tests.
+const (
+	// EnvMode selects the Mode of recorded tests. Tests replay their
+	// cassettes unless it is set to record.
+	EnvMode = "AZURE_RECORDER_MODE"
+
+	// EnvCredentials is the path to the JSON encoded Azure credentials, e.g.
+	// as created by az ad sp create-for-rbac --sdk-auth, that are used to
+	// record interactions.
+	EnvCredentials = "AZURE_AUTH_LOCATION"
+)
+
+// PollInterval is the interval at which WaitForOperation polls asynchronous
+// operations while recording. Operations are polled without delay while
+// replaying.
+const PollInterval = 10 * time.Second
+
+// maxPolls is the number of times WaitForOperation polls an asynchronous
+// operation before giving up.
+const maxPolls = 180
+
+// Placeholders that replace the IDs of the subscription, tenant and service
+// principal in which interactions are recorded.
+const (
+	SubscriptionID = "00000000-0000-0000-0000-000000000000"
+	TenantID       = "00000000-0000-0000-0000-000000000001"
+	ClientID       = "00000000-0000-0000-0000-000000000002"
+)
+
+// Start records or replays the cassette with the supplied name in the
+// testdata directory of the calling test, depending on the EnvMode. It returns
+// the Recorder and the credentials content that clients should be built from.
+// Clients built after Start send their requests using the Recorder, until the
+// returned function is called to stop it. The IDs of the credentials are
+// scrubbed from recorded interactions, in addition to the supplied options.
+func Start(t *testing.T, cassette string, o ...Option) (*Recorder, map[string]string, func()) {
+	t.Helper()
+
+	m := map[string]string{
+		azure.CredentialsKeyClientID:       ClientID,
+		azure.CredentialsKeyClientSecret:   Redacted,
+		azure.CredentialsKeyTenantID:       TenantID,
+		azure.CredentialsKeySubscriptionID: SubscriptionID,
+	}
+	mode := ModeReplay
+	if Mode(os.Getenv(EnvMode)) == ModeRecord {
+		mode = ModeRecord
+		b, err := ioutil.ReadFile(filepath.Clean(os.Getenv(EnvCredentials)))
+		if err != nil {
+			t.Fatalf("cannot read %s: %s", EnvCredentials, err)
+		}
+		c := azure.Credentials{}
+		if err := json.Unmarshal(b, &c); err != nil {
+			t.Fatalf("cannot unmarshal %s: %s", EnvCredentials, err)
+		}
+		m = c.Map()
+	}
+	azure.DefaultEnvironment(m)
+
+	o = append([]Option{
+		WithScrubbed(m[azure.CredentialsKeySubscriptionID], SubscriptionID),
+		WithScrubbed(m[azure.CredentialsKeyTenantID], TenantID),
+		WithScrubbed(m[azure.CredentialsKeyClientID], ClientID),
+	}, o...)
+	r, err := New(filepath.Join("testdata", cassette+".json"), mode, azure.Transport, o...)
+	if err != nil {
+		t.Fatalf("cannot start recorder: %s", err)
+	}
+
+	transport := azure.Transport
+	azure.Transport = r
+	return r, m, func() {
+		azure.Transport = transport
+		if err := r.Stop(); err != nil {
+			t.Errorf("cannot stop recorder: %s", err)
+		}
+	}
+}
+
+// Mode returns the mode of the Recorder.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// WaitForOperation polls the supplied asynchronous operation using the
+// supplied sender until it completes.
+func (r *Recorder) WaitForOperation(ctx context.Context, t *testing.T, s autorest.Sender, op *v1alpha3.AsyncOperation) {
+	t.Helper()
+	for i := 0; i < maxPolls; i++ {
+		if err := azure.FetchAsyncOperation(ctx, s, op); err != nil {
+			t.Fatalf("cannot fetch asynchronous operation: %s", err)
+		}
+		if op.Status != azure.AsyncOperationStatusInProgress {
+			return
+		}
+		if r.mode == ModeReplay {
+			if op.ErrorMessage != "" {
+				t.Fatalf("cannot replay asynchronous operation: %s", op.ErrorMessage)
+			}
+			continue
+		}
+		time.Sleep(PollInterval)
+	}
+	t.Fatalf("asynchronous %s operation did not complete after %d polls", op.Method, maxPolls)
+}
@@ -133,12 +133,53 @@ func NewSKU(s v1beta1.SKU) *redis.Sku {
 // supplied Azure resource. It considers only fields that can be modified in
 // place without deleting and recreating the instance.
 func NeedsUpdate(spec v1beta1.RedisParameters, az redis.ResourceType) bool {
+	return len(Diff(spec, az)) > 0
+}
+
+// Diff returns the fields of the supplied spec object that differ from the
+// supplied Azure resource. It considers only fields that can be modified in
+// place without deleting and recreating the instance. Only the entries of map
+// fields that differ are reported.
+func Diff(spec v1beta1.RedisParameters, az redis.ResourceType) azure.Diff {
 	if az.Properties == nil {
-		return true
+		return azure.Diff{{Path: "spec.forProvider", Desired: spec}}
 	}
 	patch := NewUpdateParameters(spec, az)
-	empty := redis.UpdateParameters{UpdateProperties: &redis.UpdateProperties{}}
-	return !reflect.DeepEqual(empty, patch)
+	var d azure.Diff
+	if patch.Tags != nil {
+		d = append(d, azure.FieldDiff{Path: "spec.forProvider.tags", Desired: patch.Tags, Observed: entries(az.Tags, patch.Tags)})
+	}
+	if patch.Sku != nil {
+		d = append(d, azure.FieldDiff{Path: "spec.forProvider.sku", Desired: patch.Sku, Observed: az.Properties.Sku})
+	}
+	if patch.RedisConfiguration != nil {
+		d = append(d, azure.FieldDiff{Path: "spec.forProvider.redisConfiguration", Desired: patch.RedisConfiguration, Observed: entries(az.Properties.RedisConfiguration, patch.RedisConfiguration)})
+	}
+	if patch.EnableNonSslPort != nil {
+		d = append(d, azure.FieldDiff{Path: "spec.forProvider.enableNonSslPort", Desired: patch.EnableNonSslPort, Observed: az.Properties.EnableNonSslPort})
+	}
+	if patch.ShardCount != nil {
+		d = append(d, azure.FieldDiff{Path: "spec.forProvider.shardCount", Desired: patch.ShardCount, Observed: az.Properties.ShardCount})
+	}
+	if patch.TenantSettings != nil {
+		d = append(d, azure.FieldDiff{Path: "spec.forProvider.tenantSettings", Desired: patch.TenantSettings, Observed: entries(az.Properties.TenantSettings, patch.TenantSettings)})
+	}
+	if patch.MinimumTLSVersion != "" {
+		d = append(d, azure.FieldDiff{Path: "spec.forProvider.minimumTlsVersion", Desired: patch.MinimumTLSVersion, Observed: az.Properties.MinimumTLSVersion})
+	}
+	return d
+}
+
+// entries returns the entries of the supplied map whose keys are in the
+// supplied set of keys.
+func entries(m, keys map[string]*string) map[string]*string {
+	e := map[string]*string{}
+	for k := range keys {
+		if v, ok := m[k]; ok {
+			e[k] = v
+		}
+	}
+	return e
 }
 
 // GenerateObservation produces a RedisObservation object from the redis.ResourceType
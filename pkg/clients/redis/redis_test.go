@@ -327,6 +327,62 @@ func TestNeedsUpdate(t *testing.T) {
 	}
 }
 
+func TestDiff(t *testing.T) {
+	cases := []struct {
+		name string
+		spec v1beta1.RedisParameters
+		az   redismgmt.ResourceType
+		want azure.Diff
+	}{
+		{
+			name: "DifferentFields",
+			spec: v1beta1.RedisParameters{
+				SKU: v1beta1.SKU{
+					Name:     skuName,
+					Family:   skuFamily,
+					Capacity: skuCapacity,
+				},
+				ShardCount: &shardCount,
+				Tags:       map[string]string{"key2": "val2"},
+			},
+			az: redismgmt.ResourceType{
+				Tags: azure.ToStringPtrMap(tags),
+				Properties: &redismgmt.Properties{
+					Sku: &redismgmt.Sku{
+						Name:     redismgmt.SkuName(skuName),
+						Family:   redismgmt.SkuFamily(skuFamily),
+						Capacity: azure.ToInt32Ptr(skuCapacity),
+					},
+					ShardCount: azure.ToInt32Ptr(shardCount + 1),
+				},
+			},
+			want: azure.Diff{
+				{Path: "spec.forProvider.tags", Desired: map[string]*string{"key2": azure.ToStringPtr("val2")}, Observed: map[string]*string{}},
+				{Path: "spec.forProvider.shardCount", Desired: azure.ToInt32Ptr(shardCount), Observed: azure.ToInt32Ptr(shardCount + 1)},
+			},
+		},
+		{
+			name: "NoProperties",
+			spec: v1beta1.RedisParameters{
+				ShardCount: &shardCount,
+			},
+			az: redismgmt.ResourceType{},
+			want: azure.Diff{
+				{Path: "spec.forProvider", Desired: v1beta1.RedisParameters{ShardCount: &shardCount}},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Diff(tc.spec, tc.az)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Diff(...): -want, +got\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestGenerateObservation(t *testing.T) {
 	cases := map[string]struct {
 		arg  redismgmt.ResourceType
@@ -0,0 +1,361 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/resourcegraph/mgmt/2019-04-01/resourcegraph"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+)
+
+const (
+	errQueryResourceGraph  = "cannot query Azure Resource Graph"
+	errDecodeResourceGraph = "cannot decode Azure Resource Graph query results"
+)
+
+// resourceGraphSettleTime is how long after the provider writes an Azure
+// resource a Resource Graph query must start for its results to include the
+// write. Resource Graph is usually updated within seconds of a write.
+const resourceGraphSettleTime = time.Minute
+
+// resourceGraphNested are the types of Azure resources that Resource Graph
+// does not return, but that are returned as a property of their parent, e.g.
+// the subnets of a virtual network.
+var resourceGraphNested = map[string]string{
+	"microsoft.network/virtualnetworks/subnets": "subnets",
+}
+
+// ResourceGraph caches the results of Azure Resource Graph queries, so that
+// managed resources may be observed without reading each Azure resource. It is
+// nil, and managed resources always read Azure resources, unless the provider
+// enables it.
+var ResourceGraph *ResourceGraphCache
+
+// A resourceGraphQuery queries the Azure resources of one type in one
+// subscription using one set of credentials. Queries using different
+// credentials are cached separately, because Resource Graph only returns the
+// Azure resources that the credentials may read.
+type resourceGraphQuery struct {
+	credentials  string
+	subscription string
+	typ          string
+}
+
+// credentialsIdentity returns an opaque identity of the supplied credentials,
+// which is the same for credentials with the same content.
+func credentialsIdentity(creds map[string]string) string {
+	keys := make([]string, 0, len(creds))
+	for k := range creds {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(h, "%q=%q\n", k, creds[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// A resourceGraphSnapshot is the most recent result of a resourceGraphQuery,
+// and the client of its credentials that runs the query.
+type resourceGraphSnapshot struct {
+	client  resourcegraph.BaseClient
+	used    time.Time
+	started time.Time
+
+	resources map[string]json.RawMessage
+}
+
+// A ResourceGraphCache periodically queries Azure Resource Graph for the Azure
+// resources of each subscription and type that managed resources read from it,
+// and caches the results. Azure resources that the provider wrote are not read
+// from the cache until it has been refreshed since the write.
+type ResourceGraphCache struct {
+	interval time.Duration
+	idle     time.Duration
+	log      logging.Logger
+
+	mu        sync.Mutex
+	snapshots map[resourceGraphQuery]*resourceGraphSnapshot
+	writes    map[string]time.Time
+}
+
+// NewResourceGraphCache returns a ResourceGraphCache that runs its queries at
+// the supplied interval. Queries are no longer run once no managed resource
+// read their results for the supplied idle time.
+func NewResourceGraphCache(interval, idle time.Duration, l logging.Logger) *ResourceGraphCache {
+	return &ResourceGraphCache{
+		interval:  interval,
+		idle:      idle,
+		log:       l,
+		snapshots: map[resourceGraphQuery]*resourceGraphSnapshot{},
+		writes:    map[string]time.Time{},
+	}
+}
+
+// Start refreshing the cache at its interval until the supplied channel is
+// closed. It satisfies the controller manager's Runnable interface.
+func (c *ResourceGraphCache) Start(stop <-chan struct{}) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	t := time.NewTicker(c.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-t.C:
+			c.refresh(ctx)
+		}
+	}
+}
+
+// refresh runs each query that was recently used, and forgets those that were
+// not.
+func (c *ResourceGraphCache) refresh(ctx context.Context) {
+	c.mu.Lock()
+	queries := make(map[resourceGraphQuery]resourcegraph.BaseClient, len(c.snapshots))
+	for q, s := range c.snapshots {
+		if time.Since(s.used) > c.idle {
+			delete(c.snapshots, q)
+			continue
+		}
+		queries[q] = s.client
+	}
+	for id, t := range c.writes {
+		if time.Since(t) > c.maxAge()+resourceGraphSettleTime {
+			delete(c.writes, id)
+		}
+	}
+	c.mu.Unlock()
+
+	for q, cl := range queries {
+		started := time.Now()
+		resources, err := query(ctx, cl, q)
+		if err != nil {
+			c.log.Debug("Cannot refresh Azure Resource Graph cache", "subscription", q.subscription, "type", q.typ, "error", err)
+			continue
+		}
+		c.mu.Lock()
+		if s, ok := c.snapshots[q]; ok {
+			s.started, s.resources = started, resources
+		}
+		c.mu.Unlock()
+	}
+}
+
+// maxAge is the age after which results are no longer read from the cache.
+// Results survive one failed refresh.
+func (c *ResourceGraphCache) maxAge() time.Duration {
+	return 2 * c.interval
+}
+
+// query returns the JSON encoded Azure resources of the supplied query, keyed
+// by their subscription and ID as returned by ResourceID.
+func query(ctx context.Context, cl resourcegraph.BaseClient, q resourceGraphQuery) (map[string]json.RawMessage, error) {
+	typ, nested := q.typ, ""
+	for t, property := range resourceGraphNested {
+		if t[:strings.LastIndex(t, "/")] == typ {
+			nested = property
+		}
+	}
+
+	req := resourcegraph.QueryRequest{
+		Subscriptions: &[]string{q.subscription},
+		Query:         ToStringPtr(fmt.Sprintf("Resources | where type =~ '%s'", typ)),
+		Options:       &resourcegraph.QueryRequestOptions{ResultFormat: resourcegraph.ResultFormatObjectArray},
+	}
+	resources := map[string]json.RawMessage{}
+	for {
+		resp, err := cl.Resources(ctx, req)
+		if err != nil {
+			return nil, errors.Wrap(err, errQueryResourceGraph)
+		}
+		b, err := json.Marshal(resp.Data)
+		if err != nil {
+			return nil, errors.Wrap(err, errDecodeResourceGraph)
+		}
+		rows := []json.RawMessage{}
+		if err := json.Unmarshal(b, &rows); err != nil {
+			return nil, errors.Wrap(err, errDecodeResourceGraph)
+		}
+		for _, r := range rows {
+			if err := index(resources, r, nested); err != nil {
+				return nil, err
+			}
+		}
+		if ToString(resp.SkipToken) == "" {
+			return resources, nil
+		}
+		req.Options.SkipToken = resp.SkipToken
+	}
+}
+
+// index the supplied JSON encoded Azure resource, and the resources nested in
+// the supplied property, by their subscription and ID.
+func index(resources map[string]json.RawMessage, r json.RawMessage, nested string) error {
+	row := struct {
+		ID         string                     `json:"id"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}{}
+	if err := json.Unmarshal(r, &row); err != nil {
+		return errors.Wrap(err, errDecodeResourceGraph)
+	}
+	if subscription, _, rid, ok := ParseResourceID(row.ID); ok {
+		resources[subscription+"/"+rid] = r
+	}
+	if nested == "" || row.Properties[nested] == nil {
+		return nil
+	}
+	children := []json.RawMessage{}
+	if err := json.Unmarshal(row.Properties[nested], &children); err != nil {
+		return errors.Wrap(err, errDecodeResourceGraph)
+	}
+	for _, child := range children {
+		if err := index(resources, child, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// get unmarshals the Azure resource with the supplied subscription and ID, as
+// returned by ResourceID, into the supplied object if it is fresh in the
+// results of queries using the credentials of the supplied identity. The
+// resource's type is queried using the supplied client, which must use those
+// credentials, from the next refresh, if it is not already.
+func (c *ResourceGraphCache) get(cl resourcegraph.BaseClient, credentials, subscription, id string, into interface{}) bool {
+	if c == nil || id == "" {
+		return false
+	}
+	subscription, typ, id, ok := ParseResourceID("/subscriptions/" + subscription + "/" + id)
+	if !ok {
+		return false
+	}
+	if _, ok := resourceGraphNested[typ]; ok {
+		typ = typ[:strings.LastIndex(typ, "/")]
+	}
+	q := resourceGraphQuery{credentials: credentials, subscription: subscription, typ: typ}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.snapshots[q]
+	if !ok {
+		s = &resourceGraphSnapshot{}
+		c.snapshots[q] = s
+	}
+	s.client, s.used = cl, time.Now()
+	if s.started.IsZero() || time.Since(s.started) > c.maxAge() {
+		return false
+	}
+
+	// The resource is not read from the cache if the provider wrote it, or
+	// any of its ancestors, since shortly before the snapshot was started.
+	key := subscription + "/" + id
+	for k := key; ; k = k[:strings.LastIndex(k, "/")] {
+		if w, ok := c.writes[k]; ok && s.started.Before(w.Add(resourceGraphSettleTime)) {
+			return false
+		}
+		if strings.Count(k, "/") <= 2 {
+			break
+		}
+	}
+
+	r, ok := s.resources[key]
+	if !ok {
+		// Resources that are not in the cache may nonetheless exist, e.g.
+		// because they were created after the snapshot was started.
+		return false
+	}
+	return json.Unmarshal(r, into) == nil
+}
+
+// Invalidate is an autorest.SendDecorator that records the Azure resources
+// written by each request, so that they are not read from the cache until it
+// has been refreshed since.
+func (c *ResourceGraphCache) Invalidate(s autorest.Sender) autorest.Sender {
+	if c == nil {
+		return s
+	}
+	return autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+		switch r.Method {
+		case http.MethodPut, http.MethodPatch, http.MethodDelete:
+			if subscription, _, id, ok := ParseResourceID(r.URL.Path); ok {
+				c.mu.Lock()
+				c.writes[subscription+"/"+id] = time.Now()
+				c.mu.Unlock()
+			}
+		}
+		return s.Do(r)
+	})
+}
+
+// A ResourceGraphReader reads the Azure resources of one subscription from the
+// ResourceGraph cache. A nil ResourceGraphReader never reads any.
+type ResourceGraphReader struct {
+	cache        *ResourceGraphCache
+	client       resourcegraph.BaseClient
+	credentials  string
+	subscription string
+}
+
+// NewResourceGraphReader returns a ResourceGraphReader for the subscription of
+// the supplied credentials, which queries Azure Resource Graph using the
+// supplied authorizer of those credentials. It only reads the results of
+// queries using the same credentials. It returns nil unless the ResourceGraph
+// cache is enabled.
+func NewResourceGraphReader(creds map[string]string, a autorest.Authorizer) *ResourceGraphReader {
+	if ResourceGraph == nil {
+		return nil
+	}
+	cl := resourcegraph.NewWithBaseURI(creds[CredentialsKeyResourceManagerEndpointURL])
+	ConfigureClient(&cl.Client, a)
+	return &ResourceGraphReader{
+		cache:        ResourceGraph,
+		client:       cl,
+		credentials:  credentialsIdentity(creds),
+		subscription: creds[CredentialsKeySubscriptionID],
+	}
+}
+
+// Get unmarshals the Azure resource with the supplied ID, as returned by
+// ResourceID, into the supplied object. It returns false if the resource is
+// not fresh in the cache, in which case it must be read from Azure.
+func (r *ResourceGraphReader) Get(id string, into interface{}) bool {
+	if r == nil {
+		return false
+	}
+	return r.cache.get(r.client, r.credentials, r.subscription, id, into)
+}
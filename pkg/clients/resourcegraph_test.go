@@ -0,0 +1,179 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/resourcegraph/mgmt/2019-04-01/resourcegraph"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+)
+
+const (
+	graphCredentials  = "cool-credentials"
+	graphSubscription = "bf1b0e59-93da-42e0-82c6-5a1d94227911"
+	graphNetworkID    = "/subscriptions/" + graphSubscription + "/resourceGroups/CoolGroup/providers/Microsoft.Network/virtualNetworks/coolnet"
+	graphSubnetID     = graphNetworkID + "/subnets/coolsubnet"
+)
+
+const graphResponse = `{"count":1,"data":[{
+	"id":"` + graphNetworkID + `",
+	"name":"coolnet",
+	"type":"microsoft.network/virtualnetworks",
+	"properties":{"subnets":[{"id":"` + graphSubnetID + `","name":"coolsubnet","properties":{"addressPrefix":"10.0.0.0/24"}}]}
+}]}`
+
+type graphResource struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Properties struct {
+		AddressPrefix string `json:"addressPrefix,omitempty"`
+	} `json:"properties"`
+}
+
+func TestResourceGraphCache(t *testing.T) {
+	network := ResourceID("CoolGroup", "Microsoft.Network/virtualNetworks", "coolnet")
+	subnet := ResourceID("CoolGroup", "Microsoft.Network/virtualNetworks/subnets", "coolnet", "coolsubnet")
+
+	type want struct {
+		queries  int
+		ok       bool
+		resource graphResource
+	}
+	cases := map[string]struct {
+		reason      string
+		refresh     bool
+		write       string
+		credentials string
+		id          string
+		want        want
+	}{
+		"NotYetQueried": {
+			reason: "Resources should not be read from the cache until their type has been queried.",
+			id:     network,
+		},
+		"Resource": {
+			reason:  "Resources should be read from the cache once their type has been queried.",
+			refresh: true,
+			id:      network,
+			want:    want{queries: 1, ok: true, resource: graphResource{ID: graphNetworkID, Name: "coolnet"}},
+		},
+		"NestedResource": {
+			reason:  "Nested resources should be read from the query results of their parent's type.",
+			refresh: true,
+			id:      subnet,
+			want: want{queries: 1, ok: true, resource: graphResource{ID: graphSubnetID, Name: "coolsubnet", Properties: struct {
+				AddressPrefix string `json:"addressPrefix,omitempty"`
+			}{AddressPrefix: "10.0.0.0/24"}}},
+		},
+		"MissingResource": {
+			reason:  "Resources that were not returned by the query should not be read from the cache.",
+			refresh: true,
+			id:      ResourceID("CoolGroup", "Microsoft.Network/virtualNetworks", "othernet"),
+			want:    want{queries: 1},
+		},
+		"OtherCredentials": {
+			reason:      "Resources should not be read from the results of queries using other credentials, which may read different resources.",
+			refresh:     true,
+			credentials: "other-credentials",
+			id:          network,
+			want:        want{queries: 1},
+		},
+		"WrittenResource": {
+			reason:  "Resources should not be read from the cache if they were written since shortly before it was refreshed.",
+			refresh: true,
+			write:   graphNetworkID,
+			id:      network,
+			want:    want{queries: 1},
+		},
+		"WrittenParent": {
+			reason:  "Resources should not be read from the cache if their parent was written since shortly before it was refreshed.",
+			refresh: true,
+			write:   graphNetworkID,
+			id:      subnet,
+			want:    want{queries: 1},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			queries := 0
+			cl := resourcegraph.New()
+			cl.Sender = autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+				queries++
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Body:       ioutil.NopCloser(strings.NewReader(graphResponse)),
+					Request:    r,
+				}, nil
+			})
+
+			c := NewResourceGraphCache(time.Minute, time.Hour, logging.NewNopLogger())
+			got := graphResource{}
+			c.get(cl, graphCredentials, graphSubscription, tc.id, &got)
+
+			if tc.write != "" {
+				r, _ := http.NewRequest(http.MethodPut, "https://management.azure.com"+tc.write, nil)
+				s := c.Invalidate(autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusOK, Request: r}, nil
+				}))
+				_, _ = s.Do(r)
+			}
+			if tc.refresh {
+				c.refresh(context.Background())
+			}
+
+			credentials := graphCredentials
+			if tc.credentials != "" {
+				credentials = tc.credentials
+			}
+			ok := c.get(cl, credentials, graphSubscription, tc.id, &got)
+			if diff := cmp.Diff(tc.want, want{queries: queries, ok: ok, resource: got}, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("\n%s\nc.get(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCredentialsIdentity(t *testing.T) {
+	creds := map[string]string{CredentialsKeyClientID: "cool-client", CredentialsKeyClientSecret: "secret", CredentialsKeySubscriptionID: graphSubscription}
+	same := map[string]string{CredentialsKeySubscriptionID: graphSubscription, CredentialsKeyClientSecret: "secret", CredentialsKeyClientID: "cool-client"}
+	other := map[string]string{CredentialsKeyClientID: "other-client", CredentialsKeyClientSecret: "secret", CredentialsKeySubscriptionID: graphSubscription}
+
+	if credentialsIdentity(creds) != credentialsIdentity(same) {
+		t.Errorf("credentialsIdentity(...): credentials with the same content should have the same identity")
+	}
+	if credentialsIdentity(creds) == credentialsIdentity(other) {
+		t.Errorf("credentialsIdentity(...): credentials with different content should have different identities")
+	}
+}
+
+func TestResourceGraphReaderNil(t *testing.T) {
+	var r *ResourceGraphReader
+	if r.Get(ResourceID("CoolGroup", ResourceGroupType), &graphResource{}) {
+		t.Errorf("r.Get(...): a nil ResourceGraphReader should never read from the cache")
+	}
+}
@@ -21,7 +21,6 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources/resourcesapi"
-	"github.com/Azure/go-autorest/autorest/azure/auth"
 	"github.com/pkg/errors"
 
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
@@ -40,20 +39,15 @@ func NewClient(credentials []byte) (GroupsClient, error) {
 	if err := json.Unmarshal(credentials, &c); err != nil {
 		return nil, errors.Wrap(err, "cannot unmarshal Azure client secret data")
 	}
-	client := resources.NewGroupsClient(c.SubscriptionID)
+	m := c.Map()
+	azure.DefaultEnvironment(m)
+	client := resources.NewGroupsClientWithBaseURI(m[azure.CredentialsKeyResourceManagerEndpointURL], c.SubscriptionID)
 
-	cfg := auth.ClientCredentialsConfig{
-		ClientID:     c.ClientID,
-		ClientSecret: c.ClientSecret,
-		TenantID:     c.TenantID,
-		AADEndpoint:  c.ActiveDirectoryEndpointURL,
-		Resource:     c.ResourceManagerEndpointURL,
-	}
-	a, err := cfg.Authorizer()
+	a, err := azure.NewAuthorizer(m, azure.ResourceManagerAudience(m))
 	if err != nil {
 		return nil, errors.Wrapf(err, "cannot create Azure authorizer from credentials config")
 	}
-	client.Authorizer = a
+	azure.ConfigureClient(&client.Client, a)
 	if err := client.AddToUserAgent(azure.UserAgent); err != nil {
 		return nil, errors.Wrap(err, "cannot add to Azure client user agent")
 	}
@@ -0,0 +1,75 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"strings"
+)
+
+// ResourceGroupType is the type of Azure resource groups.
+const ResourceGroupType = "Microsoft.Resources/resourceGroups"
+
+// ResourceID returns the ID of the Azure resource with the supplied type and
+// names in the supplied resource group, e.g. of a subnet given the type
+// Microsoft.Network/virtualNetworks/subnets and the names of its virtual
+// network and of the subnet. The ID of a resource group is returned given
+// ResourceGroupType and no names. IDs omit the subscription, and are lower
+// case since Azure resource IDs are case insensitive. An empty string is
+// returned if the resource group or any name is empty.
+func ResourceID(group, typ string, names ...string) string {
+	if group == "" {
+		return ""
+	}
+	id := "resourcegroups/" + group
+	if strings.EqualFold(typ, ResourceGroupType) {
+		return strings.ToLower(id)
+	}
+	types := strings.Split(typ, "/")
+	if len(types) != len(names)+1 {
+		return ""
+	}
+	id += "/providers/" + types[0]
+	for i, n := range names {
+		if n == "" {
+			return ""
+		}
+		id += "/" + types[i+1] + "/" + n
+	}
+	return strings.ToLower(id)
+}
+
+// ParseResourceID parses the supplied Azure resource ID, returning its lower
+// case subscription and type, and its ID as returned by ResourceID. Resources
+// that are not in a resource group, e.g. subscriptions, are not supported.
+func ParseResourceID(id string) (subscription, typ, rid string, ok bool) {
+	p := strings.Split(strings.Trim(id, "/"), "/")
+	if len(p) < 4 || !strings.EqualFold(p[0], "subscriptions") || !strings.EqualFold(p[2], "resourceGroups") {
+		return "", "", "", false
+	}
+	subscription = strings.ToLower(p[1])
+	if len(p) == 4 {
+		return subscription, strings.ToLower(ResourceGroupType), strings.ToLower(strings.Join(p[2:], "/")), true
+	}
+	if len(p) < 8 || len(p)%2 != 0 || !strings.EqualFold(p[4], "providers") {
+		return "", "", "", false
+	}
+	types := []string{p[5]}
+	for i := 6; i < len(p); i += 2 {
+		types = append(types, p[i])
+	}
+	return subscription, strings.ToLower(strings.Join(types, "/")), strings.ToLower(strings.Join(p[2:], "/")), true
+}
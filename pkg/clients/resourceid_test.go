@@ -0,0 +1,118 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const subnetType = "Microsoft.Network/virtualNetworks/subnets"
+
+func TestResourceID(t *testing.T) {
+	cases := map[string]struct {
+		group string
+		typ   string
+		names []string
+		want  string
+	}{
+		"ResourceGroup": {
+			group: "CoolGroup",
+			typ:   ResourceGroupType,
+			want:  "resourcegroups/coolgroup",
+		},
+		"ChildResource": {
+			group: "CoolGroup",
+			typ:   subnetType,
+			names: []string{"coolnet", "coolsubnet"},
+			want:  "resourcegroups/coolgroup/providers/microsoft.network/virtualnetworks/coolnet/subnets/coolsubnet",
+		},
+		"NoResourceGroup": {
+			typ:   subnetType,
+			names: []string{"coolnet", "coolsubnet"},
+		},
+		"NoName": {
+			group: "CoolGroup",
+			typ:   subnetType,
+			names: []string{"coolnet", ""},
+		},
+		"WrongNumberOfNames": {
+			group: "CoolGroup",
+			typ:   subnetType,
+			names: []string{"coolsubnet"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := ResourceID(tc.group, tc.typ, tc.names...)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ResourceID(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseResourceID(t *testing.T) {
+	type want struct {
+		subscription string
+		typ          string
+		rid          string
+		ok           bool
+	}
+
+	cases := map[string]struct {
+		id   string
+		want want
+	}{
+		"ResourceGroup": {
+			id: "/subscriptions/Sub/resourceGroups/CoolGroup",
+			want: want{
+				subscription: "sub",
+				typ:          "microsoft.resources/resourcegroups",
+				rid:          ResourceID("CoolGroup", ResourceGroupType),
+				ok:           true,
+			},
+		},
+		"ChildResource": {
+			id: "/subscriptions/sub/resourceGroups/CoolGroup/providers/Microsoft.Network/virtualNetworks/coolnet/subnets/coolsubnet",
+			want: want{
+				subscription: "sub",
+				typ:          "microsoft.network/virtualnetworks/subnets",
+				rid:          ResourceID("CoolGroup", subnetType, "coolnet", "coolsubnet"),
+				ok:           true,
+			},
+		},
+		"Subscription": {
+			id: "/subscriptions/sub",
+		},
+		"IncompleteResource": {
+			id: "/subscriptions/sub/resourceGroups/CoolGroup/providers/Microsoft.Network/virtualNetworks",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			subscription, typ, rid, ok := ParseResourceID(tc.id)
+			got := want{subscription: subscription, typ: typ, rid: rid, ok: ok}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("ParseResourceID(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
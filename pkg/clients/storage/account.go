@@ -20,12 +20,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 
 	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2017-06-01/storage"
-	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/Azure/go-autorest/autorest"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/pkg/errors"
 
+	"github.com/crossplane/provider-azure/apis/v1alpha3"
 	azure "github.com/crossplane/provider-azure/pkg/clients"
 )
 
@@ -36,17 +38,15 @@ func NewStorageAccountClient(data []byte) (*storage.AccountsClient, error) {
 		return nil, errors.Wrap(err, "cannot unmarshal Azure client secret data")
 	}
 
-	config := auth.NewClientCredentialsConfig(creds.ClientID, creds.ClientSecret, creds.TenantID)
-	config.AADEndpoint = creds.ActiveDirectoryEndpointURL
-	config.Resource = creds.ResourceManagerEndpointURL
-
-	authorizer, err := config.Authorizer()
+	m := creds.Map()
+	azure.DefaultEnvironment(m)
+	authorizer, err := azure.NewAuthorizer(m, azure.ResourceManagerAudience(m))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get authorizer from config: %+v", err)
 	}
 
-	client := storage.NewAccountsClient(creds.SubscriptionID)
-	client.Authorizer = authorizer
+	client := storage.NewAccountsClientWithBaseURI(m[azure.CredentialsKeyResourceManagerEndpointURL], creds.SubscriptionID)
+	azure.ConfigureClient(&client.Client, authorizer)
 
 	if err := client.AddToUserAgent(azure.UserAgent); err != nil {
 		return nil, errors.Wrap(err, "cannot add to Azure client user agent")
@@ -56,12 +56,13 @@ func NewStorageAccountClient(data []byte) (*storage.AccountsClient, error) {
 
 // AccountOperations Azure storate account interface
 type AccountOperations interface {
-	Create(context.Context, storage.AccountCreateParameters) (*storage.Account, error)
+	Create(context.Context, storage.AccountCreateParameters) (v1alpha3.AsyncOperation, error)
 	Update(context.Context, storage.AccountUpdateParameters) (*storage.Account, error)
 	Get(ctx context.Context) (*storage.Account, error)
 	Delete(ctx context.Context) error
 	IsAccountNameAvailable(context.Context, string) error
 	ListKeys(context.Context) ([]storage.AccountKey, error)
+	GetRESTClient() autorest.Sender
 }
 
 // AccountHandle implements AccountOperations interface
@@ -82,27 +83,20 @@ func NewAccountHandle(client *storage.AccountsClient, groupName, accountName str
 	}
 }
 
-// Create create new storage account with given location
-func (a *AccountHandle) Create(ctx context.Context, params storage.AccountCreateParameters) (*storage.Account, error) {
-	if err := a.IsAccountNameAvailable(ctx, a.accountName); err != nil {
-		return nil, errors.Wrapf(err, "failed to check account name availability")
+// Create starts creating a new storage account with given location and
+// returns the asynchronous operation that tracks its creation.
+func (a *AccountHandle) Create(ctx context.Context, params storage.AccountCreateParameters) (v1alpha3.AsyncOperation, error) {
+	// The availability of the name is checked even if the creation is only
+	// planned in dry-run mode.
+	if err := a.IsAccountNameAvailable(azure.Unplanned(ctx), a.accountName); err != nil {
+		return v1alpha3.AsyncOperation{}, errors.Wrapf(err, "failed to check account name availability")
 	}
 
 	future, err := a.client.Create(ctx, a.groupName, a.accountName, params)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to start creating storage account")
-	}
-
-	err = future.WaitForCompletionRef(ctx, a.client.Client)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to finish creating storage account")
+		return v1alpha3.AsyncOperation{}, errors.Wrapf(err, "failed to start creating storage account")
 	}
-
-	acct, err := future.Result(*a.client)
-	if err != nil {
-		return nil, err
-	}
-	return &acct, nil
+	return azure.NewAsyncOperation(http.MethodPut, future.Future), nil
 }
 
 // Update create new storage account with given location
@@ -148,6 +142,12 @@ func (a *AccountHandle) IsAccountNameAvailable(ctx context.Context, name string)
 	return nil
 }
 
+// GetRESTClient returns the underlying REST client that the storage account
+// handle uses.
+func (a *AccountHandle) GetRESTClient() autorest.Sender {
+	return a.client.Client
+}
+
 // ListKeys for this storage account
 func (a *AccountHandle) ListKeys(ctx context.Context) ([]storage.AccountKey, error) {
 	rs, err := a.client.ListKeys(ctx, a.groupName, a.accountName)
@@ -18,11 +18,12 @@ package storage
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 	"net/url"
 
+	"github.com/Azure/azure-pipeline-go/pipeline"
 	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/Azure/go-autorest/autorest"
 
 	azure "github.com/crossplane/provider-azure/pkg/clients"
 )
@@ -43,20 +44,42 @@ type ContainerHandle struct {
 
 var _ ContainerOperations = &ContainerHandle{}
 
-const blobFormatString = `https://%s.blob.core.windows.net`
+// blobSenderFactory is the HTTP sender of container pipelines. It sends their
+// requests using the Azure API Transport, recording them in the Azure API
+// metrics and traces. Requests that would write containers are not sent at all
+// in dry-run mode.
+var blobSenderFactory = pipeline.FactoryFunc(func(_ pipeline.Policy, _ *pipeline.PolicyOptions) pipeline.PolicyFunc {
+	s := autorest.DecorateSender(azure.Transport, azure.Metrics, azure.Tracing, azure.DryRunIntercept)
+	return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+		r, err := s.Do(request.WithContext(ctx))
+		if err != nil {
+			err = pipeline.NewError(err, "HTTP request failed")
+		}
+		return pipeline.NewHTTPResponse(r), err
+	}
+})
 
-// NewContainerHandle creates a new instance of ContainerHandle for given storage account and given container name
-func NewContainerHandle(accountName, accountKey, containerName string) (*ContainerHandle, error) {
+// NewContainerHandle creates a new instance of ContainerHandle for given storage account and given container name.
+// The blob service endpoint of the storage account is used if supplied, otherwise the Azure public cloud endpoint
+// of the account is assumed. See azure.BlobEndpoint for the endpoints of other Azure environments.
+func NewContainerHandle(endpoint, accountName, accountKey, containerName string) (*ContainerHandle, error) {
 	c, err := azblob.NewSharedKeyCredential(accountName, accountKey)
 	if err != nil {
 		return nil, err
 	}
 
 	p := azblob.NewPipeline(c, azblob.PipelineOptions{
-		Telemetry: azblob.TelemetryOptions{Value: azure.UserAgent},
+		Telemetry:  azblob.TelemetryOptions{Value: azure.UserAgent},
+		HTTPSender: blobSenderFactory,
 	})
 
-	u, _ := url.Parse(fmt.Sprintf(blobFormatString, accountName))
+	if endpoint == "" {
+		endpoint = azure.BlobEndpoint(nil, accountName)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
 	service := azblob.NewServiceURL(*u, p)
 
 	return &ContainerHandle{
@@ -0,0 +1,90 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/provider-azure/pkg/clients/recorder"
+)
+
+// Environment variables that configure the storage account in which
+// TestContainerHandle records its interactions.
+const (
+	envStorageAccount = "AZURE_STORAGE_ACCOUNT"
+	envStorageKey     = "AZURE_STORAGE_KEY"
+)
+
+// TestContainerHandle replays the interactions of a ContainerHandle with Azure
+// that are recorded in testdata/containerhandle.json. Run it with
+// AZURE_RECORDER_MODE=record, AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_KEY set
+// to record them again.
+func TestContainerHandle(t *testing.T) {
+	const (
+		account   = "crossplanerecorder"
+		container = "crossplane-recorder"
+	)
+	name, key := account, recorder.Redacted
+	if recorder.Mode(os.Getenv(recorder.EnvMode)) == recorder.ModeRecord {
+		name, key = os.Getenv(envStorageAccount), os.Getenv(envStorageKey)
+	}
+	_, _, stop := recorder.Start(t, "containerhandle", recorder.WithScrubbed(name, account))
+	defer stop()
+	ctx := context.Background()
+
+	h, err := NewContainerHandle("", name, key, container)
+	if err != nil {
+		t.Fatalf("NewContainerHandle(...): %s", err)
+	}
+
+	if err := h.Create(ctx, azblob.PublicAccessNone, nil); err != nil {
+		t.Fatalf("h.Create(...): %s", err)
+	}
+
+	// Containers cannot be created twice.
+	err = h.Create(ctx, azblob.PublicAccessNone, nil)
+	if se, ok := err.(azblob.StorageError); !ok || se.ServiceCode() != azblob.ServiceCodeContainerAlreadyExists {
+		t.Errorf("h.Create(...): want %s error, got %v", azblob.ServiceCodeContainerAlreadyExists, err)
+	}
+
+	md := azblob.Metadata{"owner": "crossplane"}
+	if err := h.Update(ctx, azblob.PublicAccessContainer, md); err != nil {
+		t.Fatalf("h.Update(...): %s", err)
+	}
+	access, gotMD, err := h.Get(ctx)
+	if err != nil {
+		t.Fatalf("h.Get(...): %s", err)
+	}
+	if diff := cmp.Diff(azblob.PublicAccessContainer, *access); diff != "" {
+		t.Errorf("h.Get(...): -want public access, +got public access:\n%s", diff)
+	}
+	if diff := cmp.Diff(md, gotMD); diff != "" {
+		t.Errorf("h.Get(...): -want metadata, +got metadata:\n%s", diff)
+	}
+
+	if err := h.Delete(ctx); err != nil {
+		t.Fatalf("h.Delete(...): %s", err)
+	}
+	if _, _, err := h.Get(ctx); !IsNotFoundError(err) {
+		t.Errorf("h.Get(...): want not found error after deletion, got %v", err)
+	}
+}
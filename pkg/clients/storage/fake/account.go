@@ -20,18 +20,21 @@ import (
 	"context"
 
 	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2017-06-01/storage"
+	"github.com/Azure/go-autorest/autorest"
 
+	"github.com/crossplane/provider-azure/apis/v1alpha3"
 	azurestorage "github.com/crossplane/provider-azure/pkg/clients/storage"
 )
 
 // MockAccountOperations mock implementation of AccountOperations
 type MockAccountOperations struct {
-	MockCreate                 func(context.Context, storage.AccountCreateParameters) (*storage.Account, error)
+	MockCreate                 func(context.Context, storage.AccountCreateParameters) (v1alpha3.AsyncOperation, error)
 	MockUpdate                 func(context.Context, storage.AccountUpdateParameters) (*storage.Account, error)
 	MockGet                    func(ctx context.Context) (*storage.Account, error)
 	MockDelete                 func(ctx context.Context) error
 	MockIsAccountNameAvailable func(context.Context, string) error
 	MockListKeys               func(context.Context) ([]storage.AccountKey, error)
+	MockGetRESTClient          func() autorest.Sender
 }
 
 var _ azurestorage.AccountOperations = &MockAccountOperations{}
@@ -39,8 +42,8 @@ var _ azurestorage.AccountOperations = &MockAccountOperations{}
 // NewMockAccountOperations returns new mock instance with default mocks
 func NewMockAccountOperations() *MockAccountOperations {
 	return &MockAccountOperations{
-		MockCreate: func(i context.Context, parameters storage.AccountCreateParameters) (account *storage.Account, e error) {
-			return nil, nil
+		MockCreate: func(i context.Context, parameters storage.AccountCreateParameters) (op v1alpha3.AsyncOperation, e error) {
+			return v1alpha3.AsyncOperation{}, nil
 		},
 		MockUpdate: func(i context.Context, parameters storage.AccountUpdateParameters) (account *storage.Account, e error) {
 			return nil, nil
@@ -57,11 +60,14 @@ func NewMockAccountOperations() *MockAccountOperations {
 		MockListKeys: func(i context.Context) ([]storage.AccountKey, error) {
 			return nil, nil
 		},
+		MockGetRESTClient: func() autorest.Sender {
+			return nil
+		},
 	}
 }
 
 // Create mock create
-func (m *MockAccountOperations) Create(ctx context.Context, params storage.AccountCreateParameters) (*storage.Account, error) {
+func (m *MockAccountOperations) Create(ctx context.Context, params storage.AccountCreateParameters) (v1alpha3.AsyncOperation, error) {
 	return m.MockCreate(ctx, params)
 }
 
@@ -89,3 +95,8 @@ func (m *MockAccountOperations) IsAccountNameAvailable(ctx context.Context, name
 func (m *MockAccountOperations) ListKeys(ctx context.Context) ([]storage.AccountKey, error) {
 	return m.MockListKeys(ctx)
 }
+
+// GetRESTClient mock get REST client
+func (m *MockAccountOperations) GetRESTClient() autorest.Sender {
+	return m.MockGetRESTClient()
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"net/http"
+
+	"github.com/Azure/go-autorest/autorest"
+	"go.opentelemetry.io/otel/api/global"
+	"go.opentelemetry.io/otel/api/trace"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/semconv"
+)
+
+// TracerName is the name of the OpenTelemetry tracer of the provider.
+const TracerName = "github.com/crossplane/provider-azure"
+
+// Azure returns the identifiers of each request in these response headers.
+// Azure support uses them to find the request in their logs.
+const (
+	HeaderRequestID            = "x-ms-request-id"
+	HeaderCorrelationRequestID = "x-ms-correlation-request-id"
+	HeaderClientRequestID      = "x-ms-client-request-id"
+)
+
+// Span attribute keys of requests made to Azure APIs.
+const (
+	AttributeService              = label.Key("azure.service")
+	AttributeResourceKind         = label.Key("azure.resource_kind")
+	AttributeRequestID            = label.Key("azure.request_id")
+	AttributeCorrelationRequestID = label.Key("azure.correlation_request_id")
+	AttributeClientRequestID      = label.Key("azure.client_request_id")
+	AttributeErrorCode            = label.Key("azure.error_code")
+)
+
+// Tracing is an autorest.SendDecorator that records a span for each request
+// made to Azure APIs. The span is a child of any span in the context of the
+// request.
+func Tracing(s autorest.Sender) autorest.Sender {
+	return autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+		service, operation, kind := requestLabels(r)
+		ctx, span := global.Tracer(TracerName).Start(r.Context(), "HTTP "+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.HTTPMethodKey.String(r.Method),
+				semconv.HTTPHostKey.String(r.URL.Host),
+				semconv.HTTPTargetKey.String(r.URL.Path),
+				AttributeService.String(service),
+				AttributeResourceKind.String(kind),
+			))
+		defer span.End()
+
+		resp, err := s.Do(r.WithContext(ctx))
+		if err != nil {
+			span.RecordError(ctx, err, trace.WithErrorStatus(codes.Error))
+			return resp, err
+		}
+		if resp == nil {
+			return resp, err
+		}
+
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
+		for k, h := range map[label.Key]string{
+			AttributeRequestID:            HeaderRequestID,
+			AttributeCorrelationRequestID: HeaderCorrelationRequestID,
+			AttributeClientRequestID:      HeaderClientRequestID,
+		} {
+			if v := resp.Header.Get(h); v != "" {
+				span.SetAttributes(k.String(v))
+			}
+		}
+		if resp.StatusCode >= http.StatusBadRequest {
+			code := errorCode(resp)
+			span.SetAttributes(AttributeErrorCode.String(code))
+			span.SetStatus(codes.Error, code)
+		}
+		return resp, err
+	})
+}
+
+// TracingAuthorizer returns an authorizer that records a span while the
+// supplied authorizer authorizes each request, which includes acquiring or
+// refreshing its Azure Active Directory token when necessary.
+func TracingAuthorizer(a autorest.Authorizer) autorest.Authorizer {
+	if a == nil {
+		return nil
+	}
+	return tracingAuthorizer{authorizer: a}
+}
+
+type tracingAuthorizer struct {
+	authorizer autorest.Authorizer
+}
+
+func (a tracingAuthorizer) WithAuthorization() autorest.PrepareDecorator {
+	authorize := a.authorizer.WithAuthorization()
+	return func(p autorest.Preparer) autorest.Preparer {
+		return autorest.PreparerFunc(func(r *http.Request) (*http.Request, error) {
+			ctx, span := global.Tracer(TracerName).Start(r.Context(), "Authorize")
+			defer span.End()
+
+			authorized, err := authorize(p).Prepare(r.WithContext(ctx))
+			if err != nil {
+				span.RecordError(ctx, err, trace.WithErrorStatus(codes.Error))
+				return authorized, err
+			}
+			// Only the authorization is a child of the span; the request is not.
+			return authorized.WithContext(r.Context()), nil
+		})
+	}
+}
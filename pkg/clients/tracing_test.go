@@ -0,0 +1,126 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/api/global"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/label"
+	exporttrace "go.opentelemetry.io/otel/sdk/export/trace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+type spanRecorder struct {
+	spans []*exporttrace.SpanData
+}
+
+func (r *spanRecorder) ExportSpans(_ context.Context, s []*exporttrace.SpanData) error {
+	r.spans = append(r.spans, s...)
+	return nil
+}
+
+func (r *spanRecorder) Shutdown(_ context.Context) error { return nil }
+
+func TestTracing(t *testing.T) {
+	type want struct {
+		name       string
+		status     codes.Code
+		attributes map[label.Key]string
+	}
+	cases := map[string]struct {
+		status int
+		header http.Header
+		want   want
+	}{
+		"Successful": {
+			status: http.StatusOK,
+			header: http.Header{
+				"X-Ms-Request-Id":             []string{"cool-request"},
+				"X-Ms-Correlation-Request-Id": []string{"cool-correlation"},
+			},
+			want: want{
+				name:   "HTTP GET",
+				status: codes.Unset,
+				attributes: map[label.Key]string{
+					AttributeService:              "Microsoft.Network",
+					AttributeResourceKind:         "virtualNetworks",
+					AttributeRequestID:            "cool-request",
+					AttributeCorrelationRequestID: "cool-correlation",
+				},
+			},
+		},
+		"Failed": {
+			status: http.StatusNotFound,
+			header: http.Header{"X-Ms-Error-Code": []string{"ResourceNotFound"}},
+			want: want{
+				name:   "HTTP GET",
+				status: codes.Error,
+				attributes: map[label.Key]string{
+					AttributeService:      "Microsoft.Network",
+					AttributeResourceKind: "virtualNetworks",
+					AttributeErrorCode:    "ResourceNotFound",
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rec := &spanRecorder{}
+			global.SetTracerProvider(sdktrace.NewTracerProvider(
+				sdktrace.WithConfig(sdktrace.Config{DefaultSampler: sdktrace.AlwaysSample()}),
+				sdktrace.WithSyncer(rec),
+			))
+
+			s := autorest.DecorateSender(autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: tc.status, Header: tc.header, Request: r}, nil
+			}), Tracing)
+
+			ctx, parent := global.Tracer(TracerName).Start(context.Background(), "parent")
+			r, _ := http.NewRequest(http.MethodGet, "https://management.azure.com/subscriptions/sub/resourceGroups/coolgroup/providers/Microsoft.Network/virtualNetworks/coolnet", nil)
+			if _, err := s.Do(r.WithContext(ctx)); err != nil {
+				t.Fatalf("s.Do(...): %s", err)
+			}
+			parent.End()
+
+			if len(rec.spans) != 2 {
+				t.Fatalf("s.Do(...): want 2 spans, got %d", len(rec.spans))
+			}
+			span := rec.spans[0]
+			if span.ParentSpanID != parent.SpanContext().SpanID {
+				t.Errorf("s.Do(...): span is not a child of the span of the request context")
+			}
+
+			attributes := map[label.Key]string{}
+			for _, kv := range span.Attributes {
+				if _, ok := tc.want.attributes[kv.Key]; ok {
+					attributes[kv.Key] = kv.Value.AsString()
+				}
+			}
+			got := want{name: span.Name, status: span.StatusCode, attributes: attributes}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("s.Do(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
@@ -17,10 +17,20 @@ limitations under the License.
 package controller
 
 import (
+	"strings"
+
+	"github.com/pkg/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 
+	cachev1beta1 "github.com/crossplane/provider-azure/apis/cache/v1beta1"
+	computev1alpha3 "github.com/crossplane/provider-azure/apis/compute/v1alpha3"
+	databasev1alpha3 "github.com/crossplane/provider-azure/apis/database/v1alpha3"
+	databasev1beta1 "github.com/crossplane/provider-azure/apis/database/v1beta1"
+	networkv1alpha3 "github.com/crossplane/provider-azure/apis/network/v1alpha3"
+	storagev1alpha3 "github.com/crossplane/provider-azure/apis/storage/v1alpha3"
+	"github.com/crossplane/provider-azure/apis/v1alpha3"
 	"github.com/crossplane/provider-azure/pkg/controller/cache"
 	"github.com/crossplane/provider-azure/pkg/controller/compute"
 	"github.com/crossplane/provider-azure/pkg/controller/config"
@@ -36,28 +46,52 @@ import (
 	"github.com/crossplane/provider-azure/pkg/controller/resourcegroup"
 	"github.com/crossplane/provider-azure/pkg/controller/storage/account"
 	"github.com/crossplane/provider-azure/pkg/controller/storage/container"
+	"github.com/crossplane/provider-azure/pkg/reconciler"
 )
 
-// Setup Azure controllers.
-func Setup(mgr ctrl.Manager, l logging.Logger) error {
-	for _, setup := range []func(ctrl.Manager, logging.Logger) error{
-		config.Setup,
-		cache.SetupRedis,
-		compute.SetupAKSCluster,
-		mysqlserver.Setup,
-		mysqlserverfirewallrule.Setup,
-		mysqlservervirtualnetworkrule.Setup,
-		postgresqlserver.Setup,
-		postgresqlserverfirewallrule.Setup,
-		postgresqlservervirtualnetworkrule.Setup,
-		cosmosdb.Setup,
-		virtualnetwork.Setup,
-		subnet.Setup,
-		resourcegroup.Setup,
-		account.Setup,
-		container.Setup,
-	} {
-		if err := setup(mgr, l); err != nil {
+const errFmtUnknownKind = "cannot reconcile unknown kind of managed resource %q"
+
+// Setup Azure controllers. The ProviderConfig controller is always set up,
+// while the controllers of managed resources are set up only for the kinds
+// that the supplied options enable.
+func Setup(mgr ctrl.Manager, l logging.Logger, o reconciler.Options) error {
+	if err := config.Setup(mgr, l, o); err != nil {
+		return err
+	}
+	controllers := []struct {
+		kind  string
+		setup func(ctrl.Manager, logging.Logger, reconciler.Options) error
+	}{
+		{cachev1beta1.RedisGroupKind, cache.SetupRedis},
+		{computev1alpha3.AKSClusterGroupKind, compute.SetupAKSCluster},
+		{databasev1beta1.MySQLServerGroupKind, mysqlserver.Setup},
+		{databasev1alpha3.MySQLServerFirewallRuleGroupKind, mysqlserverfirewallrule.Setup},
+		{databasev1alpha3.MySQLServerVirtualNetworkRuleGroupKind, mysqlservervirtualnetworkrule.Setup},
+		{databasev1beta1.PostgreSQLServerGroupKind, postgresqlserver.Setup},
+		{databasev1alpha3.PostgreSQLServerFirewallRuleGroupKind, postgresqlserverfirewallrule.Setup},
+		{databasev1alpha3.PostgreSQLServerVirtualNetworkRuleGroupKind, postgresqlservervirtualnetworkrule.Setup},
+		{databasev1alpha3.CosmosDBAccountGroupKind, cosmosdb.Setup},
+		{networkv1alpha3.VirtualNetworkGroupKind, virtualnetwork.Setup},
+		{networkv1alpha3.SubnetGroupKind, subnet.Setup},
+		{v1alpha3.ResourceGroupGroupKind, resourcegroup.Setup},
+		{storagev1alpha3.AccountGroupKind, account.Setup},
+		{storagev1alpha3.ContainerGroupKind, container.Setup},
+	}
+	for _, k := range o.Kinds {
+		known := false
+		for _, c := range controllers {
+			known = known || strings.EqualFold(k, c.kind)
+		}
+		if !known {
+			return errors.Errorf(errFmtUnknownKind, k)
+		}
+	}
+	for _, c := range controllers {
+		if !o.Enabled(c.kind) {
+			l.Debug("Not reconciling managed resources", "kind", c.kind)
+			continue
+		}
+		if err := c.setup(mgr, l, o); err != nil {
 			return err
 		}
 	}
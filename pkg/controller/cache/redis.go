@@ -18,13 +18,16 @@ package cache
 
 import (
 	"context"
+	"net/http"
 	"strconv"
 
 	"github.com/Azure/azure-sdk-for-go/profiles/latest/redis/mgmt/redis"
 	"github.com/Azure/azure-sdk-for-go/profiles/latest/redis/mgmt/redis/redisapi"
+	"github.com/Azure/go-autorest/autorest"
 	"github.com/pkg/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
@@ -36,6 +39,8 @@ import (
 	"github.com/crossplane/provider-azure/apis/cache/v1beta1"
 	azure "github.com/crossplane/provider-azure/pkg/clients"
 	redisclients "github.com/crossplane/provider-azure/pkg/clients/redis"
+	"github.com/crossplane/provider-azure/pkg/eventgrid"
+	"github.com/crossplane/provider-azure/pkg/reconciler"
 )
 
 const (
@@ -51,18 +56,38 @@ const (
 )
 
 // SetupRedis adds a controller that reconciles Redis resources.
-func SetupRedis(mgr ctrl.Manager, l logging.Logger) error {
+func SetupRedis(mgr ctrl.Manager, l logging.Logger, o reconciler.Options) error {
 	name := managed.ControllerName(v1beta1.RedisGroupKind)
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
+	errs := reconciler.NewErrorHandler(recorder)
+
+	events, err := eventgrid.Watch(mgr, &v1beta1.Redis{}, &v1beta1.RedisList{}, "Microsoft.Cache/Redis", azureResourceID)
+	if err != nil {
+		return err
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
+		WithOptions(o.Controller(v1beta1.RedisGroupKind)).
 		For(&v1beta1.Redis{}).
-		Complete(managed.NewReconciler(mgr,
+		Watches(events, &handler.EnqueueRequestForObject{}).
+		Complete(o.Jittered(errs.Reconciler(managed.NewReconciler(mgr,
 			resource.ManagedKind(v1beta1.RedisGroupVersionKind),
-			managed.WithExternalConnecter(&connector{kube: mgr.GetClient()}),
+			managed.WithExternalConnecter(errs.Connecter(reconciler.Traced(resource.ManagedKind(v1beta1.RedisGroupVersionKind), o.DryRun(v1beta1.RedisGroupKind, recorder, reconciler.RecordDrift(recorder, reconciler.ObserveOnly(reconciler.ProtectDeletion(&connector{kube: mgr.GetClient()}))))))),
 			managed.WithReferenceResolver(managed.NewAPISimpleReferenceResolver(mgr.GetClient())),
+			managed.WithLongWait(o.Poll(v1beta1.RedisGroupKind)),
 			managed.WithLogger(l.WithValues("controller", name)),
-			managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+			managed.WithRecorder(recorder)))))
+}
+
+// azureResourceID returns the ID of the Azure Redis cache that the supplied
+// managed resource manages.
+func azureResourceID(mg resource.Managed) string {
+	cr, ok := mg.(*v1beta1.Redis)
+	if !ok {
+		return ""
+	}
+	return azure.ResourceID(cr.Spec.ForProvider.ResourceGroupName, "Microsoft.Cache/Redis", meta.GetExternalName(cr))
 }
 
 type connector struct {
@@ -74,14 +99,16 @@ func (c connector) Connect(ctx context.Context, mg resource.Managed) (managed.Ex
 	if err != nil {
 		return nil, errors.Wrap(err, errConnectFailed)
 	}
-	cl := redis.NewClient(creds[azure.CredentialsKeySubscriptionID])
-	cl.Authorizer = auth
-	return &external{kube: c.kube, client: cl}, nil
+	cl := redis.NewClientWithBaseURI(creds[azure.CredentialsKeyResourceManagerEndpointURL], creds[azure.CredentialsKeySubscriptionID])
+	azure.ConfigureClient(&cl.Client, auth)
+	return &external{kube: c.kube, client: cl, sender: cl.Client, graph: azure.NewResourceGraphReader(creds, auth)}, nil
 }
 
 type external struct {
 	kube   client.Client
 	client redisapi.ClientAPI
+	sender autorest.Sender
+	graph  *azure.ResourceGraphReader
 }
 
 func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
@@ -89,16 +116,33 @@ func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 	if !ok {
 		return managed.ExternalObservation{}, errors.New(errNotRedis)
 	}
-	cache, err := c.client.Get(ctx, cr.Spec.ForProvider.ResourceGroupName, meta.GetExternalName(cr))
+	cache := redis.ResourceType{}
+	var err error
+	if !c.graph.Get(azureResourceID(cr), &cache) {
+		cache, err = c.client.Get(ctx, cr.Spec.ForProvider.ResourceGroupName, meta.GetExternalName(cr))
+	}
+	if azure.IsNotFound(err) {
+		if err := azure.UpdateLastOperation(ctx, c.sender, cr, &cr.Status.AtProvider.LastOperation); err != nil {
+			return managed.ExternalObservation{}, err
+		}
+		// The Redis cache may not be found until its creation completes.
+		creating := azure.AsyncOperationInProgress(cr.Status.AtProvider.LastOperation, http.MethodPut)
+		return managed.ExternalObservation{ResourceExists: creating, ResourceUpToDate: creating}, nil
+	}
 	if err != nil {
-		return managed.ExternalObservation{ResourceExists: false}, errors.Wrap(resource.Ignore(azure.IsNotFound, err), errGetFailed)
+		return managed.ExternalObservation{}, errors.Wrap(err, errGetFailed)
 	}
 
 	redisclients.LateInitialize(&cr.Spec.ForProvider, cache)
 	if err := c.kube.Update(ctx, cr); err != nil {
 		return managed.ExternalObservation{}, errors.Wrap(err, errUpdateRedisCRFailed)
 	}
+	op := cr.Status.AtProvider.LastOperation
 	cr.Status.AtProvider = redisclients.GenerateObservation(cache)
+	cr.Status.AtProvider.LastOperation = op
+	if err := azure.UpdateLastOperation(ctx, c.sender, cr, &cr.Status.AtProvider.LastOperation); err != nil {
+		return managed.ExternalObservation{}, err
+	}
 
 	var conn managed.ConnectionDetails
 	switch cr.Status.AtProvider.ProvisioningState {
@@ -122,7 +166,7 @@ func (c *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 	}
 	return managed.ExternalObservation{
 		ResourceExists:    true,
-		ResourceUpToDate:  !redisclients.NeedsUpdate(cr.Spec.ForProvider, cache),
+		ResourceUpToDate:  azure.SetDrift(cr, redisclients.Diff(cr.Spec.ForProvider, cache)),
 		ConnectionDetails: conn,
 	}, nil
 }
@@ -133,8 +177,12 @@ func (c *external) Create(ctx context.Context, mg resource.Managed) (managed.Ext
 		return managed.ExternalCreation{}, errors.New(errNotRedis)
 	}
 	cr.Status.SetConditions(runtimev1alpha1.Creating())
-	_, err := c.client.Create(ctx, cr.Spec.ForProvider.ResourceGroupName, meta.GetExternalName(cr), redisclients.NewCreateParameters(cr))
-	return managed.ExternalCreation{}, errors.Wrap(err, errCreateFailed)
+	f, err := c.client.Create(ctx, cr.Spec.ForProvider.ResourceGroupName, meta.GetExternalName(cr), redisclients.NewCreateParameters(cr))
+	if err != nil {
+		return managed.ExternalCreation{}, errors.Wrap(err, errCreateFailed)
+	}
+	cr.Status.AtProvider.LastOperation = azure.NewAsyncOperation(http.MethodPut, f.Future)
+	return managed.ExternalCreation{}, nil
 }
 
 func (c *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
@@ -165,9 +213,14 @@ func (c *external) Delete(ctx context.Context, mg resource.Managed) error {
 		return errors.New(errNotRedis)
 	}
 	cr.Status.SetConditions(runtimev1alpha1.Deleting())
-	if cr.Status.AtProvider.ProvisioningState == redisclients.ProvisioningStateDeleting {
+	if cr.Status.AtProvider.ProvisioningState == redisclients.ProvisioningStateDeleting ||
+		azure.AsyncOperationInProgress(cr.Status.AtProvider.LastOperation, http.MethodDelete) {
 		return nil
 	}
-	_, err := c.client.Delete(ctx, cr.Spec.ForProvider.ResourceGroupName, meta.GetExternalName(cr))
-	return errors.Wrap(resource.Ignore(azure.IsNotFound, err), errDeleteFailed)
+	f, err := c.client.Delete(ctx, cr.Spec.ForProvider.ResourceGroupName, meta.GetExternalName(cr))
+	if err != nil {
+		return errors.Wrap(resource.Ignore(azure.IsNotFound, err), errDeleteFailed)
+	}
+	cr.Status.AtProvider.LastOperation = azure.NewAsyncOperation(http.MethodDelete, f.Future)
+	return nil
 }
@@ -35,6 +35,7 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 
 	"github.com/crossplane/provider-azure/apis/cache/v1beta1"
+	azurev1alpha3 "github.com/crossplane/provider-azure/apis/v1alpha3"
 	azure "github.com/crossplane/provider-azure/pkg/clients"
 	redisclient "github.com/crossplane/provider-azure/pkg/clients/redis"
 	"github.com/crossplane/provider-azure/pkg/clients/redis/fake"
@@ -86,6 +87,10 @@ func withPort(p int) redisResourceModifier {
 	return func(r *v1beta1.Redis) { r.Status.AtProvider.Port = p }
 }
 
+func withLastOperation(op azurev1alpha3.AsyncOperation) redisResourceModifier {
+	return func(r *v1beta1.Redis) { r.Status.AtProvider.LastOperation = op }
+}
+
 func instance(rm ...redisResourceModifier) *v1beta1.Redis {
 	r := &v1beta1.Redis{
 		Spec: v1beta1.RedisSpec{
@@ -140,6 +145,9 @@ func TestObserve(t *testing.T) {
 		err error
 	}
 
+	// None of the properties of instance() are set on the observed caches.
+	drifted := azure.Drifted(redisclient.Diff(instance().Spec.ForProvider, redis.ResourceType{Properties: &redis.Properties{}}))
+
 	cases := map[string]struct {
 		args
 		want
@@ -172,7 +180,7 @@ func TestObserve(t *testing.T) {
 					withProvisioningState(redisclient.ProvisioningStateSucceeded),
 					withHostName(hostName),
 					withPort(port),
-					withConditions(runtimev1alpha1.Available()),
+					withConditions(runtimev1alpha1.Available(), drifted),
 				),
 				o: managed.ExternalObservation{
 					ResourceExists:   true,
@@ -256,7 +264,7 @@ func TestObserve(t *testing.T) {
 			want: want{
 				cr: instance(
 					withProvisioningState(redisclient.ProvisioningStateCreating),
-					withConditions(runtimev1alpha1.Creating()),
+					withConditions(runtimev1alpha1.Creating(), drifted),
 				),
 				o: managed.ExternalObservation{
 					ResourceUpToDate: false,
@@ -282,7 +290,7 @@ func TestObserve(t *testing.T) {
 			want: want{
 				cr: instance(
 					withProvisioningState(redisclient.ProvisioningStateDeleting),
-					withConditions(runtimev1alpha1.Deleting()),
+					withConditions(runtimev1alpha1.Deleting(), drifted),
 				),
 				o: managed.ExternalObservation{
 					ResourceUpToDate: false,
@@ -308,7 +316,7 @@ func TestObserve(t *testing.T) {
 			want: want{
 				cr: instance(
 					withProvisioningState(redisclient.ProvisioningStateFailed),
-					withConditions(runtimev1alpha1.Unavailable()),
+					withConditions(runtimev1alpha1.Unavailable(), drifted),
 				),
 				o: managed.ExternalObservation{
 					ResourceUpToDate: false,
@@ -364,6 +372,7 @@ func TestCreate(t *testing.T) {
 			want: want{
 				cr: instance(
 					withConditions(runtimev1alpha1.Creating()),
+					withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodPut}),
 				),
 			},
 		},
@@ -517,6 +526,7 @@ func TestDelete(t *testing.T) {
 			want: want{
 				cr: instance(
 					withConditions(runtimev1alpha1.Deleting()),
+					withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodDelete}),
 				),
 			},
 		},
@@ -18,12 +18,14 @@ package compute
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/pkg/errors"
 	"k8s.io/client-go/tools/clientcmd"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
@@ -36,6 +38,8 @@ import (
 	"github.com/crossplane/provider-azure/apis/compute/v1alpha3"
 	azure "github.com/crossplane/provider-azure/pkg/clients"
 	"github.com/crossplane/provider-azure/pkg/clients/compute"
+	"github.com/crossplane/provider-azure/pkg/eventgrid"
+	"github.com/crossplane/provider-azure/pkg/reconciler"
 )
 
 // Error strings.
@@ -49,18 +53,38 @@ const (
 )
 
 // SetupAKSCluster adds a controller that reconciles AKSClusters.
-func SetupAKSCluster(mgr ctrl.Manager, l logging.Logger) error {
+func SetupAKSCluster(mgr ctrl.Manager, l logging.Logger, o reconciler.Options) error {
 	name := managed.ControllerName(v1alpha3.AKSClusterGroupKind)
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
+	errs := reconciler.NewErrorHandler(recorder)
+
+	events, err := eventgrid.Watch(mgr, &v1alpha3.AKSCluster{}, &v1alpha3.AKSClusterList{}, "Microsoft.ContainerService/managedClusters", azureResourceID)
+	if err != nil {
+		return err
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
+		WithOptions(o.Controller(v1alpha3.AKSClusterGroupKind)).
 		For(&v1alpha3.AKSCluster{}).
-		Complete(managed.NewReconciler(mgr,
+		Watches(events, &handler.EnqueueRequestForObject{}).
+		Complete(o.Jittered(errs.Reconciler(managed.NewReconciler(mgr,
 			resource.ManagedKind(v1alpha3.AKSClusterGroupVersionKind),
-			managed.WithExternalConnecter(&connecter{client: mgr.GetClient()}),
+			managed.WithExternalConnecter(errs.Connecter(reconciler.Traced(resource.ManagedKind(v1alpha3.AKSClusterGroupVersionKind), o.DryRun(v1alpha3.AKSClusterGroupKind, recorder, reconciler.ObserveOnly(reconciler.ProtectDeletion(&connecter{client: mgr.GetClient()})))))),
 			managed.WithReferenceResolver(managed.NewAPISimpleReferenceResolver(mgr.GetClient())),
+			managed.WithLongWait(o.Poll(v1alpha3.AKSClusterGroupKind)),
 			managed.WithLogger(l.WithValues("controller", name)),
-			managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+			managed.WithRecorder(recorder)))))
+}
+
+// azureResourceID returns the ID of the Azure AKS cluster that the supplied
+// managed resource manages.
+func azureResourceID(mg resource.Managed) string {
+	cr, ok := mg.(*v1alpha3.AKSCluster)
+	if !ok {
+		return ""
+	}
+	return azure.ResourceID(cr.Spec.ResourceGroupName, "Microsoft.ContainerService/managedClusters", meta.GetExternalName(cr))
 }
 
 type connecter struct {
@@ -68,11 +92,11 @@ type connecter struct {
 }
 
 func (c *connecter) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
-	creds, auth, err := azure.GetAuthInfo(ctx, c.client, mg)
+	i, err := azure.LoadAuthInfo(ctx, c.client, mg)
 	if err != nil {
 		return nil, err
 	}
-	cl, err := compute.NewAggregateClient(creds, auth)
+	cl, err := compute.NewAggregateClient(i)
 	if err != nil {
 		return nil, err
 	}
@@ -92,12 +116,17 @@ func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 	}
 
 	c, err := e.client.GetManagedCluster(ctx, cr)
-	if azure.IsNotFound(err) {
-		return managed.ExternalObservation{ResourceExists: false}, nil
-	}
-	if err != nil {
+	if err != nil && !azure.IsNotFound(err) {
 		return managed.ExternalObservation{}, errors.Wrap(err, errGetAKSCluster)
 	}
+	if err := azure.UpdateLastOperation(ctx, e.client.GetRESTClient(), cr, &cr.Status.LastOperation); err != nil {
+		return managed.ExternalObservation{}, err
+	}
+	if azure.IsNotFound(err) {
+		// The AKS cluster may not be found until its creation completes.
+		creating := azure.AsyncOperationInProgress(cr.Status.LastOperation, http.MethodPut)
+		return managed.ExternalObservation{ResourceExists: creating, ResourceUpToDate: creating}, nil
+	}
 
 	cr.Status.ProviderID = to.String(c.ID)
 	cr.Status.State = to.String(c.ProvisioningState)
@@ -153,6 +182,9 @@ func (e *external) Delete(ctx context.Context, mg resource.Managed) error {
 		return errors.New(errNotAKSCluster)
 	}
 	cr.SetConditions(runtimev1alpha1.Deleting())
+	if azure.AsyncOperationInProgress(cr.Status.LastOperation, http.MethodDelete) {
+		return nil
+	}
 	return errors.Wrap(e.client.DeleteManagedCluster(ctx, cr), errDeleteAKSCluster)
 }
 
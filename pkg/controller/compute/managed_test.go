@@ -99,6 +99,7 @@ func TestObserve(t *testing.T) {
 		"ErrClusterNotFound": {
 			e: &external{
 				client: fake.AKSClient{
+					MockGetRESTClient: func() autorest.Sender { return nil },
 					MockGetManagedCluster: func(_ context.Context, _ *v1alpha3.AKSCluster) (containerservice.ManagedCluster, error) {
 						return containerservice.ManagedCluster{}, autorest.DetailedError{StatusCode: http.StatusNotFound}
 					},
@@ -133,6 +134,7 @@ func TestObserve(t *testing.T) {
 		"NotReady": {
 			e: &external{
 				client: fake.AKSClient{
+					MockGetRESTClient: func() autorest.Sender { return nil },
 					MockGetManagedCluster: func(_ context.Context, _ *v1alpha3.AKSCluster) (containerservice.ManagedCluster, error) {
 						return containerservice.ManagedCluster{
 							ID: to.StringPtr(id),
@@ -160,6 +162,7 @@ func TestObserve(t *testing.T) {
 		"ErrGetKubeConfig": {
 			e: &external{
 				client: fake.AKSClient{
+					MockGetRESTClient: func() autorest.Sender { return nil },
 					MockGetManagedCluster: func(_ context.Context, _ *v1alpha3.AKSCluster) (containerservice.ManagedCluster, error) {
 						return containerservice.ManagedCluster{ManagedClusterProperties: &containerservice.ManagedClusterProperties{
 							ProvisioningState: to.StringPtr(stateSucceeded),
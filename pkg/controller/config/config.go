@@ -26,11 +26,12 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 
 	"github.com/crossplane/provider-azure/apis/v1beta1"
+	"github.com/crossplane/provider-azure/pkg/reconciler"
 )
 
 // Setup adds a controller that reconciles ProviderConfigs by accounting for
-// their current usage.
-func Setup(mgr ctrl.Manager, l logging.Logger) error {
+// their current usage and periodically checking their credentials.
+func Setup(mgr ctrl.Manager, l logging.Logger, o reconciler.Options) error {
 	name := providerconfig.ControllerName(v1beta1.ProviderConfigGroupKind)
 
 	of := resource.ProviderConfigKinds{
@@ -40,9 +41,13 @@ func Setup(mgr ctrl.Manager, l logging.Logger) error {
 
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
+		WithOptions(o.Controller(v1beta1.ProviderConfigGroupKind)).
 		For(&v1beta1.ProviderConfig{}).
 		Watches(&source.Kind{Type: &v1beta1.ProviderConfigUsage{}}, &resource.EnqueueRequestForProviderConfig{}).
-		Complete(providerconfig.NewReconciler(mgr, of,
-			providerconfig.WithLogger(l.WithValues("controller", name)),
-			providerconfig.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+		Complete(NewHealthReconciler(mgr.GetClient(),
+			providerconfig.NewReconciler(mgr, of,
+				providerconfig.WithLogger(l.WithValues("controller", name)),
+				providerconfig.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))),
+			WithLogger(l.WithValues("controller", name)),
+			WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
 }
@@ -0,0 +1,251 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	"github.com/crossplane/provider-azure/apis/v1beta1"
+	azure "github.com/crossplane/provider-azure/pkg/clients"
+)
+
+const (
+	checkTimeout = 1 * time.Minute
+
+	// DefaultCheckInterval is the interval at which the credentials of a
+	// ProviderConfig are checked.
+	DefaultCheckInterval = 5 * time.Minute
+)
+
+// Condition reasons.
+const (
+	ReasonCredentialsValid   runtimev1alpha1.ConditionReason = "CredentialsValid"
+	ReasonCredentialsInvalid runtimev1alpha1.ConditionReason = "CredentialsInvalid"
+)
+
+const (
+	errGetPC        = "cannot get ProviderConfig"
+	errUpdateStatus = "cannot update ProviderConfig status"
+
+	reasonCheck event.Reason = "CheckCredentials"
+)
+
+// CredentialsValid returns a condition indicating that the credentials of a
+// ProviderConfig can be used to connect to Azure.
+func CredentialsValid() runtimev1alpha1.Condition {
+	return runtimev1alpha1.Condition{
+		Type:               runtimev1alpha1.TypeReady,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonCredentialsValid,
+	}
+}
+
+// CredentialsInvalid returns a condition indicating that the credentials of a
+// ProviderConfig cannot be used to connect to Azure. The message starts with
+// the Azure AD or Azure Resource Manager error code, if any.
+func CredentialsInvalid(err error) runtimev1alpha1.Condition {
+	msg := err.Error()
+	if code := azure.ErrorCode(err); code != "" {
+		msg = code + ": " + msg
+	}
+	return runtimev1alpha1.Condition{
+		Type:               runtimev1alpha1.TypeReady,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonCredentialsInvalid,
+		Message:            msg,
+	}
+}
+
+// A CredentialsCheckFn checks whether the credentials of the supplied
+// ProviderConfig can be used to connect to Azure.
+type CredentialsCheckFn func(ctx context.Context, pc *v1beta1.ProviderConfig) error
+
+// CheckCredentials returns a CredentialsCheckFn that acquires an Azure AD token
+// and makes a cheap Azure Resource Manager API call using the credentials of a
+// ProviderConfig.
+func CheckCredentials(c client.Client) CredentialsCheckFn {
+	return func(ctx context.Context, pc *v1beta1.ProviderConfig) error {
+		i, err := azure.ProviderConfigAuthInfo(ctx, c, pc)
+		if err != nil {
+			return err
+		}
+		return azure.ValidateAuthInfo(ctx, i)
+	}
+}
+
+// A credentialsCheck records when the credentials of a ProviderConfig were
+// last checked, and at which generation of the ProviderConfig.
+type credentialsCheck struct {
+	at         time.Time
+	generation int64
+}
+
+// A HealthReconciler wraps a ProviderConfig reconciler, periodically checking
+// the credentials of the ProviderConfig after it has been reconciled and
+// reporting the result as its Ready condition. The credentials are checked at
+// most once per interval, no matter how often the ProviderConfig is
+// reconciled, unless the ProviderConfig changes.
+type HealthReconciler struct {
+	reconcile.Reconciler
+
+	client   client.Client
+	check    CredentialsCheckFn
+	interval time.Duration
+	log      logging.Logger
+	record   event.Recorder
+
+	mu      sync.Mutex
+	checked map[types.NamespacedName]credentialsCheck
+}
+
+// A HealthReconcilerOption configures a HealthReconciler.
+type HealthReconcilerOption func(*HealthReconciler)
+
+// WithCredentialsCheck specifies how the HealthReconciler should check the
+// credentials of a ProviderConfig.
+func WithCredentialsCheck(fn CredentialsCheckFn) HealthReconcilerOption {
+	return func(r *HealthReconciler) {
+		r.check = fn
+	}
+}
+
+// WithCheckInterval specifies how often the HealthReconciler should check the
+// credentials of a ProviderConfig.
+func WithCheckInterval(d time.Duration) HealthReconcilerOption {
+	return func(r *HealthReconciler) {
+		r.interval = d
+	}
+}
+
+// WithLogger specifies how the HealthReconciler should log messages.
+func WithLogger(l logging.Logger) HealthReconcilerOption {
+	return func(r *HealthReconciler) {
+		r.log = l
+	}
+}
+
+// WithRecorder specifies how the HealthReconciler should record events.
+func WithRecorder(er event.Recorder) HealthReconcilerOption {
+	return func(r *HealthReconciler) {
+		r.record = er
+	}
+}
+
+// NewHealthReconciler returns a HealthReconciler that wraps the supplied
+// ProviderConfig reconciler.
+func NewHealthReconciler(c client.Client, wrapped reconcile.Reconciler, o ...HealthReconcilerOption) *HealthReconciler {
+	r := &HealthReconciler{
+		Reconciler: wrapped,
+		client:     c,
+		check:      CheckCredentials(c),
+		interval:   DefaultCheckInterval,
+		log:        logging.NewNopLogger(),
+		record:     event.NewNopRecorder(),
+		checked:    map[types.NamespacedName]credentialsCheck{},
+	}
+	for _, ro := range o {
+		ro(r)
+	}
+	return r
+}
+
+// Reconcile a ProviderConfig, then check its credentials.
+func (r *HealthReconciler) Reconcile(req reconcile.Request) (reconcile.Result, error) {
+	result, err := r.Reconciler.Reconcile(req)
+	if err != nil || result.Requeue || result.RequeueAfter > 0 {
+		return result, err
+	}
+
+	log := r.log.WithValues("request", req)
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+
+	pc := &v1beta1.ProviderConfig{}
+	if err := r.client.Get(ctx, req.NamespacedName, pc); err != nil {
+		log.Debug(errGetPC, "error", err)
+		if resource.IgnoreNotFound(err) == nil {
+			r.forget(req.NamespacedName)
+		}
+		return reconcile.Result{}, errors.Wrap(resource.IgnoreNotFound(err), errGetPC)
+	}
+	if meta.WasDeleted(pc) {
+		r.forget(req.NamespacedName)
+		return reconcile.Result{}, nil
+	}
+
+	if due := r.due(req.NamespacedName, pc); due > 0 {
+		return reconcile.Result{RequeueAfter: due}, nil
+	}
+
+	if err := r.check(ctx, pc); err != nil {
+		log.Debug("ProviderConfig credentials are invalid", "error", err)
+		r.record.Event(pc, event.Warning(reasonCheck, err))
+		pc.SetConditions(CredentialsInvalid(err))
+	} else {
+		pc.SetConditions(CredentialsValid())
+	}
+
+	if err := r.client.Status().Update(ctx, pc); err != nil {
+		return reconcile.Result{RequeueAfter: r.interval}, errors.Wrap(err, errUpdateStatus)
+	}
+	r.mu.Lock()
+	r.checked[req.NamespacedName] = credentialsCheck{at: time.Now(), generation: pc.GetGeneration()}
+	r.mu.Unlock()
+	return reconcile.Result{RequeueAfter: r.interval}, nil
+}
+
+// due returns how long until the credentials of the supplied ProviderConfig
+// are next due to be checked, or zero if they are due now. They are due if
+// they were never checked, if the ProviderConfig changed or lost its Ready
+// condition since, or if they were checked more than an interval ago.
+func (r *HealthReconciler) due(nn types.NamespacedName, pc *v1beta1.ProviderConfig) time.Duration {
+	r.mu.Lock()
+	c, ok := r.checked[nn]
+	r.mu.Unlock()
+	if !ok || c.generation != pc.GetGeneration() || pc.GetCondition(runtimev1alpha1.TypeReady).Status == corev1.ConditionUnknown {
+		return 0
+	}
+	if d := r.interval - time.Since(c.at); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// forget when the credentials of the supplied ProviderConfig were checked.
+func (r *HealthReconciler) forget(nn types.NamespacedName) {
+	r.mu.Lock()
+	delete(r.checked, nn)
+	r.mu.Unlock()
+}
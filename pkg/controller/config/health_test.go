@@ -0,0 +1,233 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/provider-azure/apis/v1beta1"
+)
+
+func TestHealthReconcile(t *testing.T) {
+	errBoom := errors.New("boom")
+	errAAD := errors.New("adal: Refresh request failed. Status Code = '401'. Response body: AADSTS7000215: Invalid client secret provided.")
+	now := metav1.Now()
+
+	// Requeues are relative to when the credentials were last checked, which
+	// is slightly before the reconcile.
+	equateApproxDuration := cmp.Comparer(func(a, b time.Duration) bool {
+		return a-b < time.Second && b-a < time.Second
+	})
+
+	done := reconcile.Func(func(reconcile.Request) (reconcile.Result, error) { return reconcile.Result{}, nil })
+
+	ready := func(o runtime.Object) error {
+		o.(*v1beta1.ProviderConfig).SetConditions(CredentialsValid())
+		return nil
+	}
+	mustNotCheck := func(context.Context, *v1beta1.ProviderConfig) error {
+		t.Errorf("r.Reconcile(...): credentials were checked before they were due")
+		return nil
+	}
+
+	type args struct {
+		wrapped reconcile.Reconciler
+		client  client.Client
+		check   CredentialsCheckFn
+		checked *credentialsCheck
+	}
+	type want struct {
+		result     reconcile.Result
+		err        error
+		conditions []runtimev1alpha1.Condition
+	}
+	cases := map[string]struct {
+		args args
+		want want
+	}{
+		"WrappedError": {
+			args: args{
+				wrapped: reconcile.Func(func(reconcile.Request) (reconcile.Result, error) { return reconcile.Result{}, errBoom }),
+			},
+			want: want{err: errBoom},
+		},
+		"WrappedRequeue": {
+			args: args{
+				wrapped: reconcile.Func(func(reconcile.Request) (reconcile.Result, error) {
+					return reconcile.Result{RequeueAfter: 30 * time.Second}, nil
+				}),
+			},
+			want: want{result: reconcile.Result{RequeueAfter: 30 * time.Second}},
+		},
+		"GetError": {
+			args: args{
+				wrapped: done,
+				client:  &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+			},
+			want: want{err: errors.Wrap(errBoom, errGetPC)},
+		},
+		"Deleted": {
+			args: args{
+				wrapped: done,
+				client: &test.MockClient{MockGet: test.NewMockGetFn(nil, func(o runtime.Object) error {
+					o.(*v1beta1.ProviderConfig).SetDeletionTimestamp(&now)
+					return nil
+				})},
+			},
+			want: want{},
+		},
+		"CredentialsValid": {
+			args: args{
+				wrapped: done,
+				client: &test.MockClient{
+					MockGet:          test.NewMockGetFn(nil),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+				},
+				check: func(context.Context, *v1beta1.ProviderConfig) error { return nil },
+			},
+			want: want{
+				result:     reconcile.Result{RequeueAfter: DefaultCheckInterval},
+				conditions: []runtimev1alpha1.Condition{CredentialsValid()},
+			},
+		},
+		"CredentialsInvalid": {
+			args: args{
+				wrapped: done,
+				client: &test.MockClient{
+					MockGet:          test.NewMockGetFn(nil),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+				},
+				check: func(context.Context, *v1beta1.ProviderConfig) error { return errAAD },
+			},
+			want: want{
+				result: reconcile.Result{RequeueAfter: DefaultCheckInterval},
+				conditions: []runtimev1alpha1.Condition{
+					CredentialsInvalid(errAAD),
+				},
+			},
+		},
+		"CheckNotDue": {
+			args: args{
+				wrapped: done,
+				client:  &test.MockClient{MockGet: test.NewMockGetFn(nil, ready)},
+				check:   mustNotCheck,
+				checked: &credentialsCheck{at: time.Now().Add(-time.Minute)},
+			},
+			want: want{result: reconcile.Result{RequeueAfter: DefaultCheckInterval - time.Minute}},
+		},
+		"CheckDue": {
+			args: args{
+				wrapped: done,
+				client: &test.MockClient{
+					MockGet:          test.NewMockGetFn(nil, ready),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+				},
+				check:   func(context.Context, *v1beta1.ProviderConfig) error { return errAAD },
+				checked: &credentialsCheck{at: time.Now().Add(-DefaultCheckInterval)},
+			},
+			want: want{
+				result:     reconcile.Result{RequeueAfter: DefaultCheckInterval},
+				conditions: []runtimev1alpha1.Condition{CredentialsInvalid(errAAD)},
+			},
+		},
+		"ProviderConfigChanged": {
+			args: args{
+				wrapped: done,
+				client: &test.MockClient{
+					MockGet: test.NewMockGetFn(nil, func(o runtime.Object) error {
+						o.(*v1beta1.ProviderConfig).SetGeneration(2)
+						return ready(o)
+					}),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+				},
+				check:   func(context.Context, *v1beta1.ProviderConfig) error { return errAAD },
+				checked: &credentialsCheck{at: time.Now(), generation: 1},
+			},
+			want: want{
+				result:     reconcile.Result{RequeueAfter: DefaultCheckInterval},
+				conditions: []runtimev1alpha1.Condition{CredentialsInvalid(errAAD)},
+			},
+		},
+		"StatusUpdateError": {
+			args: args{
+				wrapped: done,
+				client: &test.MockClient{
+					MockGet:          test.NewMockGetFn(nil),
+					MockStatusUpdate: test.NewMockStatusUpdateFn(errBoom),
+				},
+				check: func(context.Context, *v1beta1.ProviderConfig) error { return nil },
+			},
+			want: want{
+				result: reconcile.Result{RequeueAfter: DefaultCheckInterval},
+				err:    errors.Wrap(errBoom, errUpdateStatus),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var conditions []runtimev1alpha1.Condition
+			if mc, ok := tc.args.client.(*test.MockClient); ok && mc.MockStatusUpdate != nil {
+				update := mc.MockStatusUpdate
+				mc.MockStatusUpdate = func(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+					conditions = obj.(*v1beta1.ProviderConfig).Status.Conditions
+					return update(ctx, obj, opts...)
+				}
+			}
+
+			r := NewHealthReconciler(tc.args.client, tc.args.wrapped, WithCredentialsCheck(tc.args.check))
+			if tc.args.checked != nil {
+				r.checked[reconcile.Request{}.NamespacedName] = *tc.args.checked
+			}
+			got, err := r.Reconcile(reconcile.Request{})
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("r.Reconcile(...): -want error, +got error:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.result, got, equateApproxDuration); diff != "" {
+				t.Errorf("r.Reconcile(...): -want, +got:\n%s", diff)
+			}
+			if tc.want.conditions == nil {
+				return
+			}
+			if diff := cmp.Diff(tc.want.conditions, conditions, cmpopts.IgnoreFields(runtimev1alpha1.Condition{}, "LastTransitionTime")); diff != "" {
+				t.Errorf("r.Reconcile(...): -want conditions, +got conditions:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCredentialsInvalid(t *testing.T) {
+	err := errors.New("adal: Refresh request failed. Response body: AADSTS700016: Application not found.")
+	got := CredentialsInvalid(err)
+	want := "AADSTS700016: " + err.Error()
+	if diff := cmp.Diff(want, got.Message); diff != "" {
+		t.Errorf("CredentialsInvalid(...): -want message, +got message:\n%s", diff)
+	}
+}
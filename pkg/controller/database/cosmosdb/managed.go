@@ -21,9 +21,11 @@ import (
 	"net/http"
 
 	"github.com/Azure/azure-sdk-for-go/services/cosmos-db/mgmt/2015-04-08/documentdb"
+	"github.com/Azure/go-autorest/autorest"
 	"github.com/pkg/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
@@ -35,6 +37,8 @@ import (
 	"github.com/crossplane/provider-azure/apis/database/v1alpha3"
 	azure "github.com/crossplane/provider-azure/pkg/clients"
 	"github.com/crossplane/provider-azure/pkg/clients/database/cosmosdb"
+	"github.com/crossplane/provider-azure/pkg/eventgrid"
+	"github.com/crossplane/provider-azure/pkg/reconciler"
 )
 
 // Error strings
@@ -46,19 +50,39 @@ const (
 )
 
 // Setup adds a controller that reconciles NoSQLAccount.
-func Setup(mgr ctrl.Manager, l logging.Logger) error {
+func Setup(mgr ctrl.Manager, l logging.Logger, o reconciler.Options) error {
 	name := managed.ControllerName(v1alpha3.CosmosDBAccountGroupKind)
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
+	errs := reconciler.NewErrorHandler(recorder)
+
+	events, err := eventgrid.Watch(mgr, &v1alpha3.CosmosDBAccount{}, &v1alpha3.CosmosDBAccountList{}, "Microsoft.DocumentDB/databaseAccounts", azureResourceID)
+	if err != nil {
+		return err
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
+		WithOptions(o.Controller(v1alpha3.CosmosDBAccountGroupKind)).
 		For(&v1alpha3.CosmosDBAccount{}).
-		Complete(managed.NewReconciler(mgr,
+		Watches(events, &handler.EnqueueRequestForObject{}).
+		Complete(o.Jittered(errs.Reconciler(managed.NewReconciler(mgr,
 			resource.ManagedKind(v1alpha3.CosmosDBAccountGroupVersionKind),
 			managed.WithConnectionPublishers(),
-			managed.WithExternalConnecter(&connecter{kube: mgr.GetClient()}),
+			managed.WithExternalConnecter(errs.Connecter(reconciler.Traced(resource.ManagedKind(v1alpha3.CosmosDBAccountGroupVersionKind), o.DryRun(v1alpha3.CosmosDBAccountGroupKind, recorder, reconciler.RecordDrift(recorder, reconciler.ObserveOnly(reconciler.ProtectDeletion(&connecter{kube: mgr.GetClient()}))))))),
 			managed.WithReferenceResolver(managed.NewAPISimpleReferenceResolver(mgr.GetClient())),
+			managed.WithLongWait(o.Poll(v1alpha3.CosmosDBAccountGroupKind)),
 			managed.WithLogger(l.WithValues("controller", name)),
-			managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+			managed.WithRecorder(recorder)))))
+}
+
+// azureResourceID returns the ID of the Azure CosmosDB account that the
+// supplied managed resource manages.
+func azureResourceID(mg resource.Managed) string {
+	cr, ok := mg.(*v1alpha3.CosmosDBAccount)
+	if !ok {
+		return ""
+	}
+	return azure.ResourceID(cr.Spec.ForProvider.ResourceGroupName, "Microsoft.DocumentDB/databaseAccounts", meta.GetExternalName(cr))
 }
 
 type connecter struct {
@@ -70,15 +94,17 @@ func (c *connecter) Connect(ctx context.Context, mg resource.Managed) (managed.E
 	if err != nil {
 		return nil, err
 	}
-	cl := documentdb.NewDatabaseAccountsClient(creds[azure.CredentialsKeySubscriptionID])
-	cl.Authorizer = auth
-	return &external{kube: c.kube, client: cl}, nil
+	cl := documentdb.NewDatabaseAccountsClientWithBaseURI(creds[azure.CredentialsKeyResourceManagerEndpointURL], creds[azure.CredentialsKeySubscriptionID])
+	azure.ConfigureClient(&cl.Client, auth)
+	return &external{kube: c.kube, client: cl, sender: cl.Client, graph: azure.NewResourceGraphReader(creds, auth)}, nil
 }
 
 // external is a createsyncdeleter using the Azure API.
 type external struct {
 	kube   client.Client
 	client cosmosdb.AccountClient
+	sender autorest.Sender
+	graph  *azure.ResourceGraphReader
 }
 
 func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
@@ -87,17 +113,31 @@ func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 		return managed.ExternalObservation{}, errors.New(errNotNoSQLAccount)
 	}
 
-	res, err := e.client.CheckNameExists(ctx, meta.GetExternalName(r))
-	if res.IsHTTPStatus(http.StatusNotFound) {
-		return managed.ExternalObservation{ResourceExists: false}, nil
+	account := documentdb.DatabaseAccount{}
+	cached := e.graph.Get(azureResourceID(r), &account)
+	res := autorest.Response{}
+	if !cached {
+		var err error
+		res, err = e.client.CheckNameExists(ctx, meta.GetExternalName(r))
+		if err != nil && !res.IsHTTPStatus(http.StatusNotFound) {
+			return managed.ExternalObservation{}, errors.Wrap(err, errGetNoSQLAccount)
+		}
 	}
-	if err != nil {
-		return managed.ExternalObservation{}, errors.Wrap(err, errGetNoSQLAccount)
+	if err := azure.UpdateLastOperation(ctx, e.sender, r, &r.Status.LastOperation); err != nil {
+		return managed.ExternalObservation{}, err
+	}
+	if res.IsHTTPStatus(http.StatusNotFound) {
+		// The Database Account may not be found until its creation completes.
+		creating := azure.AsyncOperationInProgress(r.Status.LastOperation, http.MethodPut)
+		return managed.ExternalObservation{ResourceExists: creating, ResourceUpToDate: creating}, nil
 	}
 
-	account, err := e.client.Get(ctx, r.Spec.ForProvider.ResourceGroupName, meta.GetExternalName(r))
-	if err != nil {
-		return managed.ExternalObservation{}, errors.Wrap(err, errGetNoSQLAccount)
+	if !cached {
+		var err error
+		account, err = e.client.Get(ctx, r.Spec.ForProvider.ResourceGroupName, meta.GetExternalName(r))
+		if err != nil {
+			return managed.ExternalObservation{}, errors.Wrap(err, errGetNoSQLAccount)
+		}
 	}
 	cosmosdb.UpdateCosmosDBAccountObservation(&r.Status, account)
 
@@ -107,7 +147,7 @@ func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 	default:
 		r.SetConditions(runtimev1alpha1.Unavailable())
 	}
-	resourceUpToDate := cosmosdb.CheckEqualDatabaseProperties(r.Spec.ForProvider.Properties, account)
+	resourceUpToDate := azure.SetDrift(r, cosmosdb.DatabasePropertiesDiff(r.Spec.ForProvider.Properties, account))
 	return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: resourceUpToDate}, nil
 }
 
@@ -118,15 +158,27 @@ func (e *external) Create(ctx context.Context, mg resource.Managed) (managed.Ext
 	}
 
 	r.Status.SetConditions(runtimev1alpha1.Creating())
-	_, err := e.client.CreateOrUpdate(ctx,
+	f, err := e.client.CreateOrUpdate(ctx,
 		r.Spec.ForProvider.ResourceGroupName,
 		meta.GetExternalName(r),
 		cosmosdb.ToDatabaseAccountCreateOrUpdate(&r.Spec))
+	if err != nil {
+		return managed.ExternalCreation{}, errors.Wrap(err, errCreateNoSQLAccount)
+	}
+	r.Status.LastOperation = azure.NewAsyncOperation(http.MethodPut, f.Future)
 	// TODO(artursouza): handle secrets.
-	return managed.ExternalCreation{}, errors.Wrap(err, errCreateNoSQLAccount)
+	return managed.ExternalCreation{}, nil
 }
 
 func (e *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	r, ok := mg.(*v1alpha3.CosmosDBAccount)
+	if !ok {
+		return managed.ExternalUpdate{}, errors.New(errNotNoSQLAccount)
+	}
+	// Database Accounts reject updates while another operation is ongoing.
+	if r.Status.LastOperation.Status == azure.AsyncOperationStatusInProgress {
+		return managed.ExternalUpdate{}, nil
+	}
 	_, err := e.Create(ctx, mg)
 	return managed.ExternalUpdate{}, err
 }
@@ -138,6 +190,13 @@ func (e *external) Delete(ctx context.Context, mg resource.Managed) error {
 	}
 
 	r.Status.SetConditions(runtimev1alpha1.Deleting())
-	_, err := e.client.Delete(ctx, r.Spec.ForProvider.ResourceGroupName, meta.GetExternalName(r))
-	return errors.Wrap(err, errDeleteNoSQLAccount)
+	if azure.AsyncOperationInProgress(r.Status.LastOperation, http.MethodDelete) {
+		return nil
+	}
+	f, err := e.client.Delete(ctx, r.Spec.ForProvider.ResourceGroupName, meta.GetExternalName(r))
+	if err != nil {
+		return errors.Wrap(err, errDeleteNoSQLAccount)
+	}
+	r.Status.LastOperation = azure.NewAsyncOperation(http.MethodDelete, f.Future)
+	return nil
 }
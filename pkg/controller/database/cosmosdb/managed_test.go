@@ -234,7 +234,7 @@ func TestObserve(t *testing.T) {
 					ResourceUpToDate: true,
 				},
 				mg: cosmosDBAccount(
-					withConditions(runtimev1alpha1.Available())),
+					withConditions(runtimev1alpha1.Available(), azure.UpToDate())),
 			},
 		},
 	}
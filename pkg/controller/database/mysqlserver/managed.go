@@ -19,12 +19,14 @@ package mysqlserver
 import (
 	"context"
 	"fmt"
+	"net/http"
 
 	"github.com/Azure/azure-sdk-for-go/services/mysql/mgmt/2017-12-01/mysql"
 
 	"github.com/pkg/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
@@ -37,33 +39,54 @@ import (
 	"github.com/crossplane/provider-azure/apis/database/v1beta1"
 	azure "github.com/crossplane/provider-azure/pkg/clients"
 	"github.com/crossplane/provider-azure/pkg/clients/database"
+	"github.com/crossplane/provider-azure/pkg/eventgrid"
+	"github.com/crossplane/provider-azure/pkg/reconciler"
 )
 
 // Error strings.
 const (
-	errUpdateCR           = "cannot update MySQLServer custom resource"
-	errGenPassword        = "cannot generate admin password"
-	errNotMySQLServer     = "managed resource is not a MySQLServer"
-	errCreateMySQLServer  = "cannot create MySQLServer"
-	errUpdateMySQLServer  = "cannot update MySQLServer"
-	errGetMySQLServer     = "cannot get MySQLServer"
-	errDeleteMySQLServer  = "cannot delete MySQLServer"
-	errFetchLastOperation = "cannot fetch last operation"
+	errUpdateCR          = "cannot update MySQLServer custom resource"
+	errGenPassword       = "cannot generate admin password"
+	errNotMySQLServer    = "managed resource is not a MySQLServer"
+	errCreateMySQLServer = "cannot create MySQLServer"
+	errUpdateMySQLServer = "cannot update MySQLServer"
+	errGetMySQLServer    = "cannot get MySQLServer"
+	errDeleteMySQLServer = "cannot delete MySQLServer"
 )
 
 // Setup adds a controller that reconciles MySQLServers.
-func Setup(mgr ctrl.Manager, l logging.Logger) error {
+func Setup(mgr ctrl.Manager, l logging.Logger, o reconciler.Options) error {
 	name := managed.ControllerName(v1beta1.MySQLServerGroupKind)
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
+	errs := reconciler.NewErrorHandler(recorder)
+
+	events, err := eventgrid.Watch(mgr, &v1beta1.MySQLServer{}, &v1beta1.MySQLServerList{}, "Microsoft.DBforMySQL/servers", azureResourceID)
+	if err != nil {
+		return err
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
+		WithOptions(o.Controller(v1beta1.MySQLServerGroupKind)).
 		For(&v1beta1.MySQLServer{}).
-		Complete(managed.NewReconciler(mgr,
+		Watches(events, &handler.EnqueueRequestForObject{}).
+		Complete(o.Jittered(errs.Reconciler(managed.NewReconciler(mgr,
 			resource.ManagedKind(v1beta1.MySQLServerGroupVersionKind),
-			managed.WithExternalConnecter(&connecter{client: mgr.GetClient()}),
+			managed.WithExternalConnecter(errs.Connecter(reconciler.Traced(resource.ManagedKind(v1beta1.MySQLServerGroupVersionKind), o.DryRun(v1beta1.MySQLServerGroupKind, recorder, reconciler.RecordDrift(recorder, reconciler.ObserveOnly(reconciler.ProtectDeletion(&connecter{client: mgr.GetClient()}))))))),
 			managed.WithReferenceResolver(managed.NewAPISimpleReferenceResolver(mgr.GetClient())),
+			managed.WithLongWait(o.Poll(v1beta1.MySQLServerGroupKind)),
 			managed.WithLogger(l.WithValues("controller", name)),
-			managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+			managed.WithRecorder(recorder)))))
+}
+
+// azureResourceID returns the ID of the Azure MySQL server that the supplied
+// managed resource manages.
+func azureResourceID(mg resource.Managed) string {
+	cr, ok := mg.(*v1beta1.MySQLServer)
+	if !ok {
+		return ""
+	}
+	return azure.ResourceID(cr.Spec.ForProvider.ResourceGroupName, "Microsoft.DBforMySQL/servers", meta.GetExternalName(cr))
 }
 
 type connecter struct {
@@ -75,15 +98,16 @@ func (c *connecter) Connect(ctx context.Context, mg resource.Managed) (managed.E
 	if err != nil {
 		return nil, err
 	}
-	cl := mysql.NewServersClient(creds[azure.CredentialsKeySubscriptionID])
-	cl.Authorizer = auth
-	return &external{kube: c.client, client: database.NewMySQLServerClient(cl), newPasswordFn: password.Generate}, nil
+	cl := mysql.NewServersClientWithBaseURI(creds[azure.CredentialsKeyResourceManagerEndpointURL], creds[azure.CredentialsKeySubscriptionID])
+	azure.ConfigureClient(&cl.Client, auth)
+	return &external{kube: c.client, client: database.NewMySQLServerClient(cl), newPasswordFn: password.Generate, graph: azure.NewResourceGraphReader(creds, auth)}, nil
 }
 
 type external struct {
 	kube          client.Client
 	client        database.MySQLServerAPI
 	newPasswordFn func() (password string, err error)
+	graph         *azure.ResourceGraphReader
 }
 
 func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
@@ -92,17 +116,20 @@ func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 		return managed.ExternalObservation{}, errors.New(errNotMySQLServer)
 	}
 
-	server, err := e.client.GetServer(ctx, cr)
+	server := mysql.Server{}
+	var err error
+	if !e.graph.Get(azureResourceID(cr), &server) {
+		server, err = e.client.GetServer(ctx, cr)
+	}
 	if azure.IsNotFound(err) {
-		if err := azure.FetchAsyncOperation(ctx, e.client.GetRESTClient(), &cr.Status.AtProvider.LastOperation); err != nil {
-			return managed.ExternalObservation{}, errors.Wrap(err, errFetchLastOperation)
+		if err := azure.UpdateLastOperation(ctx, e.client.GetRESTClient(), cr, &cr.Status.AtProvider.LastOperation); err != nil {
+			return managed.ExternalObservation{}, err
 		}
 		// Azure returns NotFound for GET calls until creation is completed
 		// successfully and we cannot return `ResourceExists: false` during creation
 		// since this will cause `Create` to be called again and it's not idempotent.
 		// So, we check whether a creation operation in fact is in motion.
-		creating := cr.Status.AtProvider.LastOperation.Method == "PUT" &&
-			cr.Status.AtProvider.LastOperation.Status == azure.AsyncOperationStatusInProgress
+		creating := azure.AsyncOperationInProgress(cr.Status.AtProvider.LastOperation, http.MethodPut)
 		return managed.ExternalObservation{ResourceExists: creating}, nil
 	}
 	if err != nil {
@@ -117,8 +144,8 @@ func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 	// status subresource but fetches the the whole object after it's done. So,
 	// changes to status has to be done after kube.Update in order not to get them
 	// lost.
-	if err := azure.FetchAsyncOperation(ctx, e.client.GetRESTClient(), &cr.Status.AtProvider.LastOperation); err != nil {
-		return managed.ExternalObservation{}, errors.Wrap(err, errFetchLastOperation)
+	if err := azure.UpdateLastOperation(ctx, e.client.GetRESTClient(), cr, &cr.Status.AtProvider.LastOperation); err != nil {
+		return managed.ExternalObservation{}, err
 	}
 	switch cr.Status.AtProvider.UserVisibleState {
 	case v1beta1.StateReady:
@@ -129,7 +156,7 @@ func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 
 	return managed.ExternalObservation{
 		ResourceExists:   true,
-		ResourceUpToDate: database.IsMySQLUpToDate(cr.Spec.ForProvider, server),
+		ResourceUpToDate: azure.SetDrift(cr, database.MySQLDiff(cr.Spec.ForProvider, server)),
 		ConnectionDetails: managed.ConnectionDetails{
 			runtimev1alpha1.ResourceCredentialsSecretEndpointKey: []byte(cr.Status.AtProvider.FullyQualifiedDomainName),
 			runtimev1alpha1.ResourceCredentialsSecretUserKey:     []byte(fmt.Sprintf("%s@%s", cr.Spec.ForProvider.AdministratorLogin, meta.GetExternalName(cr))),
@@ -153,12 +180,10 @@ func (e *external) Create(ctx context.Context, mg resource.Managed) (managed.Ext
 	}
 
 	return managed.ExternalCreation{
-			ConnectionDetails: managed.ConnectionDetails{
-				runtimev1alpha1.ResourceCredentialsSecretPasswordKey: []byte(pw),
-			},
-		}, errors.Wrap(
-			azure.FetchAsyncOperation(ctx, e.client.GetRESTClient(), &cr.Status.AtProvider.LastOperation),
-			errFetchLastOperation)
+		ConnectionDetails: managed.ConnectionDetails{
+			runtimev1alpha1.ResourceCredentialsSecretPasswordKey: []byte(pw),
+		},
+	}, azure.UpdateLastOperation(ctx, e.client.GetRESTClient(), cr, &cr.Status.AtProvider.LastOperation)
 }
 
 func (e *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
@@ -173,9 +198,7 @@ func (e *external) Update(ctx context.Context, mg resource.Managed) (managed.Ext
 		return managed.ExternalUpdate{}, errors.Wrap(err, errUpdateMySQLServer)
 	}
 
-	return managed.ExternalUpdate{}, errors.Wrap(
-		azure.FetchAsyncOperation(ctx, e.client.GetRESTClient(), &cr.Status.AtProvider.LastOperation),
-		errFetchLastOperation)
+	return managed.ExternalUpdate{}, azure.UpdateLastOperation(ctx, e.client.GetRESTClient(), cr, &cr.Status.AtProvider.LastOperation)
 }
 
 func (e *external) Delete(ctx context.Context, mg resource.Managed) error {
@@ -191,7 +214,5 @@ func (e *external) Delete(ctx context.Context, mg resource.Managed) error {
 		return errors.Wrap(err, errDeleteMySQLServer)
 	}
 
-	return errors.Wrap(
-		azure.FetchAsyncOperation(ctx, e.client.GetRESTClient(), &cr.Status.AtProvider.LastOperation),
-		errFetchLastOperation)
+	return azure.UpdateLastOperation(ctx, e.client.GetRESTClient(), cr, &cr.Status.AtProvider.LastOperation)
 }
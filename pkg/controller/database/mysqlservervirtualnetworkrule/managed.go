@@ -18,12 +18,15 @@ package mysqlservervirtualnetworkrule
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/Azure/azure-sdk-for-go/services/mysql/mgmt/2017-12-01/mysql"
 	"github.com/Azure/azure-sdk-for-go/services/mysql/mgmt/2017-12-01/mysql/mysqlapi"
+	"github.com/Azure/go-autorest/autorest"
 	"github.com/pkg/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
@@ -35,6 +38,8 @@ import (
 	"github.com/crossplane/provider-azure/apis/database/v1alpha3"
 	azure "github.com/crossplane/provider-azure/pkg/clients"
 	"github.com/crossplane/provider-azure/pkg/clients/database"
+	"github.com/crossplane/provider-azure/pkg/eventgrid"
+	"github.com/crossplane/provider-azure/pkg/reconciler"
 )
 
 // Error strings.
@@ -47,19 +52,39 @@ const (
 )
 
 // Setup adds a controller that reconciles MySQLServerVirtualNetworkRules.
-func Setup(mgr ctrl.Manager, l logging.Logger) error {
+func Setup(mgr ctrl.Manager, l logging.Logger, o reconciler.Options) error {
 	name := managed.ControllerName(v1alpha3.MySQLServerVirtualNetworkRuleGroupKind)
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
+	errs := reconciler.NewErrorHandler(recorder)
+
+	events, err := eventgrid.Watch(mgr, &v1alpha3.MySQLServerVirtualNetworkRule{}, &v1alpha3.MySQLServerVirtualNetworkRuleList{}, "Microsoft.DBforMySQL/servers/virtualNetworkRules", azureResourceID)
+	if err != nil {
+		return err
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
+		WithOptions(o.Controller(v1alpha3.MySQLServerVirtualNetworkRuleGroupKind)).
 		For(&v1alpha3.MySQLServerVirtualNetworkRule{}).
-		Complete(managed.NewReconciler(mgr,
+		Watches(events, &handler.EnqueueRequestForObject{}).
+		Complete(o.Jittered(errs.Reconciler(managed.NewReconciler(mgr,
 			resource.ManagedKind(v1alpha3.MySQLServerVirtualNetworkRuleGroupVersionKind),
 			managed.WithConnectionPublishers(),
-			managed.WithExternalConnecter(&connecter{client: mgr.GetClient()}),
+			managed.WithExternalConnecter(errs.Connecter(reconciler.Traced(resource.ManagedKind(v1alpha3.MySQLServerVirtualNetworkRuleGroupVersionKind), o.DryRun(v1alpha3.MySQLServerVirtualNetworkRuleGroupKind, recorder, reconciler.RecordDrift(recorder, reconciler.ObserveOnly(reconciler.ProtectDeletion(&connecter{client: mgr.GetClient()}))))))),
 			managed.WithReferenceResolver(managed.NewAPISimpleReferenceResolver(mgr.GetClient())),
+			managed.WithLongWait(o.Poll(v1alpha3.MySQLServerVirtualNetworkRuleGroupKind)),
 			managed.WithLogger(l.WithValues("controller", name)),
-			managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+			managed.WithRecorder(recorder)))))
+}
+
+// azureResourceID returns the ID of the Azure MySQL server virtual network rule
+// that the supplied managed resource manages.
+func azureResourceID(mg resource.Managed) string {
+	cr, ok := mg.(*v1alpha3.MySQLServerVirtualNetworkRule)
+	if !ok {
+		return ""
+	}
+	return azure.ResourceID(cr.Spec.ResourceGroupName, "Microsoft.DBforMySQL/servers/virtualNetworkRules", cr.Spec.ServerName, meta.GetExternalName(cr))
 }
 
 type connecter struct {
@@ -72,13 +97,14 @@ func (c *connecter) Connect(ctx context.Context, mg resource.Managed) (managed.E
 		return nil, err
 	}
 
-	cl := mysql.NewVirtualNetworkRulesClient(creds[azure.CredentialsKeySubscriptionID])
-	cl.Authorizer = auth
-	return &external{client: cl}, nil
+	cl := mysql.NewVirtualNetworkRulesClientWithBaseURI(creds[azure.CredentialsKeyResourceManagerEndpointURL], creds[azure.CredentialsKeySubscriptionID])
+	azure.ConfigureClient(&cl.Client, auth)
+	return &external{client: cl, sender: cl.Client}, nil
 }
 
 type external struct {
 	client mysqlapi.VirtualNetworkRulesClientAPI
+	sender autorest.Sender
 }
 
 func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
@@ -88,18 +114,25 @@ func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 	}
 
 	az, err := e.client.Get(ctx, v.Spec.ResourceGroupName, v.Spec.ServerName, meta.GetExternalName(v))
-	if azure.IsNotFound(err) {
-		return managed.ExternalObservation{ResourceExists: false}, nil
-	}
-	if err != nil {
+	if err != nil && !azure.IsNotFound(err) {
 		return managed.ExternalObservation{}, errors.Wrap(err, errGetMySQLServerVirtualNetworkRule)
 	}
+	if err := azure.UpdateLastOperation(ctx, e.sender, v, &v.Status.LastOperation); err != nil {
+		return managed.ExternalObservation{}, err
+	}
+	if azure.IsNotFound(err) {
+		// The virtual network rule may not be found until its creation
+		// completes.
+		creating := azure.AsyncOperationInProgress(v.Status.LastOperation, http.MethodPut)
+		return managed.ExternalObservation{ResourceExists: creating, ResourceUpToDate: creating}, nil
+	}
 
 	database.UpdateMySQLVirtualNetworkRuleStatusFromAzure(v, az)
 	v.SetConditions(runtimev1alpha1.Available())
 
 	o := managed.ExternalObservation{
 		ResourceExists:    true,
+		ResourceUpToDate:  azure.SetDrift(v, database.MySQLServerVirtualNetworkRuleDiff(v, az)),
 		ConnectionDetails: managed.ConnectionDetails{},
 	}
 
@@ -115,9 +148,11 @@ func (e *external) Create(ctx context.Context, mg resource.Managed) (managed.Ext
 	v.SetConditions(runtimev1alpha1.Creating())
 
 	vnet := database.NewMySQLVirtualNetworkRuleParameters(v)
-	if _, err := e.client.CreateOrUpdate(ctx, v.Spec.ResourceGroupName, v.Spec.ServerName, meta.GetExternalName(v), vnet); err != nil {
+	f, err := e.client.CreateOrUpdate(ctx, v.Spec.ResourceGroupName, v.Spec.ServerName, meta.GetExternalName(v), vnet)
+	if err != nil {
 		return managed.ExternalCreation{}, errors.Wrap(err, errCreateMySQLServerVirtualNetworkRule)
 	}
+	v.Status.LastOperation = azure.NewAsyncOperation(http.MethodPut, f.Future)
 
 	return managed.ExternalCreation{}, nil
 }
@@ -127,6 +162,9 @@ func (e *external) Update(ctx context.Context, mg resource.Managed) (managed.Ext
 	if !ok {
 		return managed.ExternalUpdate{}, errors.New(errNotMySQLServerVirtualNetworkRule)
 	}
+	if v.Status.LastOperation.Status == azure.AsyncOperationStatusInProgress {
+		return managed.ExternalUpdate{}, nil
+	}
 
 	az, err := e.client.Get(ctx, v.Spec.ResourceGroupName, v.Spec.ServerName, meta.GetExternalName(v))
 	if err != nil {
@@ -135,9 +173,11 @@ func (e *external) Update(ctx context.Context, mg resource.Managed) (managed.Ext
 
 	if database.MySQLServerVirtualNetworkRuleNeedsUpdate(v, az) {
 		vnet := database.NewMySQLVirtualNetworkRuleParameters(v)
-		if _, err := e.client.CreateOrUpdate(ctx, v.Spec.ResourceGroupName, v.Spec.ServerName, meta.GetExternalName(v), vnet); err != nil {
+		f, err := e.client.CreateOrUpdate(ctx, v.Spec.ResourceGroupName, v.Spec.ServerName, meta.GetExternalName(v), vnet)
+		if err != nil {
 			return managed.ExternalUpdate{}, errors.Wrap(err, errUpdateMySQLServerVirtualNetworkRule)
 		}
+		v.Status.LastOperation = azure.NewAsyncOperation(http.MethodPut, f.Future)
 	}
 	return managed.ExternalUpdate{}, nil
 }
@@ -149,7 +189,14 @@ func (e *external) Delete(ctx context.Context, mg resource.Managed) error {
 	}
 
 	v.SetConditions(runtimev1alpha1.Deleting())
+	if azure.AsyncOperationInProgress(v.Status.LastOperation, http.MethodDelete) {
+		return nil
+	}
 
-	_, err := e.client.Delete(ctx, v.Spec.ResourceGroupName, v.Spec.ServerName, meta.GetExternalName(v))
-	return errors.Wrap(resource.Ignore(azure.IsNotFound, err), errDeleteMySQLServerVirtualNetworkRule)
+	f, err := e.client.Delete(ctx, v.Spec.ResourceGroupName, v.Spec.ServerName, meta.GetExternalName(v))
+	if err != nil {
+		return errors.Wrap(resource.Ignore(azure.IsNotFound, err), errDeleteMySQLServerVirtualNetworkRule)
+	}
+	v.Status.LastOperation = azure.NewAsyncOperation(http.MethodDelete, f.Future)
+	return nil
 }
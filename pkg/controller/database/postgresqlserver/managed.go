@@ -19,12 +19,14 @@ package postgresqlserver
 import (
 	"context"
 	"fmt"
+	"net/http"
 
 	"github.com/Azure/azure-sdk-for-go/services/postgresql/mgmt/2017-12-01/postgresql"
 
 	"github.com/pkg/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
@@ -37,6 +39,8 @@ import (
 	"github.com/crossplane/provider-azure/apis/database/v1beta1"
 	azure "github.com/crossplane/provider-azure/pkg/clients"
 	"github.com/crossplane/provider-azure/pkg/clients/database"
+	"github.com/crossplane/provider-azure/pkg/eventgrid"
+	"github.com/crossplane/provider-azure/pkg/reconciler"
 )
 
 // Error strings.
@@ -48,22 +52,41 @@ const (
 	errUpdatePostgreSQLServer = "cannot update PostgreSQLServer"
 	errGetPostgreSQLServer    = "cannot get PostgreSQLServer"
 	errDeletePostgreSQLServer = "cannot delete PostgreSQLServer"
-	errFetchLastOperation     = "cannot fetch last operation"
 )
 
 // Setup adds a controller that reconciles PostgreSQLInstances.
-func Setup(mgr ctrl.Manager, l logging.Logger) error {
+func Setup(mgr ctrl.Manager, l logging.Logger, o reconciler.Options) error {
 	name := managed.ControllerName(v1beta1.PostgreSQLServerGroupKind)
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
+	errs := reconciler.NewErrorHandler(recorder)
+
+	events, err := eventgrid.Watch(mgr, &v1beta1.PostgreSQLServer{}, &v1beta1.PostgreSQLServerList{}, "Microsoft.DBforPostgreSQL/servers", azureResourceID)
+	if err != nil {
+		return err
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
+		WithOptions(o.Controller(v1beta1.PostgreSQLServerGroupKind)).
 		For(&v1beta1.PostgreSQLServer{}).
-		Complete(managed.NewReconciler(mgr,
+		Watches(events, &handler.EnqueueRequestForObject{}).
+		Complete(o.Jittered(errs.Reconciler(managed.NewReconciler(mgr,
 			resource.ManagedKind(v1beta1.PostgreSQLServerGroupVersionKind),
-			managed.WithExternalConnecter(&connecter{client: mgr.GetClient()}),
+			managed.WithExternalConnecter(errs.Connecter(reconciler.Traced(resource.ManagedKind(v1beta1.PostgreSQLServerGroupVersionKind), o.DryRun(v1beta1.PostgreSQLServerGroupKind, recorder, reconciler.RecordDrift(recorder, reconciler.ObserveOnly(reconciler.ProtectDeletion(&connecter{client: mgr.GetClient()}))))))),
 			managed.WithReferenceResolver(managed.NewAPISimpleReferenceResolver(mgr.GetClient())),
+			managed.WithLongWait(o.Poll(v1beta1.PostgreSQLServerGroupKind)),
 			managed.WithLogger(l.WithValues("controller", name)),
-			managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+			managed.WithRecorder(recorder)))))
+}
+
+// azureResourceID returns the ID of the Azure PostgreSQL server that the
+// supplied managed resource manages.
+func azureResourceID(mg resource.Managed) string {
+	cr, ok := mg.(*v1beta1.PostgreSQLServer)
+	if !ok {
+		return ""
+	}
+	return azure.ResourceID(cr.Spec.ForProvider.ResourceGroupName, "Microsoft.DBforPostgreSQL/servers", meta.GetExternalName(cr))
 }
 
 type connecter struct {
@@ -75,15 +98,16 @@ func (c *connecter) Connect(ctx context.Context, mg resource.Managed) (managed.E
 	if err != nil {
 		return nil, err
 	}
-	cl := postgresql.NewServersClient(creds[azure.CredentialsKeySubscriptionID])
-	cl.Authorizer = auth
-	return &external{kube: c.client, client: database.NewPostgreSQLServerClient(cl), newPasswordFn: password.Generate}, nil
+	cl := postgresql.NewServersClientWithBaseURI(creds[azure.CredentialsKeyResourceManagerEndpointURL], creds[azure.CredentialsKeySubscriptionID])
+	azure.ConfigureClient(&cl.Client, auth)
+	return &external{kube: c.client, client: database.NewPostgreSQLServerClient(cl), newPasswordFn: password.Generate, graph: azure.NewResourceGraphReader(creds, auth)}, nil
 }
 
 type external struct {
 	kube          client.Client
 	client        database.PostgreSQLServerAPI
 	newPasswordFn func() (password string, err error)
+	graph         *azure.ResourceGraphReader
 }
 
 func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
@@ -91,17 +115,20 @@ func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 	if !ok {
 		return managed.ExternalObservation{}, errors.New(errNotPostgreSQLServer)
 	}
-	server, err := e.client.GetServer(ctx, cr)
+	server := postgresql.Server{}
+	var err error
+	if !e.graph.Get(azureResourceID(cr), &server) {
+		server, err = e.client.GetServer(ctx, cr)
+	}
 	if azure.IsNotFound(err) {
-		if err := azure.FetchAsyncOperation(ctx, e.client.GetRESTClient(), &cr.Status.AtProvider.LastOperation); err != nil {
-			return managed.ExternalObservation{}, errors.Wrap(err, errFetchLastOperation)
+		if err := azure.UpdateLastOperation(ctx, e.client.GetRESTClient(), cr, &cr.Status.AtProvider.LastOperation); err != nil {
+			return managed.ExternalObservation{}, err
 		}
 		// Azure returns NotFound for GET calls until creation is completed
 		// successfully and we cannot return `ResourceExists: false` during creation
 		// since this will cause `Create` to be called again and it's not idempotent.
 		// So, we check whether a creation operation in fact is in motion.
-		creating := cr.Status.AtProvider.LastOperation.Method == "PUT" &&
-			cr.Status.AtProvider.LastOperation.Status == azure.AsyncOperationStatusInProgress
+		creating := azure.AsyncOperationInProgress(cr.Status.AtProvider.LastOperation, http.MethodPut)
 		return managed.ExternalObservation{ResourceExists: creating}, nil
 	}
 	if err != nil {
@@ -116,8 +143,8 @@ func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 	// status subresource but fetches the the whole object after it's done. So,
 	// changes to status has to be done after kube.Update in order not to get them
 	// lost.
-	if err := azure.FetchAsyncOperation(ctx, e.client.GetRESTClient(), &cr.Status.AtProvider.LastOperation); err != nil {
-		return managed.ExternalObservation{}, errors.Wrap(err, errFetchLastOperation)
+	if err := azure.UpdateLastOperation(ctx, e.client.GetRESTClient(), cr, &cr.Status.AtProvider.LastOperation); err != nil {
+		return managed.ExternalObservation{}, err
 	}
 	// Any state beside 'ready' is considered unavailable.
 	switch server.UserVisibleState { //nolint:exhaustive
@@ -129,7 +156,7 @@ func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 
 	o := managed.ExternalObservation{
 		ResourceExists:   true,
-		ResourceUpToDate: database.IsPostgreSQLUpToDate(cr.Spec.ForProvider, server), // NOTE(negz): We don't yet support updating Azure SQL servers.
+		ResourceUpToDate: azure.SetDrift(cr, database.PostgreSQLDiff(cr.Spec.ForProvider, server)), // NOTE(negz): We don't yet support updating Azure SQL servers.
 		ConnectionDetails: managed.ConnectionDetails{
 			runtimev1alpha1.ResourceCredentialsSecretEndpointKey: []byte(cr.Status.AtProvider.FullyQualifiedDomainName),
 			runtimev1alpha1.ResourceCredentialsSecretUserKey:     []byte(fmt.Sprintf("%s@%s", cr.Spec.ForProvider.AdministratorLogin, meta.GetExternalName(cr))),
@@ -156,12 +183,10 @@ func (e *external) Create(ctx context.Context, mg resource.Managed) (managed.Ext
 	}
 
 	return managed.ExternalCreation{
-			ConnectionDetails: managed.ConnectionDetails{
-				runtimev1alpha1.ResourceCredentialsSecretPasswordKey: []byte(pw),
-			},
-		}, errors.Wrap(
-			azure.FetchAsyncOperation(ctx, e.client.GetRESTClient(), &cr.Status.AtProvider.LastOperation),
-			errFetchLastOperation)
+		ConnectionDetails: managed.ConnectionDetails{
+			runtimev1alpha1.ResourceCredentialsSecretPasswordKey: []byte(pw),
+		},
+	}, azure.UpdateLastOperation(ctx, e.client.GetRESTClient(), cr, &cr.Status.AtProvider.LastOperation)
 }
 
 func (e *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
@@ -176,9 +201,7 @@ func (e *external) Update(ctx context.Context, mg resource.Managed) (managed.Ext
 		return managed.ExternalUpdate{}, errors.Wrap(err, errUpdatePostgreSQLServer)
 	}
 
-	return managed.ExternalUpdate{}, errors.Wrap(
-		azure.FetchAsyncOperation(ctx, e.client.GetRESTClient(), &cr.Status.AtProvider.LastOperation),
-		errFetchLastOperation)
+	return managed.ExternalUpdate{}, azure.UpdateLastOperation(ctx, e.client.GetRESTClient(), cr, &cr.Status.AtProvider.LastOperation)
 }
 
 func (e *external) Delete(ctx context.Context, mg resource.Managed) error {
@@ -193,7 +216,5 @@ func (e *external) Delete(ctx context.Context, mg resource.Managed) error {
 	if err := e.client.DeleteServer(ctx, cr); resource.Ignore(azure.IsNotFound, err) != nil {
 		return errors.Wrap(err, errDeletePostgreSQLServer)
 	}
-	return errors.Wrap(
-		azure.FetchAsyncOperation(ctx, e.client.GetRESTClient(), &cr.Status.AtProvider.LastOperation),
-		errFetchLastOperation)
+	return azure.UpdateLastOperation(ctx, e.client.GetRESTClient(), cr, &cr.Status.AtProvider.LastOperation)
 }
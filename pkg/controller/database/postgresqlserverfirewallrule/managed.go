@@ -18,12 +18,15 @@ package postgresqlserverfirewallrule
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/Azure/azure-sdk-for-go/services/postgresql/mgmt/2017-12-01/postgresql"
 	"github.com/Azure/azure-sdk-for-go/services/postgresql/mgmt/2017-12-01/postgresql/postgresqlapi"
+	"github.com/Azure/go-autorest/autorest"
 	"github.com/pkg/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
@@ -35,6 +38,8 @@ import (
 	"github.com/crossplane/provider-azure/apis/database/v1alpha3"
 	azure "github.com/crossplane/provider-azure/pkg/clients"
 	"github.com/crossplane/provider-azure/pkg/clients/database"
+	"github.com/crossplane/provider-azure/pkg/eventgrid"
+	"github.com/crossplane/provider-azure/pkg/reconciler"
 )
 
 // Error strings.
@@ -47,19 +52,39 @@ const (
 )
 
 // Setup adds a controller that reconciles PostgreSQLServerFirewallRules.
-func Setup(mgr ctrl.Manager, l logging.Logger) error {
+func Setup(mgr ctrl.Manager, l logging.Logger, o reconciler.Options) error {
 	name := managed.ControllerName(v1alpha3.PostgreSQLServerFirewallRuleGroupKind)
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
+	errs := reconciler.NewErrorHandler(recorder)
+
+	events, err := eventgrid.Watch(mgr, &v1alpha3.PostgreSQLServerFirewallRule{}, &v1alpha3.PostgreSQLServerFirewallRuleList{}, "Microsoft.DBforPostgreSQL/servers/firewallRules", azureResourceID)
+	if err != nil {
+		return err
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
+		WithOptions(o.Controller(v1alpha3.PostgreSQLServerFirewallRuleGroupKind)).
 		For(&v1alpha3.PostgreSQLServerFirewallRule{}).
-		Complete(managed.NewReconciler(mgr,
+		Watches(events, &handler.EnqueueRequestForObject{}).
+		Complete(o.Jittered(errs.Reconciler(managed.NewReconciler(mgr,
 			resource.ManagedKind(v1alpha3.PostgreSQLServerFirewallRuleGroupVersionKind),
 			managed.WithConnectionPublishers(),
-			managed.WithExternalConnecter(&connecter{client: mgr.GetClient()}),
+			managed.WithExternalConnecter(errs.Connecter(reconciler.Traced(resource.ManagedKind(v1alpha3.PostgreSQLServerFirewallRuleGroupVersionKind), o.DryRun(v1alpha3.PostgreSQLServerFirewallRuleGroupKind, recorder, reconciler.RecordDrift(recorder, reconciler.ObserveOnly(reconciler.ProtectDeletion(&connecter{client: mgr.GetClient()}))))))),
 			managed.WithReferenceResolver(managed.NewAPISimpleReferenceResolver(mgr.GetClient())),
+			managed.WithLongWait(o.Poll(v1alpha3.PostgreSQLServerFirewallRuleGroupKind)),
 			managed.WithLogger(l.WithValues("controller", name)),
-			managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+			managed.WithRecorder(recorder)))))
+}
+
+// azureResourceID returns the ID of the Azure PostgreSQL server firewall rule
+// that the supplied managed resource manages.
+func azureResourceID(mg resource.Managed) string {
+	cr, ok := mg.(*v1alpha3.PostgreSQLServerFirewallRule)
+	if !ok {
+		return ""
+	}
+	return azure.ResourceID(cr.Spec.ForProvider.ResourceGroupName, "Microsoft.DBforPostgreSQL/servers/firewallRules", cr.Spec.ForProvider.ServerName, meta.GetExternalName(cr))
 }
 
 type connecter struct {
@@ -71,13 +96,14 @@ func (c *connecter) Connect(ctx context.Context, mg resource.Managed) (managed.E
 	if err != nil {
 		return nil, err
 	}
-	cl := postgresql.NewFirewallRulesClient(creds[azure.CredentialsKeySubscriptionID])
-	cl.Authorizer = auth
-	return &external{client: cl}, nil
+	cl := postgresql.NewFirewallRulesClientWithBaseURI(creds[azure.CredentialsKeyResourceManagerEndpointURL], creds[azure.CredentialsKeySubscriptionID])
+	azure.ConfigureClient(&cl.Client, auth)
+	return &external{client: cl, sender: cl.Client}, nil
 }
 
 type external struct {
 	client postgresqlapi.FirewallRulesClientAPI
+	sender autorest.Sender
 }
 
 func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
@@ -87,12 +113,17 @@ func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 	}
 
 	az, err := e.client.Get(ctx, v.Spec.ForProvider.ResourceGroupName, v.Spec.ForProvider.ServerName, meta.GetExternalName(v))
-	if azure.IsNotFound(err) {
-		return managed.ExternalObservation{ResourceExists: false}, nil
-	}
-	if err != nil {
+	if err != nil && !azure.IsNotFound(err) {
 		return managed.ExternalObservation{}, errors.Wrap(err, errGetPostgreSQLServerFirewallRule)
 	}
+	if err := azure.UpdateLastOperation(ctx, e.sender, v, &v.Status.AtProvider.LastOperation); err != nil {
+		return managed.ExternalObservation{}, err
+	}
+	if azure.IsNotFound(err) {
+		// The firewall rule may not be found until its creation completes.
+		creating := azure.AsyncOperationInProgress(v.Status.AtProvider.LastOperation, http.MethodPut)
+		return managed.ExternalObservation{ResourceExists: creating, ResourceUpToDate: creating}, nil
+	}
 
 	v.Status.AtProvider.ID = azure.ToString(az.ID)
 	v.Status.AtProvider.Type = azure.ToString(az.Type)
@@ -100,7 +131,7 @@ func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 
 	o := managed.ExternalObservation{
 		ResourceExists:   true,
-		ResourceUpToDate: database.PostgreSQLServerFirewallRuleIsUpToDate(v, az),
+		ResourceUpToDate: azure.SetDrift(v, database.PostgreSQLServerFirewallRuleDiff(v, az)),
 	}
 
 	return o, nil
@@ -114,8 +145,12 @@ func (e *external) Create(ctx context.Context, mg resource.Managed) (managed.Ext
 
 	r.SetConditions(runtimev1alpha1.Creating())
 	p := database.NewPostgreSQLFirewallRuleParameters(r)
-	_, err := e.client.CreateOrUpdate(ctx, r.Spec.ForProvider.ResourceGroupName, r.Spec.ForProvider.ServerName, meta.GetExternalName(r), p)
-	return managed.ExternalCreation{}, errors.Wrap(err, errCreatePostgreSQLServerFirewallRule)
+	f, err := e.client.CreateOrUpdate(ctx, r.Spec.ForProvider.ResourceGroupName, r.Spec.ForProvider.ServerName, meta.GetExternalName(r), p)
+	if err != nil {
+		return managed.ExternalCreation{}, errors.Wrap(err, errCreatePostgreSQLServerFirewallRule)
+	}
+	r.Status.AtProvider.LastOperation = azure.NewAsyncOperation(http.MethodPut, f.Future)
+	return managed.ExternalCreation{}, nil
 }
 
 func (e *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
@@ -123,10 +158,17 @@ func (e *external) Update(ctx context.Context, mg resource.Managed) (managed.Ext
 	if !ok {
 		return managed.ExternalUpdate{}, errors.New(errNotPostgreSQLServerFirewallRule)
 	}
+	if r.Status.AtProvider.LastOperation.Status == azure.AsyncOperationStatusInProgress {
+		return managed.ExternalUpdate{}, nil
+	}
 
 	p := database.NewPostgreSQLFirewallRuleParameters(r)
-	_, err := e.client.CreateOrUpdate(ctx, r.Spec.ForProvider.ResourceGroupName, r.Spec.ForProvider.ServerName, meta.GetExternalName(r), p)
-	return managed.ExternalUpdate{}, errors.Wrap(err, errUpdatePostgreSQLServerFirewallRule)
+	f, err := e.client.CreateOrUpdate(ctx, r.Spec.ForProvider.ResourceGroupName, r.Spec.ForProvider.ServerName, meta.GetExternalName(r), p)
+	if err != nil {
+		return managed.ExternalUpdate{}, errors.Wrap(err, errUpdatePostgreSQLServerFirewallRule)
+	}
+	r.Status.AtProvider.LastOperation = azure.NewAsyncOperation(http.MethodPut, f.Future)
+	return managed.ExternalUpdate{}, nil
 }
 
 func (e *external) Delete(ctx context.Context, mg resource.Managed) error {
@@ -136,6 +178,13 @@ func (e *external) Delete(ctx context.Context, mg resource.Managed) error {
 	}
 
 	r.SetConditions(runtimev1alpha1.Deleting())
-	_, err := e.client.Delete(ctx, r.Spec.ForProvider.ResourceGroupName, r.Spec.ForProvider.ServerName, meta.GetExternalName(r))
-	return errors.Wrap(resource.Ignore(azure.IsNotFound, err), errDeletePostgreSQLServerFirewallRule)
+	if azure.AsyncOperationInProgress(r.Status.AtProvider.LastOperation, http.MethodDelete) {
+		return nil
+	}
+	f, err := e.client.Delete(ctx, r.Spec.ForProvider.ResourceGroupName, r.Spec.ForProvider.ServerName, meta.GetExternalName(r))
+	if err != nil {
+		return errors.Wrap(resource.Ignore(azure.IsNotFound, err), errDeletePostgreSQLServerFirewallRule)
+	}
+	r.Status.AtProvider.LastOperation = azure.NewAsyncOperation(http.MethodDelete, f.Future)
+	return nil
 }
@@ -34,6 +34,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/crossplane/provider-azure/apis/database/v1alpha3"
+	azurev1alpha3 "github.com/crossplane/provider-azure/apis/v1alpha3"
 	azure "github.com/crossplane/provider-azure/pkg/clients"
 	"github.com/crossplane/provider-azure/pkg/clients/fake"
 )
@@ -61,6 +62,10 @@ func withID(s string) firewallRuleModifier {
 	return func(r *v1alpha3.PostgreSQLServerFirewallRule) { r.Status.AtProvider.ID = s }
 }
 
+func withLastOperation(op azurev1alpha3.AsyncOperation) firewallRuleModifier {
+	return func(r *v1alpha3.PostgreSQLServerFirewallRule) { r.Status.AtProvider.LastOperation = op }
+}
+
 func firewallRule(sm ...firewallRuleModifier) *v1alpha3.PostgreSQLServerFirewallRule {
 	r := &v1alpha3.PostgreSQLServerFirewallRule{
 		ObjectMeta: metav1.ObjectMeta{
@@ -146,7 +151,10 @@ func TestObserve(t *testing.T) {
 			},
 			want: want{
 				mg: firewallRule(
-					withConditions(runtimev1alpha1.Available()),
+					withConditions(runtimev1alpha1.Available(), azure.Drifted(azure.Diff{
+						{Path: "spec.forProvider.startIpAddress", Desired: "127.0.0.1"},
+						{Path: "spec.forProvider.endIpAddress", Desired: "127.0.0.1"},
+					})),
 					withType(resourceType),
 					withID(resourceID),
 				),
@@ -235,6 +243,7 @@ func TestCreate(t *testing.T) {
 			want: want{
 				mg: firewallRule(
 					withConditions(runtimev1alpha1.Creating()),
+					withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodPut}),
 				),
 			},
 		},
@@ -314,7 +323,7 @@ func TestUpdate(t *testing.T) {
 				mg: firewallRule(),
 			},
 			want: want{
-				mg: firewallRule(),
+				mg: firewallRule(withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodPut})),
 			},
 		},
 	}
@@ -370,6 +379,7 @@ func TestDelete(t *testing.T) {
 			want: want{
 				mg: firewallRule(
 					withConditions(runtimev1alpha1.Deleting()),
+					withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodDelete}),
 				),
 			},
 		},
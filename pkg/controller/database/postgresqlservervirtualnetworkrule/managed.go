@@ -18,8 +18,10 @@ package postgresqlservervirtualnetworkrule
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/Azure/azure-sdk-for-go/services/postgresql/mgmt/2017-12-01/postgresql/postgresqlapi"
+	"github.com/Azure/go-autorest/autorest"
 
 	"github.com/Azure/azure-sdk-for-go/services/postgresql/mgmt/2017-12-01/postgresql"
 
@@ -28,6 +30,7 @@ import (
 	"github.com/pkg/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
@@ -37,6 +40,8 @@ import (
 	"github.com/crossplane/provider-azure/apis/database/v1alpha3"
 	azure "github.com/crossplane/provider-azure/pkg/clients"
 	"github.com/crossplane/provider-azure/pkg/clients/database"
+	"github.com/crossplane/provider-azure/pkg/eventgrid"
+	"github.com/crossplane/provider-azure/pkg/reconciler"
 )
 
 // Error strings.
@@ -49,19 +54,39 @@ const (
 )
 
 // Setup adds a controller that reconciles PostgreSQLServerVirtualNetworkRules.
-func Setup(mgr ctrl.Manager, l logging.Logger) error {
+func Setup(mgr ctrl.Manager, l logging.Logger, o reconciler.Options) error {
 	name := managed.ControllerName(v1alpha3.PostgreSQLServerVirtualNetworkRuleGroupKind)
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
+	errs := reconciler.NewErrorHandler(recorder)
+
+	events, err := eventgrid.Watch(mgr, &v1alpha3.PostgreSQLServerVirtualNetworkRule{}, &v1alpha3.PostgreSQLServerVirtualNetworkRuleList{}, "Microsoft.DBforPostgreSQL/servers/virtualNetworkRules", azureResourceID)
+	if err != nil {
+		return err
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
+		WithOptions(o.Controller(v1alpha3.PostgreSQLServerVirtualNetworkRuleGroupKind)).
 		For(&v1alpha3.PostgreSQLServerVirtualNetworkRule{}).
-		Complete(managed.NewReconciler(mgr,
+		Watches(events, &handler.EnqueueRequestForObject{}).
+		Complete(o.Jittered(errs.Reconciler(managed.NewReconciler(mgr,
 			resource.ManagedKind(v1alpha3.PostgreSQLServerVirtualNetworkRuleGroupVersionKind),
 			managed.WithConnectionPublishers(),
-			managed.WithExternalConnecter(&connecter{client: mgr.GetClient()}),
+			managed.WithExternalConnecter(errs.Connecter(reconciler.Traced(resource.ManagedKind(v1alpha3.PostgreSQLServerVirtualNetworkRuleGroupVersionKind), o.DryRun(v1alpha3.PostgreSQLServerVirtualNetworkRuleGroupKind, recorder, reconciler.RecordDrift(recorder, reconciler.ObserveOnly(reconciler.ProtectDeletion(&connecter{client: mgr.GetClient()}))))))),
 			managed.WithReferenceResolver(managed.NewAPISimpleReferenceResolver(mgr.GetClient())),
+			managed.WithLongWait(o.Poll(v1alpha3.PostgreSQLServerVirtualNetworkRuleGroupKind)),
 			managed.WithLogger(l.WithValues("controller", name)),
-			managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+			managed.WithRecorder(recorder)))))
+}
+
+// azureResourceID returns the ID of the Azure PostgreSQL server virtual network
+// rule that the supplied managed resource manages.
+func azureResourceID(mg resource.Managed) string {
+	cr, ok := mg.(*v1alpha3.PostgreSQLServerVirtualNetworkRule)
+	if !ok {
+		return ""
+	}
+	return azure.ResourceID(cr.Spec.ResourceGroupName, "Microsoft.DBforPostgreSQL/servers/virtualNetworkRules", cr.Spec.ServerName, meta.GetExternalName(cr))
 }
 
 type connecter struct {
@@ -74,13 +99,14 @@ func (c *connecter) Connect(ctx context.Context, mg resource.Managed) (managed.E
 		return nil, err
 	}
 
-	cl := postgresql.NewVirtualNetworkRulesClient(creds[azure.CredentialsKeySubscriptionID])
-	cl.Authorizer = auth
-	return &external{client: cl}, nil
+	cl := postgresql.NewVirtualNetworkRulesClientWithBaseURI(creds[azure.CredentialsKeyResourceManagerEndpointURL], creds[azure.CredentialsKeySubscriptionID])
+	azure.ConfigureClient(&cl.Client, auth)
+	return &external{client: cl, sender: cl.Client}, nil
 }
 
 type external struct {
 	client postgresqlapi.VirtualNetworkRulesClientAPI
+	sender autorest.Sender
 }
 
 func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
@@ -90,12 +116,18 @@ func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 	}
 
 	az, err := e.client.Get(ctx, v.Spec.ResourceGroupName, v.Spec.ServerName, meta.GetExternalName(v))
-	if azure.IsNotFound(err) {
-		return managed.ExternalObservation{ResourceExists: false}, nil
-	}
-	if err != nil {
+	if err != nil && !azure.IsNotFound(err) {
 		return managed.ExternalObservation{}, errors.Wrap(err, errGetPostgreSQLServerVirtualNetworkRule)
 	}
+	if err := azure.UpdateLastOperation(ctx, e.sender, v, &v.Status.LastOperation); err != nil {
+		return managed.ExternalObservation{}, err
+	}
+	if azure.IsNotFound(err) {
+		// The virtual network rule may not be found until its creation
+		// completes.
+		creating := azure.AsyncOperationInProgress(v.Status.LastOperation, http.MethodPut)
+		return managed.ExternalObservation{ResourceExists: creating, ResourceUpToDate: creating}, nil
+	}
 
 	database.UpdatePostgreSQLVirtualNetworkRuleStatusFromAzure(v, az)
 
@@ -104,7 +136,7 @@ func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 	return managed.ExternalObservation{
 		ResourceExists:    true,
 		ConnectionDetails: managed.ConnectionDetails{},
-		ResourceUpToDate:  !database.PostgreSQLServerVirtualNetworkRuleNeedsUpdate(v, az),
+		ResourceUpToDate:  azure.SetDrift(v, database.PostgreSQLServerVirtualNetworkRuleDiff(v, az)),
 	}, nil
 }
 
@@ -117,8 +149,12 @@ func (e *external) Create(ctx context.Context, mg resource.Managed) (managed.Ext
 	v.SetConditions(runtimev1alpha1.Creating())
 
 	vnet := database.NewPostgreSQLVirtualNetworkRuleParameters(v)
-	_, err := e.client.CreateOrUpdate(ctx, v.Spec.ResourceGroupName, v.Spec.ServerName, meta.GetExternalName(v), vnet)
-	return managed.ExternalCreation{}, errors.Wrap(err, errCreatePostgreSQLServerVirtualNetworkRule)
+	f, err := e.client.CreateOrUpdate(ctx, v.Spec.ResourceGroupName, v.Spec.ServerName, meta.GetExternalName(v), vnet)
+	if err != nil {
+		return managed.ExternalCreation{}, errors.Wrap(err, errCreatePostgreSQLServerVirtualNetworkRule)
+	}
+	v.Status.LastOperation = azure.NewAsyncOperation(http.MethodPut, f.Future)
+	return managed.ExternalCreation{}, nil
 }
 
 func (e *external) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
@@ -126,10 +162,17 @@ func (e *external) Update(ctx context.Context, mg resource.Managed) (managed.Ext
 	if !ok {
 		return managed.ExternalUpdate{}, errors.New(errNotPostgreSQLServerVirtualNetworkRule)
 	}
+	if v.Status.LastOperation.Status == azure.AsyncOperationStatusInProgress {
+		return managed.ExternalUpdate{}, nil
+	}
 
 	vnet := database.NewPostgreSQLVirtualNetworkRuleParameters(v)
-	_, err := e.client.CreateOrUpdate(ctx, v.Spec.ResourceGroupName, v.Spec.ServerName, meta.GetExternalName(v), vnet)
-	return managed.ExternalUpdate{}, errors.Wrap(err, errUpdatePostgreSQLServerVirtualNetworkRule)
+	f, err := e.client.CreateOrUpdate(ctx, v.Spec.ResourceGroupName, v.Spec.ServerName, meta.GetExternalName(v), vnet)
+	if err != nil {
+		return managed.ExternalUpdate{}, errors.Wrap(err, errUpdatePostgreSQLServerVirtualNetworkRule)
+	}
+	v.Status.LastOperation = azure.NewAsyncOperation(http.MethodPut, f.Future)
+	return managed.ExternalUpdate{}, nil
 }
 
 func (e *external) Delete(ctx context.Context, mg resource.Managed) error {
@@ -139,8 +182,14 @@ func (e *external) Delete(ctx context.Context, mg resource.Managed) error {
 	}
 
 	v.SetConditions(runtimev1alpha1.Deleting())
+	if azure.AsyncOperationInProgress(v.Status.LastOperation, http.MethodDelete) {
+		return nil
+	}
 
-	_, err := e.client.Delete(ctx, v.Spec.ResourceGroupName, v.Spec.ServerName, meta.GetExternalName(v))
-
-	return errors.Wrap(resource.Ignore(azure.IsNotFound, err), errDeletePostgreSQLServerVirtualNetworkRule)
+	f, err := e.client.Delete(ctx, v.Spec.ResourceGroupName, v.Spec.ServerName, meta.GetExternalName(v))
+	if err != nil {
+		return errors.Wrap(resource.Ignore(azure.IsNotFound, err), errDeletePostgreSQLServerVirtualNetworkRule)
+	}
+	v.Status.LastOperation = azure.NewAsyncOperation(http.MethodDelete, f.Future)
+	return nil
 }
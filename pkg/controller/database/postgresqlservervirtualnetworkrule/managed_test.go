@@ -34,6 +34,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/crossplane/provider-azure/apis/database/v1alpha3"
+	azurev1alpha3 "github.com/crossplane/provider-azure/apis/v1alpha3"
 	azure "github.com/crossplane/provider-azure/pkg/clients"
 	"github.com/crossplane/provider-azure/pkg/clients/fake"
 )
@@ -79,6 +80,10 @@ func withState(s string) virtualNetworkRuleModifier {
 	return func(r *v1alpha3.PostgreSQLServerVirtualNetworkRule) { r.Status.State = s }
 }
 
+func withLastOperation(op azurev1alpha3.AsyncOperation) virtualNetworkRuleModifier {
+	return func(r *v1alpha3.PostgreSQLServerVirtualNetworkRule) { r.Status.LastOperation = op }
+}
+
 func virtualNetworkRule(sm ...virtualNetworkRuleModifier) *v1alpha3.PostgreSQLServerVirtualNetworkRule {
 	r := &v1alpha3.PostgreSQLServerVirtualNetworkRule{
 		ObjectMeta: metav1.ObjectMeta{
@@ -129,6 +134,7 @@ func TestCreate(t *testing.T) {
 			r: virtualNetworkRule(),
 			want: virtualNetworkRule(
 				withConditions(runtimev1alpha1.Creating()),
+				withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodPut}),
 			),
 		},
 		{
@@ -199,7 +205,7 @@ func TestObserve(t *testing.T) {
 			}},
 			r: virtualNetworkRule(),
 			want: virtualNetworkRule(
-				withConditions(runtimev1alpha1.Available()),
+				withConditions(runtimev1alpha1.Available(), azure.UpToDate()),
 				withState(string(postgresql.Ready)),
 				withType(resourceType),
 				withID(resourceID),
@@ -289,6 +295,18 @@ func TestDelete(t *testing.T) {
 			r: virtualNetworkRule(),
 			want: virtualNetworkRule(
 				withConditions(runtimev1alpha1.Deleting()),
+				withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodDelete}),
+			),
+		},
+		{
+			name: "SkipWhileDeleting",
+			e:    &external{client: &fake.MockPostgreSQLVirtualNetworkRulesClient{}},
+			r: virtualNetworkRule(
+				withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodDelete, Status: azure.AsyncOperationStatusInProgress}),
+			),
+			want: virtualNetworkRule(
+				withConditions(runtimev1alpha1.Deleting()),
+				withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodDelete, Status: azure.AsyncOperationStatusInProgress}),
 			),
 		},
 		{
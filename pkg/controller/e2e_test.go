@@ -0,0 +1,398 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+
+	"github.com/crossplane/provider-azure/apis"
+	"github.com/crossplane/provider-azure/pkg/clients/fake/arm"
+	"github.com/crossplane/provider-azure/pkg/controller"
+	"github.com/crossplane/provider-azure/pkg/reconciler"
+)
+
+const (
+	// The environment variables that point envtest to the kube-apiserver and
+	// etcd binaries, either individually or to the directory containing both.
+	// The end-to-end tests are skipped unless the binaries exist.
+	envAssets        = "KUBEBUILDER_ASSETS"
+	envAPIServerPath = "TEST_ASSET_KUBE_APISERVER"
+	envEtcdPath      = "TEST_ASSET_ETCD"
+
+	// defaultAssets is where envtest looks for the binaries by default.
+	defaultAssets = "/usr/local/kubebuilder/bin"
+
+	subscription = "bf1b0e59-93da-42e0-82c6-5a1d94227911"
+	group        = "e2e"
+
+	timeoutReady   = 3 * time.Minute
+	timeoutDeleted = 3 * time.Minute
+)
+
+// credentials of the service principal used to authenticate to the fake
+// Azure Resource Manager, which accepts any credentials.
+var credentials = fmt.Sprintf(`{
+  "clientId": "e2e",
+  "clientSecret": "e2e",
+  "tenantId": "e2e",
+  "subscriptionId": %q
+}`, subscription)
+
+// manifests of the managed resources created by TestEndToEnd, in the order
+// they are created, and the IDs of the Azure resources they manage.
+var manifests = []struct {
+	id       string
+	manifest string
+}{
+	{
+		id: "/subscriptions/" + subscription + "/resourceGroups/" + group,
+		manifest: `
+apiVersion: azure.crossplane.io/v1alpha3
+kind: ResourceGroup
+metadata:
+  name: e2e
+spec:
+  location: westus
+  providerConfigRef:
+    name: e2e
+`,
+	},
+	{
+		id: "/subscriptions/" + subscription + "/resourceGroups/" + group + "/providers/Microsoft.Network/virtualNetworks/e2e-vnet",
+		manifest: `
+apiVersion: network.azure.crossplane.io/v1alpha3
+kind: VirtualNetwork
+metadata:
+  name: e2e-vnet
+spec:
+  resourceGroupName: e2e
+  location: westus
+  properties:
+    addressSpace:
+      addressPrefixes:
+        - 10.2.0.0/16
+  providerConfigRef:
+    name: e2e
+`,
+	},
+	{
+		id: "/subscriptions/" + subscription + "/resourceGroups/" + group + "/providers/Microsoft.Network/virtualNetworks/e2e-vnet/subnets/e2e-subnet",
+		manifest: `
+apiVersion: network.azure.crossplane.io/v1alpha3
+kind: Subnet
+metadata:
+  name: e2e-subnet
+spec:
+  resourceGroupName: e2e
+  virtualNetworkName: e2e-vnet
+  properties:
+    addressPrefix: 10.2.0.0/24
+  providerConfigRef:
+    name: e2e
+`,
+	},
+	{
+		id: "/subscriptions/" + subscription + "/resourceGroups/" + group + "/providers/Microsoft.DBforMySQL/servers/e2e-mysql",
+		manifest: `
+apiVersion: database.azure.crossplane.io/v1beta1
+kind: MySQLServer
+metadata:
+  name: e2e-mysql
+spec:
+  forProvider:
+    administratorLogin: e2e
+    resourceGroupName: e2e
+    location: westus
+    sslEnforcement: Disabled
+    version: "5.7"
+    sku:
+      tier: GeneralPurpose
+      capacity: 2
+      family: Gen5
+    storageProfile:
+      storageMB: 20480
+  writeConnectionSecretToRef:
+    namespace: default
+    name: e2e-mysql
+  providerConfigRef:
+    name: e2e
+`,
+	},
+	{
+		id: "/subscriptions/" + subscription + "/resourceGroups/" + group + "/providers/Microsoft.DBforPostgreSQL/servers/e2e-postgresql",
+		manifest: `
+apiVersion: database.azure.crossplane.io/v1beta1
+kind: PostgreSQLServer
+metadata:
+  name: e2e-postgresql
+spec:
+  forProvider:
+    administratorLogin: e2e
+    resourceGroupName: e2e
+    location: westus
+    sslEnforcement: Disabled
+    version: "9.6"
+    sku:
+      tier: GeneralPurpose
+      capacity: 2
+      family: Gen5
+    storageProfile:
+      storageMB: 20480
+  writeConnectionSecretToRef:
+    namespace: default
+    name: e2e-postgresql
+  providerConfigRef:
+    name: e2e
+`,
+	},
+	{
+		id: "/subscriptions/" + subscription + "/resourceGroups/" + group + "/providers/Microsoft.Cache/Redis/e2e-redis",
+		manifest: `
+apiVersion: cache.azure.crossplane.io/v1beta1
+kind: Redis
+metadata:
+  name: e2e-redis
+spec:
+  forProvider:
+    resourceGroupName: e2e
+    location: westus
+    sku:
+      name: Basic
+      family: C
+      capacity: 0
+  writeConnectionSecretToRef:
+    namespace: default
+    name: e2e-redis
+  providerConfigRef:
+    name: e2e
+`,
+	},
+	{
+		id: "/subscriptions/" + subscription + "/resourceGroups/" + group + "/providers/Microsoft.Storage/storageAccounts/e2eaccount",
+		manifest: `
+apiVersion: storage.azure.crossplane.io/v1alpha3
+kind: Account
+metadata:
+  name: e2eaccount
+spec:
+  resourceGroupName: e2e
+  storageAccountSpec:
+    kind: Storage
+    location: westus
+    sku:
+      name: Standard_LRS
+      tier: Standard
+  writeConnectionSecretToRef:
+    namespace: default
+    name: e2eaccount
+  providerConfigRef:
+    name: e2e
+`,
+	},
+}
+
+// controlPlaneAvailable returns true if the kube-apiserver and etcd binaries
+// exist where envtest looks for them.
+func controlPlaneAvailable() bool {
+	dir := os.Getenv(envAssets)
+	if dir == "" {
+		dir = defaultAssets
+	}
+	for env, bin := range map[string]string{envAPIServerPath: "kube-apiserver", envEtcdPath: "etcd"} {
+		path := os.Getenv(env)
+		if path == "" {
+			path = filepath.Join(dir, bin)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// TestEndToEnd runs the controllers against a fake Azure Resource Manager and
+// a real API server started by envtest. It creates a managed resource of each
+// kind the fake Azure Resource Manager supports, waits for them to become
+// ready, then deletes them and waits for them to be gone.
+func TestEndToEnd(t *testing.T) {
+	if !controlPlaneAvailable() {
+		t.Skipf("kube-apiserver and etcd binaries not found; set %s, or %s and %s", envAssets, envAPIServerPath, envEtcdPath)
+	}
+
+	s := arm.NewServer(arm.WithOperationDuration(time.Second))
+	defer s.Close()
+
+	env := &envtest.Environment{CRDDirectoryPaths: []string{filepath.Join("..", "..", "package", "crds")}}
+	cfg, err := env.Start()
+	if err != nil {
+		t.Fatalf("env.Start(): %s", err)
+	}
+	defer func() {
+		if err := env.Stop(); err != nil {
+			t.Errorf("env.Stop(): %s", err)
+		}
+	}()
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("clientgoscheme.AddToScheme(...): %s", err)
+	}
+	if err := apis.AddToScheme(scheme); err != nil {
+		t.Fatalf("apis.AddToScheme(...): %s", err)
+	}
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{Scheme: scheme, MetricsBindAddress: "0"})
+	if err != nil {
+		t.Fatalf("ctrl.NewManager(...): %s", err)
+	}
+	if err := controller.Setup(mgr, logging.NewNopLogger(), reconciler.Options{}); err != nil {
+		t.Fatalf("controller.Setup(...): %s", err)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		if err := mgr.Start(stop); err != nil {
+			t.Errorf("mgr.Start(...): %s", err)
+		}
+	}()
+
+	ctx := context.Background()
+	kube, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		t.Fatalf("client.New(...): %s", err)
+	}
+
+	setup := []string{
+		fmt.Sprintf(`
+apiVersion: v1
+kind: Secret
+metadata:
+  namespace: default
+  name: e2e
+stringData:
+  credentials: %q
+`, credentials),
+		fmt.Sprintf(`
+apiVersion: azure.crossplane.io/v1beta1
+kind: ProviderConfig
+metadata:
+  name: e2e
+spec:
+  resourceManagerEndpoint: %s
+  credentials:
+    source: Secret
+    secretRef:
+      namespace: default
+      name: e2e
+      key: credentials
+`, s.URL),
+	}
+	for _, m := range setup {
+		if err := kube.Create(ctx, decode(t, m)); err != nil {
+			t.Fatalf("kube.Create(...): %s", err)
+		}
+	}
+
+	objs := make([]*unstructured.Unstructured, len(manifests))
+	for i, m := range manifests {
+		objs[i] = decode(t, m.manifest)
+		if err := kube.Create(ctx, objs[i]); err != nil {
+			t.Fatalf("kube.Create(%s): %s", objs[i].GetName(), err)
+		}
+	}
+
+	for i, o := range objs {
+		if err := wait.PollImmediate(time.Second, timeoutReady, func() (bool, error) {
+			return ready(ctx, kube, o)
+		}); err != nil {
+			t.Fatalf("%s %s did not become ready: %s", o.GetKind(), o.GetName(), err)
+		}
+		if _, ok := s.Resource(manifests[i].id); !ok {
+			t.Errorf("%s %s is ready, but %s does not exist", o.GetKind(), o.GetName(), manifests[i].id)
+		}
+	}
+
+	// Delete the managed resources in reverse order, so that the resource
+	// group is deleted last.
+	for i := len(objs) - 1; i >= 0; i-- {
+		if err := kube.Delete(ctx, objs[i]); err != nil {
+			t.Fatalf("kube.Delete(%s): %s", objs[i].GetName(), err)
+		}
+	}
+	for i, o := range objs {
+		if err := wait.PollImmediate(time.Second, timeoutDeleted, func() (bool, error) {
+			err := kube.Get(ctx, types.NamespacedName{Name: o.GetName()}, o.DeepCopy())
+			return kerrors.IsNotFound(err), client.IgnoreNotFound(err)
+		}); err != nil {
+			t.Fatalf("%s %s was not deleted: %s", o.GetKind(), o.GetName(), err)
+		}
+		if _, ok := s.Resource(manifests[i].id); ok {
+			t.Errorf("%s %s was deleted, but %s still exists", o.GetKind(), o.GetName(), manifests[i].id)
+		}
+	}
+}
+
+// decode the supplied YAML manifest.
+func decode(t *testing.T, manifest string) *unstructured.Unstructured {
+	t.Helper()
+	j, err := yaml.YAMLToJSON([]byte(manifest))
+	if err != nil {
+		t.Fatalf("yaml.YAMLToJSON(...): %s", err)
+	}
+	u := &unstructured.Unstructured{}
+	if err := u.UnmarshalJSON(j); err != nil {
+		t.Fatalf("u.UnmarshalJSON(...): %s", err)
+	}
+	return u
+}
+
+// ready returns true if the supplied object has a Ready condition that is
+// True.
+func ready(ctx context.Context, kube client.Client, o *unstructured.Unstructured) (bool, error) {
+	got := o.DeepCopy()
+	if err := kube.Get(ctx, types.NamespacedName{Name: o.GetName()}, got); err != nil {
+		return false, err
+	}
+	conditions, _, err := unstructured.NestedSlice(got.Object, "status", "conditions")
+	if err != nil {
+		return false, err
+	}
+	for _, c := range conditions {
+		m, ok := c.(map[string]interface{})
+		if ok && m["type"] == "Ready" && m["status"] == "True" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -18,12 +18,15 @@ package subnet
 
 import (
 	"context"
+	"net/http"
 
 	azurenetwork "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-06-01/network"
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-06-01/network/networkapi"
+	"github.com/Azure/go-autorest/autorest"
 	"github.com/pkg/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
@@ -35,6 +38,8 @@ import (
 	"github.com/crossplane/provider-azure/apis/network/v1alpha3"
 	azureclients "github.com/crossplane/provider-azure/pkg/clients"
 	"github.com/crossplane/provider-azure/pkg/clients/network"
+	"github.com/crossplane/provider-azure/pkg/eventgrid"
+	"github.com/crossplane/provider-azure/pkg/reconciler"
 )
 
 // Error strings.
@@ -47,19 +52,39 @@ const (
 )
 
 // Setup adds a controller that reconciles Subnets.
-func Setup(mgr ctrl.Manager, l logging.Logger) error {
+func Setup(mgr ctrl.Manager, l logging.Logger, o reconciler.Options) error {
 	name := managed.ControllerName(v1alpha3.SubnetGroupKind)
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
+	errs := reconciler.NewErrorHandler(recorder)
+
+	events, err := eventgrid.Watch(mgr, &v1alpha3.Subnet{}, &v1alpha3.SubnetList{}, "Microsoft.Network/virtualNetworks/subnets", azureResourceID)
+	if err != nil {
+		return err
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
+		WithOptions(o.Controller(v1alpha3.SubnetGroupKind)).
 		For(&v1alpha3.Subnet{}).
-		Complete(managed.NewReconciler(mgr,
+		Watches(events, &handler.EnqueueRequestForObject{}).
+		Complete(o.Jittered(errs.Reconciler(managed.NewReconciler(mgr,
 			resource.ManagedKind(v1alpha3.SubnetGroupVersionKind),
 			managed.WithConnectionPublishers(),
-			managed.WithExternalConnecter(&connecter{client: mgr.GetClient()}),
+			managed.WithExternalConnecter(errs.Connecter(reconciler.Traced(resource.ManagedKind(v1alpha3.SubnetGroupVersionKind), o.DryRun(v1alpha3.SubnetGroupKind, recorder, reconciler.RecordDrift(recorder, reconciler.ObserveOnly(reconciler.ProtectDeletion(&connecter{client: mgr.GetClient()}))))))),
 			managed.WithReferenceResolver(managed.NewAPISimpleReferenceResolver(mgr.GetClient())),
+			managed.WithLongWait(o.Poll(v1alpha3.SubnetGroupKind)),
 			managed.WithLogger(l.WithValues("controller", name)),
-			managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+			managed.WithRecorder(recorder)))))
+}
+
+// azureResourceID returns the ID of the Azure subnet that the supplied managed
+// resource manages.
+func azureResourceID(mg resource.Managed) string {
+	cr, ok := mg.(*v1alpha3.Subnet)
+	if !ok {
+		return ""
+	}
+	return azureclients.ResourceID(cr.Spec.ResourceGroupName, "Microsoft.Network/virtualNetworks/subnets", cr.Spec.VirtualNetworkName, meta.GetExternalName(cr))
 }
 
 type connecter struct {
@@ -71,12 +96,16 @@ func (c *connecter) Connect(ctx context.Context, mg resource.Managed) (managed.E
 	if err != nil {
 		return nil, err
 	}
-	cl := azurenetwork.NewSubnetsClient(creds[azureclients.CredentialsKeySubscriptionID])
-	cl.Authorizer = auth
-	return &external{client: cl}, nil
+	cl := azurenetwork.NewSubnetsClientWithBaseURI(creds[azureclients.CredentialsKeyResourceManagerEndpointURL], creds[azureclients.CredentialsKeySubscriptionID])
+	azureclients.ConfigureClient(&cl.Client, auth)
+	return &external{client: cl, sender: cl.Client, graph: azureclients.NewResourceGraphReader(creds, auth)}, nil
 }
 
-type external struct{ client networkapi.SubnetsClientAPI }
+type external struct {
+	client networkapi.SubnetsClientAPI
+	sender autorest.Sender
+	graph  *azureclients.ResourceGraphReader
+}
 
 func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
 	s, ok := mg.(*v1alpha3.Subnet)
@@ -84,19 +113,29 @@ func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 		return managed.ExternalObservation{}, errors.New(errNotSubnet)
 	}
 
-	az, err := e.client.Get(ctx, s.Spec.ResourceGroupName, s.Spec.VirtualNetworkName, meta.GetExternalName(s), "")
-	if azureclients.IsNotFound(err) {
-		return managed.ExternalObservation{ResourceExists: false}, nil
+	az := azurenetwork.Subnet{}
+	var err error
+	if !e.graph.Get(azureResourceID(s), &az) {
+		az, err = e.client.Get(ctx, s.Spec.ResourceGroupName, s.Spec.VirtualNetworkName, meta.GetExternalName(s), "")
 	}
-	if err != nil {
+	if err != nil && !azureclients.IsNotFound(err) {
 		return managed.ExternalObservation{}, errors.Wrap(err, errGetSubnet)
 	}
+	if err := azureclients.UpdateLastOperation(ctx, e.sender, s, &s.Status.LastOperation); err != nil {
+		return managed.ExternalObservation{}, err
+	}
+	if azureclients.IsNotFound(err) {
+		// The Subnet may not be found until its creation completes.
+		creating := azureclients.AsyncOperationInProgress(s.Status.LastOperation, http.MethodPut)
+		return managed.ExternalObservation{ResourceExists: creating, ResourceUpToDate: creating}, nil
+	}
 
 	network.UpdateSubnetStatusFromAzure(s, az)
 	s.SetConditions(runtimev1alpha1.Available())
 
 	o := managed.ExternalObservation{
 		ResourceExists:    true,
+		ResourceUpToDate:  azureclients.SetDrift(s, network.SubnetDiff(s, az)),
 		ConnectionDetails: managed.ConnectionDetails{},
 	}
 
@@ -112,9 +151,11 @@ func (e *external) Create(ctx context.Context, mg resource.Managed) (managed.Ext
 	s.Status.SetConditions(runtimev1alpha1.Creating())
 
 	snet := network.NewSubnetParameters(s)
-	if _, err := e.client.CreateOrUpdate(ctx, s.Spec.ResourceGroupName, s.Spec.VirtualNetworkName, meta.GetExternalName(s), snet); err != nil {
+	f, err := e.client.CreateOrUpdate(ctx, s.Spec.ResourceGroupName, s.Spec.VirtualNetworkName, meta.GetExternalName(s), snet)
+	if err != nil {
 		return managed.ExternalCreation{}, errors.Wrap(err, errCreateSubnet)
 	}
+	s.Status.LastOperation = azureclients.NewAsyncOperation(http.MethodPut, f.Future)
 
 	return managed.ExternalCreation{}, nil
 }
@@ -124,6 +165,9 @@ func (e *external) Update(ctx context.Context, mg resource.Managed) (managed.Ext
 	if !ok {
 		return managed.ExternalUpdate{}, errors.New(errNotSubnet)
 	}
+	if s.Status.LastOperation.Status == azureclients.AsyncOperationStatusInProgress {
+		return managed.ExternalUpdate{}, nil
+	}
 
 	az, err := e.client.Get(ctx, s.Spec.ResourceGroupName, s.Spec.VirtualNetworkName, meta.GetExternalName(s), "")
 	if err != nil {
@@ -132,9 +176,11 @@ func (e *external) Update(ctx context.Context, mg resource.Managed) (managed.Ext
 
 	if network.SubnetNeedsUpdate(s, az) {
 		snet := network.NewSubnetParameters(s)
-		if _, err := e.client.CreateOrUpdate(ctx, s.Spec.ResourceGroupName, s.Spec.VirtualNetworkName, meta.GetExternalName(s), snet); err != nil {
+		f, err := e.client.CreateOrUpdate(ctx, s.Spec.ResourceGroupName, s.Spec.VirtualNetworkName, meta.GetExternalName(s), snet)
+		if err != nil {
 			return managed.ExternalUpdate{}, errors.Wrap(err, errUpdateSubnet)
 		}
+		s.Status.LastOperation = azureclients.NewAsyncOperation(http.MethodPut, f.Future)
 	}
 	return managed.ExternalUpdate{}, nil
 }
@@ -146,7 +192,14 @@ func (e *external) Delete(ctx context.Context, mg resource.Managed) error {
 	}
 
 	mg.SetConditions(runtimev1alpha1.Deleting())
+	if azureclients.AsyncOperationInProgress(s.Status.LastOperation, http.MethodDelete) {
+		return nil
+	}
 
-	_, err := e.client.Delete(ctx, s.Spec.ResourceGroupName, s.Spec.VirtualNetworkName, meta.GetExternalName(s))
-	return errors.Wrap(resource.Ignore(azureclients.IsNotFound, err), errDeleteSubnet)
+	f, err := e.client.Delete(ctx, s.Spec.ResourceGroupName, s.Spec.VirtualNetworkName, meta.GetExternalName(s))
+	if err != nil {
+		return errors.Wrap(resource.Ignore(azureclients.IsNotFound, err), errDeleteSubnet)
+	}
+	s.Status.LastOperation = azureclients.NewAsyncOperation(http.MethodDelete, f.Future)
+	return nil
 }
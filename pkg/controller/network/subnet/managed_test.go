@@ -35,6 +35,7 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 
 	"github.com/crossplane/provider-azure/apis/network/v1alpha3"
+	azurev1alpha3 "github.com/crossplane/provider-azure/apis/v1alpha3"
 	azure "github.com/crossplane/provider-azure/pkg/clients"
 	"github.com/crossplane/provider-azure/pkg/clients/network/fake"
 )
@@ -69,6 +70,10 @@ func withConditions(c ...runtimev1alpha1.Condition) subnetModifier {
 func withState(s string) subnetModifier {
 	return func(r *v1alpha3.Subnet) { r.Status.State = s }
 }
+
+func withLastOperation(op azurev1alpha3.AsyncOperation) subnetModifier {
+	return func(r *v1alpha3.Subnet) { r.Status.LastOperation = op }
+}
 func subnet(sm ...subnetModifier) *v1alpha3.Subnet {
 	r := &v1alpha3.Subnet{
 		ObjectMeta: metav1.ObjectMeta{
@@ -118,6 +123,7 @@ func TestCreate(t *testing.T) {
 			r: subnet(),
 			want: subnet(
 				withConditions(runtimev1alpha1.Creating()),
+				withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodPut}),
 			),
 		},
 		{
@@ -164,12 +170,12 @@ func TestObserve(t *testing.T) {
 			e: &external{client: &fake.MockSubnetsClient{
 				MockGet: func(_ context.Context, _ string, _ string, _ string, _ string) (result network.Subnet, err error) {
 					return network.Subnet{
-							SubnetPropertiesFormat: &network.SubnetPropertiesFormat{
-								AddressPrefix: azure.ToStringPtr(addressPrefix),
-							},
-						}, autorest.DetailedError{
-							StatusCode: http.StatusNotFound,
-						}
+						SubnetPropertiesFormat: &network.SubnetPropertiesFormat{
+							AddressPrefix: azure.ToStringPtr(addressPrefix),
+						},
+					}, autorest.DetailedError{
+						StatusCode: http.StatusNotFound,
+					}
 				},
 			}},
 			r:    subnet(),
@@ -189,7 +195,7 @@ func TestObserve(t *testing.T) {
 			}},
 			r: subnet(),
 			want: subnet(
-				withConditions(runtimev1alpha1.Available()),
+				withConditions(runtimev1alpha1.Available(), azure.UpToDate()),
 				withState(string(network.Available)),
 			),
 		},
@@ -259,7 +265,7 @@ func TestUpdate(t *testing.T) {
 				},
 			}},
 			r:    subnet(),
-			want: subnet(),
+			want: subnet(withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodPut})),
 		},
 		{
 			name: "UnsuccessfulGet",
@@ -330,6 +336,7 @@ func TestDelete(t *testing.T) {
 			r: subnet(),
 			want: subnet(
 				withConditions(runtimev1alpha1.Deleting()),
+				withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodDelete}),
 			),
 		},
 		{
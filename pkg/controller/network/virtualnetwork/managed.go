@@ -18,12 +18,15 @@ package virtualnetwork
 
 import (
 	"context"
+	"net/http"
 
 	azurenetwork "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-06-01/network"
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-06-01/network/networkapi"
+	"github.com/Azure/go-autorest/autorest"
 	"github.com/pkg/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
@@ -35,6 +38,8 @@ import (
 	"github.com/crossplane/provider-azure/apis/network/v1alpha3"
 	azureclients "github.com/crossplane/provider-azure/pkg/clients"
 	"github.com/crossplane/provider-azure/pkg/clients/network"
+	"github.com/crossplane/provider-azure/pkg/eventgrid"
+	"github.com/crossplane/provider-azure/pkg/reconciler"
 )
 
 // Error strings.
@@ -47,19 +52,39 @@ const (
 )
 
 // Setup adds a controller that reconciles VirtualNetworks.
-func Setup(mgr ctrl.Manager, l logging.Logger) error {
+func Setup(mgr ctrl.Manager, l logging.Logger, o reconciler.Options) error {
 	name := managed.ControllerName(v1alpha3.VirtualNetworkGroupKind)
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
+	errs := reconciler.NewErrorHandler(recorder)
+
+	events, err := eventgrid.Watch(mgr, &v1alpha3.VirtualNetwork{}, &v1alpha3.VirtualNetworkList{}, "Microsoft.Network/virtualNetworks", azureResourceID)
+	if err != nil {
+		return err
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
+		WithOptions(o.Controller(v1alpha3.VirtualNetworkGroupKind)).
 		For(&v1alpha3.VirtualNetwork{}).
-		Complete(managed.NewReconciler(mgr,
+		Watches(events, &handler.EnqueueRequestForObject{}).
+		Complete(o.Jittered(errs.Reconciler(managed.NewReconciler(mgr,
 			resource.ManagedKind(v1alpha3.VirtualNetworkGroupVersionKind),
 			managed.WithConnectionPublishers(),
-			managed.WithExternalConnecter(&connecter{client: mgr.GetClient()}),
+			managed.WithExternalConnecter(errs.Connecter(reconciler.Traced(resource.ManagedKind(v1alpha3.VirtualNetworkGroupVersionKind), o.DryRun(v1alpha3.VirtualNetworkGroupKind, recorder, reconciler.RecordDrift(recorder, reconciler.ObserveOnly(reconciler.ProtectDeletion(&connecter{client: mgr.GetClient()}))))))),
 			managed.WithReferenceResolver(managed.NewAPISimpleReferenceResolver(mgr.GetClient())),
+			managed.WithLongWait(o.Poll(v1alpha3.VirtualNetworkGroupKind)),
 			managed.WithLogger(l.WithValues("controller", name)),
-			managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+			managed.WithRecorder(recorder)))))
+}
+
+// azureResourceID returns the ID of the Azure virtual network that the supplied
+// managed resource manages.
+func azureResourceID(mg resource.Managed) string {
+	cr, ok := mg.(*v1alpha3.VirtualNetwork)
+	if !ok {
+		return ""
+	}
+	return azureclients.ResourceID(cr.Spec.ResourceGroupName, "Microsoft.Network/virtualNetworks", meta.GetExternalName(cr))
 }
 
 type connecter struct {
@@ -71,13 +96,15 @@ func (c *connecter) Connect(ctx context.Context, mg resource.Managed) (managed.E
 	if err != nil {
 		return nil, err
 	}
-	cl := azurenetwork.NewVirtualNetworksClient(creds[azureclients.CredentialsKeySubscriptionID])
-	cl.Authorizer = auth
-	return &external{client: cl}, nil
+	cl := azurenetwork.NewVirtualNetworksClientWithBaseURI(creds[azureclients.CredentialsKeyResourceManagerEndpointURL], creds[azureclients.CredentialsKeySubscriptionID])
+	azureclients.ConfigureClient(&cl.Client, auth)
+	return &external{client: cl, sender: cl.Client, graph: azureclients.NewResourceGraphReader(creds, auth)}, nil
 }
 
 type external struct {
 	client networkapi.VirtualNetworksClientAPI
+	sender autorest.Sender
+	graph  *azureclients.ResourceGraphReader
 }
 
 func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
@@ -86,13 +113,22 @@ func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 		return managed.ExternalObservation{}, errors.New(errNotVirtualNetwork)
 	}
 
-	az, err := e.client.Get(ctx, v.Spec.ResourceGroupName, meta.GetExternalName(v), "")
-	if azureclients.IsNotFound(err) {
-		return managed.ExternalObservation{ResourceExists: false}, nil
+	az := azurenetwork.VirtualNetwork{}
+	var err error
+	if !e.graph.Get(azureResourceID(v), &az) {
+		az, err = e.client.Get(ctx, v.Spec.ResourceGroupName, meta.GetExternalName(v), "")
 	}
-	if err != nil {
+	if err != nil && !azureclients.IsNotFound(err) {
 		return managed.ExternalObservation{}, errors.Wrap(err, errGetVirtualNetwork)
 	}
+	if err := azureclients.UpdateLastOperation(ctx, e.sender, v, &v.Status.LastOperation); err != nil {
+		return managed.ExternalObservation{}, err
+	}
+	if azureclients.IsNotFound(err) {
+		// The VirtualNetwork may not be found until its creation completes.
+		creating := azureclients.AsyncOperationInProgress(v.Status.LastOperation, http.MethodPut)
+		return managed.ExternalObservation{ResourceExists: creating, ResourceUpToDate: creating}, nil
+	}
 
 	network.UpdateVirtualNetworkStatusFromAzure(v, az)
 
@@ -100,6 +136,7 @@ func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 
 	o := managed.ExternalObservation{
 		ResourceExists:    true,
+		ResourceUpToDate:  azureclients.SetDrift(v, network.VirtualNetworkDiff(v, az)),
 		ConnectionDetails: managed.ConnectionDetails{},
 	}
 
@@ -115,9 +152,11 @@ func (e *external) Create(ctx context.Context, mg resource.Managed) (managed.Ext
 	v.Status.SetConditions(runtimev1alpha1.Creating())
 
 	vnet := network.NewVirtualNetworkParameters(v)
-	if _, err := e.client.CreateOrUpdate(ctx, v.Spec.ResourceGroupName, meta.GetExternalName(v), vnet); err != nil {
+	f, err := e.client.CreateOrUpdate(ctx, v.Spec.ResourceGroupName, meta.GetExternalName(v), vnet)
+	if err != nil {
 		return managed.ExternalCreation{}, errors.Wrap(err, errCreateVirtualNetwork)
 	}
+	v.Status.LastOperation = azureclients.NewAsyncOperation(http.MethodPut, f.Future)
 
 	return managed.ExternalCreation{}, nil
 }
@@ -127,6 +166,9 @@ func (e *external) Update(ctx context.Context, mg resource.Managed) (managed.Ext
 	if !ok {
 		return managed.ExternalUpdate{}, errors.New(errNotVirtualNetwork)
 	}
+	if v.Status.LastOperation.Status == azureclients.AsyncOperationStatusInProgress {
+		return managed.ExternalUpdate{}, nil
+	}
 
 	az, err := e.client.Get(ctx, v.Spec.ResourceGroupName, meta.GetExternalName(v), "")
 	if err != nil {
@@ -135,9 +177,11 @@ func (e *external) Update(ctx context.Context, mg resource.Managed) (managed.Ext
 
 	if network.VirtualNetworkNeedsUpdate(v, az) {
 		vnet := network.NewVirtualNetworkParameters(v)
-		if _, err := e.client.CreateOrUpdate(ctx, v.Spec.ResourceGroupName, meta.GetExternalName(v), vnet); err != nil {
+		f, err := e.client.CreateOrUpdate(ctx, v.Spec.ResourceGroupName, meta.GetExternalName(v), vnet)
+		if err != nil {
 			return managed.ExternalUpdate{}, errors.Wrap(err, errUpdateVirtualNetwork)
 		}
+		v.Status.LastOperation = azureclients.NewAsyncOperation(http.MethodPut, f.Future)
 	}
 	return managed.ExternalUpdate{}, nil
 }
@@ -149,7 +193,14 @@ func (e *external) Delete(ctx context.Context, mg resource.Managed) error {
 	}
 
 	mg.SetConditions(runtimev1alpha1.Deleting())
+	if azureclients.AsyncOperationInProgress(v.Status.LastOperation, http.MethodDelete) {
+		return nil
+	}
 
-	_, err := e.client.Delete(ctx, v.Spec.ResourceGroupName, meta.GetExternalName(v))
-	return errors.Wrap(resource.Ignore(azureclients.IsNotFound, err), errDeleteVirtualNetwork)
+	f, err := e.client.Delete(ctx, v.Spec.ResourceGroupName, meta.GetExternalName(v))
+	if err != nil {
+		return errors.Wrap(resource.Ignore(azureclients.IsNotFound, err), errDeleteVirtualNetwork)
+	}
+	v.Status.LastOperation = azureclients.NewAsyncOperation(http.MethodDelete, f.Future)
+	return nil
 }
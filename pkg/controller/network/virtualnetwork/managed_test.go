@@ -18,7 +18,9 @@ package virtualnetwork
 
 import (
 	"context"
+	"io/ioutil"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-06-01/network"
@@ -35,6 +37,7 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 
 	"github.com/crossplane/provider-azure/apis/network/v1alpha3"
+	azurev1alpha3 "github.com/crossplane/provider-azure/apis/v1alpha3"
 	azure "github.com/crossplane/provider-azure/pkg/clients"
 	"github.com/crossplane/provider-azure/pkg/clients/network/fake"
 )
@@ -45,6 +48,10 @@ const (
 	addressPrefix     = "10.0.0.0/16"
 	resourceGroupName = "coolRG"
 	location          = "coolplace"
+	pollingURL        = "https://management.azure.com/operations/cool"
+
+	inProgressResponse = `{"status": "InProgress"}`
+	failedResponse     = `{"status": "Failed", "error": {"code": "Conflict", "message": "boom"}}`
 )
 
 var (
@@ -71,6 +78,21 @@ func withState(s string) virtualNetworkModifier {
 	return func(r *v1alpha3.VirtualNetwork) { r.Status.State = s }
 }
 
+func withLastOperation(op azurev1alpha3.AsyncOperation) virtualNetworkModifier {
+	return func(r *v1alpha3.VirtualNetwork) { r.Status.LastOperation = op }
+}
+
+func operationSender(body string) autorest.Sender {
+	return autorest.SenderFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			Request:       req,
+			StatusCode:    http.StatusOK,
+			Body:          ioutil.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+		}, nil
+	})
+}
+
 func virtualNetwork(vm ...virtualNetworkModifier) *v1alpha3.VirtualNetwork {
 	r := &v1alpha3.VirtualNetwork{
 		ObjectMeta: metav1.ObjectMeta{
@@ -124,6 +146,7 @@ func TestCreate(t *testing.T) {
 			r: virtualNetwork(),
 			want: virtualNetwork(
 				withConditions(runtimev1alpha1.Creating()),
+				withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodPut}),
 			),
 		},
 		{
@@ -170,17 +193,17 @@ func TestObserve(t *testing.T) {
 			e: &external{client: &fake.MockVirtualNetworksClient{
 				MockGet: func(_ context.Context, _ string, _ string, _ string) (result network.VirtualNetwork, err error) {
 					return network.VirtualNetwork{
-							Tags: azure.ToStringPtrMap(tags),
-							VirtualNetworkPropertiesFormat: &network.VirtualNetworkPropertiesFormat{
-								AddressSpace: &network.AddressSpace{
-									AddressPrefixes: &[]string{addressPrefix},
-								},
-								EnableDdosProtection: azure.ToBoolPtr(true),
-								EnableVMProtection:   azure.ToBoolPtr(true),
+						Tags: azure.ToStringPtrMap(tags),
+						VirtualNetworkPropertiesFormat: &network.VirtualNetworkPropertiesFormat{
+							AddressSpace: &network.AddressSpace{
+								AddressPrefixes: &[]string{addressPrefix},
 							},
-						}, autorest.DetailedError{
-							StatusCode: http.StatusNotFound,
-						}
+							EnableDdosProtection: azure.ToBoolPtr(true),
+							EnableVMProtection:   azure.ToBoolPtr(true),
+						},
+					}, autorest.DetailedError{
+						StatusCode: http.StatusNotFound,
+					}
 				},
 			}},
 			r:    virtualNetwork(),
@@ -205,10 +228,52 @@ func TestObserve(t *testing.T) {
 			}},
 			r: virtualNetwork(),
 			want: virtualNetwork(
-				withConditions(runtimev1alpha1.Available()),
+				withConditions(runtimev1alpha1.Available(), azure.UpToDate()),
 				withState(string(network.Available)),
 			),
 		},
+		{
+			name: "SuccessfulObserveCreating",
+			e: &external{
+				client: &fake.MockVirtualNetworksClient{
+					MockGet: func(_ context.Context, _ string, _ string, _ string) (result network.VirtualNetwork, err error) {
+						return network.VirtualNetwork{}, autorest.DetailedError{StatusCode: http.StatusNotFound}
+					},
+				},
+				sender: operationSender(inProgressResponse),
+			},
+			r: virtualNetwork(
+				withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodPut, PollingURL: pollingURL}),
+			),
+			want: virtualNetwork(
+				withConditions(azure.LastAsyncOperationInProgress()),
+				withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodPut, PollingURL: pollingURL, Status: azure.AsyncOperationStatusInProgress}),
+			),
+		},
+		{
+			name: "LastOperationFailed",
+			e: &external{
+				client: &fake.MockVirtualNetworksClient{
+					MockGet: func(_ context.Context, _ string, _ string, _ string) (result network.VirtualNetwork, err error) {
+						return network.VirtualNetwork{}, autorest.DetailedError{StatusCode: http.StatusNotFound}
+					},
+				},
+				sender: operationSender(failedResponse),
+			},
+			r: virtualNetwork(
+				withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodPut, PollingURL: pollingURL, Status: azure.AsyncOperationStatusInProgress}),
+			),
+			want: virtualNetwork(
+				withConditions(azure.LastAsyncOperationFailed(`Code="Conflict" Message="boom"`)),
+				withLastOperation(azurev1alpha3.AsyncOperation{
+					Method:       http.MethodPut,
+					PollingURL:   pollingURL,
+					Status:       azure.AsyncOperationStatusFailed,
+					ErrorMessage: `Code="Conflict" Message="boom"`,
+				}),
+			),
+			wantErr: errors.New(`asynchronous PUT operation failed: Code="Conflict" Message="boom"`),
+		},
 		{
 			name: "FailedObserve",
 			e: &external{client: &fake.MockVirtualNetworksClient{
@@ -285,7 +350,17 @@ func TestUpdate(t *testing.T) {
 				},
 			}},
 			r:    virtualNetwork(),
-			want: virtualNetwork(),
+			want: virtualNetwork(withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodPut})),
+		},
+		{
+			name: "SkipWhileOperationInProgress",
+			e:    &external{client: &fake.MockVirtualNetworksClient{}},
+			r: virtualNetwork(
+				withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodPut, Status: azure.AsyncOperationStatusInProgress}),
+			),
+			want: virtualNetwork(
+				withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodPut, Status: azure.AsyncOperationStatusInProgress}),
+			),
 		},
 		{
 			name: "UnsuccessfulGet",
@@ -366,6 +441,18 @@ func TestDelete(t *testing.T) {
 			r: virtualNetwork(),
 			want: virtualNetwork(
 				withConditions(runtimev1alpha1.Deleting()),
+				withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodDelete}),
+			),
+		},
+		{
+			name: "SkipWhileDeleting",
+			e:    &external{client: &fake.MockVirtualNetworksClient{}},
+			r: virtualNetwork(
+				withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodDelete, Status: azure.AsyncOperationStatusInProgress}),
+			),
+			want: virtualNetwork(
+				withConditions(runtimev1alpha1.Deleting()),
+				withLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodDelete, Status: azure.AsyncOperationStatusInProgress}),
 			),
 		},
 		{
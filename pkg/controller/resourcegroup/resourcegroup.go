@@ -24,10 +24,12 @@ import (
 
 	azure "github.com/crossplane/provider-azure/pkg/clients"
 
+	"github.com/Azure/go-autorest/autorest"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/pkg/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
@@ -38,6 +40,8 @@ import (
 
 	"github.com/crossplane/provider-azure/apis/v1alpha3"
 	"github.com/crossplane/provider-azure/pkg/clients/resourcegroup"
+	"github.com/crossplane/provider-azure/pkg/eventgrid"
+	"github.com/crossplane/provider-azure/pkg/reconciler"
 )
 
 // Error strings
@@ -50,18 +54,38 @@ const (
 )
 
 // Setup adds a controller that reconciles ResourceGroups.
-func Setup(mgr ctrl.Manager, l logging.Logger) error {
+func Setup(mgr ctrl.Manager, l logging.Logger, o reconciler.Options) error {
 	name := managed.ControllerName(v1alpha3.ResourceGroupGroupKind)
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
+	errs := reconciler.NewErrorHandler(recorder)
+
+	events, err := eventgrid.Watch(mgr, &v1alpha3.ResourceGroup{}, &v1alpha3.ResourceGroupList{}, azure.ResourceGroupType, azureResourceID)
+	if err != nil {
+		return err
+	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
+		WithOptions(o.Controller(v1alpha3.ResourceGroupGroupKind)).
 		For(&v1alpha3.ResourceGroup{}).
-		Complete(managed.NewReconciler(mgr,
+		Watches(events, &handler.EnqueueRequestForObject{}).
+		Complete(o.Jittered(errs.Reconciler(managed.NewReconciler(mgr,
 			resource.ManagedKind(v1alpha3.ResourceGroupGroupVersionKind),
 			managed.WithConnectionPublishers(),
-			managed.WithExternalConnecter(&connecter{kube: mgr.GetClient()}),
+			managed.WithExternalConnecter(errs.Connecter(reconciler.Traced(resource.ManagedKind(v1alpha3.ResourceGroupGroupVersionKind), o.DryRun(v1alpha3.ResourceGroupGroupKind, recorder, reconciler.ObserveOnly(reconciler.ProtectDeletion(&connecter{kube: mgr.GetClient()})))))),
+			managed.WithLongWait(o.Poll(v1alpha3.ResourceGroupGroupKind)),
 			managed.WithLogger(l.WithValues("controller", name)),
-			managed.WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+			managed.WithRecorder(recorder)))))
+}
+
+// azureResourceID returns the ID of the Azure resource group that the supplied
+// managed resource manages.
+func azureResourceID(mg resource.Managed) string {
+	cr, ok := mg.(*v1alpha3.ResourceGroup)
+	if !ok {
+		return ""
+	}
+	return azure.ResourceID(meta.GetExternalName(cr), azure.ResourceGroupType)
 }
 
 type connecter struct {
@@ -73,14 +97,15 @@ func (c *connecter) Connect(ctx context.Context, mg resource.Managed) (managed.E
 	if err != nil {
 		return nil, err
 	}
-	cl := resources.NewGroupsClient(creds[azure.CredentialsKeySubscriptionID])
-	cl.Authorizer = auth
-	return &external{client: cl}, nil
+	cl := resources.NewGroupsClientWithBaseURI(creds[azure.CredentialsKeyResourceManagerEndpointURL], creds[azure.CredentialsKeySubscriptionID])
+	azure.ConfigureClient(&cl.Client, auth)
+	return &external{client: cl, sender: cl.Client}, nil
 }
 
 // external is a createsyncdeleter using the Azure Groups API.
 type external struct {
 	client resourcegroup.GroupsClient
+	sender autorest.Sender
 }
 
 func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
@@ -94,6 +119,10 @@ func (e *external) Observe(ctx context.Context, mg resource.Managed) (managed.Ex
 		return managed.ExternalObservation{}, errors.Wrap(err, errCheckResourceGroup)
 	}
 
+	if err := azure.UpdateLastOperation(ctx, e.sender, r, &r.Status.LastOperation); err != nil {
+		return managed.ExternalObservation{}, err
+	}
+
 	if res.Response.StatusCode == http.StatusNotFound {
 		return managed.ExternalObservation{ResourceExists: false}, nil
 	}
@@ -140,6 +169,10 @@ func (e *external) Delete(ctx context.Context, mg resource.Managed) error {
 	}
 
 	r.Status.SetConditions(runtimev1alpha1.Deleting())
-	_, err := e.client.Delete(ctx, meta.GetExternalName(r))
-	return errors.Wrap(err, errDeleteResourceGroup)
+	f, err := e.client.Delete(ctx, meta.GetExternalName(r))
+	if err != nil {
+		return errors.Wrap(err, errDeleteResourceGroup)
+	}
+	r.Status.LastOperation = azure.NewAsyncOperation(http.MethodDelete, f.Future)
+	return nil
 }
@@ -55,6 +55,10 @@ func withProvisioningstate(s v1alpha3.ProvisioningState) resourceGroupModifier {
 	return func(r *v1alpha3.ResourceGroup) { r.Status.ProvisioningState = s }
 }
 
+func withLastOperation(op v1alpha3.AsyncOperation) resourceGroupModifier {
+	return func(r *v1alpha3.ResourceGroup) { r.Status.LastOperation = op }
+}
+
 func resourceGrp(rm ...resourceGroupModifier) *v1alpha3.ResourceGroup {
 	r := &v1alpha3.ResourceGroup{
 		ObjectMeta: metav1.ObjectMeta{
@@ -313,6 +317,24 @@ func TestDelete(t *testing.T) {
 				),
 			},
 		},
+		"Successful": {
+			e: &external{
+				client: &fakerg.MockClient{
+					MockDelete: func(_ context.Context, _ string) (result resources.GroupsDeleteFuture, err error) {
+						return resources.GroupsDeleteFuture{}, nil
+					},
+				},
+			},
+			args: args{
+				mg: resourceGrp(),
+			},
+			want: want{
+				mg: resourceGrp(
+					withConditions(runtimev1alpha1.Deleting()),
+					withLastOperation(v1alpha3.AsyncOperation{Method: http.MethodDelete}),
+				),
+			},
+		},
 		"DeleteError": {
 			e: &external{
 				client: &fakerg.MockClient{
@@ -18,6 +18,7 @@ package account
 
 import (
 	"context"
+	"net/http"
 	"reflect"
 	"time"
 
@@ -29,9 +30,11 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
@@ -40,6 +43,8 @@ import (
 	"github.com/crossplane/provider-azure/apis/storage/v1alpha3"
 	azure "github.com/crossplane/provider-azure/pkg/clients"
 	azurestorage "github.com/crossplane/provider-azure/pkg/clients/storage"
+	"github.com/crossplane/provider-azure/pkg/eventgrid"
+	"github.com/crossplane/provider-azure/pkg/reconciler"
 )
 
 const (
@@ -49,6 +54,8 @@ const (
 	reconcileTimeout      = 2 * time.Minute
 	requeueAfterOnSuccess = 1 * time.Minute
 	requeueAfterOnWait    = 30 * time.Second
+
+	reasonCannotDelete event.Reason = "CannotDeleteExternalResource"
 )
 
 var (
@@ -57,6 +64,17 @@ var (
 	requeueOnWait    = reconcile.Result{RequeueAfter: requeueAfterOnWait}
 )
 
+// requeueOnError returns the result of a reconcile that failed with the
+// supplied error. Errors that are unlikely to be resolved by retrying soon are
+// requeued after a long backoff, and Retry-After delays requested by Azure are
+// honored.
+func requeueOnError(err error) reconcile.Result {
+	if d := azure.Backoff(err); d > 0 {
+		return reconcile.Result{RequeueAfter: d}
+	}
+	return resultRequeue
+}
+
 // Reconciler reconciles an Azure storage account
 type Reconciler struct {
 	client.Client
@@ -64,25 +82,48 @@ type Reconciler struct {
 	managed.ReferenceResolver
 	managed.Initializer
 
+	// poll is the interval at which resources that are up to date are
+	// observed.
+	poll time.Duration
+
 	log logging.Logger
 }
 
 // Setup adds a controller that reconciles Accounts.
-func Setup(mgr ctrl.Manager, l logging.Logger) error {
+func Setup(mgr ctrl.Manager, l logging.Logger, o reconciler.Options) error {
 	name := managed.ControllerName(v1alpha3.AccountGroupKind)
 
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
 	r := &Reconciler{
 		Client:           mgr.GetClient(),
-		syncdeleterMaker: &accountSyncdeleterMaker{mgr.GetClient()},
+		syncdeleterMaker: &accountSyncdeleterMaker{mgr.GetClient(), recorder, o.Planner(v1alpha3.AccountGroupKind, recorder)},
 		Initializer:      managed.NewNameAsExternalName(mgr.GetClient()),
+		poll:             o.Poll(v1alpha3.AccountGroupKind),
 		log:              l.WithValues("controller", name),
 	}
 
+	events, err := eventgrid.Watch(mgr, &v1alpha3.Account{}, &v1alpha3.AccountList{}, "Microsoft.Storage/storageAccounts", azureResourceID)
+	if err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
+		WithOptions(o.Controller(v1alpha3.AccountGroupKind)).
 		For(&v1alpha3.Account{}).
+		Watches(events, &handler.EnqueueRequestForObject{}).
 		Owns(&corev1.Secret{}).
-		Complete(r)
+		Complete(o.Jittered(r))
+}
+
+// azureResourceID returns the ID of the Azure storage account that the supplied
+// managed resource manages.
+func azureResourceID(mg resource.Managed) string {
+	cr, ok := mg.(*v1alpha3.Account)
+	if !ok {
+		return ""
+	}
+	return azure.ResourceID(cr.Spec.ResourceGroupName, "Microsoft.Storage/storageAccounts", meta.GetExternalName(cr))
 }
 
 // Reconcile reads that state of the cluster for a Provider acct and makes changes based on the state read
@@ -104,18 +145,32 @@ func (r *Reconciler) Reconcile(request reconcile.Request) (reconcile.Result, err
 		return reconcile.Result{}, err
 	}
 
+	ctx, span := reconciler.StartSpan(ctx, v1alpha3.AccountKind, "Reconcile", b)
+	result, err := r.reconcile(ctx, b)
+	reconciler.EndSpan(ctx, span, err)
+	return result, err
+}
+
+// reconcile the supplied account.
+func (r *Reconciler) reconcile(ctx context.Context, b *v1alpha3.Account) (reconcile.Result, error) {
 	bh, err := r.newSyncdeleter(ctx, b)
 	if err != nil {
 		b.Status.SetConditions(runtimev1alpha1.ReconcileError(err))
 		return resultRequeue, r.Status().Update(ctx, b)
 	}
 
+	azure.ResetDeletionProtected(b)
+
 	// Check for deletion
 	if b.DeletionTimestamp != nil {
 		return bh.delete(ctx)
 	}
 
-	return bh.sync(ctx)
+	result, err := bh.sync(ctx)
+	if result == requeueOnSuccess && r.poll > 0 {
+		result.RequeueAfter = r.poll
+	}
+	return result, err
 }
 
 type syncdeleterMaker interface {
@@ -124,6 +179,8 @@ type syncdeleterMaker interface {
 
 type accountSyncdeleterMaker struct {
 	client.Client
+	record event.Recorder
+	plan   *reconciler.Planner
 }
 
 func (m *accountSyncdeleterMaker) newSyncdeleter(ctx context.Context, b *v1alpha3.Account) (syncdeleter, error) {
@@ -132,12 +189,12 @@ func (m *accountSyncdeleterMaker) newSyncdeleter(ctx context.Context, b *v1alpha
 		return nil, errors.Wrap(err, "cannot get auth information")
 	}
 
-	cl := storage.NewAccountsClient(creds[azure.CredentialsKeySubscriptionID])
-	cl.Authorizer = auth
+	cl := storage.NewAccountsClientWithBaseURI(creds[azure.CredentialsKeyResourceManagerEndpointURL], creds[azure.CredentialsKeySubscriptionID])
+	azure.ConfigureClient(&cl.Client, auth)
 
 	return newAccountSyncDeleter(
 		azurestorage.NewAccountHandle(&cl, b.Spec.ResourceGroupName, meta.GetExternalName(b)),
-		m.Client, b), nil
+		m.Client, m.record, m.plan, b), nil
 }
 
 type deleter interface {
@@ -171,17 +228,26 @@ type syncdeleter interface {
 
 type accountSyncDeleter struct {
 	createupdater
+	syncbacker
 	azurestorage.AccountOperations
-	kube client.Client
-	acct *v1alpha3.Account
+	kube   client.Client
+	record event.Recorder
+	acct   *v1alpha3.Account
+
+	// plan the operations of the account rather than performing them, if
+	// the provider runs in dry-run mode.
+	plan *reconciler.Planner
 }
 
-func newAccountSyncDeleter(ao azurestorage.AccountOperations, kube client.Client, b *v1alpha3.Account) *accountSyncDeleter {
+func newAccountSyncDeleter(ao azurestorage.AccountOperations, kube client.Client, r event.Recorder, p *reconciler.Planner, b *v1alpha3.Account) *accountSyncDeleter {
 	return &accountSyncDeleter{
-		createupdater:     newAccountCreateUpdater(ao, kube, b),
+		createupdater:     newAccountCreateUpdater(ao, kube, p, b),
+		syncbacker:        newAccountSyncBacker(ao, kube, b),
 		AccountOperations: ao,
 		kube:              kube,
+		record:            r,
 		acct:              b,
+		plan:              p,
 	}
 }
 
@@ -189,9 +255,21 @@ func (asd *accountSyncDeleter) delete(ctx context.Context) (reconcile.Result, er
 	asd.acct.Status.SetConditions(runtimev1alpha1.Deleting())
 	switch asd.acct.Spec.DeletionPolicy {
 	case runtimev1alpha1.DeletionDelete, "":
+		if azure.IsObserveOnly(asd.acct) {
+			// Accounts that are only observed are never deleted.
+			break
+		}
+		if azure.IsDeletionProtected(asd.acct) {
+			asd.record.Event(asd.acct, event.Warning(reasonCannotDelete, azure.ErrDeletionProtected))
+			asd.acct.Status.SetConditions(azure.DeletionProtected(), runtimev1alpha1.ReconcileError(azure.ErrDeletionProtected))
+			return requeueOnSuccess, asd.kube.Status().Update(ctx, asd.acct)
+		}
+		if asd.plan != nil {
+			return asd.planDelete(ctx)
+		}
 		if err := asd.Delete(ctx); err != nil && !azure.IsNotFound(err) {
 			asd.acct.Status.SetConditions(runtimev1alpha1.ReconcileError(err))
-			return resultRequeue, asd.kube.Status().Update(ctx, asd.acct)
+			return requeueOnError(err), asd.kube.Status().Update(ctx, asd.acct)
 		}
 	case runtimev1alpha1.DeletionOrphan:
 		// No need to do anything if we plan to orphan this account.
@@ -204,22 +282,68 @@ func (asd *accountSyncDeleter) delete(ctx context.Context) (reconcile.Result, er
 	return reconcile.Result{}, asd.kube.Update(ctx, asd.acct)
 }
 
+// planDelete plans the deletion of the storage account resource, if it exists,
+// in dry-run mode. The account Kubernetes acct keeps its finalizer until the
+// storage account resource is deleted.
+func (asd *accountSyncDeleter) planDelete(ctx context.Context) (reconcile.Result, error) {
+	_, err := asd.Get(ctx)
+	if azure.IsNotFound(err) {
+		meta.RemoveFinalizer(asd.acct, finalizer)
+		return reconcile.Result{}, asd.kube.Update(ctx, asd.acct)
+	}
+	if err == nil {
+		err = asd.plan.Plan(ctx, asd.acct, reconciler.OperationDelete, asd.Delete)
+	}
+	if err != nil {
+		asd.acct.Status.SetConditions(runtimev1alpha1.ReconcileError(err))
+		return requeueOnError(err), asd.kube.Status().Update(ctx, asd.acct)
+	}
+	asd.acct.Status.SetConditions(runtimev1alpha1.ReconcileSuccess())
+	return requeueOnSuccess, asd.kube.Status().Update(ctx, asd.acct)
+}
+
 // sync - synchronizes the state of the storage account resource with the state of the
 // account Kubernetes acct
 func (asd *accountSyncDeleter) sync(ctx context.Context) (reconcile.Result, error) {
 	account, err := asd.Get(ctx)
 	if err != nil && !azure.IsNotFound(err) {
+		asd.acct.Status.SetConditions(runtimev1alpha1.ReconcileError(err))
+		return requeueOnError(err), asd.kube.Status().Update(ctx, asd.acct)
+	}
+
+	if err := azure.UpdateLastOperation(ctx, asd.GetRESTClient(), asd.acct, &asd.acct.Status.LastOperation); err != nil {
 		asd.acct.Status.SetConditions(runtimev1alpha1.ReconcileError(err))
 		return resultRequeue, asd.kube.Status().Update(ctx, asd.acct)
 	}
 
+	if azure.IsObserveOnly(asd.acct) {
+		return asd.observe(ctx, account)
+	}
+
 	if account == nil {
+		// The storage account may not be found until its creation completes.
+		if azure.AsyncOperationInProgress(asd.acct.Status.LastOperation, http.MethodPut) {
+			return requeueOnWait, asd.kube.Status().Update(ctx, asd.acct)
+		}
 		return asd.create(ctx)
 	}
 
 	return asd.update(ctx, account)
 }
 
+// observe the supplied storage account resource, which is never created or
+// updated, and sync the account Kubernetes acct back from it
+func (asd *accountSyncDeleter) observe(ctx context.Context, account *storage.Account) (reconcile.Result, error) {
+	if account == nil {
+		asd.acct.Status.SetConditions(runtimev1alpha1.ReconcileError(azure.ErrObserveOnlyNotFound))
+		return requeueOnSuccess, asd.kube.Status().Update(ctx, asd.acct)
+	}
+	if account.ProvisioningState == storage.Succeeded {
+		asd.acct.Status.SetConditions(runtimev1alpha1.Available())
+	}
+	return asd.syncback(ctx, account)
+}
+
 // createupdater interface defining create and update operations on/for storage account resource
 type createupdater interface {
 	creator
@@ -233,32 +357,53 @@ type accountCreateUpdater struct {
 	kube      client.Client
 	acct      *v1alpha3.Account
 	projectID string
+
+	// plan the creation and updates of the storage account resource rather
+	// than performing them, if the provider runs in dry-run mode.
+	plan *reconciler.Planner
 }
 
 // newAccountCreateUpdater new instance of accountCreateUpdater
-func newAccountCreateUpdater(ao azurestorage.AccountOperations, kube client.Client, acct *v1alpha3.Account) *accountCreateUpdater {
+func newAccountCreateUpdater(ao azurestorage.AccountOperations, kube client.Client, p *reconciler.Planner, acct *v1alpha3.Account) *accountCreateUpdater {
 	return &accountCreateUpdater{
 		syncbacker:        newAccountSyncBacker(ao, kube, acct),
 		AccountOperations: ao,
 		kube:              kube,
 		acct:              acct,
+		plan:              p,
 	}
 }
 
-// create new storage account resource and save changes back to account specs
+// create starts creating a new storage account resource and records the
+// operation that tracks its creation in the account status
 func (acu *accountCreateUpdater) create(ctx context.Context) (reconcile.Result, error) {
+	if acu.plan != nil {
+		return acu.planned(ctx, reconciler.OperationCreate, func(ctx context.Context) error {
+			_, err := acu.Create(ctx, v1alpha3.ToStorageAccountCreate(acu.acct.Spec.StorageAccountSpec))
+			return err
+		})
+	}
+
 	acu.acct.Status.SetConditions(runtimev1alpha1.Creating())
 	meta.AddFinalizer(acu.acct, finalizer)
 
 	accountSpec := v1alpha3.ToStorageAccountCreate(acu.acct.Spec.StorageAccountSpec)
 
-	a, err := acu.Create(ctx, accountSpec)
+	op, err := acu.Create(ctx, accountSpec)
 	if err != nil {
 		acu.acct.Status.SetConditions(runtimev1alpha1.ReconcileError(err))
-		return resultRequeue, acu.kube.Status().Update(ctx, acu.acct)
+		return requeueOnError(err), acu.kube.Status().Update(ctx, acu.acct)
+	}
+
+	// Updating the account to persist its finalizer overwrites its status, so
+	// the operation is recorded afterwards.
+	if err := acu.kube.Update(ctx, acu.acct); err != nil {
+		return resultRequeue, err
 	}
 
-	return acu.syncback(ctx, a)
+	acu.acct.Status.LastOperation = op
+	acu.acct.Status.SetConditions(runtimev1alpha1.Creating(), runtimev1alpha1.ReconcileSuccess())
+	return requeueOnWait, acu.kube.Status().Update(ctx, acu.acct)
 }
 
 // update storage account resource if needed
@@ -268,14 +413,24 @@ func (acu *accountCreateUpdater) update(ctx context.Context, account *storage.Ac
 
 		current := v1alpha3.NewStorageAccountSpec(account)
 		if reflect.DeepEqual(current, acu.acct.Spec.StorageAccountSpec) {
+			if acu.plan != nil {
+				acu.plan.UpToDate(acu.acct)
+			}
 			acu.acct.Status.SetConditions(runtimev1alpha1.ReconcileSuccess())
 			return requeueOnSuccess, acu.kube.Status().Update(ctx, acu.acct)
 		}
 
+		if acu.plan != nil {
+			return acu.planned(ctx, reconciler.OperationUpdate, func(ctx context.Context) error {
+				_, err := acu.Update(ctx, v1alpha3.ToStorageAccountUpdate(acu.acct.Spec.StorageAccountSpec))
+				return err
+			})
+		}
+
 		a, err := acu.Update(ctx, v1alpha3.ToStorageAccountUpdate(acu.acct.Spec.StorageAccountSpec))
 		if err != nil {
 			acu.acct.Status.SetConditions(runtimev1alpha1.ReconcileError(err))
-			return resultRequeue, acu.kube.Status().Update(ctx, acu.acct)
+			return requeueOnError(err), acu.kube.Status().Update(ctx, acu.acct)
 		}
 		account = a
 	}
@@ -283,6 +438,18 @@ func (acu *accountCreateUpdater) update(ctx context.Context, account *storage.Ac
 	return acu.syncback(ctx, account)
 }
 
+// planned plans the supplied operation of the storage account resource in
+// dry-run mode. Neither the account Kubernetes acct nor its connection secret
+// are changed by operations that were not performed.
+func (acu *accountCreateUpdater) planned(ctx context.Context, operation string, fn func(ctx context.Context) error) (reconcile.Result, error) {
+	if err := acu.plan.Plan(ctx, acu.acct, operation, fn); err != nil {
+		acu.acct.Status.SetConditions(runtimev1alpha1.ReconcileError(err))
+		return requeueOnError(err), acu.kube.Status().Update(ctx, acu.acct)
+	}
+	acu.acct.Status.SetConditions(runtimev1alpha1.ReconcileSuccess())
+	return requeueOnSuccess, acu.kube.Status().Update(ctx, acu.acct)
+}
+
 type accountSyncbacker struct {
 	secretupdater
 	acct *v1alpha3.Account
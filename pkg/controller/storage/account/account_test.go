@@ -18,7 +18,9 @@ package account
 
 import (
 	"context"
+	"io/ioutil"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -40,6 +42,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
@@ -47,8 +50,10 @@ import (
 	"github.com/crossplane/provider-azure/apis/storage/v1alpha3"
 	v1alpha3test "github.com/crossplane/provider-azure/apis/storage/v1alpha3/test"
 	azurev1alpha3 "github.com/crossplane/provider-azure/apis/v1alpha3"
+	azure "github.com/crossplane/provider-azure/pkg/clients"
 	azurestorage "github.com/crossplane/provider-azure/pkg/clients/storage"
 	azurestoragefake "github.com/crossplane/provider-azure/pkg/clients/storage/fake"
+	"github.com/crossplane/provider-azure/pkg/reconciler"
 )
 
 func init() {
@@ -186,6 +191,11 @@ const (
 	testAccountName = "testAccount"
 )
 
+var (
+	observeOnly       = map[string]string{azure.AnnotationKeyManagementPolicy: azure.ManagementPolicyObserveOnly}
+	deletionProtected = map[string]string{azure.AnnotationKeyDeletionProtection: "true"}
+)
+
 func TestReconciler_Reconcile(t *testing.T) {
 	name := testAccountName
 	key := types.NamespacedName{Name: name}
@@ -379,6 +389,33 @@ func Test_syncdeleter_delete(t *testing.T) {
 					Account,
 			},
 		},
+		{
+			name: "DeletionProtected",
+			fields: fields{
+				acct: v1alpha3test.NewMockAccount(bucketName).WithSpecDeletionPolicy(runtimev1alpha1.DeletionDelete).
+					WithAnnotations(deletionProtected).
+					WithFinalizer(finalizer).Account,
+				cc: &test.MockClient{
+					MockStatusUpdate: func(ctx context.Context, obj runtime.Object, _ ...client.UpdateOption) error {
+						return nil
+					},
+				},
+				ao: &azurestoragefake.MockAccountOperations{
+					MockDelete: func(ctx context.Context) error {
+						return errBoom
+					},
+				},
+			},
+			want: want{
+				err: nil,
+				res: requeueOnSuccess,
+				acct: v1alpha3test.NewMockAccount(bucketName).WithSpecDeletionPolicy(runtimev1alpha1.DeletionDelete).
+					WithAnnotations(deletionProtected).
+					WithFinalizer(finalizer).
+					WithStatusConditions(runtimev1alpha1.Deleting(), azure.DeletionProtected(), runtimev1alpha1.ReconcileError(azure.ErrDeletionProtected)).
+					Account,
+			},
+		},
 		{
 			name: "DeleteNonExistent",
 			fields: fields{
@@ -408,7 +445,7 @@ func Test_syncdeleter_delete(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			bh := newAccountSyncDeleter(tt.fields.ao, tt.fields.cc, tt.fields.acct)
+			bh := newAccountSyncDeleter(tt.fields.ao, tt.fields.cc, event.NewNopRecorder(), nil, tt.fields.acct)
 			got, err := bh.delete(ctx)
 			if diff := cmp.Diff(tt.want.err, err, test.EquateErrors()); diff != "" {
 				t.Errorf("accountSyncDeleter.delete(): -want error, +got error: \n%s", diff)
@@ -479,6 +516,7 @@ func Test_syncdeleter_sync(t *testing.T) {
 							StatusCode: http.StatusNotFound,
 						}
 					},
+					MockGetRESTClient: func() autorest.Sender { return nil },
 				},
 				acct: v1alpha3test.NewMockAccount(name).WithUID("test-uid").Account,
 			},
@@ -487,6 +525,63 @@ func Test_syncdeleter_sync(t *testing.T) {
 				acct: v1alpha3test.NewMockAccount(name).WithUID("test-uid").Account,
 			},
 		},
+		{
+			name: "AttrsNotFoundObserveOnly",
+			fields: fields{
+				kube: &test.MockClient{
+					MockStatusUpdate: func(ctx context.Context, obj runtime.Object, _ ...client.UpdateOption) error {
+						return nil
+					},
+				},
+				ao: &azurestoragefake.MockAccountOperations{
+					MockGet: func(i context.Context) (attrs *storage.Account, e error) {
+						return nil, autorest.DetailedError{
+							StatusCode: http.StatusNotFound,
+						}
+					},
+					MockGetRESTClient: func() autorest.Sender { return nil },
+				},
+				acct: v1alpha3test.NewMockAccount(name).WithUID("test-uid").WithAnnotations(observeOnly).Account,
+			},
+			want: want{
+				res: requeueOnSuccess,
+				acct: v1alpha3test.NewMockAccount(name).
+					WithUID("test-uid").
+					WithAnnotations(observeOnly).
+					WithStatusConditions(runtimev1alpha1.ReconcileError(azure.ErrObserveOnlyNotFound)).
+					Account,
+			},
+		},
+		{
+			name: "AttrsNotFoundCreating",
+			fields: fields{
+				kube: &test.MockClient{
+					MockStatusUpdate: func(ctx context.Context, obj runtime.Object, _ ...client.UpdateOption) error {
+						return nil
+					},
+				},
+				ao: &azurestoragefake.MockAccountOperations{
+					MockGet: func(i context.Context) (attrs *storage.Account, e error) {
+						return nil, autorest.DetailedError{
+							StatusCode: http.StatusNotFound,
+						}
+					},
+					MockGetRESTClient: func() autorest.Sender { return nil },
+				},
+				acct: v1alpha3test.NewMockAccount(name).
+					WithUID("test-uid").
+					WithStatusLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodPut, Status: azure.AsyncOperationStatusInProgress}).
+					Account,
+			},
+			want: want{
+				res: requeueOnWait,
+				acct: v1alpha3test.NewMockAccount(name).
+					WithUID("test-uid").
+					WithStatusLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodPut, Status: azure.AsyncOperationStatusInProgress}).
+					WithStatusConditions(azure.LastAsyncOperationInProgress()).
+					Account,
+			},
+		},
 		{
 			name: "Update",
 			fields: fields{
@@ -497,6 +592,7 @@ func Test_syncdeleter_sync(t *testing.T) {
 					MockGet: func(i context.Context) (attrs *storage.Account, e error) {
 						return &storage.Account{}, nil
 					},
+					MockGetRESTClient: func() autorest.Sender { return nil },
 				},
 				acct: v1alpha3test.NewMockAccount(name).WithUID("test-uid").Account,
 			},
@@ -505,11 +601,37 @@ func Test_syncdeleter_sync(t *testing.T) {
 				acct: v1alpha3test.NewMockAccount(name).WithUID("test-uid").Account,
 			},
 		},
+		{
+			name: "ObserveOnly",
+			fields: fields{
+				kube: &test.MockClient{},
+				ao: &azurestoragefake.MockAccountOperations{
+					MockGet: func(i context.Context) (attrs *storage.Account, e error) {
+						return &storage.Account{AccountProperties: &storage.AccountProperties{ProvisioningState: storage.Succeeded}}, nil
+					},
+					MockGetRESTClient: func() autorest.Sender { return nil },
+				},
+				acct: v1alpha3test.NewMockAccount(name).WithUID("test-uid").WithAnnotations(observeOnly).Account,
+			},
+			want: want{
+				// The mock syncbacker requeues on wait, while the mock
+				// createupdater would requeue on success.
+				res: requeueOnWait,
+				acct: v1alpha3test.NewMockAccount(name).
+					WithUID("test-uid").
+					WithAnnotations(observeOnly).
+					WithStatusConditions(runtimev1alpha1.Available()).
+					Account,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			bh := &accountSyncDeleter{
-				createupdater:     newMockAccountCreateUpdater(),
+				createupdater: newMockAccountCreateUpdater(),
+				syncbacker: &MockAccountSyncbacker{MockSyncback: func(context.Context, *storage.Account) (reconcile.Result, error) {
+					return requeueOnWait, nil
+				}},
 				AccountOperations: tt.fields.ao,
 				kube:              tt.fields.kube,
 				acct:              tt.fields.acct,
@@ -556,8 +678,8 @@ func Test_createupdater_create(t *testing.T) {
 			name: "CreateFailed",
 			fields: fields{
 				ao: &azurestoragefake.MockAccountOperations{
-					MockCreate: func(ctx context.Context, params storage.AccountCreateParameters) (*storage.Account, error) {
-						return nil, errBoom
+					MockCreate: func(ctx context.Context, params storage.AccountCreateParameters) (azurev1alpha3.AsyncOperation, error) {
+						return azurev1alpha3.AsyncOperation{}, errBoom
 					},
 				},
 				kube: &test.MockClient{
@@ -577,15 +699,14 @@ func Test_createupdater_create(t *testing.T) {
 			},
 		},
 		{
-			name: "CreateSuccessful",
+			name: "UpdateFailed",
 			fields: fields{
-				sb: &MockAccountSyncbacker{
-					MockSyncback: func(ctx context.Context, a *storage.Account) (result reconcile.Result, e error) {
-						return resultRequeue, errBoom
+				ao: azurestoragefake.NewMockAccountOperations(),
+				kube: &test.MockClient{
+					MockUpdate: func(ctx context.Context, obj runtime.Object, _ ...client.UpdateOption) error {
+						return errBoom
 					},
 				},
-				ao:   azurestoragefake.NewMockAccountOperations(),
-				kube: test.NewMockClient(),
 				acct: v1alpha3test.NewMockAccount(name).
 					WithUID("test-uid").
 					Account,
@@ -600,6 +721,29 @@ func Test_createupdater_create(t *testing.T) {
 					Account,
 			},
 		},
+		{
+			name: "CreateSuccessful",
+			fields: fields{
+				ao: &azurestoragefake.MockAccountOperations{
+					MockCreate: func(ctx context.Context, params storage.AccountCreateParameters) (azurev1alpha3.AsyncOperation, error) {
+						return azurev1alpha3.AsyncOperation{Method: http.MethodPut, Status: azure.AsyncOperationStatusInProgress}, nil
+					},
+				},
+				kube: test.NewMockClient(),
+				acct: v1alpha3test.NewMockAccount(name).
+					WithUID("test-uid").
+					Account,
+			},
+			want: want{
+				res: requeueOnWait,
+				obj: v1alpha3test.NewMockAccount(name).
+					WithUID("test-uid").
+					WithFinalizer(finalizer).
+					WithStatusConditions(runtimev1alpha1.Creating(), runtimev1alpha1.ReconcileSuccess()).
+					WithStatusLastOperation(azurev1alpha3.AsyncOperation{Method: http.MethodPut, Status: azure.AsyncOperationStatusInProgress}).
+					Account,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1058,3 +1202,133 @@ func Test_accountSecretUpdater_updatesecret(t *testing.T) {
 		})
 	}
 }
+
+func Test_accountSyncDeleter_dryRun(t *testing.T) {
+	const (
+		subscription = "bf1b0e59-93da-42e0-82c6-5a1d94227911"
+		group        = "coolgroup"
+		accountName  = "coolaccount"
+	)
+	accountURL := storage.DefaultBaseURI + "/subscriptions/" + subscription + "/resourceGroups/" + group +
+		"/providers/Microsoft.Storage/storageAccounts/" + accountName + "?api-version=2017-06-01"
+
+	azure.DryRun = true
+	defer func() { azure.DryRun = false }()
+
+	type want struct {
+		res        reconcile.Result
+		operation  string
+		requests   []string
+		finalizers []string
+	}
+	cases := map[string]struct {
+		reason string
+		exists bool
+		call   func(ctx context.Context, asd *accountSyncDeleter) (reconcile.Result, error)
+		acct   *v1alpha3.Account
+		want   want
+	}{
+		"Create": {
+			reason: "The creation of a storage account that does not exist should be planned rather than sent.",
+			call:   func(ctx context.Context, asd *accountSyncDeleter) (reconcile.Result, error) { return asd.sync(ctx) },
+			acct: v1alpha3test.NewMockAccount(accountName).WithSpecStorageAccountSpec(&v1alpha3.StorageAccountSpec{
+				Kind:     storage.Storage,
+				Location: "westus",
+				Sku:      &v1alpha3.Sku{Name: storage.StandardLRS},
+			}).Account,
+			want: want{
+				res:       requeueOnSuccess,
+				operation: reconciler.OperationCreate,
+				requests:  []string{http.MethodPut + " " + accountURL},
+			},
+		},
+		"Delete": {
+			reason: "The deletion of a storage account that exists should be planned rather than sent, and its finalizer kept.",
+			exists: true,
+			call:   func(ctx context.Context, asd *accountSyncDeleter) (reconcile.Result, error) { return asd.delete(ctx) },
+			acct: v1alpha3test.NewMockAccount(accountName).WithSpecDeletionPolicy(runtimev1alpha1.DeletionDelete).
+				WithDeleteTimestamp(metav1.Now()).WithFinalizer(finalizer).Account,
+			want: want{
+				res:        requeueOnSuccess,
+				operation:  reconciler.OperationDelete,
+				requests:   []string{http.MethodDelete + " " + accountURL},
+				finalizers: []string{finalizer},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Reads of the storage account and checks of the availability of
+			// its name are answered. Any other request reaching the sender
+			// would change Azure resources.
+			s := autorest.DecorateSender(autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+				status, body := http.StatusNotFound, `{"error":{"code":"ResourceNotFound"}}`
+				switch {
+				case r.Method == http.MethodGet && tc.exists:
+					status, body = http.StatusOK, `{"name":"`+accountName+`","location":"westus","properties":{"provisioningState":"Succeeded"}}`
+				case r.Method == http.MethodGet:
+				case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/checkNameAvailability"):
+					status, body = http.StatusOK, `{"nameAvailable":true}`
+				default:
+					t.Errorf("\n%s\nrequest that would change an Azure resource was sent: %s %s", tc.reason, r.Method, r.URL)
+				}
+				return &http.Response{
+					Request:    r,
+					StatusCode: status,
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Body:       ioutil.NopCloser(strings.NewReader(body)),
+				}, nil
+			}), azure.DryRunIntercept)
+			cl := storage.NewAccountsClient(subscription)
+			cl.Sender = s
+
+			kube := &test.MockClient{
+				MockUpdate: func(_ context.Context, _ runtime.Object, _ ...client.UpdateOption) error {
+					t.Errorf("\n%s\nthe account was updated in dry-run mode", tc.reason)
+					return nil
+				},
+				MockStatusUpdate: func(_ context.Context, _ runtime.Object, _ ...client.UpdateOption) error { return nil },
+			}
+			plan := reconciler.NewPlan()
+			var events []event.Event
+			p := reconciler.Options{Plan: plan}.Planner(v1alpha3.AccountGroupKind, recorderFn(func(_ runtime.Object, e event.Event) { events = append(events, e) }))
+			asd := newAccountSyncDeleter(azurestorage.NewAccountHandle(&cl, group, accountName), kube, event.NewNopRecorder(), p, tc.acct)
+
+			res, err := tc.call(context.Background(), asd)
+			if err != nil {
+				t.Fatalf("\n%s\naccountSyncDeleter: %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want.res, res); diff != "" {
+				t.Errorf("\n%s\naccountSyncDeleter: -want result, +got result:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.finalizers, tc.acct.GetFinalizers()); diff != "" {
+				t.Errorf("\n%s\naccountSyncDeleter: -want finalizers, +got finalizers:\n%s", tc.reason, diff)
+			}
+
+			ops := plan.Operations()
+			if len(ops) != 1 {
+				t.Fatalf("\n%s\nplan.Operations(): want 1 planned operation, got %d", tc.reason, len(ops))
+			}
+			got := make([]string, len(ops[0].Requests))
+			for i, r := range ops[0].Requests {
+				got[i] = r.Method + " " + r.URL
+			}
+			if diff := cmp.Diff(tc.want.requests, got); diff != "" {
+				t.Errorf("\n%s\nplan.Operations(): -want planned requests, +got planned requests:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.operation, ops[0].Operation); diff != "" {
+				t.Errorf("\n%s\nplan.Operations(): -want operation, +got operation:\n%s", tc.reason, diff)
+			}
+			if len(events) != 1 {
+				t.Errorf("\n%s\naccountSyncDeleter: want 1 event recording the planned operation, got %d", tc.reason, len(events))
+			}
+		})
+	}
+}
+
+type recorderFn func(obj runtime.Object, e event.Event)
+
+func (fn recorderFn) Event(obj runtime.Object, e event.Event) { fn(obj, e) }
+
+func (fn recorderFn) WithAnnotations(_ ...string) event.Recorder { return fn }
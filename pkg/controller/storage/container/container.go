@@ -32,12 +32,14 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 
 	azure "github.com/crossplane/provider-azure/pkg/clients"
+	"github.com/crossplane/provider-azure/pkg/reconciler"
 
 	"github.com/crossplane/provider-azure/apis/storage/v1alpha3"
 	"github.com/crossplane/provider-azure/pkg/clients/storage"
@@ -49,11 +51,14 @@ const (
 
 	reconcileTimeout      = 2 * time.Minute
 	requeueAfterOnSuccess = 1 * time.Minute
+
+	reasonCannotDelete event.Reason = "CannotDeleteExternalResource"
 )
 
 // Error strings
 const (
-	errAcctSecretNil = "account does not have a connection secret"
+	errAcctSecretNil      = "account does not have a connection secret"
+	errGetAcctEnvironment = "cannot get the Azure environment of the storage account"
 )
 
 var (
@@ -68,24 +73,31 @@ type Reconciler struct {
 	managed.ReferenceResolver
 	managed.Initializer
 
+	// poll is the interval at which resources that are up to date are
+	// observed.
+	poll time.Duration
+
 	log logging.Logger
 }
 
 // Setup adds a controller that reconciles Containers.
-func Setup(mgr ctrl.Manager, l logging.Logger) error {
+func Setup(mgr ctrl.Manager, l logging.Logger, o reconciler.Options) error {
 	name := managed.ControllerName(v1alpha3.ContainerGroupKind)
 
+	recorder := event.NewAPIRecorder(mgr.GetEventRecorderFor(name))
 	r := &Reconciler{
 		Client:           mgr.GetClient(),
-		syncdeleterMaker: &containerSyncdeleterMaker{mgr.GetClient()},
+		syncdeleterMaker: &containerSyncdeleterMaker{mgr.GetClient(), recorder, o.Planner(v1alpha3.ContainerGroupKind, recorder)},
 		Initializer:      managed.NewNameAsExternalName(mgr.GetClient()),
+		poll:             o.Poll(v1alpha3.ContainerGroupKind),
 		log:              l.WithValues("controller", name),
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
+		WithOptions(o.Controller(v1alpha3.ContainerGroupKind)).
 		For(&v1alpha3.Container{}).
-		Complete(r)
+		Complete(o.Jittered(r))
 }
 
 // Reconcile reads that state of the cluster for a Provider acct and makes changes based on the state read
@@ -104,18 +116,32 @@ func (r *Reconciler) Reconcile(request reconcile.Request) (reconcile.Result, err
 		return reconcile.Result{}, err
 	}
 
+	ctx, span := reconciler.StartSpan(ctx, v1alpha3.ContainerKind, "Reconcile", c)
+	result, err := r.reconcile(ctx, c)
+	reconciler.EndSpan(ctx, span, err)
+	return result, err
+}
+
+// reconcile the supplied container.
+func (r *Reconciler) reconcile(ctx context.Context, c *v1alpha3.Container) (reconcile.Result, error) {
 	sd, err := r.newSyncdeleter(ctx, c)
 	if err != nil {
 		c.Status.SetConditions(runtimev1alpha1.ReconcileError(err))
 		return resultRequeue, r.Status().Update(ctx, c)
 	}
 
+	azure.ResetDeletionProtected(c)
+
 	// Check for deletion
 	if c.DeletionTimestamp != nil {
 		return sd.delete(ctx)
 	}
 
-	return sd.sync(ctx)
+	result, err := sd.sync(ctx)
+	if result == requeueOnSuccess && r.poll > 0 {
+		result.RequeueAfter = r.poll
+	}
+	return result, err
 }
 
 type syncdeleterMaker interface {
@@ -124,6 +150,8 @@ type syncdeleterMaker interface {
 
 type containerSyncdeleterMaker struct {
 	client.Client
+	record event.Recorder
+	plan   *reconciler.Planner
 }
 
 func (m *containerSyncdeleterMaker) newSyncdeleter(ctx context.Context, c *v1alpha3.Container) (syncdeleter, error) { // nolint:gocyclo
@@ -167,9 +195,21 @@ func (m *containerSyncdeleterMaker) newSyncdeleter(ctx context.Context, c *v1alp
 
 	accountName := string(s.Data[runtimev1alpha1.ResourceCredentialsSecretUserKey])
 	accountPassword := string(s.Data[runtimev1alpha1.ResourceCredentialsSecretPasswordKey])
+	endpoint := string(s.Data[runtimev1alpha1.ResourceCredentialsSecretEndpointKey])
 	containerName := meta.GetExternalName(c)
 
-	ch, err := storage.NewContainerHandle(accountName, accountPassword, containerName)
+	// The blob endpoint is not published until the storage account reports
+	// its endpoints, so it is derived from the Azure environment of the
+	// account, which may be a sovereign cloud or an Azure Stack Hub.
+	if endpoint == "" {
+		i, err := azure.LoadAuthInfo(ctx, m.Client, acct)
+		if err != nil {
+			return nil, errors.Wrap(err, errGetAcctEnvironment)
+		}
+		endpoint = azure.BlobEndpoint(i.Content(), accountName)
+	}
+
+	ch, err := storage.NewContainerHandle(endpoint, accountName, accountPassword, containerName)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to create client handle: %s, storage account: %s", containerName, accountName)
 	}
@@ -185,10 +225,13 @@ func (m *containerSyncdeleterMaker) newSyncdeleter(ctx context.Context, c *v1alp
 			ContainerOperations: ch,
 			kube:                m.Client,
 			container:           c,
+			plan:                m.plan,
 		},
 		ContainerOperations: ch,
 		kube:                m.Client,
+		record:              m.record,
 		container:           c,
+		plan:                m.plan,
 	}, nil
 }
 
@@ -217,12 +260,25 @@ type containerSyncdeleter struct {
 	createupdater
 	storage.ContainerOperations
 	kube      client.Client
+	record    event.Recorder
 	container *v1alpha3.Container
+
+	// plan the operations of the container rather than performing them, if
+	// the provider runs in dry-run mode.
+	plan *reconciler.Planner
 }
 
 func (csd *containerSyncdeleter) delete(ctx context.Context) (reconcile.Result, error) {
 	csd.container.Status.SetConditions(runtimev1alpha1.Deleting())
-	if csd.container.Spec.DeletionPolicy == runtimev1alpha1.DeletionDelete {
+	if csd.container.Spec.DeletionPolicy == runtimev1alpha1.DeletionDelete && !azure.IsObserveOnly(csd.container) {
+		if azure.IsDeletionProtected(csd.container) {
+			csd.record.Event(csd.container, event.Warning(reasonCannotDelete, azure.ErrDeletionProtected))
+			csd.container.Status.SetConditions(azure.DeletionProtected(), runtimev1alpha1.ReconcileError(azure.ErrDeletionProtected))
+			return requeueOnSuccess, csd.kube.Status().Update(ctx, csd.container)
+		}
+		if csd.plan != nil {
+			return csd.planDelete(ctx)
+		}
 		if err := csd.Delete(ctx); err != nil && !azure.IsNotFound(err) {
 			csd.container.Status.SetConditions(runtimev1alpha1.ReconcileError(err))
 			return resultRequeue, csd.kube.Status().Update(ctx, csd.container)
@@ -236,6 +292,26 @@ func (csd *containerSyncdeleter) delete(ctx context.Context) (reconcile.Result,
 	return reconcile.Result{}, csd.kube.Update(ctx, csd.container)
 }
 
+// planDelete plans the deletion of the container resource, if it exists, in
+// dry-run mode. The container keeps its finalizer until the container resource
+// is deleted.
+func (csd *containerSyncdeleter) planDelete(ctx context.Context) (reconcile.Result, error) {
+	_, _, err := csd.Get(ctx)
+	if storage.IsNotFoundError(err) {
+		meta.RemoveFinalizer(csd.container, finalizer)
+		return reconcile.Result{}, csd.kube.Update(ctx, csd.container)
+	}
+	if err == nil {
+		err = csd.plan.Plan(ctx, csd.container, reconciler.OperationDelete, csd.Delete)
+	}
+	if err != nil {
+		csd.container.Status.SetConditions(runtimev1alpha1.ReconcileError(err))
+		return resultRequeue, csd.kube.Status().Update(ctx, csd.container)
+	}
+	csd.container.Status.SetConditions(runtimev1alpha1.ReconcileSuccess())
+	return requeueOnSuccess, csd.kube.Status().Update(ctx, csd.container)
+}
+
 func (csd *containerSyncdeleter) sync(ctx context.Context) (reconcile.Result, error) {
 	access, meta, err := csd.Get(ctx)
 	if err != nil && !storage.IsNotFoundError(err) {
@@ -260,12 +336,26 @@ type containerCreateUpdater struct {
 	storage.ContainerOperations
 	kube      client.Client
 	container *v1alpha3.Container
+
+	// plan the creation and updates of the container resource rather than
+	// performing them, if the provider runs in dry-run mode.
+	plan *reconciler.Planner
 }
 
 var _ createupdater = &containerCreateUpdater{}
 
 func (ccu *containerCreateUpdater) create(ctx context.Context) (reconcile.Result, error) {
 	container := ccu.container
+	if azure.IsObserveOnly(container) {
+		// Containers that are only observed are never created.
+		container.Status.SetConditions(runtimev1alpha1.ReconcileError(azure.ErrObserveOnlyNotFound))
+		return requeueOnSuccess, ccu.kube.Status().Update(ctx, container)
+	}
+	if ccu.plan != nil {
+		return ccu.planned(ctx, reconciler.OperationCreate, func(ctx context.Context) error {
+			return ccu.Create(ctx, container.Spec.PublicAccessType, container.Spec.Metadata)
+		})
+	}
 	container.Status.SetConditions(runtimev1alpha1.Creating())
 
 	meta.AddFinalizer(container, finalizer)
@@ -285,15 +375,51 @@ func (ccu *containerCreateUpdater) create(ctx context.Context) (reconcile.Result
 
 func (ccu *containerCreateUpdater) update(ctx context.Context, accessType *azblob.PublicAccessType, meta azblob.Metadata) (reconcile.Result, error) {
 	container := ccu.container
+	if azure.IsObserveOnly(container) {
+		return ccu.observe(ctx, accessType, meta)
+	}
 	spec := container.Spec
 
 	if !reflect.DeepEqual(*accessType, spec.PublicAccessType) || !reflect.DeepEqual(meta, spec.Metadata) {
+		if ccu.plan != nil {
+			return ccu.planned(ctx, reconciler.OperationUpdate, func(ctx context.Context) error {
+				return ccu.Update(ctx, spec.PublicAccessType, spec.Metadata)
+			})
+		}
 		if err := ccu.Update(ctx, spec.PublicAccessType, spec.Metadata); err != nil {
 			container.Status.SetConditions(runtimev1alpha1.ReconcileError(err))
 			return resultRequeue, ccu.kube.Status().Update(ctx, container)
 		}
 	}
 
+	if ccu.plan != nil {
+		ccu.plan.UpToDate(container)
+	}
 	container.Status.SetConditions(runtimev1alpha1.Available(), runtimev1alpha1.ReconcileSuccess())
 	return requeueOnSuccess, ccu.kube.Status().Update(ctx, ccu.container)
 }
+
+// planned plans the supplied operation of the container resource in dry-run
+// mode. The container is not changed by operations that were not performed.
+func (ccu *containerCreateUpdater) planned(ctx context.Context, operation string, fn func(ctx context.Context) error) (reconcile.Result, error) {
+	if err := ccu.plan.Plan(ctx, ccu.container, operation, fn); err != nil {
+		ccu.container.Status.SetConditions(runtimev1alpha1.ReconcileError(err))
+		return resultRequeue, ccu.kube.Status().Update(ctx, ccu.container)
+	}
+	ccu.container.Status.SetConditions(runtimev1alpha1.ReconcileSuccess())
+	return requeueOnSuccess, ccu.kube.Status().Update(ctx, ccu.container)
+}
+
+// observe the supplied access type and metadata of a container that is never
+// updated, and sync the container spec back from them
+func (ccu *containerCreateUpdater) observe(ctx context.Context, accessType *azblob.PublicAccessType, meta azblob.Metadata) (reconcile.Result, error) {
+	container := ccu.container
+	container.Spec.PublicAccessType = *accessType
+	container.Spec.Metadata = meta
+	if err := ccu.kube.Update(ctx, container); err != nil {
+		return resultRequeue, errors.Wrapf(err, "failed to update container spec")
+	}
+
+	container.Status.SetConditions(runtimev1alpha1.Available(), runtimev1alpha1.ReconcileSuccess())
+	return requeueOnSuccess, ccu.kube.Status().Update(ctx, container)
+}
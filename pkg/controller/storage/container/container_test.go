@@ -27,6 +27,7 @@ import (
 
 	"github.com/Azure/azure-storage-blob-go/azblob"
 	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/pkg/errors"
@@ -42,12 +43,15 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 
 	"github.com/crossplane/provider-azure/apis/storage/v1alpha3"
 	v1alpha3test "github.com/crossplane/provider-azure/apis/storage/v1alpha3/test"
+	apisv1beta1 "github.com/crossplane/provider-azure/apis/v1beta1"
+	azure "github.com/crossplane/provider-azure/pkg/clients"
 	"github.com/crossplane/provider-azure/pkg/clients/storage"
 	azurestoragefake "github.com/crossplane/provider-azure/pkg/clients/storage/fake"
 )
@@ -127,6 +131,11 @@ func newSecret(ns, name string, data map[string][]byte) *v1.Secret {
 	}
 }
 
+func withProviderConfig(a *v1alpha3.Account, name string) *v1alpha3.Account {
+	a.SetProviderConfigReference(&runtimev1alpha1.Reference{Name: name})
+	return a
+}
+
 func newSecretNotFoundError(name string) error {
 	return kerrors.NewNotFound(
 		schema.GroupResource{Group: v1.GroupName, Resource: "secrets"}, name)
@@ -142,6 +151,11 @@ const (
 	testAccountName   = "testAccount"
 )
 
+var (
+	observeOnly       = map[string]string{azure.AnnotationKeyManagementPolicy: azure.ManagementPolicyObserveOnly}
+	deletionProtected = map[string]string{azure.AnnotationKeyDeletionProtection: "true"}
+)
+
 func TestReconciler_Reconcile(t *testing.T) {
 	key := types.NamespacedName{Name: testContainerName}
 	req := reconcile.Request{NamespacedName: key}
@@ -291,7 +305,7 @@ func Test_containerSyncdeleterMaker_newSyncdeleter(t *testing.T) {
 	ctx := context.TODO()
 	testAccountKey := "dGVzdC1rZXkK"
 
-	ch, err := storage.NewContainerHandle(testAccountName, testAccountKey, testContainerName)
+	ch, err := storage.NewContainerHandle("", testAccountName, testAccountKey, testContainerName)
 	if err != nil {
 		t.Errorf("containerSyncdeleterMaker.newSyncdeleter() unexpected error %v", err)
 	}
@@ -304,9 +318,10 @@ func Test_containerSyncdeleterMaker_newSyncdeleter(t *testing.T) {
 		c   *v1alpha3.Container
 	}
 	type want struct {
-		err    error
-		syndel syncdeleter
-		cont   *v1alpha3.Container
+		err      error
+		syndel   syncdeleter
+		endpoint string
+		cont     *v1alpha3.Container
 	}
 	tests := []struct {
 		name   string
@@ -393,6 +408,7 @@ func Test_containerSyncdeleterMaker_newSyncdeleter(t *testing.T) {
 					newSecret(testNamespace, testAccountName, map[string][]byte{
 						runtimev1alpha1.ResourceCredentialsSecretUserKey:     []byte(testAccountName),
 						runtimev1alpha1.ResourceCredentialsSecretPasswordKey: []byte("test-key"),
+						runtimev1alpha1.ResourceCredentialsSecretEndpointKey: []byte("https://testAccount.blob.core.windows.net"),
 					}),
 					v1alpha3test.NewMockAccount(testAccountName).
 						WithSpecWriteConnectionSecretToReference(testNamespace, testAccountName).
@@ -417,6 +433,7 @@ func Test_containerSyncdeleterMaker_newSyncdeleter(t *testing.T) {
 					newSecret(testNamespace, testAccountName, map[string][]byte{
 						runtimev1alpha1.ResourceCredentialsSecretUserKey:     []byte(testAccountName),
 						runtimev1alpha1.ResourceCredentialsSecretPasswordKey: []byte("dGVzdC1rZXkK"),
+						runtimev1alpha1.ResourceCredentialsSecretEndpointKey: []byte("https://testAccount.blob.core.windows.net"),
 					}),
 					v1alpha3test.NewMockAccount(testAccountName).
 						WithSpecWriteConnectionSecretToReference(testNamespace, testAccountName).
@@ -432,6 +449,72 @@ func Test_containerSyncdeleterMaker_newSyncdeleter(t *testing.T) {
 				syndel: &containerSyncdeleter{},
 			},
 		},
+		{
+			name: "FailedToGetAccountEnvironment",
+			fields: fields{
+				Client: fake.NewFakeClient(
+					newCont().WithSpecProviderRef(testAccountName).WithFinalizer(finalizer).Container,
+					newSecret(testNamespace, testAccountName, map[string][]byte{
+						runtimev1alpha1.ResourceCredentialsSecretUserKey:     []byte(testAccountName),
+						runtimev1alpha1.ResourceCredentialsSecretPasswordKey: []byte("dGVzdC1rZXkK"),
+					}),
+					v1alpha3test.NewMockAccount(testAccountName).
+						WithSpecWriteConnectionSecretToReference(testNamespace, testAccountName).
+						Account),
+			},
+			args: args{
+				ctx: ctx,
+				c: newCont().WithSpecProviderRef(testAccountName).
+					WithFinalizer(finalizer).
+					Container,
+			},
+			want: want{
+				err: errors.Wrap(errors.New("neither providerConfigRef nor providerRef was supplied"), errGetAcctEnvironment),
+			},
+		},
+		{
+			name: "SuccessEndpointFromEnvironment",
+			fields: fields{
+				Client: fake.NewFakeClient(
+					newCont().WithSpecProviderRef(testAccountName).WithFinalizer(finalizer).Container,
+					newSecret(testNamespace, testAccountName, map[string][]byte{
+						runtimev1alpha1.ResourceCredentialsSecretUserKey:     []byte(testAccountName),
+						runtimev1alpha1.ResourceCredentialsSecretPasswordKey: []byte("dGVzdC1rZXkK"),
+					}),
+					newSecret(testNamespace, "china-creds", map[string][]byte{
+						"creds": []byte(`{"clientId": "cool-client", "clientSecret": "cool-secret", "tenantId": "cool-tenant", "subscriptionId": "cool-subscription"}`),
+					}),
+					&apisv1beta1.ProviderConfig{
+						ObjectMeta: metav1.ObjectMeta{Name: "china"},
+						Spec: apisv1beta1.ProviderConfigSpec{
+							ProviderConfigSpec: runtimev1alpha1.ProviderConfigSpec{
+								Credentials: runtimev1alpha1.ProviderCredentials{
+									Source: runtimev1alpha1.CredentialsSourceSecret,
+									SecretRef: &runtimev1alpha1.SecretKeySelector{
+										SecretReference: runtimev1alpha1.SecretReference{Namespace: testNamespace, Name: "china-creds"},
+										Key:             "creds",
+									},
+								},
+							},
+							Environment: to.StringPtr("AzureChinaCloud"),
+						},
+					},
+					withProviderConfig(v1alpha3test.NewMockAccount(testAccountName).
+						WithUID("cool-account").
+						WithSpecWriteConnectionSecretToReference(testNamespace, testAccountName).
+						Account, "china")),
+			},
+			args: args{
+				ctx: ctx,
+				c: newCont().WithSpecProviderRef(testAccountName).
+					WithFinalizer(finalizer).
+					Container,
+			},
+			want: want{
+				syndel:   &containerSyncdeleter{},
+				endpoint: "https://testAccount.blob.core.chinacloudapi.cn",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -443,6 +526,10 @@ func Test_containerSyncdeleterMaker_newSyncdeleter(t *testing.T) {
 				t.Errorf("containerSyncdeleterMaker.newSyncdeleter(): -got error, +want error: \n%s", diff)
 			}
 			if tt.want.syndel != nil {
+				ch := ch
+				if tt.want.endpoint != "" {
+					ch, _ = storage.NewContainerHandle(tt.want.endpoint, testAccountName, testAccountKey, testContainerName)
+				}
 				tt.want.syndel = &containerSyncdeleter{
 					createupdater: &containerCreateUpdater{
 						ContainerOperations: ch,
@@ -542,6 +629,31 @@ func Test_containerSyncdeleter_delete(t *testing.T) {
 					Container,
 			},
 		},
+		{
+			name: "DeletionProtected",
+			fields: fields{
+				kube: test.NewMockClient(),
+				ContainerOperations: &azurestoragefake.MockContainerOperations{
+					MockDelete: func(ctx context.Context) error {
+						return errBoom
+					},
+				},
+				container: v1alpha3test.NewMockContainer(testContainerName).
+					WithSpecDeletionPolicy(runtimev1alpha1.DeletionDelete).
+					WithAnnotations(deletionProtected).
+					WithFinalizer(finalizer).Container,
+			},
+			args: args{ctx: ctx},
+			want: want{
+				res: requeueOnSuccess,
+				cont: v1alpha3test.NewMockContainer(testContainerName).
+					WithSpecDeletionPolicy(runtimev1alpha1.DeletionDelete).
+					WithAnnotations(deletionProtected).
+					WithFinalizer(finalizer).
+					WithStatusConditions(runtimev1alpha1.Deleting(), azure.DeletionProtected(), runtimev1alpha1.ReconcileError(azure.ErrDeletionProtected)).
+					Container,
+			},
+		},
 		{
 			name: "DeleteErrorOther",
 			fields: fields{
@@ -572,6 +684,7 @@ func Test_containerSyncdeleter_delete(t *testing.T) {
 				createupdater:       tt.fields.createupdater,
 				ContainerOperations: tt.fields.ContainerOperations,
 				kube:                tt.fields.kube,
+				record:              event.NewNopRecorder(),
 				container:           tt.fields.container,
 			}
 			got, err := csd.delete(tt.args.ctx)
@@ -768,6 +881,21 @@ func Test_containerCreateUpdater_create(t *testing.T) {
 					Container,
 			},
 		},
+		{
+			name: "ObserveOnly",
+			fields: fields{
+				container: v1alpha3test.NewMockContainer(testContainerName).WithAnnotations(observeOnly).Container,
+				kube:      test.NewMockClient(),
+			},
+			args: args{ctx: ctx},
+			want: want{
+				res: requeueOnSuccess,
+				cont: v1alpha3test.NewMockContainer(testContainerName).
+					WithAnnotations(observeOnly).
+					WithStatusConditions(runtimev1alpha1.ReconcileError(azure.ErrObserveOnlyNotFound)).
+					Container,
+			},
+		},
 		{
 			name: "CreateSuccessful",
 			fields: fields{
@@ -879,6 +1007,32 @@ func Test_containerCreateUpdater_update(t *testing.T) {
 					Container,
 			},
 		},
+		{
+			name: "ObserveOnly",
+			fields: fields{
+				container: v1alpha3test.NewMockContainer(testContainerName).
+					WithAnnotations(observeOnly).
+					WithSpecPAC(azblob.PublicAccessContainer).
+					Container,
+				kube: test.NewMockClient(),
+			},
+			args: args{
+				ctx:        ctx,
+				accessType: azurestoragefake.PublicAccessTypePtr(azblob.PublicAccessBlob),
+				meta: azblob.Metadata{
+					"foo": "bar",
+				},
+			},
+			want: want{
+				res: requeueOnSuccess,
+				cont: v1alpha3test.NewMockContainer(testContainerName).
+					WithAnnotations(observeOnly).
+					WithSpecPAC(azblob.PublicAccessBlob).
+					WithSpecMetadata(map[string]string{"foo": "bar"}).
+					WithStatusConditions(runtimev1alpha1.Available(), runtimev1alpha1.ReconcileSuccess()).
+					Container,
+			},
+		},
 		{
 			name: "ContainerUpdateSuccessful",
 			fields: fields{
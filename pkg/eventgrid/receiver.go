@@ -0,0 +1,225 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventgrid
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+)
+
+const (
+	errServe    = "cannot serve Event Grid receiver"
+	errShutdown = "cannot shut down Event Grid receiver"
+)
+
+// Event types that the Receiver handles.
+const (
+	// EventTypeSubscriptionValidation is sent by Event Grid to validate that
+	// the Receiver accepts the events of a new event subscription.
+	EventTypeSubscriptionValidation = "Microsoft.EventGrid.SubscriptionValidationEvent"
+
+	// EventTypeResourceWriteSuccess is sent when an Azure resource is created
+	// or updated.
+	EventTypeResourceWriteSuccess = "Microsoft.Resources.ResourceWriteSuccess"
+
+	// EventTypeResourceDeleteSuccess is sent when an Azure resource is
+	// deleted.
+	EventTypeResourceDeleteSuccess = "Microsoft.Resources.ResourceDeleteSuccess"
+)
+
+// KeyParameter is the query parameter of the URL of the Receiver that must
+// contain its key.
+const KeyParameter = "key"
+
+// maxBodyBytes is the maximum size of an Event Grid delivery, which is limited
+// to 1MB by Event Grid.
+const maxBodyBytes = 1 << 20
+
+// shutdownTimeout is how long the Receiver waits for deliveries in progress
+// when it is stopped.
+const shutdownTimeout = 5 * time.Second
+
+// An Event delivered by Event Grid using the Event Grid event schema.
+type Event struct {
+	ID        string          `json:"id"`
+	Topic     string          `json:"topic,omitempty"`
+	Subject   string          `json:"subject"`
+	EventType string          `json:"eventType"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// ValidationData is the data of a subscription validation event.
+type ValidationData struct {
+	ValidationCode string `json:"validationCode"`
+}
+
+// ValidationResponse is the response to a subscription validation event.
+type ValidationResponse struct {
+	ValidationResponse string `json:"validationResponse"`
+}
+
+// ResourceData is the data of a resource write or delete event.
+type ResourceData struct {
+	ResourceURI string `json:"resourceUri"`
+}
+
+// A ReceiverOption configures a Receiver.
+type ReceiverOption func(*Receiver)
+
+// WithKey requires Event Grid to deliver events to a URL whose KeyParameter
+// is the supplied key, e.g. https://example.org/?key=secret. Event Grid event
+// subscriptions cannot otherwise authenticate to webhooks that are not
+// protected by Azure Active Directory. A Receiver without a key rejects all
+// deliveries.
+func WithKey(key string) ReceiverOption {
+	return func(r *Receiver) {
+		r.key = key
+	}
+}
+
+// WithLogger specifies how the Receiver should log messages.
+func WithLogger(l logging.Logger) ReceiverOption {
+	return func(r *Receiver) {
+		r.log = l
+	}
+}
+
+// A Receiver is an Event Grid webhook. It dispatches the resource write and
+// delete events of an Azure subscription or resource group event subscription,
+// so that the managed resources that manage changed Azure resources are
+// reconciled immediately.
+type Receiver struct {
+	dispatcher Dispatcher
+	key        string
+	log        logging.Logger
+}
+
+// NewReceiver returns a Receiver that dispatches events using the supplied
+// Dispatcher.
+func NewReceiver(d Dispatcher, o ...ReceiverOption) *Receiver {
+	r := &Receiver{dispatcher: d, log: logging.NewNopLogger()}
+	for _, fn := range o {
+		fn(r)
+	}
+	return r
+}
+
+// ServeHTTP handles a delivery of Event Grid events. Deliveries are rejected
+// unless they supply the key of the Receiver, and with an error if any of
+// their events cannot be dispatched, so that Event Grid retries them.
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.key == "" || subtle.ConstantTimeCompare([]byte(req.URL.Query().Get(KeyParameter)), []byte(r.key)) != 1 {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	if req.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	events := []Event{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxBodyBytes)).Decode(&events); err != nil {
+		http.Error(w, "cannot decode events: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, e := range events {
+		switch e.EventType {
+		case EventTypeSubscriptionValidation:
+			d := ValidationData{}
+			if err := json.Unmarshal(e.Data, &d); err != nil {
+				http.Error(w, "cannot decode validation event: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			r.log.Debug("Validating Event Grid subscription", "topic", e.Topic)
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(ValidationResponse{ValidationResponse: d.ValidationCode})
+			return
+		case EventTypeResourceWriteSuccess, EventTypeResourceDeleteSuccess:
+			id := e.Subject
+			d := ResourceData{}
+			if err := json.Unmarshal(e.Data, &d); err == nil && d.ResourceURI != "" {
+				id = d.ResourceURI
+			}
+			r.log.Debug("Dispatching Event Grid event", "id", e.ID, "type", e.EventType, "resource", id)
+			if err := r.dispatcher.Dispatch(req.Context(), id); err != nil {
+				r.log.Info("Cannot dispatch Event Grid event", "id", e.ID, "resource", id, "error", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// A ServeOption configures how a handler is served.
+type ServeOption func(*serveOptions)
+
+type serveOptions struct {
+	certFile string
+	keyFile  string
+}
+
+// WithTLS serves the handler over HTTPS using the supplied PEM encoded
+// certificate and private key files. Handlers are served over plain HTTP
+// otherwise, and must only be exposed through a proxy or ingress that
+// terminates TLS, because Event Grid deliveries include the Receiver's key.
+func WithTLS(certFile, keyFile string) ServeOption {
+	return func(so *serveOptions) {
+		so.certFile = certFile
+		so.keyFile = keyFile
+	}
+}
+
+// Serve returns a manager.Runnable that serves the supplied handler at the
+// supplied address until it is stopped. The Runnable is only started by the
+// elected leader, whose controllers consume dispatched events.
+func Serve(addr string, h http.Handler, o ...ServeOption) manager.Runnable {
+	return manager.RunnableFunc(func(stop <-chan struct{}) error {
+		so := &serveOptions{}
+		for _, fn := range o {
+			fn(so)
+		}
+
+		srv := &http.Server{Addr: addr, Handler: h}
+		errs := make(chan error, 1)
+		go func() {
+			if so.certFile != "" || so.keyFile != "" {
+				errs <- srv.ListenAndServeTLS(so.certFile, so.keyFile)
+				return
+			}
+			errs <- srv.ListenAndServe()
+		}()
+
+		select {
+		case err := <-errs:
+			return errors.Wrap(err, errServe)
+		case <-stop:
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			return errors.Wrap(srv.Shutdown(ctx), errShutdown)
+		}
+	})
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventgrid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+)
+
+type dispatcherFn func(ctx context.Context, id string) error
+
+func (fn dispatcherFn) Dispatch(ctx context.Context, id string) error {
+	return fn(ctx, id)
+}
+
+func TestReceiver(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	type args struct {
+		o      []ReceiverOption
+		method string
+		url    string
+		body   string
+		err    error
+	}
+	type want struct {
+		status     int
+		body       string
+		dispatched []string
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"SubscriptionValidation": {
+			reason: "Subscription validation events should be answered with their validation code.",
+			args: args{
+				o:      []ReceiverOption{WithKey("secret")},
+				method: http.MethodPost,
+				url:    "/?key=secret",
+				body:   `[{"id":"1","subject":"","eventType":"Microsoft.EventGrid.SubscriptionValidationEvent","data":{"validationCode":"cool-code"}}]`,
+			},
+			want: want{
+				status: http.StatusOK,
+				body:   `{"validationResponse":"cool-code"}` + "\n",
+			},
+		},
+		"ResourceEvents": {
+			reason: "The resources of write and delete events should be dispatched, and other events ignored.",
+			args: args{
+				o:      []ReceiverOption{WithKey("secret")},
+				method: http.MethodPost,
+				url:    "/?key=secret",
+				body: `[
+					{"id":"1","subject":"/subscriptions/sub/resourceGroups/group/providers/Microsoft.Cache/Redis/cool","eventType":"Microsoft.Resources.ResourceWriteSuccess","data":{"resourceUri":"/subscriptions/sub/resourceGroups/group/providers/Microsoft.Cache/Redis/cool"}},
+					{"id":"2","subject":"/subscriptions/sub/resourceGroups/group","eventType":"Microsoft.Resources.ResourceDeleteSuccess"},
+					{"id":"3","subject":"/subscriptions/sub/resourceGroups/group","eventType":"Microsoft.Resources.ResourceActionSuccess"}
+				]`,
+			},
+			want: want{
+				status: http.StatusOK,
+				dispatched: []string{
+					"/subscriptions/sub/resourceGroups/group/providers/Microsoft.Cache/Redis/cool",
+					"/subscriptions/sub/resourceGroups/group",
+				},
+			},
+		},
+		"DispatchError": {
+			reason: "Deliveries whose events cannot be dispatched should fail, so that Event Grid retries them.",
+			args: args{
+				o:      []ReceiverOption{WithKey("secret")},
+				method: http.MethodPost,
+				url:    "/?key=secret",
+				body:   `[{"id":"1","subject":"/subscriptions/sub/resourceGroups/group","eventType":"Microsoft.Resources.ResourceWriteSuccess"}]`,
+				err:    errBoom,
+			},
+			want: want{
+				status:     http.StatusInternalServerError,
+				body:       errBoom.Error() + "\n",
+				dispatched: []string{"/subscriptions/sub/resourceGroups/group"},
+			},
+		},
+		"InvalidBody": {
+			reason: "Deliveries that are not Event Grid events should be rejected.",
+			args: args{
+				o:      []ReceiverOption{WithKey("secret")},
+				method: http.MethodPost,
+				url:    "/?key=secret",
+				body:   `{}`,
+			},
+			want: want{
+				status: http.StatusBadRequest,
+				body:   "cannot decode events: json: cannot unmarshal object into Go value of type []eventgrid.Event\n",
+			},
+		},
+		"WrongMethod": {
+			reason: "Requests that are not POSTs should be rejected.",
+			args: args{
+				o:      []ReceiverOption{WithKey("secret")},
+				method: http.MethodGet,
+				url:    "/?key=secret",
+			},
+			want: want{
+				status: http.StatusMethodNotAllowed,
+				body:   http.StatusText(http.StatusMethodNotAllowed) + "\n",
+			},
+		},
+		"WrongKey": {
+			reason: "Requests without the key of the Receiver should be rejected.",
+			args: args{
+				o:      []ReceiverOption{WithKey("secret")},
+				method: http.MethodPost,
+				url:    "/?key=guess",
+				body:   `[]`,
+			},
+			want: want{
+				status: http.StatusUnauthorized,
+				body:   http.StatusText(http.StatusUnauthorized) + "\n",
+			},
+		},
+		"NoKey": {
+			reason: "Requests to a Receiver without a key should be rejected, because they cannot be authenticated.",
+			args: args{
+				method: http.MethodPost,
+				url:    "/",
+				body:   `[{"id":"1","subject":"/subscriptions/sub/resourceGroups/group","eventType":"Microsoft.Resources.ResourceWriteSuccess"}]`,
+			},
+			want: want{
+				status: http.StatusUnauthorized,
+				body:   http.StatusText(http.StatusUnauthorized) + "\n",
+			},
+		},
+		"Key": {
+			reason: "Requests with the key of the Receiver should be accepted.",
+			args: args{
+				o:      []ReceiverOption{WithKey("secret")},
+				method: http.MethodPost,
+				url:    "/?key=secret",
+				body:   `[]`,
+			},
+			want: want{
+				status: http.StatusOK,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var dispatched []string
+			d := dispatcherFn(func(_ context.Context, id string) error {
+				dispatched = append(dispatched, id)
+				return tc.args.err
+			})
+
+			w := httptest.NewRecorder()
+			NewReceiver(d, tc.args.o...).ServeHTTP(w, httptest.NewRequest(tc.args.method, tc.args.url, strings.NewReader(tc.args.body)))
+
+			if diff := cmp.Diff(tc.want.status, w.Code); diff != "" {
+				t.Errorf("\n%s\nr.ServeHTTP(...): -want status, +got status:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.body, w.Body.String()); diff != "" {
+				t.Errorf("\n%s\nr.ServeHTTP(...): -want body, +got body:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.dispatched, dispatched); diff != "" {
+				t.Errorf("\n%s\nr.ServeHTTP(...): -want dispatched, +got dispatched:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
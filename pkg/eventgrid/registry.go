@@ -0,0 +1,145 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package eventgrid enqueues managed resources as soon as Azure Event Grid
+// reports that the Azure resources they manage were changed or deleted, so
+// that out-of-band changes are corrected without waiting for the next sync.
+package eventgrid
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	azure "github.com/crossplane/provider-azure/pkg/clients"
+)
+
+const (
+	errIndex    = "cannot index managed resources by Azure resource ID"
+	errList     = "cannot list managed resources by Azure resource ID"
+	errDispatch = "cannot dispatch event"
+)
+
+// indexResourceID is the field index of managed resources by the ID of the
+// Azure resource they manage.
+const indexResourceID = "azureResourceID"
+
+// bufferSize is the number of events that may be dispatched to a kind of
+// managed resource before its controller receives them.
+const bufferSize = 1024
+
+// An IDFunc returns the ID of the Azure resource that the supplied managed
+// resource manages, as returned by azure.ResourceID. It returns an empty
+// string if the ID is not yet known, e.g. because the managed resource has no
+// external name.
+type IDFunc func(mg resource.Managed) string
+
+// A Dispatcher dispatches events for the managed resources that manage the
+// Azure resource with the supplied ID.
+type Dispatcher interface {
+	Dispatch(ctx context.Context, id string) error
+}
+
+type kind struct {
+	client client.Reader
+	list   resource.ManagedList
+	events chan event.GenericEvent
+}
+
+// A Registry of the kinds of managed resources that watch for Event Grid
+// events, indexed by the type of Azure resource they manage.
+type Registry struct {
+	mu    sync.RWMutex
+	kinds map[string][]kind
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{kinds: make(map[string][]kind)}
+}
+
+// DefaultRegistry is the Registry that managed resource controllers watch
+// using Watch.
+var DefaultRegistry = NewRegistry()
+
+// Watch the DefaultRegistry for events for managed resources of the supplied
+// kind, which manage Azure resources of the supplied type.
+func Watch(mgr ctrl.Manager, o resource.Managed, l resource.ManagedList, typ string, fn IDFunc) (source.Source, error) {
+	return DefaultRegistry.Watch(mgr, o, l, typ, fn)
+}
+
+// Watch returns a source of events for managed resources of the supplied
+// kind, which manage Azure resources of the supplied type. The managed
+// resources are indexed in the cache of the supplied manager by the ID that
+// the supplied IDFunc returns.
+func (r *Registry) Watch(mgr ctrl.Manager, o resource.Managed, l resource.ManagedList, typ string, fn IDFunc) (source.Source, error) {
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), o, indexResourceID, func(obj runtime.Object) []string {
+		mg, ok := obj.(resource.Managed)
+		if !ok {
+			return nil
+		}
+		if id := fn(mg); id != "" {
+			return []string{id}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, errIndex)
+	}
+
+	k := kind{client: mgr.GetClient(), list: l, events: make(chan event.GenericEvent, bufferSize)}
+	r.mu.Lock()
+	r.kinds[strings.ToLower(typ)] = append(r.kinds[strings.ToLower(typ)], k)
+	r.mu.Unlock()
+	return &source.Channel{Source: k.events}, nil
+}
+
+// Dispatch an event for each managed resource that manages the Azure resource
+// with the supplied ID. IDs of Azure resources that no kind of managed
+// resource manages are ignored.
+func (r *Registry) Dispatch(ctx context.Context, id string) error {
+	_, typ, rid, ok := azure.ParseResourceID(id)
+	if !ok {
+		return nil
+	}
+	r.mu.RLock()
+	kinds := r.kinds[typ]
+	r.mu.RUnlock()
+
+	for _, k := range kinds {
+		l := k.list.DeepCopyObject().(resource.ManagedList)
+		if err := k.client.List(ctx, l, client.MatchingFields{indexResourceID: rid}); err != nil {
+			return errors.Wrap(err, errList)
+		}
+		for _, mg := range l.GetItems() {
+			select {
+			case k.events <- event.GenericEvent{Meta: mg, Object: mg}:
+			case <-ctx.Done():
+				return errors.Wrap(ctx.Err(), errDispatch)
+			}
+		}
+	}
+	return nil
+}
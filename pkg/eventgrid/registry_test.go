@@ -0,0 +1,125 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventgrid
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/provider-azure/apis/network/v1alpha3"
+	azure "github.com/crossplane/provider-azure/pkg/clients"
+)
+
+const (
+	subnetType = "Microsoft.Network/virtualNetworks/subnets"
+	subnetID   = "/subscriptions/bf1b0e59-93da-42e0-82c6-5a1d94227911/resourceGroups/CoolGroup/providers/Microsoft.Network/virtualNetworks/coolnet/subnets/coolsubnet"
+)
+
+func TestDispatch(t *testing.T) {
+	errBoom := errors.New("boom")
+	subnet := v1alpha3.Subnet{ObjectMeta: metav1.ObjectMeta{Name: "coolsubnet"}}
+
+	type args struct {
+		client client.Reader
+		id     string
+	}
+	type want struct {
+		err    error
+		events []string
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Dispatched": {
+			reason: "An event should be dispatched for each managed resource indexed by the ID.",
+			args: args{
+				client: &test.MockClient{MockList: func(_ context.Context, obj runtime.Object, opts ...client.ListOption) error {
+					o := &client.ListOptions{}
+					o.ApplyOptions(opts)
+					want := azure.ResourceID("CoolGroup", subnetType, "coolnet", "coolsubnet")
+					if !o.FieldSelector.Matches(fields.Set{indexResourceID: want}) {
+						return errors.Errorf("unexpected field selector %s", o.FieldSelector)
+					}
+					obj.(*v1alpha3.SubnetList).Items = []v1alpha3.Subnet{subnet}
+					return nil
+				}},
+				id: subnetID,
+			},
+			want: want{
+				events: []string{subnet.GetName()},
+			},
+		},
+		"UnwatchedType": {
+			reason: "Events for types of Azure resources no managed resource manages should be ignored.",
+			args: args{
+				client: &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+				id:     "/subscriptions/sub/resourceGroups/CoolGroup/providers/Microsoft.Network/virtualNetworks/coolnet",
+			},
+		},
+		"InvalidID": {
+			reason: "Events for invalid IDs should be ignored.",
+			args: args{
+				client: &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+				id:     "/subscriptions/sub",
+			},
+		},
+		"ListError": {
+			reason: "Errors listing managed resources should be returned.",
+			args: args{
+				client: &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+				id:     subnetID,
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errList),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			events := make(chan event.GenericEvent, bufferSize)
+			r := &Registry{kinds: map[string][]kind{
+				"microsoft.network/virtualnetworks/subnets": {{client: tc.args.client, list: &v1alpha3.SubnetList{}, events: events}},
+			}}
+			err := r.Dispatch(context.Background(), tc.args.id)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nr.Dispatch(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			close(events)
+			var got []string
+			for e := range events {
+				got = append(got, e.Meta.GetName())
+			}
+			if diff := cmp.Diff(tc.want.events, got); diff != "" {
+				t.Errorf("\n%s\nr.Dispatch(...): -want events, +got events:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,591 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package export exports the existing Azure resources of a subscription as
+// managed resources that adopt them.
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/profiles/latest/redis/mgmt/redis"
+	"github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2018-03-31/containerservice"
+	"github.com/Azure/azure-sdk-for-go/services/cosmos-db/mgmt/2015-04-08/documentdb"
+	"github.com/Azure/azure-sdk-for-go/services/mysql/mgmt/2017-12-01/mysql"
+	networkmgmt "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-06-01/network"
+	"github.com/Azure/azure-sdk-for-go/services/postgresql/mgmt/2017-12-01/postgresql"
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
+	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2017-06-01/storage"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	cachev1beta1 "github.com/crossplane/provider-azure/apis/cache/v1beta1"
+	computev1alpha3 "github.com/crossplane/provider-azure/apis/compute/v1alpha3"
+	databasev1alpha3 "github.com/crossplane/provider-azure/apis/database/v1alpha3"
+	databasev1beta1 "github.com/crossplane/provider-azure/apis/database/v1beta1"
+	networkv1alpha3 "github.com/crossplane/provider-azure/apis/network/v1alpha3"
+	storagev1alpha3 "github.com/crossplane/provider-azure/apis/storage/v1alpha3"
+	"github.com/crossplane/provider-azure/apis/v1alpha3"
+	azure "github.com/crossplane/provider-azure/pkg/clients"
+	"github.com/crossplane/provider-azure/pkg/clients/database"
+	"github.com/crossplane/provider-azure/pkg/clients/database/cosmosdb"
+	azureredis "github.com/crossplane/provider-azure/pkg/clients/redis"
+)
+
+const (
+	errGetResourceGroup        = "cannot get resource group"
+	errListResourceGroups      = "cannot list resource groups"
+	errFmtListResources        = "cannot list %s of resource group %s"
+	errFmtListServerResources  = "cannot list %s of server %s"
+	errFmtListNetworkResources = "cannot list subnets of virtual network %s"
+	errConvertManaged          = "cannot convert managed resource to unstructured"
+	errMarshalManaged          = "cannot marshal managed resource"
+	errWriteManaged            = "cannot write managed resource"
+)
+
+// maxNameLength is the maximum length of the name of a Kubernetes object.
+const maxNameLength = 253
+
+// invalidName matches the characters of Azure resource names that are not
+// valid in the names of Kubernetes objects.
+var invalidName = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// An Exporter exports the Azure resources of a subscription, or of one of its
+// resource groups, that are supported by the provider as managed resources.
+type Exporter struct {
+	baseURI        string
+	subscription   string
+	authorizer     autorest.Authorizer
+	providerConfig string
+	annotations    map[string]string
+	group          string
+}
+
+// An ExporterOption configures an Exporter.
+type ExporterOption func(*Exporter)
+
+// WithSubscription causes the Exporter to export the Azure resources of the
+// supplied subscription rather than of the subscription of its credentials.
+// Exported managed resources are annotated with the subscription.
+func WithSubscription(id string) ExporterOption {
+	return func(e *Exporter) {
+		if id == "" {
+			return
+		}
+		e.subscription = id
+		e.annotations[azure.AnnotationKeySubscriptionID] = id
+	}
+}
+
+// WithResourceGroup causes the Exporter to only export the supplied resource
+// group and the Azure resources within it.
+func WithResourceGroup(name string) ExporterOption {
+	return func(e *Exporter) {
+		e.group = name
+	}
+}
+
+// WithObserveOnly causes the Exporter to export managed resources whose
+// management policy is ObserveOnly, so that they never change or delete the
+// Azure resources they adopt.
+func WithObserveOnly(observeOnly bool) ExporterOption {
+	return func(e *Exporter) {
+		if observeOnly {
+			e.annotations[azure.AnnotationKeyManagementPolicy] = azure.ManagementPolicyObserveOnly
+		}
+	}
+}
+
+// NewExporter returns an Exporter that reads Azure resources using the
+// supplied credentials content and authorizer, and exports managed resources
+// that reference the supplied ProviderConfig.
+func NewExporter(creds map[string]string, a autorest.Authorizer, providerConfig string, o ...ExporterOption) *Exporter {
+	e := &Exporter{
+		baseURI:        creds[azure.CredentialsKeyResourceManagerEndpointURL],
+		subscription:   creds[azure.CredentialsKeySubscriptionID],
+		authorizer:     a,
+		providerConfig: providerConfig,
+		annotations:    map[string]string{},
+	}
+	for _, eo := range o {
+		eo(e)
+	}
+	return e
+}
+
+// Export returns a managed resource for every supported Azure resource. The
+// external name of each managed resource is the name of the Azure resource it
+// adopts. Managed resources are named after their Azure resource and, if it is
+// nested, its parent. A numeric suffix is added where names collide.
+// Names are truncated to the maximum length of a Kubernetes object name.
+func (e *Exporter) Export(ctx context.Context) ([]resource.Managed, error) {
+	groups, err := e.resourceGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	exporters := []func(context.Context, string) ([]resource.Managed, error){
+		e.virtualNetworks,
+		e.mysqlServers,
+		e.postgresqlServers,
+		e.redisCaches,
+		e.cosmosDBAccounts,
+		e.storageAccounts,
+		e.aksClusters,
+	}
+	mgs := make([]resource.Managed, 0, len(groups))
+	for _, g := range groups {
+		mgs = append(mgs, g)
+		for _, fn := range exporters {
+			exported, err := fn(ctx, meta.GetExternalName(g))
+			if err != nil {
+				return nil, err
+			}
+			mgs = append(mgs, exported...)
+		}
+	}
+
+	uniqueNames(mgs)
+	return mgs, nil
+}
+
+// uniqueNames renames the supplied managed resources whose name is already
+// used by a preceding managed resource of the same kind, by adding the lowest
+// numeric suffix that makes their name unique among all of them.
+func uniqueNames(mgs []resource.Managed) {
+	key := func(mg resource.Managed, name string) string { return fmt.Sprintf("%T/%s", mg, name) }
+
+	taken := map[string]bool{}
+	for _, mg := range mgs {
+		taken[key(mg, mg.GetName())] = true
+	}
+
+	kept := map[string]bool{}
+	for _, mg := range mgs {
+		if k := key(mg, mg.GetName()); !kept[k] {
+			kept[k] = true
+			continue
+		}
+		for n := 2; ; n++ {
+			candidate := suffixed(mg.GetName(), n)
+			if k := key(mg, candidate); !taken[k] {
+				taken[k], kept[k] = true, true
+				mg.SetName(candidate)
+				break
+			}
+		}
+	}
+}
+
+// suffixed returns the supplied name with the supplied numeric suffix, with
+// the name truncated so that the result is a valid Kubernetes object name.
+func suffixed(name string, n int) string {
+	suffix := fmt.Sprintf("-%d", n)
+	if len(name)+len(suffix) > maxNameLength {
+		name = strings.TrimRight(name[:maxNameLength-len(suffix)], "-.")
+	}
+	return name + suffix
+}
+
+// adopt sets the supplied managed resource's name, external name, annotations
+// and ProviderConfig reference.
+func (e *Exporter) adopt(mg resource.Managed, externalName string, parents ...string) resource.Managed {
+	mg.SetName(name(append(parents, externalName)...))
+	a := map[string]string{meta.AnnotationKeyExternalName: externalName}
+	for k, v := range e.annotations {
+		a[k] = v
+	}
+	mg.SetAnnotations(a)
+	mg.SetProviderConfigReference(&runtimev1alpha1.Reference{Name: e.providerConfig})
+	return mg
+}
+
+func (e *Exporter) resourceGroups(ctx context.Context) ([]resource.Managed, error) {
+	cl := resources.NewGroupsClientWithBaseURI(e.baseURI, e.subscription)
+	azure.ConfigureClient(&cl.Client, e.authorizer)
+
+	if e.group != "" {
+		g, err := cl.Get(ctx, e.group)
+		if err != nil {
+			return nil, errors.Wrap(err, errGetResourceGroup)
+		}
+		return []resource.Managed{e.resourceGroup(g)}, nil
+	}
+
+	mgs := []resource.Managed{}
+	it, err := cl.ListComplete(ctx, "", nil)
+	for ; err == nil && it.NotDone(); err = it.NextWithContext(ctx) {
+		mgs = append(mgs, e.resourceGroup(it.Value()))
+	}
+	return mgs, errors.Wrap(err, errListResourceGroups)
+}
+
+func (e *Exporter) resourceGroup(g resources.Group) resource.Managed {
+	cr := &v1alpha3.ResourceGroup{Spec: v1alpha3.ResourceGroupSpec{Location: azure.ToString(g.Location)}}
+	cr.SetGroupVersionKind(v1alpha3.ResourceGroupGroupVersionKind)
+	return e.adopt(cr, azure.ToString(g.Name))
+}
+
+func (e *Exporter) virtualNetworks(ctx context.Context, group string) ([]resource.Managed, error) {
+	cl := networkmgmt.NewVirtualNetworksClientWithBaseURI(e.baseURI, e.subscription)
+	azure.ConfigureClient(&cl.Client, e.authorizer)
+	scl := networkmgmt.NewSubnetsClientWithBaseURI(e.baseURI, e.subscription)
+	azure.ConfigureClient(&scl.Client, e.authorizer)
+
+	mgs := []resource.Managed{}
+	it, err := cl.ListComplete(ctx, group)
+	for ; err == nil && it.NotDone(); err = it.NextWithContext(ctx) {
+		v := it.Value()
+		cr := &networkv1alpha3.VirtualNetwork{Spec: networkv1alpha3.VirtualNetworkSpec{
+			ResourceGroupName: group,
+			Location:          azure.ToString(v.Location),
+			Tags:              azure.ToStringMap(v.Tags),
+		}}
+		if p := v.VirtualNetworkPropertiesFormat; p != nil {
+			if p.AddressSpace != nil && p.AddressSpace.AddressPrefixes != nil {
+				cr.Spec.AddressSpace.AddressPrefixes = *p.AddressSpace.AddressPrefixes
+			}
+			cr.Spec.EnableDDOSProtection = azure.ToBool(p.EnableDdosProtection)
+			cr.Spec.EnableVMProtection = azure.ToBool(p.EnableVMProtection)
+		}
+		cr.SetGroupVersionKind(networkv1alpha3.VirtualNetworkGroupVersionKind)
+		mgs = append(mgs, e.adopt(cr, azure.ToString(v.Name)))
+
+		subnets, err := e.subnets(ctx, scl, group, azure.ToString(v.Name))
+		if err != nil {
+			return nil, err
+		}
+		mgs = append(mgs, subnets...)
+	}
+	return mgs, errors.Wrapf(err, errFmtListResources, "virtual networks", group)
+}
+
+func (e *Exporter) subnets(ctx context.Context, cl networkmgmt.SubnetsClient, group, network string) ([]resource.Managed, error) {
+	mgs := []resource.Managed{}
+	it, err := cl.ListComplete(ctx, group, network)
+	for ; err == nil && it.NotDone(); err = it.NextWithContext(ctx) {
+		s := it.Value()
+		cr := &networkv1alpha3.Subnet{Spec: networkv1alpha3.SubnetSpec{
+			ResourceGroupName:  group,
+			VirtualNetworkName: network,
+		}}
+		if p := s.SubnetPropertiesFormat; p != nil {
+			cr.Spec.AddressPrefix = azure.ToString(p.AddressPrefix)
+			if p.ServiceEndpoints != nil {
+				for _, se := range *p.ServiceEndpoints {
+					ep := networkv1alpha3.ServiceEndpointPropertiesFormat{Service: azure.ToString(se.Service)}
+					if se.Locations != nil {
+						ep.Locations = *se.Locations
+					}
+					cr.Spec.ServiceEndpoints = append(cr.Spec.ServiceEndpoints, ep)
+				}
+			}
+		}
+		cr.SetGroupVersionKind(networkv1alpha3.SubnetGroupVersionKind)
+		mgs = append(mgs, e.adopt(cr, azure.ToString(s.Name), network))
+	}
+	return mgs, errors.Wrapf(err, errFmtListNetworkResources, network)
+}
+
+func (e *Exporter) mysqlServers(ctx context.Context, group string) ([]resource.Managed, error) {
+	cl := mysql.NewServersClientWithBaseURI(e.baseURI, e.subscription)
+	azure.ConfigureClient(&cl.Client, e.authorizer)
+	fcl := mysql.NewFirewallRulesClientWithBaseURI(e.baseURI, e.subscription)
+	azure.ConfigureClient(&fcl.Client, e.authorizer)
+	vcl := mysql.NewVirtualNetworkRulesClientWithBaseURI(e.baseURI, e.subscription)
+	azure.ConfigureClient(&vcl.Client, e.authorizer)
+
+	l, err := cl.ListByResourceGroup(ctx, group)
+	if err != nil || l.Value == nil {
+		return nil, errors.Wrapf(err, errFmtListResources, "MySQL servers", group)
+	}
+	mgs := []resource.Managed{}
+	for _, s := range *l.Value {
+		cr := &databasev1beta1.MySQLServer{Spec: databasev1beta1.SQLServerSpec{ForProvider: databasev1beta1.SQLServerParameters{
+			ResourceGroupName: group,
+			Location:          azure.ToString(s.Location),
+		}}}
+		if s.Sku != nil {
+			cr.Spec.ForProvider.SKU = databasev1beta1.SKU{Tier: string(s.Sku.Tier), Capacity: azure.ToInt(s.Sku.Capacity), Family: azure.ToString(s.Sku.Family)}
+		}
+		if s.ServerProperties != nil {
+			cr.Spec.ForProvider.AdministratorLogin = azure.ToString(s.AdministratorLogin)
+			cr.Spec.ForProvider.Version = string(s.Version)
+			cr.Spec.ForProvider.SSLEnforcement = string(s.SslEnforcement)
+			if s.StorageProfile != nil {
+				cr.Spec.ForProvider.StorageProfile.StorageMB = azure.ToInt(s.StorageProfile.StorageMB)
+			}
+		}
+		database.LateInitializeMySQL(&cr.Spec.ForProvider, s)
+		cr.SetGroupVersionKind(databasev1beta1.MySQLServerGroupVersionKind)
+		server := azure.ToString(s.Name)
+		mgs = append(mgs, e.adopt(cr, server))
+
+		fl, err := fcl.ListByServer(ctx, group, server)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtListServerResources, "firewall rules", server)
+		}
+		if fl.Value != nil {
+			for _, r := range *fl.Value {
+				cr := &databasev1alpha3.MySQLServerFirewallRule{Spec: databasev1alpha3.FirewallRuleSpec{ForProvider: databasev1alpha3.FirewallRuleParameters{
+					ServerName:        server,
+					ResourceGroupName: group,
+				}}}
+				if r.FirewallRuleProperties != nil {
+					cr.Spec.ForProvider.StartIPAddress = azure.ToString(r.StartIPAddress)
+					cr.Spec.ForProvider.EndIPAddress = azure.ToString(r.EndIPAddress)
+				}
+				cr.SetGroupVersionKind(databasev1alpha3.MySQLServerFirewallRuleGroupVersionKind)
+				mgs = append(mgs, e.adopt(cr, azure.ToString(r.Name), server))
+			}
+		}
+
+		it, err := vcl.ListByServerComplete(ctx, group, server)
+		for ; err == nil && it.NotDone(); err = it.NextWithContext(ctx) {
+			r := it.Value()
+			cr := &databasev1alpha3.MySQLServerVirtualNetworkRule{Spec: databasev1alpha3.MySQLVirtualNetworkRuleSpec{
+				ServerName:        server,
+				ResourceGroupName: group,
+			}}
+			if r.VirtualNetworkRuleProperties != nil {
+				cr.Spec.VirtualNetworkSubnetID = azure.ToString(r.VirtualNetworkSubnetID)
+				cr.Spec.IgnoreMissingVnetServiceEndpoint = azure.ToBool(r.IgnoreMissingVnetServiceEndpoint)
+			}
+			cr.SetGroupVersionKind(databasev1alpha3.MySQLServerVirtualNetworkRuleGroupVersionKind)
+			mgs = append(mgs, e.adopt(cr, azure.ToString(r.Name), server))
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtListServerResources, "virtual network rules", server)
+		}
+	}
+	return mgs, nil
+}
+
+func (e *Exporter) postgresqlServers(ctx context.Context, group string) ([]resource.Managed, error) {
+	cl := postgresql.NewServersClientWithBaseURI(e.baseURI, e.subscription)
+	azure.ConfigureClient(&cl.Client, e.authorizer)
+	fcl := postgresql.NewFirewallRulesClientWithBaseURI(e.baseURI, e.subscription)
+	azure.ConfigureClient(&fcl.Client, e.authorizer)
+	vcl := postgresql.NewVirtualNetworkRulesClientWithBaseURI(e.baseURI, e.subscription)
+	azure.ConfigureClient(&vcl.Client, e.authorizer)
+
+	l, err := cl.ListByResourceGroup(ctx, group)
+	if err != nil || l.Value == nil {
+		return nil, errors.Wrapf(err, errFmtListResources, "PostgreSQL servers", group)
+	}
+	mgs := []resource.Managed{}
+	for _, s := range *l.Value {
+		cr := &databasev1beta1.PostgreSQLServer{Spec: databasev1beta1.SQLServerSpec{ForProvider: databasev1beta1.SQLServerParameters{
+			ResourceGroupName: group,
+			Location:          azure.ToString(s.Location),
+		}}}
+		if s.Sku != nil {
+			cr.Spec.ForProvider.SKU = databasev1beta1.SKU{Tier: string(s.Sku.Tier), Capacity: azure.ToInt(s.Sku.Capacity), Family: azure.ToString(s.Sku.Family)}
+		}
+		if s.ServerProperties != nil {
+			cr.Spec.ForProvider.AdministratorLogin = azure.ToString(s.AdministratorLogin)
+			cr.Spec.ForProvider.Version = string(s.Version)
+			cr.Spec.ForProvider.SSLEnforcement = string(s.SslEnforcement)
+			if s.StorageProfile != nil {
+				cr.Spec.ForProvider.StorageProfile.StorageMB = azure.ToInt(s.StorageProfile.StorageMB)
+			}
+		}
+		database.LateInitializePostgreSQL(&cr.Spec.ForProvider, s)
+		cr.SetGroupVersionKind(databasev1beta1.PostgreSQLServerGroupVersionKind)
+		server := azure.ToString(s.Name)
+		mgs = append(mgs, e.adopt(cr, server))
+
+		fl, err := fcl.ListByServer(ctx, group, server)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtListServerResources, "firewall rules", server)
+		}
+		if fl.Value != nil {
+			for _, r := range *fl.Value {
+				cr := &databasev1alpha3.PostgreSQLServerFirewallRule{Spec: databasev1alpha3.FirewallRuleSpec{ForProvider: databasev1alpha3.FirewallRuleParameters{
+					ServerName:        server,
+					ResourceGroupName: group,
+				}}}
+				if r.FirewallRuleProperties != nil {
+					cr.Spec.ForProvider.StartIPAddress = azure.ToString(r.StartIPAddress)
+					cr.Spec.ForProvider.EndIPAddress = azure.ToString(r.EndIPAddress)
+				}
+				cr.SetGroupVersionKind(databasev1alpha3.PostgreSQLServerFirewallRuleGroupVersionKind)
+				mgs = append(mgs, e.adopt(cr, azure.ToString(r.Name), server))
+			}
+		}
+
+		it, err := vcl.ListByServerComplete(ctx, group, server)
+		for ; err == nil && it.NotDone(); err = it.NextWithContext(ctx) {
+			r := it.Value()
+			cr := &databasev1alpha3.PostgreSQLServerVirtualNetworkRule{Spec: databasev1alpha3.PostgreSQLVirtualNetworkRuleSpec{
+				ServerName:        server,
+				ResourceGroupName: group,
+			}}
+			if r.VirtualNetworkRuleProperties != nil {
+				cr.Spec.VirtualNetworkSubnetID = azure.ToString(r.VirtualNetworkSubnetID)
+				cr.Spec.IgnoreMissingVnetServiceEndpoint = azure.ToBool(r.IgnoreMissingVnetServiceEndpoint)
+			}
+			cr.SetGroupVersionKind(databasev1alpha3.PostgreSQLServerVirtualNetworkRuleGroupVersionKind)
+			mgs = append(mgs, e.adopt(cr, azure.ToString(r.Name), server))
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtListServerResources, "virtual network rules", server)
+		}
+	}
+	return mgs, nil
+}
+
+func (e *Exporter) redisCaches(ctx context.Context, group string) ([]resource.Managed, error) {
+	cl := redis.NewClientWithBaseURI(e.baseURI, e.subscription)
+	azure.ConfigureClient(&cl.Client, e.authorizer)
+
+	mgs := []resource.Managed{}
+	it, err := cl.ListByResourceGroupComplete(ctx, group)
+	for ; err == nil && it.NotDone(); err = it.NextWithContext(ctx) {
+		r := it.Value()
+		cr := &cachev1beta1.Redis{Spec: cachev1beta1.RedisSpec{ForProvider: cachev1beta1.RedisParameters{
+			ResourceGroupName: group,
+			Location:          azure.ToString(r.Location),
+		}}}
+		if r.Properties != nil && r.Properties.Sku != nil {
+			sku := r.Properties.Sku
+			cr.Spec.ForProvider.SKU = cachev1beta1.SKU{Name: string(sku.Name), Family: string(sku.Family), Capacity: azure.ToInt(sku.Capacity)}
+		}
+		azureredis.LateInitialize(&cr.Spec.ForProvider, r)
+		cr.SetGroupVersionKind(cachev1beta1.RedisGroupVersionKind)
+		mgs = append(mgs, e.adopt(cr, azure.ToString(r.Name)))
+	}
+	return mgs, errors.Wrapf(err, errFmtListResources, "Redis caches", group)
+}
+
+func (e *Exporter) cosmosDBAccounts(ctx context.Context, group string) ([]resource.Managed, error) {
+	cl := documentdb.NewDatabaseAccountsClientWithBaseURI(e.baseURI, e.subscription)
+	azure.ConfigureClient(&cl.Client, e.authorizer)
+
+	l, err := cl.ListByResourceGroup(ctx, group)
+	if err != nil || l.Value == nil {
+		return nil, errors.Wrapf(err, errFmtListResources, "CosmosDB accounts", group)
+	}
+	mgs := []resource.Managed{}
+	for _, a := range *l.Value {
+		cr := &databasev1alpha3.CosmosDBAccount{Spec: databasev1alpha3.CosmosDBAccountSpec{ForProvider: cosmosdb.FromDatabaseAccount(a)}}
+		cr.Spec.ForProvider.ResourceGroupName = group
+		cr.SetGroupVersionKind(databasev1alpha3.CosmosDBAccountGroupVersionKind)
+		mgs = append(mgs, e.adopt(cr, azure.ToString(a.Name)))
+	}
+	return mgs, nil
+}
+
+func (e *Exporter) storageAccounts(ctx context.Context, group string) ([]resource.Managed, error) {
+	cl := storage.NewAccountsClientWithBaseURI(e.baseURI, e.subscription)
+	azure.ConfigureClient(&cl.Client, e.authorizer)
+
+	l, err := cl.ListByResourceGroup(ctx, group)
+	if err != nil || l.Value == nil {
+		return nil, errors.Wrapf(err, errFmtListResources, "storage accounts", group)
+	}
+	mgs := []resource.Managed{}
+	for i := range *l.Value {
+		a := &(*l.Value)[i]
+		cr := &storagev1alpha3.Account{Spec: storagev1alpha3.AccountSpec{AccountParameters: storagev1alpha3.AccountParameters{
+			ResourceGroupName:  group,
+			StorageAccountSpec: storagev1alpha3.NewStorageAccountSpec(a),
+		}}}
+		cr.SetGroupVersionKind(storagev1alpha3.AccountGroupVersionKind)
+		mgs = append(mgs, e.adopt(cr, azure.ToString(a.Name)))
+	}
+	return mgs, nil
+}
+
+func (e *Exporter) aksClusters(ctx context.Context, group string) ([]resource.Managed, error) {
+	cl := containerservice.NewManagedClustersClientWithBaseURI(e.baseURI, e.subscription)
+	azure.ConfigureClient(&cl.Client, e.authorizer)
+
+	mgs := []resource.Managed{}
+	it, err := cl.ListByResourceGroupComplete(ctx, group)
+	for ; err == nil && it.NotDone(); err = it.NextWithContext(ctx) {
+		c := it.Value()
+		cr := &computev1alpha3.AKSCluster{Spec: computev1alpha3.AKSClusterSpec{AKSClusterParameters: computev1alpha3.AKSClusterParameters{
+			ResourceGroupName: group,
+			Location:          azure.ToString(c.Location),
+		}}}
+		if p := c.ManagedClusterProperties; p != nil {
+			cr.Spec.Version = azure.ToString(p.KubernetesVersion)
+			cr.Spec.DNSNamePrefix = azure.ToString(p.DNSPrefix)
+			cr.Spec.DisableRBAC = p.EnableRBAC != nil && !*p.EnableRBAC
+			if p.AgentPoolProfiles != nil && len(*p.AgentPoolProfiles) > 0 {
+				pool := (*p.AgentPoolProfiles)[0]
+				if pool.Count != nil {
+					n := int(*pool.Count)
+					cr.Spec.NodeCount = &n
+				}
+				cr.Spec.NodeVMSize = string(pool.VMSize)
+				cr.Spec.VnetSubnetID = azure.ToString(pool.VnetSubnetID)
+			}
+		}
+		cr.SetGroupVersionKind(computev1alpha3.AKSClusterGroupVersionKind)
+		mgs = append(mgs, e.adopt(cr, azure.ToString(c.Name)))
+	}
+	return mgs, errors.Wrapf(err, errFmtListResources, "AKS clusters", group)
+}
+
+// Write the supplied managed resources to the supplied writer as a stream of
+// YAML documents, omitting their status and any other fields that are not
+// applicable.
+func Write(w io.Writer, mgs []resource.Managed) error {
+	for i, mg := range mgs {
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(mg)
+		if err != nil {
+			return errors.Wrap(err, errConvertManaged)
+		}
+		delete(u, "status")
+		unstructured.RemoveNestedField(u, "metadata", "creationTimestamp")
+
+		b, err := yaml.Marshal(u)
+		if err != nil {
+			return errors.Wrap(err, errMarshalManaged)
+		}
+		if i > 0 {
+			b = append([]byte("---\n"), b...)
+		}
+		if _, err := w.Write(b); err != nil {
+			return errors.Wrap(err, errWriteManaged)
+		}
+	}
+	return nil
+}
+
+// name returns a valid Kubernetes object name for an Azure resource with the
+// supplied name, and the supplied names of its parents, if any. Names that are
+// too long are truncated.
+func name(names ...string) string {
+	n := strings.Trim(invalidName.ReplaceAllString(strings.ToLower(strings.Join(names, "-")), "-"), "-.")
+	if len(n) > maxNameLength {
+		n = strings.TrimRight(n[:maxNameLength], "-.")
+	}
+	return n
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	networkv1alpha3 "github.com/crossplane/provider-azure/apis/network/v1alpha3"
+	"github.com/crossplane/provider-azure/apis/v1alpha3"
+	azure "github.com/crossplane/provider-azure/pkg/clients"
+	"github.com/crossplane/provider-azure/pkg/clients/fake/arm"
+)
+
+const (
+	subscription   = "bf1b0e59-93da-42e0-82c6-5a1d94227911"
+	providerConfig = "coolconfig"
+)
+
+type exported struct {
+	Kind         string
+	Name         string
+	ExternalName string
+	Subscription string
+	ObserveOnly  bool
+}
+
+func TestExport(t *testing.T) {
+	s := arm.NewServer()
+	defer s.Close()
+
+	id := func(path string) string {
+		return "/subscriptions/" + subscription + "/resourceGroups/" + path
+	}
+	s.Add(id("CoolGroup"), map[string]interface{}{"location": "westus"})
+	s.Add(id("OtherGroup"), map[string]interface{}{"location": "westus"})
+	s.Add(id("CoolGroup/providers/Microsoft.Network/virtualNetworks/CoolNet"), map[string]interface{}{
+		"location":   "westus",
+		"properties": map[string]interface{}{"addressSpace": map[string]interface{}{"addressPrefixes": []string{"10.0.0.0/16"}}},
+	})
+	s.Add(id("CoolGroup/providers/Microsoft.Network/virtualNetworks/CoolNet/subnets/default"), map[string]interface{}{
+		"properties": map[string]interface{}{"addressPrefix": "10.0.0.0/24"},
+	})
+	s.Add(id("CoolGroup/providers/Microsoft.DBforMySQL/servers/coolserver"), map[string]interface{}{
+		"location":   "westus",
+		"sku":        map[string]interface{}{"tier": "GeneralPurpose", "capacity": 2, "family": "Gen5"},
+		"properties": map[string]interface{}{"administratorLogin": "cool", "version": "5.7"},
+	})
+	s.Add(id("CoolGroup/providers/Microsoft.DBforMySQL/servers/coolserver/firewallRules/allow_all"), map[string]interface{}{
+		"properties": map[string]interface{}{"startIpAddress": "0.0.0.0", "endIpAddress": "255.255.255.255"},
+	})
+	s.Add(id("OtherGroup/providers/Microsoft.Network/virtualNetworks/coolnet"), map[string]interface{}{"location": "westus"})
+	s.Add(id("OtherGroup/providers/Microsoft.Cache/Redis/coolcache"), map[string]interface{}{
+		"location":   "westus",
+		"properties": map[string]interface{}{"sku": map[string]interface{}{"name": "Basic", "family": "C", "capacity": 0}},
+	})
+
+	type want struct {
+		exported []exported
+		err      bool
+	}
+	cases := map[string]struct {
+		reason       string
+		subscription string
+		o            []ExporterOption
+		want         want
+	}{
+		"Subscription": {
+			reason:       "Every supported Azure resource of the subscription should be exported, with a numeric suffix where names collide.",
+			subscription: subscription,
+			want: want{exported: []exported{
+				{Kind: "ResourceGroup", Name: "coolgroup", ExternalName: "CoolGroup"},
+				{Kind: "VirtualNetwork", Name: "coolnet", ExternalName: "CoolNet"},
+				{Kind: "Subnet", Name: "coolnet-default", ExternalName: "default"},
+				{Kind: "MySQLServer", Name: "coolserver", ExternalName: "coolserver"},
+				{Kind: "MySQLServerFirewallRule", Name: "coolserver-allow-all", ExternalName: "allow_all"},
+				{Kind: "ResourceGroup", Name: "othergroup", ExternalName: "OtherGroup"},
+				{Kind: "VirtualNetwork", Name: "coolnet-2", ExternalName: "coolnet"},
+				{Kind: "Redis", Name: "coolcache", ExternalName: "coolcache"},
+			}},
+		},
+		"ObserveOnlyResourceGroup": {
+			reason:       "Only the supplied resource group and the Azure resources within it should be exported, with the ObserveOnly management policy if requested.",
+			subscription: subscription,
+			o:            []ExporterOption{WithResourceGroup("OtherGroup"), WithObserveOnly(true)},
+			want: want{exported: []exported{
+				{Kind: "ResourceGroup", Name: "othergroup", ExternalName: "OtherGroup", ObserveOnly: true},
+				{Kind: "VirtualNetwork", Name: "coolnet", ExternalName: "coolnet", ObserveOnly: true},
+				{Kind: "Redis", Name: "coolcache", ExternalName: "coolcache", ObserveOnly: true},
+			}},
+		},
+		"OtherSubscription": {
+			reason: "Azure resources of the supplied subscription should be exported as managed resources annotated with the subscription.",
+			o:      []ExporterOption{WithSubscription(subscription), WithResourceGroup("OtherGroup")},
+			want: want{exported: []exported{
+				{Kind: "ResourceGroup", Name: "othergroup", ExternalName: "OtherGroup", Subscription: subscription},
+				{Kind: "VirtualNetwork", Name: "coolnet", ExternalName: "coolnet", Subscription: subscription},
+				{Kind: "Redis", Name: "coolcache", ExternalName: "coolcache", Subscription: subscription},
+			}},
+		},
+		"ResourceGroupNotFound": {
+			reason:       "An error should be returned if the supplied resource group does not exist.",
+			subscription: subscription,
+			o:            []ExporterOption{WithResourceGroup("MissingGroup")},
+			want:         want{err: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			creds := map[string]string{
+				azure.CredentialsKeyResourceManagerEndpointURL: s.URL,
+				azure.CredentialsKeySubscriptionID:             tc.subscription,
+			}
+			mgs, err := NewExporter(creds, autorest.NullAuthorizer{}, providerConfig, tc.o...).Export(context.Background())
+			if diff := cmp.Diff(tc.want.err, err != nil); diff != "" {
+				t.Errorf("\n%s\ne.Export(...): -want error, +got error:\n%s\n%v", tc.reason, diff, err)
+			}
+
+			var got []exported
+			for _, mg := range mgs {
+				if mg.GetProviderConfigReference().Name != providerConfig {
+					t.Errorf("\n%s\ne.Export(...): %s does not reference ProviderConfig %s", tc.reason, mg.GetName(), providerConfig)
+				}
+				got = append(got, exported{
+					Kind:         mg.GetObjectKind().GroupVersionKind().Kind,
+					Name:         mg.GetName(),
+					ExternalName: meta.GetExternalName(mg),
+					Subscription: mg.GetAnnotations()[azure.AnnotationKeySubscriptionID],
+					ObserveOnly:  azure.IsObserveOnly(mg),
+				})
+			}
+			if diff := cmp.Diff(tc.want.exported, got); diff != "" {
+				t.Errorf("\n%s\ne.Export(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	rg := func(name, location string) resource.Managed {
+		cr := &v1alpha3.ResourceGroup{Spec: v1alpha3.ResourceGroupSpec{Location: location}}
+		cr.SetGroupVersionKind(v1alpha3.ResourceGroupGroupVersionKind)
+		cr.SetName(name)
+		return cr
+	}
+
+	want := `apiVersion: azure.crossplane.io/v1alpha3
+kind: ResourceGroup
+metadata:
+  name: coolgroup
+spec:
+  location: westus
+---
+apiVersion: azure.crossplane.io/v1alpha3
+kind: ResourceGroup
+metadata:
+  name: othergroup
+spec:
+  location: eastus
+`
+	b := &bytes.Buffer{}
+	if err := Write(b, []resource.Managed{rg("coolgroup", "westus"), rg("othergroup", "eastus")}); err != nil {
+		t.Fatalf("Write(...): %s", err)
+	}
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("Write(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestUniqueNames(t *testing.T) {
+	long := strings.Repeat("a", maxNameLength)
+	group := func(n string) resource.Managed {
+		g := &v1alpha3.ResourceGroup{}
+		g.SetName(n)
+		return g
+	}
+	network := func(n string) resource.Managed {
+		v := &networkv1alpha3.VirtualNetwork{}
+		v.SetName(n)
+		return v
+	}
+
+	cases := map[string]struct {
+		reason string
+		mgs    []resource.Managed
+		want   []string
+	}{
+		"Unique": {
+			reason: "Managed resources whose names are unique should keep them.",
+			mgs:    []resource.Managed{group("foo"), group("bar")},
+			want:   []string{"foo", "bar"},
+		},
+		"OtherKinds": {
+			reason: "Managed resources of different kinds may have the same name.",
+			mgs:    []resource.Managed{group("foo"), network("foo")},
+			want:   []string{"foo", "foo"},
+		},
+		"Collision": {
+			reason: "Managed resources whose name collides should have the lowest suffix that is not used by any other managed resource.",
+			mgs:    []resource.Managed{group("foo"), group("foo"), group("foo-2"), group("foo")},
+			want:   []string{"foo", "foo-3", "foo-2", "foo-4"},
+		},
+		"LongName": {
+			reason: "Suffixed names should be truncated to the maximum length of a Kubernetes object name.",
+			mgs:    []resource.Managed{group(long), group(long)},
+			want:   []string{long, long[:maxNameLength-2] + "-2"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			uniqueNames(tc.mgs)
+			got := make([]string, len(tc.mgs))
+			for i, mg := range tc.mgs {
+				got[i] = mg.GetName()
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nuniqueNames(...): -want names, +got names:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestName(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		names  []string
+		want   string
+	}{
+		"Nested": {
+			reason: "Nested Azure resources should be named after their parents, with invalid characters replaced.",
+			names:  []string{"Cool_Server", "AllowAll"},
+			want:   "cool-server-allowall",
+		},
+		"TooLong": {
+			reason: "Names should be truncated to the maximum length of a Kubernetes object name, without a trailing separator.",
+			names:  []string{strings.Repeat("s", maxNameLength-1), "rule"},
+			want:   strings.Repeat("s", maxNameLength-1),
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, name(tc.names...)); diff != "" {
+				t.Errorf("\n%s\nname(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
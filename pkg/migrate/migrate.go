@@ -0,0 +1,271 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package migrate migrates from the deprecated Provider kind to ProviderConfig.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	"github.com/crossplane/provider-azure/apis/v1alpha3"
+	"github.com/crossplane/provider-azure/apis/v1beta1"
+)
+
+const (
+	errListProviders        = "cannot list Providers"
+	errGetProviderConfig    = "cannot get ProviderConfig"
+	errCreateProviderConfig = "cannot create ProviderConfig"
+	errFmtCredentialsDiffer = "cannot migrate Provider %s: existing ProviderConfig %s does not use its credentials secret %s"
+	errFmtNewList           = "cannot create list of %s"
+	errFmtListManaged       = "cannot list %s"
+	errFmtExtractList       = "cannot extract items of %s"
+	errFmtPatchManaged      = "cannot patch %s %s"
+)
+
+// A Change made, or that would be made in a dry run, by a Migrator.
+type Change struct {
+	// Kind of the changed object.
+	Kind string
+
+	// Name of the changed object.
+	Name string
+
+	// Description of the change.
+	Description string
+}
+
+// String returns a human readable description of the change.
+func (c Change) String() string {
+	return fmt.Sprintf("%s %s: %s", c.Kind, c.Name, c.Description)
+}
+
+// A Migrator creates a ProviderConfig for every deprecated Provider, and
+// changes every managed resource that references a Provider to reference the
+// equivalent ProviderConfig instead.
+type Migrator struct {
+	client client.Client
+	scheme *runtime.Scheme
+	kinds  []schema.GroupVersionKind
+	dryRun bool
+}
+
+// A MigratorOption configures a Migrator.
+type MigratorOption func(*Migrator)
+
+// WithDryRun causes the Migrator to only report the changes it would make.
+// Changes are still submitted to the API server, which validates but does not
+// persist them.
+func WithDryRun(dryRun bool) MigratorOption {
+	return func(m *Migrator) {
+		m.dryRun = dryRun
+	}
+}
+
+// WithManagedKinds specifies the kinds of managed resources the Migrator
+// should migrate. Defaults to all managed resource kinds known to the scheme
+// of the Migrator.
+func WithManagedKinds(k ...schema.GroupVersionKind) MigratorOption {
+	return func(m *Migrator) {
+		m.kinds = k
+	}
+}
+
+// NewMigrator returns a Migrator that uses the supplied client and scheme.
+func NewMigrator(c client.Client, s *runtime.Scheme, o ...MigratorOption) *Migrator {
+	m := &Migrator{client: c, scheme: s, kinds: ManagedKinds(s)}
+	for _, mo := range o {
+		mo(m)
+	}
+	return m
+}
+
+// ManagedKinds returns the kinds of all managed resources known to the
+// supplied scheme, sorted by name.
+func ManagedKinds(s *runtime.Scheme) []schema.GroupVersionKind {
+	kinds := []schema.GroupVersionKind{}
+	for gvk := range s.AllKnownTypes() {
+		if strings.HasSuffix(gvk.Kind, "List") {
+			continue
+		}
+		o, err := s.New(gvk)
+		if err != nil {
+			continue
+		}
+		if _, ok := o.(resource.Managed); !ok {
+			continue
+		}
+		if _, err := s.New(gvk.GroupVersion().WithKind(gvk.Kind + "List")); err != nil {
+			continue
+		}
+		kinds = append(kinds, gvk)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i].String() < kinds[j].String() })
+	return kinds
+}
+
+// Run the migration, returning the changes that were made.
+func (m *Migrator) Run(ctx context.Context) ([]Change, error) {
+	changes, err := m.migrateProviders(ctx)
+	if err != nil {
+		return changes, err
+	}
+	for _, gvk := range m.kinds {
+		c, err := m.migrateManaged(ctx, gvk)
+		changes = append(changes, c...)
+		if err != nil {
+			return changes, err
+		}
+	}
+	return changes, nil
+}
+
+func (m *Migrator) migrateProviders(ctx context.Context) ([]Change, error) {
+	l := &v1alpha3.ProviderList{}
+	if err := m.client.List(ctx, l); err != nil {
+		return nil, errors.Wrap(err, errListProviders)
+	}
+	sort.Slice(l.Items, func(i, j int) bool { return l.Items[i].GetName() < l.Items[j].GetName() })
+
+	changes := []Change{}
+	for _, p := range l.Items {
+		ref := p.Spec.CredentialsSecretRef
+
+		// Managed resources that reference the Provider will reference a
+		// ProviderConfig of the same name, which must therefore use the
+		// same credentials if it already exists.
+		existing := &v1beta1.ProviderConfig{}
+		err := m.client.Get(ctx, client.ObjectKey{Name: p.GetName()}, existing)
+		if err == nil {
+			if !usesSecret(existing, ref) {
+				return changes, errors.Errorf(errFmtCredentialsDiffer, p.GetName(), existing.GetName(), secret(ref))
+			}
+			changes = append(changes, Change{
+				Kind:        v1beta1.ProviderConfigKind,
+				Name:        existing.GetName(),
+				Description: fmt.Sprintf("reused for Provider %s using secret %s", p.GetName(), secret(ref)),
+			})
+			continue
+		}
+		if !kerrors.IsNotFound(err) {
+			return changes, errors.Wrap(err, errGetProviderConfig)
+		}
+
+		pc := &v1beta1.ProviderConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   p.GetName(),
+				Labels: p.GetLabels(),
+			},
+			Spec: v1beta1.ProviderConfigSpec{
+				ProviderConfigSpec: runtimev1alpha1.ProviderConfigSpec{
+					Credentials: runtimev1alpha1.ProviderCredentials{
+						Source:    runtimev1alpha1.CredentialsSourceSecret,
+						SecretRef: &ref,
+					},
+				},
+			},
+		}
+		if err := m.client.Create(ctx, pc, m.createOptions()...); err != nil {
+			return changes, errors.Wrap(err, errCreateProviderConfig)
+		}
+		changes = append(changes, Change{
+			Kind:        v1beta1.ProviderConfigKind,
+			Name:        pc.GetName(),
+			Description: fmt.Sprintf("created from Provider %s using secret %s", p.GetName(), secret(ref)),
+		})
+	}
+	return changes, nil
+}
+
+// usesSecret returns true if the supplied ProviderConfig reads its credentials
+// from the supplied secret key.
+func usesSecret(pc *v1beta1.ProviderConfig, ref runtimev1alpha1.SecretKeySelector) bool {
+	c := pc.Spec.Credentials
+	return c.Source == runtimev1alpha1.CredentialsSourceSecret && c.SecretRef != nil && *c.SecretRef == ref
+}
+
+// secret returns a human readable description of the supplied secret key.
+func secret(ref runtimev1alpha1.SecretKeySelector) string {
+	return fmt.Sprintf("%s/%s key %s", ref.Namespace, ref.Name, ref.Key)
+}
+
+func (m *Migrator) migrateManaged(ctx context.Context, gvk schema.GroupVersionKind) ([]Change, error) {
+	o, err := m.scheme.New(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err != nil {
+		return nil, errors.Wrapf(err, errFmtNewList, gvk.Kind)
+	}
+	if err := m.client.List(ctx, o); err != nil {
+		return nil, errors.Wrapf(err, errFmtListManaged, gvk.Kind)
+	}
+	items, err := meta.ExtractList(o)
+	if err != nil {
+		return nil, errors.Wrapf(err, errFmtExtractList, gvk.Kind)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].(metav1.Object).GetName() < items[j].(metav1.Object).GetName()
+	})
+
+	changes := []Change{}
+	for _, i := range items {
+		mg, ok := i.(resource.Managed)
+		if !ok || mg.GetProviderReference() == nil {
+			continue
+		}
+
+		p := client.MergeFrom(mg.DeepCopyObject())
+		name := mg.GetProviderReference().Name
+		desc := fmt.Sprintf("replaced providerRef %s with providerConfigRef %s", name, name)
+		if ref := mg.GetProviderConfigReference(); ref != nil {
+			desc = fmt.Sprintf("removed providerRef %s in favor of existing providerConfigRef %s", name, ref.Name)
+		} else {
+			mg.SetProviderConfigReference(&runtimev1alpha1.Reference{Name: name})
+		}
+		mg.SetProviderReference(nil)
+
+		if err := m.client.Patch(ctx, mg, p, m.patchOptions()...); err != nil {
+			return changes, errors.Wrapf(err, errFmtPatchManaged, gvk.Kind, mg.GetName())
+		}
+		changes = append(changes, Change{Kind: gvk.Kind, Name: mg.GetName(), Description: desc})
+	}
+	return changes, nil
+}
+
+func (m *Migrator) createOptions() []client.CreateOption {
+	if m.dryRun {
+		return []client.CreateOption{client.DryRunAll}
+	}
+	return nil
+}
+
+func (m *Migrator) patchOptions() []client.PatchOption {
+	if m.dryRun {
+		return []client.PatchOption{client.DryRunAll}
+	}
+	return nil
+}
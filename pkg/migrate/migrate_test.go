@@ -0,0 +1,197 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/provider-azure/apis"
+	"github.com/crossplane/provider-azure/apis/v1alpha3"
+	"github.com/crossplane/provider-azure/apis/v1beta1"
+)
+
+func TestMigratorRun(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := apis.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+
+	ref := runtimev1alpha1.SecretKeySelector{
+		SecretReference: runtimev1alpha1.SecretReference{Namespace: "crossplane-system", Name: "azure-creds"},
+		Key:             "credentials",
+	}
+	provider := func(name string) *v1alpha3.Provider {
+		return &v1alpha3.Provider{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       v1alpha3.ProviderSpec{CredentialsSecretRef: ref},
+		}
+	}
+	resourceGroup := func(name string, p, pc *runtimev1alpha1.Reference) *v1alpha3.ResourceGroup {
+		rg := &v1alpha3.ResourceGroup{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		rg.SetProviderReference(p)
+		rg.SetProviderConfigReference(pc)
+		return rg
+	}
+	providerConfig := func(name string, ref runtimev1alpha1.SecretKeySelector) *v1beta1.ProviderConfig {
+		return &v1beta1.ProviderConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: v1beta1.ProviderConfigSpec{
+				ProviderConfigSpec: runtimev1alpha1.ProviderConfigSpec{
+					Credentials: runtimev1alpha1.ProviderCredentials{
+						Source:    runtimev1alpha1.CredentialsSourceSecret,
+						SecretRef: &ref,
+					},
+				},
+			},
+		}
+	}
+	otherRef := ref
+	otherRef.Name = "other-creds"
+
+	type want struct {
+		err      error
+		changes  []Change
+		pcs      []string
+		rgConfig map[string]string
+	}
+	cases := map[string]struct {
+		dryRun   bool
+		existing *v1beta1.ProviderConfig
+		want     want
+	}{
+		"Migrate": {
+			existing: providerConfig("migrated", ref),
+			want: want{
+				changes: []Change{
+					{Kind: v1beta1.ProviderConfigKind, Name: "legacy", Description: "created from Provider legacy using secret crossplane-system/azure-creds key credentials"},
+					{Kind: v1beta1.ProviderConfigKind, Name: "migrated", Description: "reused for Provider migrated using secret crossplane-system/azure-creds key credentials"},
+					{Kind: v1alpha3.ResourceGroupKind, Name: "both", Description: "removed providerRef legacy in favor of existing providerConfigRef migrated"},
+					{Kind: v1alpha3.ResourceGroupKind, Name: "old", Description: "replaced providerRef legacy with providerConfigRef legacy"},
+				},
+				pcs:      []string{"legacy", "migrated"},
+				rgConfig: map[string]string{"both": "migrated", "old": "legacy", "new": "migrated"},
+			},
+		},
+		"DryRun": {
+			dryRun:   true,
+			existing: providerConfig("migrated", ref),
+			want: want{
+				changes: []Change{
+					{Kind: v1beta1.ProviderConfigKind, Name: "legacy", Description: "created from Provider legacy using secret crossplane-system/azure-creds key credentials"},
+					{Kind: v1beta1.ProviderConfigKind, Name: "migrated", Description: "reused for Provider migrated using secret crossplane-system/azure-creds key credentials"},
+					{Kind: v1alpha3.ResourceGroupKind, Name: "both", Description: "removed providerRef legacy in favor of existing providerConfigRef migrated"},
+					{Kind: v1alpha3.ResourceGroupKind, Name: "old", Description: "replaced providerRef legacy with providerConfigRef legacy"},
+				},
+				pcs:      []string{"migrated"},
+				rgConfig: map[string]string{"both": "migrated", "old": "", "new": "migrated"},
+			},
+		},
+		"ExistingProviderConfigDiffers": {
+			existing: providerConfig("migrated", otherRef),
+			want: want{
+				err: errors.Errorf(errFmtCredentialsDiffer, "migrated", "migrated", "crossplane-system/azure-creds key credentials"),
+				changes: []Change{
+					{Kind: v1beta1.ProviderConfigKind, Name: "legacy", Description: "created from Provider legacy using secret crossplane-system/azure-creds key credentials"},
+				},
+				pcs:      []string{"legacy", "migrated"},
+				rgConfig: map[string]string{"both": "migrated", "old": "", "new": "migrated"},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(s,
+				provider("legacy"),
+				provider("migrated"),
+				tc.existing,
+				resourceGroup("old", &runtimev1alpha1.Reference{Name: "legacy"}, nil),
+				resourceGroup("both", &runtimev1alpha1.Reference{Name: "legacy"}, &runtimev1alpha1.Reference{Name: "migrated"}),
+				resourceGroup("new", nil, &runtimev1alpha1.Reference{Name: "migrated"}),
+			)
+
+			m := NewMigrator(c, s, WithDryRun(tc.dryRun), WithManagedKinds(v1alpha3.ResourceGroupGroupVersionKind))
+			got, err := m.Run(context.Background())
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("m.Run(...): -want error, +got error:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.changes, got); diff != "" {
+				t.Errorf("m.Run(...): -want changes, +got changes:\n%s", diff)
+			}
+
+			for _, n := range []string{"legacy", "migrated"} {
+				err := c.Get(context.Background(), client.ObjectKey{Name: n}, &v1beta1.ProviderConfig{})
+				exists := err == nil
+				if err != nil && !kerrors.IsNotFound(err) {
+					t.Fatal(err)
+				}
+				want := false
+				for _, pc := range tc.want.pcs {
+					want = want || pc == n
+				}
+				if diff := cmp.Diff(want, exists); diff != "" {
+					t.Errorf("ProviderConfig %s: -want exists, +got exists:\n%s", n, diff)
+				}
+			}
+
+			for n, pc := range tc.want.rgConfig {
+				rg := &v1alpha3.ResourceGroup{}
+				if err := c.Get(context.Background(), client.ObjectKey{Name: n}, rg); err != nil {
+					t.Fatal(err)
+				}
+				got := ""
+				if ref := rg.GetProviderConfigReference(); ref != nil {
+					got = ref.Name
+				}
+				if diff := cmp.Diff(pc, got); diff != "" {
+					t.Errorf("ResourceGroup %s: -want providerConfigRef, +got providerConfigRef:\n%s", n, diff)
+				}
+				if !tc.dryRun && tc.want.err == nil && rg.GetProviderReference() != nil {
+					t.Errorf("ResourceGroup %s: want providerRef removed", n)
+				}
+			}
+		})
+	}
+}
+
+func TestManagedKinds(t *testing.T) {
+	s := runtime.NewScheme()
+	if err := apis.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	for _, gvk := range ManagedKinds(s) {
+		if gvk == v1alpha3.ProviderGroupVersionKind || gvk == v1beta1.ProviderConfigGroupVersionKind {
+			t.Errorf("ManagedKinds(...): %s is not a managed resource", gvk)
+		}
+		if gvk == v1alpha3.ResourceGroupGroupVersionKind {
+			return
+		}
+	}
+	t.Errorf("ManagedKinds(...): want %s", v1alpha3.ResourceGroupGroupVersionKind)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	azure "github.com/crossplane/provider-azure/pkg/clients"
+)
+
+// ReasonDrifted is the reason of the events recorded for managed resources
+// whose Azure resource drifted from their desired state.
+const ReasonDrifted event.Reason = "Drifted"
+
+// RecordDrift decorates the supplied ExternalConnecter such that the
+// ExternalClients it connects record an event whenever they observe that the
+// Azure resource of a managed resource differs from its desired state in a way
+// it did not before, as reported by its UpToDate condition.
+func RecordDrift(r event.Recorder, c managed.ExternalConnecter) managed.ExternalConnecter {
+	return &driftConnecter{connecter: c, record: r}
+}
+
+type driftConnecter struct {
+	connecter managed.ExternalConnecter
+	record    event.Recorder
+}
+
+func (c *driftConnecter) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	ec, err := c.connecter.Connect(ctx, mg)
+	if err != nil {
+		return nil, err
+	}
+	return &driftExternal{ExternalClient: ec, record: c.record}, nil
+}
+
+type driftExternal struct {
+	managed.ExternalClient
+	record event.Recorder
+}
+
+func (e *driftExternal) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	before := mg.GetCondition(azure.TypeUpToDate)
+	o, err := e.ExternalClient.Observe(ctx, mg)
+	if err != nil {
+		return o, err
+	}
+	after := mg.GetCondition(azure.TypeUpToDate)
+	if after.Reason == azure.ReasonDrifted && !after.Equal(before) {
+		e.record.Event(mg, event.Normal(ReasonDrifted, after.Message))
+	}
+	return o, nil
+}
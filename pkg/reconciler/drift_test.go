@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+
+	azureclients "github.com/crossplane/provider-azure/pkg/clients"
+)
+
+var _ managed.ExternalClient = &driftExternal{}
+
+type recorderFn func(obj runtime.Object, e event.Event)
+
+func (fn recorderFn) Event(obj runtime.Object, e event.Event) { fn(obj, e) }
+
+func (fn recorderFn) WithAnnotations(_ ...string) event.Recorder { return fn }
+
+func TestDriftExternal(t *testing.T) {
+	drifted := azureclients.Diff{{Path: "spec.forProvider.version", Desired: "5.7", Observed: "5.6"}}
+	driftedMore := append(drifted, azureclients.FieldDiff{Path: "spec.forProvider.sslEnforcement", Desired: "Enabled", Observed: "Disabled"})
+
+	cases := map[string]struct {
+		reason string
+		before []runtimev1alpha1.Condition
+		d      azureclients.Diff
+		want   []string
+	}{
+		"UpToDate": {
+			reason: "No event should be recorded for managed resources that are up to date.",
+			before: []runtimev1alpha1.Condition{azureclients.Drifted(drifted)},
+		},
+		"Drifted": {
+			reason: "An event should be recorded for managed resources that drifted.",
+			before: []runtimev1alpha1.Condition{azureclients.UpToDate()},
+			d:      drifted,
+			want:   []string{drifted.String()},
+		},
+		"StillDrifted": {
+			reason: "No event should be recorded for managed resources that drifted in the same way as before.",
+			before: []runtimev1alpha1.Condition{azureclients.Drifted(drifted)},
+			d:      drifted,
+		},
+		"DriftedMore": {
+			reason: "An event should be recorded for managed resources that drifted in a new way.",
+			before: []runtimev1alpha1.Condition{azureclients.Drifted(drifted)},
+			d:      driftedMore,
+			want:   []string{driftedMore.String()},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			e := &driftExternal{
+				ExternalClient: &managed.ExternalClientFns{
+					ObserveFn: func(_ context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+						return managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: azureclients.SetDrift(mg, tc.d)}, nil
+					},
+				},
+				record: recorderFn(func(_ runtime.Object, e event.Event) {
+					got = append(got, e.Message)
+				}),
+			}
+
+			mg := &fake.Managed{}
+			mg.SetConditions(tc.before...)
+			if _, err := e.Observe(context.Background(), mg); err != nil {
+				t.Fatalf("\n%s\ne.Observe(...): %s", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\ne.Observe(...): -want events, +got events:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
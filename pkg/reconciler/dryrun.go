@@ -0,0 +1,249 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	azure "github.com/crossplane/provider-azure/pkg/clients"
+)
+
+// Operations that managed resources plan in dry-run mode.
+const (
+	OperationCreate = "Create"
+	OperationUpdate = "Update"
+	OperationDelete = "Delete"
+)
+
+// Reasons of the events recorded for the operations that managed resources
+// plan in dry-run mode.
+const (
+	ReasonPlannedCreate event.Reason = "PlannedCreate"
+	ReasonPlannedUpdate event.Reason = "PlannedUpdate"
+	ReasonPlannedDelete event.Reason = "PlannedDelete"
+)
+
+var plannedReasons = map[string]event.Reason{
+	OperationCreate: ReasonPlannedCreate,
+	OperationUpdate: ReasonPlannedUpdate,
+	OperationDelete: ReasonPlannedDelete,
+}
+
+// A PlannedOperation is a Create, Update or Delete operation that a managed
+// resource would have performed, but did not because the provider runs in
+// dry-run mode.
+type PlannedOperation struct {
+	Kind         string                 `json:"kind"`
+	Name         string                 `json:"name"`
+	ExternalName string                 `json:"externalName,omitempty"`
+	Operation    string                 `json:"operation"`
+	Requests     []azure.PlannedRequest `json:"requests"`
+	Planned      time.Time              `json:"planned"`
+}
+
+// A Plan aggregates the latest operation that each managed resource plans in
+// dry-run mode. Managed resources are removed from the plan once they are
+// observed to be up to date.
+type Plan struct {
+	mu         sync.Mutex
+	operations map[string]PlannedOperation
+}
+
+// NewPlan returns an empty Plan.
+func NewPlan() *Plan {
+	return &Plan{operations: map[string]PlannedOperation{}}
+}
+
+// Record the supplied operation, replacing any operation that its managed
+// resource planned before.
+func (p *Plan) Record(op PlannedOperation) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.operations[op.Kind+"/"+op.Name] = op
+}
+
+// Forget any operation that the managed resource of the supplied kind and
+// name planned.
+func (p *Plan) Forget(kind, name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.operations, kind+"/"+name)
+}
+
+// Operations returns the planned operations, sorted by kind and name.
+func (p *Plan) Operations() []PlannedOperation {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ops := make([]PlannedOperation, 0, len(p.operations))
+	for _, op := range p.operations {
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].Kind != ops[j].Kind {
+			return ops[i].Kind < ops[j].Kind
+		}
+		return ops[i].Name < ops[j].Name
+	})
+	return ops
+}
+
+// Write the planned operations to the supplied writer as JSON.
+func (p *Plan) Write(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(p.Operations())
+}
+
+// ServeHTTP serves the planned operations as JSON.
+func (p *Plan) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = p.Write(w)
+}
+
+// A Planner records the operations that managed resources of one kind plan in
+// dry-run mode in a Plan and as events. Controllers that do not use the managed
+// resource reconciler, such as those of storage accounts, use it to plan their
+// operations.
+type Planner struct {
+	kind   string
+	plan   *Plan
+	record event.Recorder
+}
+
+// Planner returns a Planner of the supplied kind of managed resource that
+// records events using the supplied recorder, or nil if the Options do not
+// specify a Plan.
+func (o Options) Planner(kind string, r event.Recorder) *Planner {
+	if o.Plan == nil {
+		return nil
+	}
+	return &Planner{kind: kind, plan: o.Plan, record: r}
+}
+
+// Plan calls the supplied operation of the supplied managed resource, recording
+// rather than sending the requests it makes that would change Azure resources.
+// The error returned by the operation is returned only if it made no such
+// requests. Operations that did may fail because the Azure resources they
+// planned to change were not changed, e.g. when reading a resource they
+// planned to create.
+func (p *Planner) Plan(ctx context.Context, mg resource.Managed, operation string, fn func(ctx context.Context) error) error {
+	ctx, requests := azure.WithDryRun(ctx)
+	err := fn(ctx)
+	reqs := requests()
+	if len(reqs) == 0 {
+		return err
+	}
+
+	p.plan.Record(PlannedOperation{
+		Kind:         p.kind,
+		Name:         mg.GetName(),
+		ExternalName: meta.GetExternalName(mg),
+		Operation:    operation,
+		Requests:     reqs,
+		Planned:      time.Now(),
+	})
+	msg := make([]string, len(reqs))
+	for i := range reqs {
+		msg[i] = reqs[i].String()
+	}
+	p.record.Event(mg, event.Normal(plannedReasons[operation], strings.Join(msg, "\n")))
+	return nil
+}
+
+// UpToDate forgets any operation that the supplied managed resource planned,
+// because its Azure resource was observed to be up to date.
+func (p *Planner) UpToDate(mg resource.Managed) {
+	p.plan.Forget(p.kind, mg.GetName())
+}
+
+// DryRun decorates the supplied ExternalConnecter of the supplied kind of
+// managed resource such that, if the Options specify a Plan, the
+// ExternalClients it connects observe Azure resources as usual but record the
+// requests they would make to create, update or delete them in the Plan and as
+// events rather than sending them. The managed resources they are called with
+// are not changed by these operations.
+func (o Options) DryRun(kind string, r event.Recorder, c managed.ExternalConnecter) managed.ExternalConnecter {
+	p := o.Planner(kind, r)
+	if p == nil {
+		return c
+	}
+	return &dryRunConnecter{connecter: c, planner: p}
+}
+
+type dryRunConnecter struct {
+	connecter managed.ExternalConnecter
+	planner   *Planner
+}
+
+func (c *dryRunConnecter) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	ec, err := c.connecter.Connect(ctx, mg)
+	if err != nil {
+		return nil, err
+	}
+	return &dryRunExternal{client: ec, planner: c.planner}, nil
+}
+
+// A dryRunExternal plans the operations of its client. They are called with a
+// copy of the managed resource, so that it is not changed by operations that
+// were not performed.
+type dryRunExternal struct {
+	client  managed.ExternalClient
+	planner *Planner
+}
+
+func (e *dryRunExternal) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	o, err := e.client.Observe(ctx, mg)
+	if err == nil && o.ResourceExists && o.ResourceUpToDate && !meta.WasDeleted(mg) {
+		e.planner.UpToDate(mg)
+	}
+	return o, err
+}
+
+func (e *dryRunExternal) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	cp := mg.DeepCopyObject().(resource.Managed)
+	return managed.ExternalCreation{}, e.planner.Plan(ctx, mg, OperationCreate, func(ctx context.Context) error {
+		_, err := e.client.Create(ctx, cp)
+		return err
+	})
+}
+
+func (e *dryRunExternal) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	cp := mg.DeepCopyObject().(resource.Managed)
+	return managed.ExternalUpdate{}, e.planner.Plan(ctx, mg, OperationUpdate, func(ctx context.Context) error {
+		_, err := e.client.Update(ctx, cp)
+		return err
+	})
+}
+
+func (e *dryRunExternal) Delete(ctx context.Context, mg resource.Managed) error {
+	cp := mg.DeepCopyObject().(resource.Managed)
+	return e.planner.Plan(ctx, mg, OperationDelete, func(ctx context.Context) error {
+		return e.client.Delete(ctx, cp)
+	})
+}
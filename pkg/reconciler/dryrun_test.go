@@ -0,0 +1,214 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	azureclients "github.com/crossplane/provider-azure/pkg/clients"
+)
+
+var _ managed.ExternalClient = &dryRunExternal{}
+
+func TestDryRunExternal(t *testing.T) {
+	const url = "https://management.azure.com/subscriptions/sub/resourceGroups/coolgroup"
+	errBoom := errors.New("boom")
+
+	azureclients.DryRun = true
+	defer func() { azureclients.DryRun = false }()
+
+	// send sends a request that is never received, because it is intercepted
+	// in dry-run mode unless it is a read.
+	var sent []string
+	send := func(ctx context.Context, method, body string) error {
+		s := autorest.DecorateSender(autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+			sent = append(sent, r.Method)
+			return &http.Response{StatusCode: http.StatusOK}, nil
+		}), azureclients.DryRunIntercept)
+		r, _ := http.NewRequestWithContext(ctx, method, url, strings.NewReader(body))
+		resp, err := s.Do(r)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			return errors.New("request failed")
+		}
+		return nil
+	}
+
+	type want struct {
+		err    error
+		events []event.Event
+		plan   []PlannedOperation
+		sent   []string
+	}
+	cases := map[string]struct {
+		reason string
+		client managed.ExternalClient
+		call   func(ctx context.Context, e managed.ExternalClient, mg resource.Managed) error
+		want   want
+	}{
+		"Create": {
+			reason: "The requests made to create an Azure resource should be recorded as an event and in the plan rather than sent, and their error should not be returned.",
+			client: &managed.ExternalClientFns{
+				CreateFn: func(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+					mg.SetConditions(azureclients.LastAsyncOperationInProgress())
+					return managed.ExternalCreation{}, send(ctx, http.MethodPut, `{"location":"westus"}`)
+				},
+			},
+			call: func(ctx context.Context, e managed.ExternalClient, mg resource.Managed) error {
+				_, err := e.Create(ctx, mg)
+				return err
+			},
+			want: want{
+				events: []event.Event{event.Normal(ReasonPlannedCreate, "PUT "+url+` {"location":"westus"}`)},
+				plan: []PlannedOperation{{
+					Kind:      subnetKind,
+					Name:      "cool",
+					Operation: OperationCreate,
+					Requests:  []azureclients.PlannedRequest{{Method: http.MethodPut, URL: url, Body: []byte(`{"location":"westus"}`)}},
+				}},
+			},
+		},
+		"CreateWithPassword": {
+			reason: "The secrets of the requests made to create an Azure resource should be redacted from the event and the plan.",
+			client: &managed.ExternalClientFns{
+				CreateFn: func(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+					return managed.ExternalCreation{}, send(ctx, http.MethodPut, `{"properties":{"administratorLoginPassword":"hunter2"}}`)
+				},
+			},
+			call: func(ctx context.Context, e managed.ExternalClient, mg resource.Managed) error {
+				_, err := e.Create(ctx, mg)
+				return err
+			},
+			want: want{
+				events: []event.Event{event.Normal(ReasonPlannedCreate, "PUT "+url+` {"properties":{"administratorLoginPassword":"REDACTED"}}`)},
+				plan: []PlannedOperation{{
+					Kind:      subnetKind,
+					Name:      "cool",
+					Operation: OperationCreate,
+					Requests:  []azureclients.PlannedRequest{{Method: http.MethodPut, URL: url, Body: []byte(`{"properties":{"administratorLoginPassword":"REDACTED"}}`)}},
+				}},
+			},
+		},
+		"UpdateUpToDate": {
+			reason: "Nothing should be recorded for updates that make no requests that would change the Azure resource.",
+			client: &managed.ExternalClientFns{
+				UpdateFn: func(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+					return managed.ExternalUpdate{}, send(ctx, http.MethodGet, "")
+				},
+			},
+			call: func(ctx context.Context, e managed.ExternalClient, mg resource.Managed) error {
+				_, err := e.Update(ctx, mg)
+				return err
+			},
+			want: want{sent: []string{http.MethodGet}},
+		},
+		"DeleteAction": {
+			reason: "POST requests made to delete an Azure resource should be recorded rather than sent.",
+			client: &managed.ExternalClientFns{
+				DeleteFn: func(ctx context.Context, mg resource.Managed) error {
+					return send(ctx, http.MethodPost, "")
+				},
+			},
+			call: func(ctx context.Context, e managed.ExternalClient, mg resource.Managed) error {
+				return e.Delete(ctx, mg)
+			},
+			want: want{
+				events: []event.Event{event.Normal(ReasonPlannedDelete, "POST "+url)},
+				plan: []PlannedOperation{{
+					Kind:      subnetKind,
+					Name:      "cool",
+					Operation: OperationDelete,
+					Requests:  []azureclients.PlannedRequest{{Method: http.MethodPost, URL: url}},
+				}},
+			},
+		},
+		"DeleteError": {
+			reason: "Errors of operations that make no requests that would change the Azure resource should be returned.",
+			client: &managed.ExternalClientFns{
+				DeleteFn: func(ctx context.Context, mg resource.Managed) error {
+					return errBoom
+				},
+			},
+			call: func(ctx context.Context, e managed.ExternalClient, mg resource.Managed) error {
+				return e.Delete(ctx, mg)
+			},
+			want: want{err: errBoom},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			sent = nil
+			var events []event.Event
+			p := NewPlan()
+			e := &dryRunExternal{
+				client:  tc.client,
+				planner: Options{Plan: p}.Planner(subnetKind, recorderFn(func(_ runtime.Object, e event.Event) { events = append(events, e) })),
+			}
+
+			mg := &fake.Managed{ObjectMeta: metav1.ObjectMeta{Name: "cool"}}
+			err := tc.call(context.Background(), e, mg)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ne: -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.events, events); diff != "" {
+				t.Errorf("\n%s\ne: -want events, +got events:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.plan, p.Operations(), cmpopts.EquateEmpty(), cmpopts.IgnoreFields(PlannedOperation{}, "Planned")); diff != "" {
+				t.Errorf("\n%s\np.Operations(): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.sent, sent); diff != "" {
+				t.Errorf("\n%s\ne: -want sent requests, +got sent requests:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(&fake.Managed{ObjectMeta: metav1.ObjectMeta{Name: "cool"}}, mg); diff != "" {
+				t.Errorf("\n%s\ne: managed resource should not be changed: -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	p := NewPlan()
+	p.Record(PlannedOperation{Kind: subnetKind, Name: "b", Operation: OperationUpdate})
+	p.Record(PlannedOperation{Kind: redisKind, Name: "a", Operation: OperationCreate})
+	p.Record(PlannedOperation{Kind: subnetKind, Name: "a", Operation: OperationCreate})
+	p.Record(PlannedOperation{Kind: subnetKind, Name: "a", Operation: OperationDelete})
+	p.Forget(subnetKind, "b")
+
+	want := []PlannedOperation{
+		{Kind: redisKind, Name: "a", Operation: OperationCreate},
+		{Kind: subnetKind, Name: "a", Operation: OperationDelete},
+	}
+	if diff := cmp.Diff(want, p.Operations()); diff != "" {
+		t.Errorf("p.Operations(): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package reconciler contains decorators that adapt the generic managed
+// resource reconciler to the behaviour of Azure APIs.
+package reconciler
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	azure "github.com/crossplane/provider-azure/pkg/clients"
+)
+
+// TypeTerminalError managed resources report whether the last error returned
+// by Azure can be resolved by retrying the request that caused it.
+const TypeTerminalError runtimev1alpha1.ConditionType = "TerminalError"
+
+// ReasonRetryable managed resources are not affected by a terminal error.
+const ReasonRetryable runtimev1alpha1.ConditionReason = "Retryable"
+
+// TerminalError returns a condition indicating that the supplied error will
+// not be resolved by retrying, and that the managed resource will only be
+// reconciled again after a long backoff, or when it changes.
+func TerminalError(e *azure.Error) runtimev1alpha1.Condition {
+	return runtimev1alpha1.Condition{
+		Type:               TypeTerminalError,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             runtimev1alpha1.ConditionReason(e.Reason()),
+		Message:            e.Message,
+	}
+}
+
+// NoTerminalError returns a condition indicating that a managed resource is
+// not affected by a terminal error.
+func NoTerminalError() runtimev1alpha1.Condition {
+	return runtimev1alpha1.Condition{
+		Type:               TypeTerminalError,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             ReasonRetryable,
+	}
+}
+
+// An ErrorHandler classifies the errors that an external client returns to a
+// managed resource reconciler. It records an event whose reason is the Azure
+// error code of each error, requeues the managed resource no sooner than any
+// Retry-After delay Azure asked for, and requeues managed resources whose
+// errors are unlikely to be resolved by retrying soon after a long backoff.
+type ErrorHandler struct {
+	record event.Recorder
+
+	mu     sync.Mutex
+	errors map[string]*azure.Error
+}
+
+// NewErrorHandler returns an ErrorHandler that records events using the
+// supplied recorder.
+func NewErrorHandler(r event.Recorder) *ErrorHandler {
+	return &ErrorHandler{record: r, errors: map[string]*azure.Error{}}
+}
+
+// Connecter decorates the supplied ExternalConnecter such that the errors
+// returned by it and by the ExternalClients it connects are classified.
+func (h *ErrorHandler) Connecter(c managed.ExternalConnecter) managed.ExternalConnecter {
+	return &errorHandlingConnecter{connecter: c, handler: h}
+}
+
+// Reconciler decorates the supplied managed resource Reconciler such that it
+// requeues managed resources according to the errors classified by this
+// ErrorHandler. The supplied Reconciler must use an ExternalConnecter that was
+// decorated by this ErrorHandler.
+func (h *ErrorHandler) Reconciler(r reconcile.Reconciler) reconcile.Reconciler {
+	return &errorHandlingReconciler{reconciler: r, handler: h}
+}
+
+// handle classifies the supplied error, which was returned while reconciling
+// the supplied managed resource.
+func (h *ErrorHandler) handle(mg resource.Managed, err error) error {
+	e := azure.Classify(err)
+	if e == nil {
+		return err
+	}
+
+	h.mu.Lock()
+	h.errors[mg.GetName()] = e
+	h.mu.Unlock()
+
+	h.record.Event(mg, event.Warning(event.Reason(e.Reason()), err))
+	if !e.Retryable() {
+		mg.SetConditions(TerminalError(e))
+	}
+	return err
+}
+
+// resolve indicates that the supplied managed resource was reconciled without
+// encountering an error.
+func (h *ErrorHandler) resolve(mg resource.Managed) {
+	if mg.GetCondition(TypeTerminalError).Status == corev1.ConditionTrue {
+		mg.SetConditions(NoTerminalError())
+	}
+}
+
+// pop returns and forgets the error classified while reconciling the managed
+// resource with the supplied name, if any.
+func (h *ErrorHandler) pop(name string) *azure.Error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	e := h.errors[name]
+	delete(h.errors, name)
+	return e
+}
+
+type errorHandlingReconciler struct {
+	reconciler reconcile.Reconciler
+	handler    *ErrorHandler
+}
+
+func (r *errorHandlingReconciler) Reconcile(req reconcile.Request) (reconcile.Result, error) {
+	result, err := r.reconciler.Reconcile(req)
+	e := r.handler.pop(req.Name)
+	if err != nil || e == nil {
+		return result, err
+	}
+
+	// Managed resources are also reconciled again when they change, which is
+	// what it usually takes to resolve a terminal error.
+	if b := e.Backoff(); b > result.RequeueAfter {
+		return reconcile.Result{RequeueAfter: b}, nil
+	}
+	return result, nil
+}
+
+type errorHandlingConnecter struct {
+	connecter managed.ExternalConnecter
+	handler   *ErrorHandler
+}
+
+func (c *errorHandlingConnecter) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	ec, err := c.connecter.Connect(ctx, mg)
+	if err != nil {
+		return nil, c.handler.handle(mg, err)
+	}
+	return &errorHandlingExternal{client: ec, handler: c.handler}, nil
+}
+
+type errorHandlingExternal struct {
+	client  managed.ExternalClient
+	handler *ErrorHandler
+}
+
+func (e *errorHandlingExternal) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	o, err := e.client.Observe(ctx, mg)
+	if err != nil {
+		return o, e.handler.handle(mg, err)
+	}
+
+	// The reconciler will not call the external client again if the external
+	// resource exists and is up to date.
+	if o.ResourceExists && o.ResourceUpToDate {
+		e.handler.resolve(mg)
+	}
+	return o, nil
+}
+
+func (e *errorHandlingExternal) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	c, err := e.client.Create(ctx, mg)
+	if err != nil {
+		return c, e.handler.handle(mg, err)
+	}
+	e.handler.resolve(mg)
+	return c, nil
+}
+
+func (e *errorHandlingExternal) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	u, err := e.client.Update(ctx, mg)
+	if err != nil {
+		return u, e.handler.handle(mg, err)
+	}
+	e.handler.resolve(mg)
+	return u, nil
+}
+
+func (e *errorHandlingExternal) Delete(ctx context.Context, mg resource.Managed) error {
+	if err := e.client.Delete(ctx, mg); err != nil {
+		return e.handler.handle(mg, err)
+	}
+	e.handler.resolve(mg)
+	return nil
+}
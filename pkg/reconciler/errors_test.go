@@ -0,0 +1,244 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	azureclients "github.com/crossplane/provider-azure/pkg/clients"
+)
+
+const name = "cool-resource"
+
+var (
+	_ managed.ExternalConnecter = &errorHandlingConnecter{}
+	_ managed.ExternalClient    = &errorHandlingExternal{}
+	_ reconcile.Reconciler      = &errorHandlingReconciler{}
+)
+
+type reconcilerFn func(reconcile.Request) (reconcile.Result, error)
+
+func (fn reconcilerFn) Reconcile(req reconcile.Request) (reconcile.Result, error) {
+	return fn(req)
+}
+
+type recorder struct {
+	events []event.Event
+}
+
+func (r *recorder) Event(_ runtime.Object, e event.Event) {
+	r.events = append(r.events, e)
+}
+
+func (r *recorder) WithAnnotations(_ ...string) event.Recorder {
+	return r
+}
+
+func azureError(status int, code string) error {
+	return errors.Wrap(autorest.DetailedError{
+		StatusCode: status,
+		Original:   &azure.RequestError{ServiceError: &azure.ServiceError{Code: code, Message: "boom"}},
+	}, "cannot create the thing")
+}
+
+func managedResource(c ...runtimev1alpha1.Condition) *fake.Managed {
+	mg := &fake.Managed{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	mg.SetConditions(c...)
+	return mg
+}
+
+func TestErrorHandlingReconciler(t *testing.T) {
+	errBoom := errors.New("boom")
+	requeue := reconcile.Result{RequeueAfter: 30 * time.Second}
+
+	type want struct {
+		result reconcile.Result
+		err    error
+	}
+	cases := map[string]struct {
+		errors map[string]*azureclients.Error
+		result reconcile.Result
+		err    error
+		want   want
+	}{
+		"NoError": {
+			result: requeue,
+			want:   want{result: requeue},
+		},
+		"ReconcileError": {
+			errors: map[string]*azureclients.Error{name: {Class: azureclients.ErrorClassThrottled, RetryAfter: time.Minute}},
+			err:    errBoom,
+			want:   want{err: errBoom},
+		},
+		"Terminal": {
+			errors: map[string]*azureclients.Error{name: {Class: azureclients.ErrorClassQuotaExceeded}},
+			result: requeue,
+			want:   want{result: reconcile.Result{RequeueAfter: azureclients.TerminalErrorBackoff}},
+		},
+		"BadRequest": {
+			errors: map[string]*azureclients.Error{name: {Class: azureclients.ErrorClassBadRequest, Code: "OperationNotAllowed"}},
+			result: requeue,
+			want:   want{result: reconcile.Result{RequeueAfter: azureclients.BadRequestBackoff}},
+		},
+		"RetryAfter": {
+			errors: map[string]*azureclients.Error{name: {Class: azureclients.ErrorClassThrottled, RetryAfter: time.Minute}},
+			result: requeue,
+			want:   want{result: reconcile.Result{RequeueAfter: time.Minute}},
+		},
+		"ShortRetryAfter": {
+			errors: map[string]*azureclients.Error{name: {Class: azureclients.ErrorClassThrottled, RetryAfter: time.Second}},
+			result: requeue,
+			want:   want{result: requeue},
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			h := NewErrorHandler(&recorder{})
+			for k, v := range tc.errors {
+				h.errors[k] = v
+			}
+			r := h.Reconciler(reconcilerFn(func(_ reconcile.Request) (reconcile.Result, error) { return tc.result, tc.err }))
+
+			got, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("r.Reconcile(...): -want error, +got error:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.result, got); diff != "" {
+				t.Errorf("r.Reconcile(...): -want, +got:\n%s", diff)
+			}
+			if len(h.errors) != 0 {
+				t.Errorf("r.Reconcile(...): classified errors were not forgotten: %v", h.errors)
+			}
+		})
+	}
+}
+
+func TestErrorHandlingExternal(t *testing.T) {
+	errBoom := errors.New("boom")
+	errQuota := azureError(http.StatusConflict, "CoreQuotaExceeded")
+	errThrottled := azureError(http.StatusTooManyRequests, "SubscriptionRequestsThrottled")
+
+	type want struct {
+		mg     resource.Managed
+		err    error
+		events []event.Event
+		class  azureclients.ErrorClass
+	}
+	cases := map[string]struct {
+		client managed.ExternalClient
+		mg     resource.Managed
+		want   want
+	}{
+		"NotAzureError": {
+			client: &managed.ExternalClientFns{
+				CreateFn: func(_ context.Context, _ resource.Managed) (managed.ExternalCreation, error) {
+					return managed.ExternalCreation{}, errBoom
+				},
+			},
+			mg: managedResource(),
+			want: want{
+				mg:  managedResource(),
+				err: errBoom,
+			},
+		},
+		"Retryable": {
+			client: &managed.ExternalClientFns{
+				CreateFn: func(_ context.Context, _ resource.Managed) (managed.ExternalCreation, error) {
+					return managed.ExternalCreation{}, errThrottled
+				},
+			},
+			mg: managedResource(),
+			want: want{
+				mg:     managedResource(),
+				err:    errThrottled,
+				events: []event.Event{event.Warning("SubscriptionRequestsThrottled", errThrottled)},
+				class:  azureclients.ErrorClassThrottled,
+			},
+		},
+		"Terminal": {
+			client: &managed.ExternalClientFns{
+				CreateFn: func(_ context.Context, _ resource.Managed) (managed.ExternalCreation, error) {
+					return managed.ExternalCreation{}, errQuota
+				},
+			},
+			mg: managedResource(),
+			want: want{
+				mg:     managedResource(TerminalError(&azureclients.Error{Code: "CoreQuotaExceeded", Message: "boom"})),
+				err:    errQuota,
+				events: []event.Event{event.Warning("CoreQuotaExceeded", errQuota)},
+				class:  azureclients.ErrorClassQuotaExceeded,
+			},
+		},
+		"Resolved": {
+			client: &managed.ExternalClientFns{
+				CreateFn: func(_ context.Context, _ resource.Managed) (managed.ExternalCreation, error) {
+					return managed.ExternalCreation{}, nil
+				},
+			},
+			mg: managedResource(TerminalError(&azureclients.Error{Code: "CoreQuotaExceeded", Message: "boom"})),
+			want: want{
+				mg: managedResource(NoTerminalError()),
+			},
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			r := &recorder{}
+			h := NewErrorHandler(r)
+			e := &errorHandlingExternal{client: tc.client, handler: h}
+
+			_, err := e.Create(context.Background(), tc.mg)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("e.Create(...): -want error, +got error:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.mg, tc.mg, test.EquateConditions()); diff != "" {
+				t.Errorf("e.Create(...): -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.events, r.events, test.EquateErrors()); diff != "" {
+				t.Errorf("e.Create(...): -want events, +got events:\n%s", diff)
+			}
+			var class azureclients.ErrorClass
+			if e := h.pop(name); e != nil {
+				class = e.Class
+			}
+			if diff := cmp.Diff(tc.want.class, class); diff != "" {
+				t.Errorf("e.Create(...): -want class, +got class:\n%s", diff)
+			}
+		})
+	}
+}
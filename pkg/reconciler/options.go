@@ -0,0 +1,129 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"math/rand"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// DefaultPollInterval is the interval at which managed resources that are
+// up to date are observed, unless Options specify otherwise.
+const DefaultPollInterval = 1 * time.Minute
+
+// Options configure the controllers of each kind of managed resource. Kinds
+// are identified as returned by schema.GroupKind's String method, e.g.
+// Subnet.network.azure.crossplane.io, and matched case insensitively. The zero
+// value enables every kind with the default concurrency and poll interval.
+type Options struct {
+	// Kinds of managed resources to reconcile. All kinds are reconciled if
+	// none are specified.
+	Kinds []string
+
+	// MaxConcurrentReconciles of each controller, unless GroupConcurrency
+	// specifies otherwise for its API group.
+	MaxConcurrentReconciles int
+
+	// GroupConcurrency is the maximum number of concurrent reconciles of each
+	// controller of the managed resources of an API group, keyed by the API
+	// group, e.g. database.azure.crossplane.io.
+	GroupConcurrency map[string]int
+
+	// PollInterval at which managed resources are observed, unless
+	// KindPollIntervals specify otherwise for their kind.
+	PollInterval time.Duration
+
+	// KindPollIntervals are the intervals at which managed resources of each
+	// kind are observed, keyed by kind.
+	KindPollIntervals map[string]time.Duration
+
+	// PollJitter is the maximum fraction by which each requeue of a managed
+	// resource is randomly delayed, so that managed resources created at the
+	// same time are not observed in lockstep.
+	PollJitter float64
+
+	// Plan records the operations that managed resources would perform to
+	// create, update or delete Azure resources, rather than performing them.
+	Plan *Plan
+}
+
+// Enabled returns true if managed resources of the supplied kind should be
+// reconciled.
+func (o Options) Enabled(kind string) bool {
+	if len(o.Kinds) == 0 {
+		return true
+	}
+	for _, k := range o.Kinds {
+		if strings.EqualFold(k, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// Controller returns the options of the controller of the supplied kind.
+func (o Options) Controller(kind string) controller.Options {
+	n := o.MaxConcurrentReconciles
+	group := schema.ParseGroupKind(kind).Group
+	for g, c := range o.GroupConcurrency {
+		if strings.EqualFold(g, group) {
+			n = c
+		}
+	}
+	return controller.Options{MaxConcurrentReconciles: n}
+}
+
+// Poll returns the interval at which managed resources of the supplied kind
+// are observed.
+func (o Options) Poll(kind string) time.Duration {
+	for k, d := range o.KindPollIntervals {
+		if strings.EqualFold(k, kind) {
+			return d
+		}
+	}
+	if o.PollInterval > 0 {
+		return o.PollInterval
+	}
+	return DefaultPollInterval
+}
+
+// Jittered decorates the supplied Reconciler such that the managed resources
+// it requeues after a delay are requeued after up to PollJitter longer.
+func (o Options) Jittered(r reconcile.Reconciler) reconcile.Reconciler {
+	if o.PollJitter <= 0 {
+		return r
+	}
+	return &jitteringReconciler{reconciler: r, jitter: o.PollJitter}
+}
+
+type jitteringReconciler struct {
+	reconciler reconcile.Reconciler
+	jitter     float64
+}
+
+func (r *jitteringReconciler) Reconcile(req reconcile.Request) (reconcile.Result, error) {
+	result, err := r.reconciler.Reconcile(req)
+	if result.RequeueAfter > 0 {
+		result.RequeueAfter += time.Duration(rand.Float64() * r.jitter * float64(result.RequeueAfter)) // nolint:gosec
+	}
+	return result, err
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	subnetKind = "Subnet.network.azure.crossplane.io"
+	redisKind  = "Redis.cache.azure.crossplane.io"
+)
+
+var _ reconcile.Reconciler = &jitteringReconciler{}
+
+func TestOptions(t *testing.T) {
+	type want struct {
+		enabled     bool
+		concurrency int
+		poll        time.Duration
+	}
+
+	cases := map[string]struct {
+		o    Options
+		kind string
+		want want
+	}{
+		"Defaults": {
+			kind: subnetKind,
+			want: want{enabled: true, poll: DefaultPollInterval},
+		},
+		"Overridden": {
+			o: Options{
+				Kinds:                   []string{"subnet.network.azure.crossplane.io"},
+				MaxConcurrentReconciles: 2,
+				GroupConcurrency:        map[string]int{"network.azure.crossplane.io": 5},
+				PollInterval:            5 * time.Minute,
+				KindPollIntervals:       map[string]time.Duration{subnetKind: 10 * time.Minute},
+			},
+			kind: subnetKind,
+			want: want{enabled: true, concurrency: 5, poll: 10 * time.Minute},
+		},
+		"NotOverridden": {
+			o: Options{
+				Kinds:                   []string{subnetKind},
+				MaxConcurrentReconciles: 2,
+				GroupConcurrency:        map[string]int{"network.azure.crossplane.io": 5},
+				PollInterval:            5 * time.Minute,
+				KindPollIntervals:       map[string]time.Duration{subnetKind: 10 * time.Minute},
+			},
+			kind: redisKind,
+			want: want{concurrency: 2, poll: 5 * time.Minute},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := want{
+				enabled:     tc.o.Enabled(tc.kind),
+				concurrency: tc.o.Controller(tc.kind).MaxConcurrentReconciles,
+				poll:        tc.o.Poll(tc.kind),
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("Options: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestJittered(t *testing.T) {
+	poll := reconcilerFn(func(_ reconcile.Request) (reconcile.Result, error) {
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	})
+
+	for i := 0; i < 10; i++ {
+		got, _ := Options{PollJitter: 0.5}.Jittered(poll).Reconcile(reconcile.Request{})
+		if got.RequeueAfter < time.Minute || got.RequeueAfter > 90*time.Second {
+			t.Errorf("Jittered(...).Reconcile(...): requeue after %s is not within the jitter", got.RequeueAfter)
+		}
+	}
+
+	got, _ := Options{}.Jittered(poll).Reconcile(reconcile.Request{})
+	if diff := cmp.Diff(reconcile.Result{RequeueAfter: time.Minute}, got); diff != "" {
+		t.Errorf("Jittered(...).Reconcile(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	azure "github.com/crossplane/provider-azure/pkg/clients"
+)
+
+// ObserveOnly decorates the supplied ExternalConnecter such that the
+// ExternalClients it connects never create, update or delete the Azure
+// resources of managed resources whose management policy is ObserveOnly.
+func ObserveOnly(c managed.ExternalConnecter) managed.ExternalConnecter {
+	return &observeOnlyConnecter{connecter: c}
+}
+
+type observeOnlyConnecter struct {
+	connecter managed.ExternalConnecter
+}
+
+func (c *observeOnlyConnecter) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	ec, err := c.connecter.Connect(ctx, mg)
+	if err != nil {
+		return nil, err
+	}
+	return &observeOnlyExternal{client: ec}, nil
+}
+
+type observeOnlyExternal struct {
+	client managed.ExternalClient
+}
+
+func (e *observeOnlyExternal) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	if !azure.IsObserveOnly(mg) {
+		return e.client.Observe(ctx, mg)
+	}
+	// The reconciler removes the finalizer of managed resources that are
+	// deleted once their Azure resource no longer exists, so it is reported as
+	// not existing rather than deleted.
+	if meta.WasDeleted(mg) {
+		return managed.ExternalObservation{}, nil
+	}
+	o, err := e.client.Observe(ctx, mg)
+	if err != nil {
+		return o, err
+	}
+	if !o.ResourceExists {
+		return o, azure.ErrObserveOnlyNotFound
+	}
+	// Reporting the Azure resource as up to date keeps the reconciler from
+	// updating it to match the managed resource.
+	o.ResourceUpToDate = true
+	return o, nil
+}
+
+func (e *observeOnlyExternal) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	if azure.IsObserveOnly(mg) {
+		return managed.ExternalCreation{}, nil
+	}
+	return e.client.Create(ctx, mg)
+}
+
+func (e *observeOnlyExternal) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	if azure.IsObserveOnly(mg) {
+		return managed.ExternalUpdate{}, nil
+	}
+	return e.client.Update(ctx, mg)
+}
+
+func (e *observeOnlyExternal) Delete(ctx context.Context, mg resource.Managed) error {
+	if azure.IsObserveOnly(mg) {
+		return nil
+	}
+	return e.client.Delete(ctx, mg)
+}
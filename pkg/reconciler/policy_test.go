@@ -0,0 +1,123 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	azureclients "github.com/crossplane/provider-azure/pkg/clients"
+)
+
+var _ managed.ExternalClient = &observeOnlyExternal{}
+
+func TestObserveOnlyExternal(t *testing.T) {
+	observeOnly := map[string]string{azureclients.AnnotationKeyManagementPolicy: azureclients.ManagementPolicyObserveOnly}
+	now := metav1.Now()
+
+	type want struct {
+		o     managed.ExternalObservation
+		err   error
+		calls []string
+	}
+	cases := map[string]struct {
+		reason string
+		mg     resource.Managed
+		o      managed.ExternalObservation
+		want   want
+	}{
+		"Managed": {
+			reason: "Managed resources without a management policy should be observed, created, updated and deleted as usual.",
+			mg:     &fake.Managed{},
+			o:      managed.ExternalObservation{ResourceExists: true},
+			want: want{
+				o:     managed.ExternalObservation{ResourceExists: true},
+				calls: []string{"Observe", "Create", "Update", "Delete"},
+			},
+		},
+		"ObserveOnly": {
+			reason: "Managed resources that are only observed should be reported as up to date, and never created, updated or deleted.",
+			mg:     &fake.Managed{ObjectMeta: metav1.ObjectMeta{Annotations: observeOnly}},
+			o:      managed.ExternalObservation{ResourceExists: true},
+			want: want{
+				o:     managed.ExternalObservation{ResourceExists: true, ResourceUpToDate: true},
+				calls: []string{"Observe"},
+			},
+		},
+		"ObserveOnlyNotFound": {
+			reason: "Managed resources that are only observed should return an error if their Azure resource does not exist.",
+			mg:     &fake.Managed{ObjectMeta: metav1.ObjectMeta{Annotations: observeOnly}},
+			want: want{
+				err:   azureclients.ErrObserveOnlyNotFound,
+				calls: []string{"Observe"},
+			},
+		},
+		"ObserveOnlyDeleted": {
+			reason: "Managed resources that are only observed should be reported as not existing once they are deleted.",
+			mg:     &fake.Managed{ObjectMeta: metav1.ObjectMeta{Annotations: observeOnly, DeletionTimestamp: &now}},
+			o:      managed.ExternalObservation{ResourceExists: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var calls []string
+			e := &observeOnlyExternal{client: &managed.ExternalClientFns{
+				ObserveFn: func(_ context.Context, _ resource.Managed) (managed.ExternalObservation, error) {
+					calls = append(calls, "Observe")
+					return tc.o, nil
+				},
+				CreateFn: func(_ context.Context, _ resource.Managed) (managed.ExternalCreation, error) {
+					calls = append(calls, "Create")
+					return managed.ExternalCreation{}, nil
+				},
+				UpdateFn: func(_ context.Context, _ resource.Managed) (managed.ExternalUpdate, error) {
+					calls = append(calls, "Update")
+					return managed.ExternalUpdate{}, nil
+				},
+				DeleteFn: func(_ context.Context, _ resource.Managed) error {
+					calls = append(calls, "Delete")
+					return nil
+				},
+			}}
+
+			ctx := context.Background()
+			o, err := e.Observe(ctx, tc.mg)
+			_, _ = e.Create(ctx, tc.mg)
+			_, _ = e.Update(ctx, tc.mg)
+			_ = e.Delete(ctx, tc.mg)
+
+			if diff := cmp.Diff(tc.want.o, o); diff != "" {
+				t.Errorf("\n%s\ne.Observe(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ne.Observe(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.calls, calls); diff != "" {
+				t.Errorf("\n%s\ne: -want calls, +got calls:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	azure "github.com/crossplane/provider-azure/pkg/clients"
+)
+
+// ProtectDeletion decorates the supplied ExternalConnecter such that the
+// ExternalClients it connects refuse to delete the Azure resources of managed
+// resources whose deletion protection is enabled. The managed resource
+// reconciler records the refusal as an event and keeps the managed resource
+// until deletion protection is removed. The DeletionProtected condition of the
+// managed resource is reset when it is next observed after that.
+func ProtectDeletion(c managed.ExternalConnecter) managed.ExternalConnecter {
+	return &protectionConnecter{connecter: c}
+}
+
+type protectionConnecter struct {
+	connecter managed.ExternalConnecter
+}
+
+func (c *protectionConnecter) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	ec, err := c.connecter.Connect(ctx, mg)
+	if err != nil {
+		return nil, err
+	}
+	return &protectionExternal{client: ec}, nil
+}
+
+type protectionExternal struct {
+	client managed.ExternalClient
+}
+
+func (e *protectionExternal) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	azure.ResetDeletionProtected(mg)
+	return e.client.Observe(ctx, mg)
+}
+
+func (e *protectionExternal) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	return e.client.Create(ctx, mg)
+}
+
+func (e *protectionExternal) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	return e.client.Update(ctx, mg)
+}
+
+func (e *protectionExternal) Delete(ctx context.Context, mg resource.Managed) error {
+	if azure.IsDeletionProtected(mg) {
+		mg.SetConditions(azure.DeletionProtected())
+		return azure.ErrDeletionProtected
+	}
+	return e.client.Delete(ctx, mg)
+}
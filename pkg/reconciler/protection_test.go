@@ -0,0 +1,144 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	azureclients "github.com/crossplane/provider-azure/pkg/clients"
+)
+
+var _ managed.ExternalClient = &protectionExternal{}
+
+func TestProtectionExternal(t *testing.T) {
+	type want struct {
+		err        error
+		conditions []runtimev1alpha1.Condition
+		calls      []string
+	}
+	cases := map[string]struct {
+		reason      string
+		annotations map[string]string
+		want        want
+	}{
+		"Unprotected": {
+			reason: "Managed resources without deletion protection should be deleted as usual.",
+			want: want{
+				calls: []string{"Delete"},
+			},
+		},
+		"ProtectionDisabled": {
+			reason:      "Managed resources whose deletion protection is not set to true should be deleted as usual.",
+			annotations: map[string]string{azureclients.AnnotationKeyDeletionProtection: "false"},
+			want: want{
+				calls: []string{"Delete"},
+			},
+		},
+		"Protected": {
+			reason:      "Managed resources with deletion protection should report that their deletion was refused, and never be deleted.",
+			annotations: map[string]string{azureclients.AnnotationKeyDeletionProtection: "true"},
+			want: want{
+				err:        azureclients.ErrDeletionProtected,
+				conditions: []runtimev1alpha1.Condition{azureclients.DeletionProtected()},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var calls []string
+			e := &protectionExternal{client: &managed.ExternalClientFns{
+				DeleteFn: func(_ context.Context, _ resource.Managed) error {
+					calls = append(calls, "Delete")
+					return nil
+				},
+			}}
+
+			mg := &fake.Managed{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			err := e.Delete(context.Background(), mg)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ne.Delete(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.conditions, mg.Conditions, test.EquateConditions()); diff != "" {
+				t.Errorf("\n%s\ne.Delete(...): -want conditions, +got conditions:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.calls, calls); diff != "" {
+				t.Errorf("\n%s\ne: -want calls, +got calls:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestProtectionExternalObserve(t *testing.T) {
+	cases := map[string]struct {
+		reason      string
+		annotations map[string]string
+		conditions  []runtimev1alpha1.Condition
+		want        []runtimev1alpha1.Condition
+	}{
+		"NeverRefused": {
+			reason: "Managed resources whose deletion was never refused should not gain a DeletionProtected condition.",
+		},
+		"StillProtected": {
+			reason:      "Managed resources whose deletion protection is still enabled should keep reporting that their deletion was refused.",
+			annotations: map[string]string{azureclients.AnnotationKeyDeletionProtection: "true"},
+			conditions:  []runtimev1alpha1.Condition{azureclients.DeletionProtected()},
+			want:        []runtimev1alpha1.Condition{azureclients.DeletionProtected()},
+		},
+		"ProtectionRemoved": {
+			reason:     "Managed resources whose deletion was refused should no longer report so once their deletion protection is removed.",
+			conditions: []runtimev1alpha1.Condition{azureclients.DeletionProtected()},
+			want:       []runtimev1alpha1.Condition{azureclients.DeletionAllowed()},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var observed bool
+			e := &protectionExternal{client: &managed.ExternalClientFns{
+				ObserveFn: func(_ context.Context, _ resource.Managed) (managed.ExternalObservation, error) {
+					observed = true
+					return managed.ExternalObservation{}, nil
+				},
+			}}
+
+			mg := &fake.Managed{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			mg.SetConditions(tc.conditions...)
+			if _, err := e.Observe(context.Background(), mg); err != nil {
+				t.Errorf("\n%s\ne.Observe(...): %s", tc.reason, err)
+			}
+
+			if diff := cmp.Diff(tc.want, mg.Conditions, test.EquateConditions()); diff != "" {
+				t.Errorf("\n%s\ne.Observe(...): -want conditions, +got conditions:\n%s", tc.reason, diff)
+			}
+			if !observed {
+				t.Errorf("\n%s\ne.Observe(...): the external resource was not observed", tc.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/api/global"
+	"go.opentelemetry.io/otel/api/trace"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/label"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	azure "github.com/crossplane/provider-azure/pkg/clients"
+)
+
+// Span attribute keys of managed resources.
+const (
+	AttributeKind         = label.Key("crossplane.kind")
+	AttributeName         = label.Key("crossplane.name")
+	AttributeExternalName = label.Key("crossplane.external_name")
+)
+
+// Traced decorates the supplied ExternalConnecter of the supplied kind of
+// managed resource such that a span is recorded for each call to it and to the
+// ExternalClients it connects. The requests they make to Azure APIs are
+// recorded as children of these spans.
+func Traced(of resource.ManagedKind, c managed.ExternalConnecter) managed.ExternalConnecter {
+	return &tracingConnecter{connecter: c, kind: schema.GroupVersionKind(of).Kind}
+}
+
+// StartSpan starts a span for the supplied operation on the supplied managed
+// resource of the supplied kind, e.g. a Reconcile of a storage Account by a
+// controller that does not use an ExternalConnecter.
+func StartSpan(ctx context.Context, kind, operation string, mg resource.Managed) (context.Context, trace.Span) {
+	return global.Tracer(azure.TracerName).Start(ctx, kind+"."+operation,
+		trace.WithAttributes(
+			AttributeKind.String(kind),
+			AttributeName.String(mg.GetName()),
+			AttributeExternalName.String(meta.GetExternalName(mg)),
+		))
+}
+
+// EndSpan ends the supplied span, recording the supplied error, if any.
+func EndSpan(ctx context.Context, span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(ctx, err, trace.WithErrorStatus(codes.Error))
+	}
+	span.End()
+}
+
+type tracingConnecter struct {
+	connecter managed.ExternalConnecter
+	kind      string
+}
+
+func (c *tracingConnecter) Connect(ctx context.Context, mg resource.Managed) (managed.ExternalClient, error) {
+	ctx, span := StartSpan(ctx, c.kind, "Connect", mg)
+	ec, err := c.connecter.Connect(ctx, mg)
+	EndSpan(ctx, span, err)
+	if err != nil {
+		return nil, err
+	}
+	return &tracingExternal{client: ec, kind: c.kind}, nil
+}
+
+type tracingExternal struct {
+	client managed.ExternalClient
+	kind   string
+}
+
+func (e *tracingExternal) Observe(ctx context.Context, mg resource.Managed) (managed.ExternalObservation, error) {
+	ctx, span := StartSpan(ctx, e.kind, "Observe", mg)
+	o, err := e.client.Observe(ctx, mg)
+	if err == nil {
+		span.SetAttributes(
+			label.Bool("crossplane.resource_exists", o.ResourceExists),
+			label.Bool("crossplane.resource_up_to_date", o.ResourceUpToDate),
+		)
+	}
+	EndSpan(ctx, span, err)
+	return o, err
+}
+
+func (e *tracingExternal) Create(ctx context.Context, mg resource.Managed) (managed.ExternalCreation, error) {
+	ctx, span := StartSpan(ctx, e.kind, "Create", mg)
+	c, err := e.client.Create(ctx, mg)
+	EndSpan(ctx, span, err)
+	return c, err
+}
+
+func (e *tracingExternal) Update(ctx context.Context, mg resource.Managed) (managed.ExternalUpdate, error) {
+	ctx, span := StartSpan(ctx, e.kind, "Update", mg)
+	u, err := e.client.Update(ctx, mg)
+	EndSpan(ctx, span, err)
+	return u, err
+}
+
+func (e *tracingExternal) Delete(ctx context.Context, mg resource.Managed) error {
+	ctx, span := StartSpan(ctx, e.kind, "Delete", mg)
+	err := e.client.Delete(ctx, mg)
+	EndSpan(ctx, span, err)
+	return err
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/api/global"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/label"
+	exporttrace "go.opentelemetry.io/otel/sdk/export/trace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+)
+
+var (
+	_ managed.ExternalConnecter = &tracingConnecter{}
+	_ managed.ExternalClient    = &tracingExternal{}
+)
+
+type spanRecorder struct {
+	spans []*exporttrace.SpanData
+}
+
+func (r *spanRecorder) ExportSpans(_ context.Context, s []*exporttrace.SpanData) error {
+	r.spans = append(r.spans, s...)
+	return nil
+}
+
+func (r *spanRecorder) Shutdown(_ context.Context) error { return nil }
+
+// span is the part of a recorded span that the tests compare.
+type span struct {
+	Name       string
+	Status     codes.Code
+	Attributes map[label.Key]interface{}
+}
+
+func TestTraced(t *testing.T) {
+	errBoom := errors.New("boom")
+	kind := resource.ManagedKind(schema.GroupVersionKind{Group: "network.azure.crossplane.io", Version: "v1alpha3", Kind: "VirtualNetwork"})
+	resourceAttributes := func(extra map[label.Key]interface{}) map[label.Key]interface{} {
+		a := map[label.Key]interface{}{
+			AttributeKind:         "VirtualNetwork",
+			AttributeName:         "cool",
+			AttributeExternalName: "coolnet",
+		}
+		for k, v := range extra {
+			a[k] = v
+		}
+		return a
+	}
+
+	cases := map[string]struct {
+		reason string
+		client managed.ExternalClient
+		call   func(ctx context.Context, e managed.ExternalClient, mg resource.Managed)
+		want   []span
+	}{
+		"Observe": {
+			reason: "A span named after the kind and operation should be recorded with the managed resource and the observation as attributes.",
+			client: &managed.ExternalClientFns{
+				ObserveFn: func(_ context.Context, _ resource.Managed) (managed.ExternalObservation, error) {
+					return managed.ExternalObservation{ResourceExists: true}, nil
+				},
+			},
+			call: func(ctx context.Context, e managed.ExternalClient, mg resource.Managed) {
+				_, _ = e.Observe(ctx, mg)
+			},
+			want: []span{
+				{Name: "VirtualNetwork.Connect", Status: codes.Unset, Attributes: resourceAttributes(nil)},
+				{Name: "VirtualNetwork.Observe", Status: codes.Unset, Attributes: resourceAttributes(map[label.Key]interface{}{
+					"crossplane.resource_exists":     true,
+					"crossplane.resource_up_to_date": false,
+				})},
+			},
+		},
+		"DeleteError": {
+			reason: "A span whose status is an error should be recorded when an operation fails.",
+			client: &managed.ExternalClientFns{
+				DeleteFn: func(_ context.Context, _ resource.Managed) error { return errBoom },
+			},
+			call: func(ctx context.Context, e managed.ExternalClient, mg resource.Managed) {
+				_ = e.Delete(ctx, mg)
+			},
+			want: []span{
+				{Name: "VirtualNetwork.Connect", Status: codes.Unset, Attributes: resourceAttributes(nil)},
+				{Name: "VirtualNetwork.Delete", Status: codes.Error, Attributes: resourceAttributes(nil)},
+			},
+		},
+		"Create": {
+			reason: "The context of the span of an operation should be passed to the external client, so that Azure API requests are its children.",
+			client: &managed.ExternalClientFns{
+				CreateFn: func(ctx context.Context, _ resource.Managed) (managed.ExternalCreation, error) {
+					_, s := global.Tracer("test").Start(ctx, "HTTP PUT")
+					s.End()
+					return managed.ExternalCreation{}, nil
+				},
+			},
+			call: func(ctx context.Context, e managed.ExternalClient, mg resource.Managed) {
+				_, _ = e.Create(ctx, mg)
+			},
+			want: []span{
+				{Name: "VirtualNetwork.Connect", Status: codes.Unset, Attributes: resourceAttributes(nil)},
+				{Name: "HTTP PUT", Status: codes.Unset, Attributes: map[label.Key]interface{}{}},
+				{Name: "VirtualNetwork.Create", Status: codes.Unset, Attributes: resourceAttributes(nil)},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rec := &spanRecorder{}
+			global.SetTracerProvider(sdktrace.NewTracerProvider(
+				sdktrace.WithConfig(sdktrace.Config{DefaultSampler: sdktrace.AlwaysSample()}),
+				sdktrace.WithSyncer(rec),
+			))
+
+			c := Traced(kind, managed.ExternalConnectorFn(func(_ context.Context, _ resource.Managed) (managed.ExternalClient, error) {
+				return tc.client, nil
+			}))
+			mg := &fake.Managed{ObjectMeta: metav1.ObjectMeta{Name: "cool"}}
+			meta.SetExternalName(mg, "coolnet")
+
+			ctx := context.Background()
+			e, err := c.Connect(ctx, mg)
+			if err != nil {
+				t.Fatalf("c.Connect(...): %s", err)
+			}
+			tc.call(ctx, e, mg)
+
+			got := make([]span, len(rec.spans))
+			for i, s := range rec.spans {
+				got[i] = span{Name: s.Name, Status: s.StatusCode, Attributes: map[label.Key]interface{}{}}
+				for _, kv := range s.Attributes {
+					got[i].Attributes[kv.Key] = kv.Value.AsInterface()
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nTraced(...): -want spans, +got spans:\n%s", tc.reason, diff)
+			}
+
+			// The Create span is ended after the request span it contains.
+			if name == "Create" && rec.spans[1].ParentSpanID != rec.spans[2].SpanContext.SpanID {
+				t.Errorf("\n%s\nTraced(...): request span is not a child of the Create span", tc.reason)
+			}
+		})
+	}
+}